@@ -0,0 +1,117 @@
+package archive
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 原始响应归档：target以 .tar.gz 或 .tgz 结尾时所有响应写入同一个压缩包，否则每个响应保存为目录中的单独文件
+type Archive struct {
+	mu     sync.Mutex
+	dir    string
+	file   *os.File
+	gz     *gzip.Writer
+	tw     *tar.Writer
+	names  map[string]int // 已使用的文件名 -> 次数，避免同名响应互相覆盖
+	count  int
+	closed bool
+}
+
+// 是否为压缩包形式的归档路径
+func isTarball(target string) bool {
+	lower := strings.ToLower(target)
+	return strings.HasSuffix(lower, ".tar.gz") || strings.HasSuffix(lower, ".tgz")
+}
+
+// 打开归档，目录不存在时自动创建
+func Open(target string) (*Archive, error) {
+	a := &Archive{names: make(map[string]int)}
+	if !isTarball(target) {
+		if err := os.MkdirAll(target, 0755); err != nil {
+			return nil, fmt.Errorf("无法创建归档目录: %v", err)
+		}
+		a.dir = target
+		return a, nil
+	}
+	if dir := filepath.Dir(target); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("无法创建归档目录: %v", err)
+		}
+	}
+	file, err := os.Create(target)
+	if err != nil {
+		return nil, fmt.Errorf("无法创建归档文件: %v", err)
+	}
+	a.file = file
+	a.gz = gzip.NewWriter(file)
+	a.tw = tar.NewWriter(a.gz)
+	return a, nil
+}
+
+// 保存一个响应，name为不含扩展名的文件名，返回保存的文件路径（压缩包中为成员名）
+func (a *Archive) Save(name string, data []byte) (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.closed {
+		return "", errors.New("归档已关闭")
+	}
+	a.names[name]++
+	if n := a.names[name]; n > 1 {
+		name = fmt.Sprintf("%s_%d", name, n)
+	}
+	name += ".http"
+
+	if a.tw == nil {
+		path := filepath.Join(a.dir, name)
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			return "", err
+		}
+		a.count++
+		return path, nil
+	}
+	header := &tar.Header{Name: name, Mode: 0644, Size: int64(len(data)), ModTime: time.Now()}
+	if err := a.tw.WriteHeader(header); err != nil {
+		return "", err
+	}
+	if _, err := a.tw.Write(data); err != nil {
+		return "", err
+	}
+	a.count++
+	return name, nil
+}
+
+// 已保存的响应数
+func (a *Archive) Count() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.count
+}
+
+// 关闭归档，压缩包形式时写入结尾并关闭文件
+func (a *Archive) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.closed {
+		return nil
+	}
+	a.closed = true
+	if a.tw == nil {
+		return nil
+	}
+	if err := a.tw.Close(); err != nil {
+		a.file.Close()
+		return err
+	}
+	if err := a.gz.Close(); err != nil {
+		a.file.Close()
+		return err
+	}
+	return a.file.Close()
+}
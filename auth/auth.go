@@ -0,0 +1,185 @@
+package auth
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+)
+
+// 认证方式
+type Type string
+
+const (
+	Basic  Type = "basic"
+	Bearer Type = "bearer"
+	NTLM   Type = "ntlm"
+)
+
+// 一组认证凭据
+type Credential struct {
+	Type     Type
+	Username string
+	Password string
+	Domain   string // NTLM的域，写成 DOMAIN\user 时设置
+	Token    string // Bearer令牌
+}
+
+// 解析凭据：
+//
+//	basic:user:password
+//	bearer:token
+//	ntlm:DOMAIN\user:password（或省略域 ntlm:user:password）
+//
+// 密码部分可以写成 env:NAME，从环境变量读取，避免出现在命令行和配置文件中
+func Parse(spec string) (*Credential, error) {
+	kind, rest, ok := strings.Cut(strings.TrimSpace(spec), ":")
+	if !ok || rest == "" {
+		return nil, fmt.Errorf("无效的认证配置 %q，格式为 basic:user:pass、bearer:token 或 ntlm:DOMAIN\\user:pass", spec)
+	}
+	cred := &Credential{Type: Type(strings.ToLower(kind))}
+	switch cred.Type {
+	case Bearer:
+		cred.Token = secret(rest)
+	case Basic, NTLM:
+		user, password, ok := strings.Cut(rest, ":")
+		if !ok || user == "" {
+			return nil, fmt.Errorf("无效的认证配置 %q，缺少用户名或密码", kind+":...")
+		}
+		cred.Username, cred.Password = user, secret(password)
+		if cred.Type == NTLM {
+			if domain, name, ok := strings.Cut(user, `\`); ok {
+				cred.Domain, cred.Username = domain, name
+			}
+		}
+	default:
+		return nil, fmt.Errorf("未知的认证方式 %q，可用方式: basic, bearer, ntlm", kind)
+	}
+	return cred, nil
+}
+
+// 读取 env:NAME 形式的环境变量，其他写法原样返回
+func secret(value string) string {
+	if name, ok := strings.CutPrefix(value, "env:"); ok {
+		return os.Getenv(name)
+	}
+	return value
+}
+
+// 浏览器认证对话框中使用的用户名，NTLM带域时为 DOMAIN\user
+func (c *Credential) BrowserUsername() string {
+	if c.Domain != "" {
+		return c.Domain + `\` + c.Username
+	}
+	return c.Username
+}
+
+// 用于提示的说明，不包含密码和令牌
+func (c *Credential) String() string {
+	if c.Type == Bearer {
+		return "bearer"
+	}
+	return string(c.Type) + " " + c.BrowserUsername()
+}
+
+// 按主机匹配的规则
+type rule struct {
+	pattern string
+	cred    *Credential
+}
+
+// 凭据配置：按主机匹配的规则（-auth-file）优先，未匹配时使用全局凭据（-auth）
+type Store struct {
+	fallback *Credential
+	rules    []rule
+}
+
+// 根据全局凭据和凭据文件创建配置，两者都为空时返回nil。
+// 凭据文件每行为"主机模式 凭据"，主机模式可以是精确的主机名或通配符（如 *.corp.example.com、intranet-*），
+// 按行的顺序匹配第一条；忽略空行和 # 开头的注释
+func Load(spec, filename string) (*Store, error) {
+	if spec == "" && filename == "" {
+		return nil, nil
+	}
+	s := &Store{}
+	if spec != "" {
+		cred, err := Parse(spec)
+		if err != nil {
+			return nil, err
+		}
+		s.fallback = cred
+	}
+	if filename == "" {
+		return s, nil
+	}
+
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("无法读取认证配置文件: %v", err)
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		pattern, spec, ok := strings.Cut(line, " ")
+		if !ok {
+			return nil, fmt.Errorf("认证配置文件第 %d 行格式错误，应为\"主机模式 凭据\"", lineNo)
+		}
+		pattern = strings.ToLower(pattern)
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("认证配置文件第 %d 行的主机模式无效: %s", lineNo, pattern)
+		}
+		cred, err := Parse(spec)
+		if err != nil {
+			return nil, fmt.Errorf("认证配置文件第 %d 行: %v", lineNo, err)
+		}
+		s.rules = append(s.rules, rule{pattern: pattern, cred: cred})
+	}
+	return s, scanner.Err()
+}
+
+// 返回主机使用的凭据，没有时返回nil
+func (s *Store) For(host string) *Credential {
+	if s == nil {
+		return nil
+	}
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	for _, r := range s.rules {
+		if matched, _ := path.Match(r.pattern, host); matched {
+			return r.cred
+		}
+	}
+	return s.fallback
+}
+
+// 是否配置了需要服务器质询后才发送的凭据（Basic、NTLM），截图时据此决定是否处理浏览器的认证请求
+func (s *Store) HasChallengeAuth() bool {
+	if s == nil {
+		return false
+	}
+	if s.fallback != nil && s.fallback.Type != Bearer {
+		return true
+	}
+	for _, r := range s.rules {
+		if r.cred.Type != Bearer {
+			return true
+		}
+	}
+	return false
+}
+
+// 用于启动提示的说明
+func (s *Store) String() string {
+	var parts []string
+	if s.fallback != nil {
+		parts = append(parts, "默认 "+s.fallback.String())
+	}
+	if len(s.rules) > 0 {
+		parts = append(parts, fmt.Sprintf("%d 条按主机匹配的规则", len(s.rules)))
+	}
+	return strings.Join(parts, "，")
+}
@@ -0,0 +1,129 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"golang.org/x/crypto/md4"
+)
+
+// NTLM消息标志
+const (
+	ntlmNegotiateUnicode    = 0x00000001
+	ntlmRequestTarget       = 0x00000004
+	ntlmNegotiateNTLM       = 0x00000200
+	ntlmNegotiateAlwaysSign = 0x00008000
+	ntlmNegotiateExtended   = 0x00080000
+	ntlmNegotiateTargetInfo = 0x00800000
+	ntlmNegotiate128        = 0x20000000
+	ntlmNegotiate56         = 0x80000000
+)
+
+var ntlmSignature = []byte("NTLMSSP\x00")
+
+const ntlmNegotiateFlags = ntlmNegotiateUnicode | ntlmRequestTarget | ntlmNegotiateNTLM |
+	ntlmNegotiateAlwaysSign | ntlmNegotiateExtended | ntlmNegotiateTargetInfo | ntlmNegotiate128 | ntlmNegotiate56
+
+// NTLM第一步的协商消息（Type 1）
+func NegotiateMessage() []byte {
+	msg := make([]byte, 32)
+	copy(msg, ntlmSignature)
+	binary.LittleEndian.PutUint32(msg[8:], 1)
+	binary.LittleEndian.PutUint32(msg[12:], ntlmNegotiateFlags)
+	return msg
+}
+
+// 根据服务器的质询消息（Type 2）生成NTLMv2认证消息（Type 3）
+func AuthenticateMessage(challenge []byte, cred *Credential) ([]byte, error) {
+	clientChallenge := make([]byte, 8)
+	if _, err := rand.Read(clientChallenge); err != nil {
+		return nil, err
+	}
+	return authenticateMessage(challenge, cred, clientChallenge, time.Now())
+}
+
+// 使用指定的客户端质询和时间生成认证消息
+func authenticateMessage(challenge []byte, cred *Credential, clientChallenge []byte, now time.Time) ([]byte, error) {
+	if len(challenge) < 32 || !bytes.Equal(challenge[:8], ntlmSignature) || binary.LittleEndian.Uint32(challenge[8:]) != 2 {
+		return nil, errors.New("无效的NTLM质询消息")
+	}
+	flags := binary.LittleEndian.Uint32(challenge[20:])
+	serverChallenge := challenge[24:32]
+	var targetInfo []byte
+	if len(challenge) >= 48 {
+		length := int(binary.LittleEndian.Uint16(challenge[40:]))
+		offset := int(binary.LittleEndian.Uint32(challenge[44:]))
+		if offset+length > len(challenge) {
+			return nil, errors.New("NTLM质询消息长度错误")
+		}
+		targetInfo = challenge[offset : offset+length]
+	}
+
+	// 时间戳为1601年以来的100纳秒数
+	timestamp := make([]byte, 8)
+	binary.LittleEndian.PutUint64(timestamp, uint64(now.Unix()+11644473600)*10000000+uint64(now.Nanosecond()/100))
+
+	v2Hash := ntowfv2(cred)
+
+	var blob bytes.Buffer
+	blob.Write([]byte{1, 1, 0, 0, 0, 0, 0, 0})
+	blob.Write(timestamp)
+	blob.Write(clientChallenge)
+	blob.Write([]byte{0, 0, 0, 0})
+	blob.Write(targetInfo)
+	blob.Write([]byte{0, 0, 0, 0})
+
+	proof := hmacMD5(v2Hash, append(append([]byte{}, serverChallenge...), blob.Bytes()...))
+	ntResponse := append(proof, blob.Bytes()...)
+	lmResponse := append(hmacMD5(v2Hash, append(append([]byte{}, serverChallenge...), clientChallenge...)), clientChallenge...)
+
+	// 头部64字节，之后依次为LM响应、NT响应、域、用户名、工作站
+	fields := [][]byte{lmResponse, ntResponse, utf16le(cred.Domain), utf16le(cred.Username), nil}
+	msg := make([]byte, 64)
+	copy(msg, ntlmSignature)
+	binary.LittleEndian.PutUint32(msg[8:], 3)
+	offset := len(msg)
+	for i, field := range fields {
+		pos := 12 + i*8
+		binary.LittleEndian.PutUint16(msg[pos:], uint16(len(field)))
+		binary.LittleEndian.PutUint16(msg[pos+2:], uint16(len(field)))
+		binary.LittleEndian.PutUint32(msg[pos+4:], uint32(offset))
+		offset += len(field)
+	}
+	// 会话密钥为空
+	binary.LittleEndian.PutUint32(msg[56:], uint32(offset))
+	binary.LittleEndian.PutUint32(msg[60:], flags&^ntlmRequestTarget)
+	for _, field := range fields {
+		msg = append(msg, field...)
+	}
+	return msg, nil
+}
+
+// NTLMv2的密钥：以密码的MD4为密钥，对大写的用户名加域名计算HMAC-MD5
+func ntowfv2(cred *Credential) []byte {
+	hash := md4.New()
+	hash.Write(utf16le(cred.Password))
+	return hmacMD5(hash.Sum(nil), utf16le(strings.ToUpper(cred.Username)+cred.Domain))
+}
+
+func hmacMD5(key, data []byte) []byte {
+	mac := hmac.New(md5.New, key)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
+func utf16le(s string) []byte {
+	codes := utf16.Encode([]rune(s))
+	b := make([]byte, len(codes)*2)
+	for i, c := range codes {
+		binary.LittleEndian.PutUint16(b[i*2:], c)
+	}
+	return b
+}
@@ -0,0 +1,151 @@
+package auth
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"testing"
+	"time"
+)
+
+// MS-NLMP 4.2.1 和 4.2.4 中的NTLMv2示例
+var (
+	testCredential      = &Credential{Type: NTLM, Domain: "Domain", Username: "User", Password: "Password"}
+	testServerChallenge = mustHex("0123456789abcdef")
+	testClientChallenge = mustHex("aaaaaaaaaaaaaaaa")
+	// 时间戳为0，即1601-01-01
+	testTime = time.Date(1601, 1, 1, 0, 0, 0, 0, time.UTC)
+	// MsvAvNbDomainName "Domain"、MsvAvNbComputerName "Server"、MsvAvEOL
+	testTargetInfo = mustHex("02000c0044006f006d00610069006e0001000c00530065007200760065007200" + "00000000")
+)
+
+func mustHex(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// 构造质询消息（Type 2），目标名为 "Domain"
+func challengeMessage(flags uint32, serverChallenge, targetInfo []byte) []byte {
+	targetName := utf16le("Domain")
+	msg := make([]byte, 48)
+	copy(msg, ntlmSignature)
+	binary.LittleEndian.PutUint32(msg[8:], 2)
+	binary.LittleEndian.PutUint16(msg[12:], uint16(len(targetName)))
+	binary.LittleEndian.PutUint16(msg[14:], uint16(len(targetName)))
+	binary.LittleEndian.PutUint32(msg[16:], 48)
+	binary.LittleEndian.PutUint32(msg[20:], flags)
+	copy(msg[24:], serverChallenge)
+	binary.LittleEndian.PutUint16(msg[40:], uint16(len(targetInfo)))
+	binary.LittleEndian.PutUint16(msg[42:], uint16(len(targetInfo)))
+	binary.LittleEndian.PutUint32(msg[44:], uint32(48+len(targetName)))
+	msg = append(msg, targetName...)
+	return append(msg, targetInfo...)
+}
+
+// 取出认证消息（Type 3）中第index个字段：0 LM响应、1 NT响应、2 域、3 用户名、4 工作站
+func messageField(t *testing.T, msg []byte, index int) []byte {
+	t.Helper()
+	pos := 12 + index*8
+	length := int(binary.LittleEndian.Uint16(msg[pos:]))
+	offset := int(binary.LittleEndian.Uint32(msg[pos+4:]))
+	if offset+length > len(msg) {
+		t.Fatalf("field %d out of range: offset %d length %d message %d", index, offset, length, len(msg))
+	}
+	return msg[offset : offset+length]
+}
+
+func TestNTOWFv2(t *testing.T) {
+	want := mustHex("0c868a403bfd7a93a3001ef22ef02e3f")
+	if got := ntowfv2(testCredential); !bytes.Equal(got, want) {
+		t.Errorf("ntowfv2 = %x, want %x", got, want)
+	}
+}
+
+func TestAuthenticateMessageKnownAnswer(t *testing.T) {
+	const flags = 0xe28a8233
+	challenge := challengeMessage(flags, testServerChallenge, testTargetInfo)
+	msg, err := authenticateMessage(challenge, testCredential, testClientChallenge, testTime)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(msg[:8], ntlmSignature) || binary.LittleEndian.Uint32(msg[8:]) != 3 {
+		t.Fatalf("not an AUTHENTICATE_MESSAGE: %x", msg[:12])
+	}
+
+	// 4.2.4.2.1 LMv2响应
+	if got, want := messageField(t, msg, 0), mustHex("86c35097ac9cec102554764a57cccc19aaaaaaaaaaaaaaaa"); !bytes.Equal(got, want) {
+		t.Errorf("LMv2 response = %x, want %x", got, want)
+	}
+
+	// 4.2.4.2.2 NTProofStr，之后是 temp（版本、保留、时间戳、客户端质询、保留、目标信息、保留）
+	nt := messageField(t, msg, 1)
+	if got, want := nt[:16], mustHex("68cd0ab851e51c96aabc927bebef6a1c"); !bytes.Equal(got, want) {
+		t.Errorf("NTProofStr = %x, want %x", got, want)
+	}
+	temp := append(append(append(mustHex("0101000000000000"+"0000000000000000"), testClientChallenge...), 0, 0, 0, 0), testTargetInfo...)
+	temp = append(temp, 0, 0, 0, 0)
+	if got := nt[16:]; !bytes.Equal(got, temp) {
+		t.Errorf("NTLMv2 client challenge blob = %x, want %x", got, temp)
+	}
+
+	if got, want := messageField(t, msg, 2), utf16le("Domain"); !bytes.Equal(got, want) {
+		t.Errorf("domain = %x, want %x", got, want)
+	}
+	if got, want := messageField(t, msg, 3), utf16le("User"); !bytes.Equal(got, want) {
+		t.Errorf("user = %x, want %x", got, want)
+	}
+	if got := messageField(t, msg, 4); len(got) != 0 {
+		t.Errorf("workstation = %x, want empty", got)
+	}
+	if got := binary.LittleEndian.Uint32(msg[60:]); got != flags&^ntlmRequestTarget {
+		t.Errorf("flags = %#x, want %#x", got, flags&^ntlmRequestTarget)
+	}
+}
+
+func TestAuthenticateMessageRandomClientChallenge(t *testing.T) {
+	challenge := challengeMessage(ntlmNegotiateFlags, testServerChallenge, testTargetInfo)
+	first, err := AuthenticateMessage(challenge, testCredential)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := AuthenticateMessage(challenge, testCredential)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(messageField(t, first, 0), messageField(t, second, 0)) {
+		t.Error("two authenticate messages share the same client challenge")
+	}
+}
+
+func TestAuthenticateMessageInvalidChallenge(t *testing.T) {
+	valid := challengeMessage(ntlmNegotiateFlags, testServerChallenge, testTargetInfo)
+	wrongType := append([]byte(nil), valid...)
+	binary.LittleEndian.PutUint32(wrongType[8:], 1)
+	badInfo := append([]byte(nil), valid...)
+	binary.LittleEndian.PutUint32(badInfo[44:], uint32(len(valid)))
+
+	tests := map[string][]byte{
+		"short":               valid[:24],
+		"signature":           append([]byte("NTLMSSX\x00"), valid[8:]...),
+		"message type":        wrongType,
+		"target info overrun": badInfo,
+	}
+	for name, challenge := range tests {
+		if _, err := AuthenticateMessage(challenge, testCredential); err == nil {
+			t.Errorf("%s: AuthenticateMessage succeeded", name)
+		}
+	}
+}
+
+func TestNegotiateMessage(t *testing.T) {
+	msg := NegotiateMessage()
+	if len(msg) != 32 || !bytes.Equal(msg[:8], ntlmSignature) || binary.LittleEndian.Uint32(msg[8:]) != 1 {
+		t.Fatalf("NegotiateMessage = %x", msg)
+	}
+	if got := binary.LittleEndian.Uint32(msg[12:]); got != ntlmNegotiateFlags {
+		t.Errorf("flags = %#x, want %#x", got, ntlmNegotiateFlags)
+	}
+}
@@ -0,0 +1,195 @@
+package axfr
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// 单次域传送最多接收的记录数，防止异常的服务器无限发送
+const maxRecords = 100000
+
+// 根域名的域传送（AXFR）尝试结果
+type Transfer struct {
+	Domain      string   `json:"domain"`
+	Nameservers []string `json:"nameservers,omitempty"` // 根域名的权威DNS服务器
+	Allowed     []string `json:"allowed,omitempty"`     // 允许域传送的DNS服务器
+	Records     int      `json:"records,omitempty"`     // 传回的记录数
+	Hosts       []string `json:"hosts,omitempty"`       // 记录中属于该根域名的主机名
+	Error       string   `json:"error,omitempty"`       // 查询权威DNS服务器失败的原因
+}
+
+// 是否有DNS服务器允许域传送
+func (t Transfer) Vulnerable() bool {
+	return len(t.Allowed) > 0
+}
+
+// 向根域名的每个权威DNS服务器尝试域传送，记录允许传送的服务器和传回的主机名。
+// 正确配置的服务器会拒绝（REFUSED/NOTAUTH）或直接断开连接
+func Attempt(domain string, timeout time.Duration) Transfer {
+	transfer := Transfer{Domain: domain}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	nameservers, err := net.DefaultResolver.LookupNS(ctx, domain)
+	cancel()
+	if err != nil {
+		transfer.Error = err.Error()
+		return transfer
+	}
+
+	hosts := make(map[string]bool)
+	for _, ns := range nameservers {
+		server := strings.TrimSuffix(strings.ToLower(ns.Host), ".")
+		transfer.Nameservers = append(transfer.Nameservers, server)
+		records, err := transferFrom(server, domain, timeout)
+		if err != nil || len(records) == 0 {
+			continue
+		}
+		transfer.Allowed = append(transfer.Allowed, server)
+		transfer.Records = max(transfer.Records, len(records))
+		for _, record := range records {
+			if host, ok := recordHost(record, domain); ok {
+				hosts[host] = true
+			}
+		}
+	}
+	for host := range hosts {
+		transfer.Hosts = append(transfer.Hosts, host)
+	}
+	sort.Strings(transfer.Hosts)
+	return transfer
+}
+
+// 并发尝试多个根域名的域传送，结果按输入顺序返回
+func AttemptAll(domains []string, timeout time.Duration, workers int) []Transfer {
+	transfers := make([]Transfer, len(domains))
+	indexes := make(chan int, len(domains))
+	for i := range domains {
+		indexes <- i
+	}
+	close(indexes)
+	var wg sync.WaitGroup
+	for w := 0; w < min(max(workers, 1), len(domains)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				transfers[i] = Attempt(domains[i], timeout)
+			}
+		}()
+	}
+	wg.Wait()
+	return transfers
+}
+
+// 依次尝试DNS服务器的各个地址，返回第一个成功的域传送记录
+func transferFrom(server, domain string, timeout time.Duration) ([]dnsmessage.Resource, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	addrs, err := net.DefaultResolver.LookupHost(ctx, server)
+	cancel()
+	if err != nil {
+		return nil, err
+	}
+	var lastErr error
+	for _, addr := range addrs {
+		records, err := transfer(net.JoinHostPort(addr, "53"), domain, timeout)
+		if err == nil {
+			return records, nil
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+// 通过TCP向DNS服务器发送AXFR请求并读取全部响应，区域数据以SOA记录开始和结束
+func transfer(addr, domain string, timeout time.Duration) ([]dnsmessage.Resource, error) {
+	name, err := dnsmessage.NewName(strings.TrimSuffix(domain, ".") + ".")
+	if err != nil {
+		return nil, err
+	}
+	id := uint16(rand.Intn(1 << 16))
+	query := dnsmessage.Message{
+		Header:    dnsmessage.Header{ID: id},
+		Questions: []dnsmessage.Question{{Name: name, Type: dnsmessage.TypeAXFR, Class: dnsmessage.ClassINET}},
+	}
+	packet, err := query.Pack()
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := net.DialTimeout("tcp", addr, timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	// TCP上的DNS消息以2字节长度开头
+	frame := binary.BigEndian.AppendUint16(nil, uint16(len(packet)))
+	if _, err := conn.Write(append(frame, packet...)); err != nil {
+		return nil, err
+	}
+
+	var records []dnsmessage.Resource
+	soas := 0
+	for soas < 2 && len(records) < maxRecords {
+		var length [2]byte
+		if _, err := io.ReadFull(conn, length[:]); err != nil {
+			return nil, err
+		}
+		buf := make([]byte, binary.BigEndian.Uint16(length[:]))
+		if _, err := io.ReadFull(conn, buf); err != nil {
+			return nil, err
+		}
+		var msg dnsmessage.Message
+		if err := msg.Unpack(buf); err != nil {
+			return nil, err
+		}
+		if msg.Header.ID != id {
+			return nil, fmt.Errorf("响应ID不匹配")
+		}
+		if msg.Header.RCode != dnsmessage.RCodeSuccess {
+			return nil, fmt.Errorf("域传送被拒绝: %s", msg.Header.RCode)
+		}
+		if len(msg.Answers) == 0 {
+			return nil, fmt.Errorf("域传送没有返回记录")
+		}
+		for _, answer := range msg.Answers {
+			if answer.Header.Type == dnsmessage.TypeSOA {
+				soas++
+				if soas == 2 {
+					break
+				}
+			}
+			records = append(records, answer)
+		}
+	}
+	return records, nil
+}
+
+// 记录的主机名：A、AAAA和CNAME记录中属于根域名的名称，不含通配符和 _ 开头的服务记录
+func recordHost(record dnsmessage.Resource, domain string) (string, bool) {
+	switch record.Header.Type {
+	case dnsmessage.TypeA, dnsmessage.TypeAAAA, dnsmessage.TypeCNAME:
+	default:
+		return "", false
+	}
+	host := strings.TrimSuffix(strings.ToLower(record.Header.Name.String()), ".")
+	if host != domain && !strings.HasSuffix(host, "."+domain) {
+		return "", false
+	}
+	for _, label := range strings.Split(host, ".") {
+		if label == "*" || strings.HasPrefix(label, "_") {
+			return "", false
+		}
+	}
+	return host, true
+}
@@ -0,0 +1,133 @@
+package checker
+
+import (
+	"encoding/base64"
+	"io"
+	"net/http"
+	"strings"
+
+	"subdomain-checker/auth"
+	"subdomain-checker/logger"
+)
+
+// 认证凭据（-auth、-auth-file），为nil时不认证
+var credentials *auth.Store
+
+// 设置认证凭据：之后的每个请求按主机选择凭据；为nil时不认证
+func SetCredentials(store *auth.Store) {
+	credentials = store
+}
+
+// 附加认证信息的Transport。Basic和Bearer直接附加Authorization头，
+// NTLM先发送协商消息，收到质询后在同一连接上发送认证消息。
+// 请求已带有Authorization头（如 -H 指定）时不覆盖
+type authTransport struct {
+	base http.RoundTripper
+	// 为每次NTLM握手创建只有一个连接的Transport。NTLM认证的是连接而不是请求，
+	// 共享连接池中认证消息可能被分配到另一个连接上，因此每次握手独占一个连接
+	ntlmTransport func() *http.Transport
+}
+
+func (t authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	cred := credentials.For(req.URL.Hostname())
+	if cred == nil || req.Header.Get("Authorization") != "" {
+		return t.base.RoundTrip(req)
+	}
+
+	switch cred.Type {
+	case auth.Basic:
+		req = req.Clone(req.Context())
+		req.SetBasicAuth(cred.Username, cred.Password)
+		return t.base.RoundTrip(req)
+	case auth.Bearer:
+		req = req.Clone(req.Context())
+		req.Header.Set("Authorization", "Bearer "+cred.Token)
+		return t.base.RoundTrip(req)
+	}
+	return t.ntlm(req, cred)
+}
+
+// NTLM握手：协商 -> 401质询 -> 认证。服务器不要求NTLM时直接返回协商请求的响应。
+// 握手在独占的单连接Transport上进行，响应体关闭后释放该连接
+func (t authTransport) ntlm(req *http.Request, cred *auth.Credential) (*http.Response, error) {
+	dedicated := t.ntlmTransport()
+	base := recordingTransport{base: dedicated}
+	resp, err := t.ntlmHandshake(base, req, cred)
+	if err != nil {
+		dedicated.CloseIdleConnections()
+		return nil, err
+	}
+	resp.Body = &releaseBody{ReadCloser: resp.Body, release: dedicated.CloseIdleConnections}
+	return resp, nil
+}
+
+func (t authTransport) ntlmHandshake(base http.RoundTripper, req *http.Request, cred *auth.Credential) (*http.Response, error) {
+	negotiate, err := cloneWithBody(req)
+	if err != nil {
+		return nil, err
+	}
+	negotiate.Header.Set("Authorization", "NTLM "+base64.StdEncoding.EncodeToString(auth.NegotiateMessage()))
+	resp, err := base.RoundTrip(negotiate)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+
+	challenge := ntlmChallenge(resp.Header)
+	if challenge == nil {
+		return resp, nil
+	}
+	message, err := auth.AuthenticateMessage(challenge, cred)
+	if err != nil {
+		logger.Warn("NTLM认证失败", "url", req.URL.String(), "error", err)
+		return resp, nil
+	}
+	// 读完并关闭质询响应，使连接回到单连接Transport中供认证请求复用
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	resp.Body.Close()
+
+	authenticate, err := cloneWithBody(req)
+	if err != nil {
+		return nil, err
+	}
+	authenticate.Header.Set("Authorization", "NTLM "+base64.StdEncoding.EncodeToString(message))
+	return base.RoundTrip(authenticate)
+}
+
+// 关闭时释放NTLM握手独占连接的响应体
+type releaseBody struct {
+	io.ReadCloser
+	release func()
+}
+
+func (b *releaseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.release()
+	return err
+}
+
+// 复制请求，有请求体时通过GetBody重新获取，使请求可以发送两次
+func cloneWithBody(req *http.Request) (*http.Request, error) {
+	clone := req.Clone(req.Context())
+	if req.Body != nil && req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		clone.Body = body
+	}
+	return clone, nil
+}
+
+// 从WWW-Authenticate头中取出NTLM质询消息
+func ntlmChallenge(header http.Header) []byte {
+	for _, value := range header.Values("WWW-Authenticate") {
+		scheme, data, ok := strings.Cut(strings.TrimSpace(value), " ")
+		if !ok || !strings.EqualFold(scheme, "NTLM") {
+			continue
+		}
+		if challenge, err := base64.StdEncoding.DecodeString(strings.TrimSpace(data)); err == nil {
+			return challenge
+		}
+	}
+	return nil
+}
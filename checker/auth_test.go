@@ -0,0 +1,94 @@
+package checker
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"subdomain-checker/auth"
+	"subdomain-checker/config"
+)
+
+// 模拟要求NTLM的服务器：只有在收到质询的同一连接上发送认证消息才返回200
+func ntlmServer(t *testing.T) *httptest.Server {
+	var mu sync.Mutex
+	challenged := make(map[string]bool)
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, ok := strings.CutPrefix(r.Header.Get("Authorization"), "NTLM ")
+		msg, err := base64.StdEncoding.DecodeString(data)
+		if !ok || err != nil || len(msg) < 12 {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		switch binary.LittleEndian.Uint32(msg[8:]) {
+		case 1:
+			challenge := make([]byte, 32)
+			copy(challenge, "NTLMSSP\x00")
+			binary.LittleEndian.PutUint32(challenge[8:], 2)
+			copy(challenge[24:], r.RemoteAddr)
+			challenged[r.RemoteAddr] = true
+			w.Header().Set("WWW-Authenticate", "NTLM "+base64.StdEncoding.EncodeToString(challenge))
+			w.WriteHeader(http.StatusUnauthorized)
+		case 3:
+			if !challenged[r.RemoteAddr] {
+				t.Errorf("authenticate message on %s, which never received a challenge", r.RemoteAddr)
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			delete(challenged, r.RemoteAddr)
+			w.Write([]byte("ok"))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+}
+
+func TestNTLMHandshakeKeepsConnection(t *testing.T) {
+	server := ntlmServer(t)
+	defer server.Close()
+
+	store, err := auth.Load(`ntlm:CORP\user:password`, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetCredentials(store)
+	defer SetCredentials(nil)
+
+	client := newHTTPClient(config.Config{Timeout: 5})
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := client.Get(server.URL)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				t.Errorf("status = %d, want 200", resp.StatusCode)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestNTLMChallengeHeader(t *testing.T) {
+	header := http.Header{}
+	header.Add("WWW-Authenticate", "Negotiate")
+	header.Add("WWW-Authenticate", "ntlm "+base64.StdEncoding.EncodeToString([]byte("NTLMSSP\x00")))
+	if got := ntlmChallenge(header); string(got) != "NTLMSSP\x00" {
+		t.Errorf("ntlmChallenge = %q", got)
+	}
+	if got := ntlmChallenge(http.Header{"Www-Authenticate": {"Basic realm=x"}}); got != nil {
+		t.Errorf("ntlmChallenge without NTLM = %q, want nil", got)
+	}
+}
@@ -0,0 +1,83 @@
+package checker
+
+import (
+	"net"
+	"sync"
+	"time"
+)
+
+// 每个响应最多读取的字节数（-max-body-kb），0表示不限制。
+// 超出部分不再读取，标题提取、关键词匹配和哈希只使用已读取的部分
+var maxBodyBytes int64 = 5 << 20
+
+// 设置每个响应最多读取的字节数，0表示不限制
+func SetMaxBodySize(n int64) {
+	maxBodyBytes = n
+}
+
+// 整个扫描的带宽上限（-max-bandwidth-kb），为nil时不限制
+var bandwidth *bandwidthLimiter
+
+// 设置整个扫描的带宽上限（字节/秒），0表示不限制。
+// 限制作用于检测、路径探测等HTTP请求的连接（收发合计），不包括浏览器截图的流量
+func SetBandwidthLimit(bytesPerSecond int64) {
+	if bytesPerSecond <= 0 {
+		bandwidth = nil
+		return
+	}
+	bandwidth = &bandwidthLimiter{rate: float64(bytesPerSecond), tokens: float64(bytesPerSecond), last: time.Now()}
+}
+
+// 令牌桶限速：每秒补充rate个字节的额度，最多积累1秒；
+// 额度不足时先记账再等待，多个连接按到达顺序分摊带宽
+type bandwidthLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	tokens float64
+	last   time.Time
+}
+
+// 使用n个字节的额度，额度不足时等待
+func (l *bandwidthLimiter) wait(n int) {
+	if n <= 0 {
+		return
+	}
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens = min(l.tokens+now.Sub(l.last).Seconds()*l.rate, l.rate)
+	l.last = now
+	l.tokens -= float64(n)
+	var delay time.Duration
+	if l.tokens < 0 {
+		delay = time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+	l.mu.Unlock()
+	if delay > 0 {
+		time.Sleep(delay)
+	}
+}
+
+// 受带宽上限约束的连接
+type throttledConn struct {
+	net.Conn
+	limiter *bandwidthLimiter
+}
+
+func (c throttledConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	c.limiter.wait(n)
+	return n, err
+}
+
+func (c throttledConn) Write(p []byte) (int, error) {
+	c.limiter.wait(len(p))
+	return c.Conn.Write(p)
+}
+
+// 启用带宽上限时包装连接
+func throttle(conn net.Conn, err error) (net.Conn, error) {
+	if err != nil || bandwidth == nil {
+		return conn, err
+	}
+	return throttledConn{Conn: conn, limiter: bandwidth}, nil
+}
@@ -0,0 +1,173 @@
+package checker
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// 开放端口上识别出的服务
+type Service struct {
+	Port   int    `json:"port"`
+	Name   string `json:"name"`             // 服务名称，如 ssh、ftp、redis、http，无法识别时为 unknown
+	Banner string `json:"banner,omitempty"` // 服务返回的欢迎信息或版本，HTTP服务为Server响应头
+}
+
+// 格式化为 "22/ssh (SSH-2.0-OpenSSH_8.9)"
+func (s Service) String() string {
+	text := strconv.Itoa(s.Port) + "/" + s.Name
+	if s.Banner != "" {
+		text += " (" + s.Banner + ")"
+	}
+	return text
+}
+
+// 是否为Web服务，可以交给检测流程
+func (s Service) Web() bool {
+	return s.Name == "http" || s.Name == "https"
+}
+
+// 欢迎信息的最大长度
+const maxBannerLen = 100
+
+// 常见端口的默认服务，欢迎信息无法识别时使用
+var wellKnownPorts = map[int]string{
+	21: "ftp", 22: "ssh", 23: "telnet", 25: "smtp", 53: "dns", 110: "pop3", 143: "imap",
+	389: "ldap", 445: "smb", 465: "smtps", 587: "smtp", 993: "imaps", 995: "pop3s",
+	1433: "mssql", 1521: "oracle", 2049: "nfs", 2375: "docker", 3306: "mysql", 3389: "rdp",
+	5432: "postgresql", 5900: "vnc", 5984: "couchdb", 6379: "redis", 9200: "elasticsearch",
+	11211: "memcached", 27017: "mongodb",
+}
+
+// 使用TLS但不是Web服务的端口
+var tlsServicePorts = map[int]string{465: "smtps", 636: "ldaps", 993: "imaps", 995: "pop3s"}
+
+// 识别端口上的服务：先读取服务主动发送的欢迎信息（SSH、FTP、SMTP、MySQL等），
+// 没有时发送HTTP请求，Redis等对HTTP请求的错误回复也能识别；仍无法识别时尝试TLS握手
+func identifyService(ip, host string, port int, timeout time.Duration) Service {
+	addr := net.JoinHostPort(ip, strconv.Itoa(port))
+	service := Service{Port: port}
+
+	if banner := readBanner(addr, timeout, nil); len(banner) > 0 {
+		service.Name, service.Banner = matchBanner(banner)
+	}
+	if service.Name == "" {
+		probe := []byte(fmt.Sprintf("HEAD / HTTP/1.0\r\nHost: %s\r\n\r\n", host))
+		if reply := readBanner(addr, timeout, probe); len(reply) > 0 {
+			service.Name, service.Banner = matchBanner(reply)
+		}
+	}
+	if service.Name == "" && handshakeTLS(addr, host, timeout) {
+		service.Name = "https"
+		if name, ok := tlsServicePorts[port]; ok {
+			service.Name = name
+		}
+	}
+	if service.Name == "" {
+		service.Name = "unknown"
+		if name, ok := wellKnownPorts[port]; ok {
+			service.Name = name
+		}
+	}
+	return service
+}
+
+// 连接端口，发送probe（为nil时不发送）后读取回复的前若干字节
+func readBanner(addr string, timeout time.Duration, probe []byte) []byte {
+	conn, err := net.DialTimeout("tcp", addr, timeout)
+	if err != nil {
+		return nil
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
+	if probe != nil {
+		if _, err := conn.Write(probe); err != nil {
+			return nil
+		}
+	}
+	buf := make([]byte, 1024)
+	n, _ := conn.Read(buf)
+	return buf[:n]
+}
+
+// 按欢迎信息识别服务，返回服务名称和可读的欢迎信息，无法识别时名称为空
+func matchBanner(data []byte) (name, banner string) {
+	// MySQL握手包：3字节长度、1字节序号，之后是协议版本10和以\0结尾的服务器版本
+	if len(data) > 5 && data[4] == 0x0a {
+		if end := bytes.IndexByte(data[5:], 0); end > 0 {
+			return "mysql", cleanBanner(data[5 : 5+end])
+		}
+	}
+
+	line := firstLine(data)
+	upper := strings.ToUpper(line)
+	switch {
+	case strings.HasPrefix(line, "HTTP/"):
+		resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(data)), nil)
+		if err == nil {
+			resp.Body.Close()
+			return "http", cleanBanner([]byte(resp.Header.Get("Server")))
+		}
+		return "http", ""
+	case strings.HasPrefix(line, "SSH-"):
+		return "ssh", line
+	case strings.HasPrefix(line, "220"):
+		if strings.Contains(upper, "SMTP") || strings.Contains(upper, "MAIL") {
+			return "smtp", line
+		}
+		return "ftp", line
+	case strings.HasPrefix(line, "* OK"):
+		return "imap", line
+	case strings.HasPrefix(line, "+OK"):
+		return "pop3", line
+	case strings.HasPrefix(line, "-ERR"), strings.HasPrefix(line, "-NOAUTH"), strings.HasPrefix(line, "-DENIED"):
+		return "redis", "" // 对探测请求的错误回复，不是欢迎信息
+	case strings.HasPrefix(line, "RFB "):
+		return "vnc", line
+	}
+	return "", ""
+}
+
+// 第一行中的可读文本
+func firstLine(data []byte) string {
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+		data = data[:i]
+	}
+	return cleanBanner(data)
+}
+
+// 去掉不可打印字符并截断到maxBannerLen
+func cleanBanner(data []byte) string {
+	var b strings.Builder
+	for _, r := range string(data) {
+		if r >= 0x20 && r != 0x7f && r != utf8.RuneError {
+			b.WriteRune(r)
+		}
+	}
+	text := strings.TrimSpace(b.String())
+	if runes := []rune(text); len(runes) > maxBannerLen {
+		text = string(runes[:maxBannerLen]) + "..."
+	}
+	return text
+}
+
+// 能否完成TLS握手，只判断是否为TLS服务，不校验证书
+func handshakeTLS(addr, host string, timeout time.Duration) bool {
+	dialer := &net.Dialer{Timeout: timeout}
+	conn, err := tls.DialWithDialer(dialer, "tcp", addr, &tls.Config{
+		ServerName:         host,
+		InsecureSkipVerify: true,
+	})
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
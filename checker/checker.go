@@ -1,22 +1,30 @@
 package checker
 
 import (
+	"cmp"
 	"context"
 	"fmt"
 	"image"
 	"image/color"
 	"image/png"
 	"io"
+	"net"
 	"net/http"
+	"net/http/httptrace"
 	"os"
 	"path/filepath"
 	"regexp"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
+	"subdomain-checker/cloud"
 	"subdomain-checker/config"
+	"subdomain-checker/geoip"
+	"subdomain-checker/scope"
 	"subdomain-checker/screenshot"
+	"subdomain-checker/utils"
 
 	"github.com/chromedp/chromedp"
 	"github.com/fogleman/gg"
@@ -24,15 +32,181 @@ import (
 
 // 子域名检测结果
 type Result struct {
-	Domain       string
-	Status       int
-	Alive        bool
-	StatusText   string // 状态文本，如"存活"、"404"、"403"等
-	Message      string
-	ResponseTime time.Duration
-	PageInfo     *PageType // 页面信息
-	Title        string    // 页面标题
-	Screenshot   string    // 保存的截图文件名
+	Domain         string        `json:"domain"`
+	UnicodeDomain  string        `json:"unicode_domain,omitempty"` // 国际化域名的Unicode形式（Domain为punycode形式）
+	Status         int           `json:"status"`
+	Alive          bool          `json:"alive"`
+	StatusText     string        `json:"status_text"`          // 状态文本，如"存活"、"404"、"403"等
+	Message        string        `json:"message"`              // 状态说明或错误详情
+	ErrorType      ErrorType     `json:"error_type,omitempty"` // 失败类型，存活时为空
+	ResponseTime   time.Duration `json:"response_time_ns"`
+	PageInfo       *PageType     `json:"page_info,omitempty"`       // 页面信息
+	Title          string        `json:"title"`                     // 页面标题
+	Screenshot     string        `json:"screenshot,omitempty"`      // 截图相对于报告的路径，如 screenshots/xxx.png
+	ScreenshotPath string        `json:"screenshot_path,omitempty"` // 截图文件的绝对路径
+	IP             string        `json:"ip,omitempty"`              // 实际连接的IP地址（未建立连接时为解析到的地址）
+	IPFamily       string        `json:"ip_family,omitempty"`       // 应答的地址族：IPv4或IPv6
+	Families       []FamilyCheck `json:"families,omitempty"`        // -ip-family both 时各地址族的检测结果
+	Matches        []Match       `json:"matches,omitempty"`         // 关键词正则命中的证据片段
+	BodyHash       string        `json:"body_hash,omitempty"`       // 归一化页面内容的SHA-256，用于聚类相同页面
+	SimHash        uint64        `json:"simhash,omitempty"`         // 归一化页面内容的SimHash，用于聚类近似页面
+	Note           string        `json:"note,omitempty"`            // 输入文件中的备注
+	FinalURL       string        `json:"final_url,omitempty"`       // 最终落地的URL（未跟随重定向时为重定向目标）
+	RedirectChain  []RedirectHop `json:"redirect_chain,omitempty"`  // 重定向链，每一跳的URL和状态码
+	CNAMEs         []string      `json:"cnames,omitempty"`          // CNAME链，依次指向的目标（-cname）
+	DanglingCNAME  bool          `json:"dangling_cname,omitempty"`  // CNAME链末端的目标不存在，可能被接管
+	ASN            uint          `json:"asn,omitempty"`             // IP所属的自治系统号（-geoip）
+	ASOrg          string        `json:"as_org,omitempty"`          // 自治系统所属组织
+	Country        string        `json:"country,omitempty"`         // IP所在国家或地区代码
+	Cloud          string        `json:"cloud,omitempty"`           // IP所属的云服务商（-cloud）
+	OpenPorts      []int         `json:"open_ports,omitempty"`      // IP上开放的TCP端口（-ports）
+	Services       []Service     `json:"services,omitempty"`        // 开放端口上识别出的服务
+	Robots         *RobotsInfo   `json:"robots,omitempty"`          // robots.txt 和 sitemap.xml 的收集结果（-robots）
+	Paths          []PathResult  `json:"paths,omitempty"`           // 各探测路径的结果（-paths）
+	Soft404        bool          `json:"soft_404,omitempty"`        // 主机对不存在的路径也返回2xx（-soft404）
+	CORS           *CORSInfo     `json:"cors,omitempty"`            // 以测试Origin请求时的CORS响应头（-cors）
+	Methods        []string      `json:"methods,omitempty"`         // 主机允许的HTTP方法（-methods）
+	Headers        []HeaderField `json:"headers,omitempty"`         // 响应头，不含Set-Cookie（-capture-headers）
+	Cookies        []CookieField `json:"cookies,omitempty"`         // 响应设置的Cookie（-capture-headers）
+	RiskyMethods   []string      `json:"risky_methods,omitempty"`   // 其中有风险的方法，如 TRACE、PUT、DELETE
+	RawResponse    string        `json:"raw_response,omitempty"`    // 保存的原始响应文件（-archive），压缩包中为成员名
+	ClientCert     string        `json:"client_cert,omitempty"`     // 服务器是否请求客户端证书（mTLS）: requested|required
+	TLSVersion     string        `json:"tls_version,omitempty"`     // 协商的TLS版本，如 1.3
+	TLSCipher      string        `json:"tls_cipher,omitempty"`      // 协商的加密套件
+	JARM           string        `json:"jarm,omitempty"`            // JARM主动TLS指纹（-jarm）
+	JA3S           string        `json:"ja3s,omitempty"`            // JA3S服务器指纹
+	JARMMatch      string        `json:"jarm_match,omitempty"`      // 命中的已知JARM指纹说明（-jarm-list）
+	OCRText        string        `json:"ocr_text,omitempty"`        // 从截图中识别出的文字（-ocr）
+	Placeholder    string        `json:"placeholder,omitempty"`     // 占位页面的类别：停放域名、注册商占位页、服务器默认页、CDN错误页
+	ContentType    string        `json:"content_type,omitempty"`    // 响应的媒体类型，如 text/html、application/json
+	Charset        string        `json:"charset,omitempty"`         // 页面不是UTF-8时的原始字符集，如 gbk、gb18030、big5、iso-8859-2
+	LoginForm      bool          `json:"login_form,omitempty"`      // 初始页面含有密码输入框
+	UploadForm     bool          `json:"upload_form,omitempty"`     // 初始页面含有文件上传表单
+	OpenRedirect   bool          `json:"open_redirect,omitempty"`   // 页面链接或URL中有值为外部地址的跳转参数
+	RedirectParams []string      `json:"redirect_params,omitempty"` // 疑似开放重定向的参数名
+	CheckedAt      time.Time     `json:"checked_at"`                // 发起请求的时间
+}
+
+// 结果值得关注的一条原因：Key为消息模板（同时是翻译目录中的键），Args为填入模板的参数，
+// 便于报告按语言翻译模板后再填入参数
+type Finding struct {
+	Key  string
+	Args []any
+}
+
+// 以中文模板格式化的原因
+func (f Finding) String() string {
+	if len(f.Args) == 0 {
+		return f.Key
+	}
+	return fmt.Sprintf(f.Key, f.Args...)
+}
+
+// 返回结果值得关注的原因（识别出的页面类型、关键词命中、悬挂CNAME），没有则返回nil
+func (r Result) Findings() []Finding {
+	if r.DanglingCNAME {
+		return []Finding{{Key: "悬挂CNAME → %s", Args: []any{r.CNAMEs[len(r.CNAMEs)-1]}}}
+	}
+	var findings []Finding
+	if r.JARMMatch != "" {
+		findings = append(findings, Finding{Key: "JARM指纹命中: %s", Args: []any{r.JARMMatch}})
+	}
+	if !r.Alive {
+		return findings
+	}
+	if r.PageInfo != nil {
+		findings = append(findings, Finding{Key: r.PageInfo.Type})
+	}
+	if len(r.Matches) > 0 {
+		findings = append(findings, Finding{Key: "关键词命中%d处", Args: []any{len(r.Matches)}})
+	}
+	if r.UploadForm {
+		findings = append(findings, Finding{Key: "文件上传表单"})
+	}
+	if r.LoginForm && (r.PageInfo == nil || r.PageInfo.Type != "登录页面") {
+		findings = append(findings, Finding{Key: "登录表单"})
+	}
+	if r.OpenRedirect {
+		findings = append(findings, Finding{Key: "疑似开放重定向参数: %s", Args: []any{strings.Join(r.RedirectParams, ",")}})
+	}
+	if r.CORS != nil && r.CORS.Permissive {
+		findings = append(findings, Finding{Key: "CORS允许任意来源携带凭据"})
+	}
+	if len(r.RiskyMethods) > 0 {
+		findings = append(findings, Finding{Key: "危险的HTTP方法: %s", Args: []any{strings.Join(r.RiskyMethods, ",")}})
+	}
+	return findings
+}
+
+// 以中文格式化的 Findings，用于通知、syslog等不区分语言的输出
+func (r Result) FindingReasons() []string {
+	findings := r.Findings()
+	if len(findings) == 0 {
+		return nil
+	}
+	reasons := make([]string, len(findings))
+	for i, finding := range findings {
+		reasons[i] = finding.String()
+	}
+	return reasons
+}
+
+// 发现的风险等级
+const (
+	SeverityHigh   = "高"
+	SeverityMedium = "中"
+	SeverityLow    = "低"
+)
+
+// 返回结果的风险等级：悬挂CNAME、已知恶意JARM指纹、管理后台/上传页面和文件上传表单为高，
+// 登录页面、登录表单、疑似开放重定向、过于宽松的CORS、危险的HTTP方法或关键词命中为中，API接口为低，无发现返回空字符串
+func (r Result) Severity() string {
+	if r.DanglingCNAME || r.JARMMatch != "" {
+		return SeverityHigh
+	}
+	if !r.Alive {
+		return ""
+	}
+	severity := ""
+	if r.PageInfo != nil {
+		switch r.PageInfo.Type {
+		case "管理后台", "上传页面":
+			return SeverityHigh
+		case "登录页面":
+			severity = SeverityMedium
+		case "API接口":
+			severity = SeverityLow
+		}
+	}
+	if r.UploadForm {
+		return SeverityHigh
+	}
+	if len(r.Matches) > 0 || r.LoginForm || r.OpenRedirect || (r.CORS != nil && r.CORS.Permissive) || len(r.RiskyMethods) > 0 {
+		severity = SeverityMedium
+	}
+	return severity
+}
+
+// 返回用于报告显示的域名，国际化域名显示Unicode形式
+func (r Result) DisplayDomain() string {
+	if r.UnicodeDomain != "" {
+		return r.UnicodeDomain
+	}
+	return r.Domain
+}
+
+// 用于分类规则匹配的URL：优先使用最终落地或重定向目标URL
+func (r Result) pageURL() string {
+	if r.FinalURL != "" {
+		return r.FinalURL
+	}
+	return r.Domain
+}
+
+// 重定向链中的一跳
+type RedirectHop struct {
+	URL    string `json:"url"`
+	Status int    `json:"status"`
 }
 
 // 配置项
@@ -53,8 +227,8 @@ type Config struct {
 
 // 页面类型
 type PageType struct {
-	Type        string // 页面类型：登录页面、后台页面等
-	Description string // 更详细的描述
+	Type        string `json:"type"`        // 页面类型：登录页面、后台页面等
+	Description string `json:"description"` // 更详细的描述
 }
 
 // 截图任务
@@ -172,176 +346,428 @@ func takeScreenshotWithContext(ctx context.Context, url string, screenshotPath s
 	return os.WriteFile(screenshotPath, buf, 0644)
 }
 
-// 检查域名是否存活
-func CheckDomain(domain string, cfg config.Config, resultChan chan<- Result, screenshotPool *screenshot.ScreenshotPool) {
-	// 如果已经指定了协议，直接使用
-	if strings.HasPrefix(domain, "http://") || strings.HasPrefix(domain, "https://") {
-		checkSingleDomain(domain, cfg, resultChan, screenshotPool)
-		return
+// 创建检测结果，国际化域名同时记录Unicode形式
+func newResult(domain string) Result {
+	result := Result{Domain: domain}
+	if unicode := utils.ToUnicodeTarget(domain); unicode != domain {
+		result.UnicodeDomain = unicode
 	}
+	return result
+}
 
-	// 未指定协议，先尝试HTTPS
-	httpsDomain := "https://" + domain
-	httpsResult := Result{
-		Domain: httpsDomain,
-		Alive:  false,
-	}
+// 检查域名是否存活并发送结果，返回HTTP检测的结果（不含截图）
+// 需要截图时结果在截图完成后才发送到resultChan，调用方无需等待截图即可继续检测下一个域名
+func CheckDomain(domain string, cfg config.Config, resultChan chan<- Result, screenshotPool *screenshot.ScreenshotPool) Result {
+	result := Check(domain, cfg)
+	Deliver(result, cfg, resultChan, screenshotPool)
+	return result
+}
 
-	// 创建一个带有连接池的客户端
-	transport := &http.Transport{
-		MaxIdleConns:        100,
-		MaxIdleConnsPerHost: 10,
-		IdleConnTimeout:     30 * time.Second,
-		DisableKeepAlives:   false, // 启用keep-alive
+// 检查域名是否存活（只进行HTTP检测，不截图也不发送结果），启用 -cname 时同时记录CNAME链，
+// 加载了 -geoip 数据库时补充IP的ASN和国家，启用 -cloud 时标记IP所属的云服务商，
+// 启用 -robots 时收集存活主机的 robots.txt 和 sitemap.xml，启用 -cors 时检查CORS配置，启用 -methods 时枚举允许的HTTP方法，启用 -soft404 时检测存活主机是否对不存在的路径返回成功，
+// 指定 -paths 时在存活主机上探测各路径
+func Check(domain string, cfg config.Config) Result {
+	result := checkHTTP(domain, cfg)
+	if cfg.CNAME {
+		annotateCNAME(&result, cfg)
+	}
+	if geoDB != nil && result.IP != "" {
+		info := geoDB.Lookup(result.IP)
+		result.ASN, result.ASOrg, result.Country = info.ASN, info.Org, info.Country
+	}
+	if cloudMatcher != nil && result.IP != "" {
+		result.Cloud = cloudMatcher.Lookup(result.IP)
+	}
+	if len(scanPorts) > 0 {
+		annotatePorts(&result, cfg)
+	}
+	if cfg.Robots {
+		annotateRobots(&result, cfg)
+	}
+	if cfg.CORS {
+		annotateCORS(&result, cfg)
+	}
+	if cfg.Methods {
+		annotateMethods(&result, cfg)
 	}
+	var soft404 *soft404Baseline
+	if cfg.Soft404 {
+		soft404 = annotateSoft404(&result, cfg)
+	}
+	if len(probePaths) > 0 {
+		annotatePaths(&result, soft404, cfg)
+	}
+	if cfg.JARM {
+		annotateJARM(&result, cfg)
+	}
+	return result
+}
 
-	client := &http.Client{
-		Timeout:   time.Duration(cfg.Timeout) * time.Second,
-		Transport: transport,
+// 扫描范围，由 -exclude、-scope 和团队策略的排除规则合并而成
+var targetScope *scope.Scope
+
+// 设置扫描范围：连接被排除的IP、重定向到范围外的主机时不会发起请求；为nil时不限制
+func SetScope(s *scope.Scope) {
+	targetScope = s
+}
+
+// 建立连接前检查解析出的IP是否被排除，拦截解析到范围外地址的域名
+func scopeControl(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil
 	}
+	if ip := net.ParseIP(host); ip != nil && !targetScope.AllowsIP(ip) {
+		return fmt.Errorf("目标IP %s 不在扫描范围内", host)
+	}
+	return nil
+}
 
-	// 处理重定向
-	if !cfg.FollowRedirects {
-		client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
-			return http.ErrUseLastResponse
-		}
+// 云服务商地址段匹配器，通过 -cloud 加载
+var cloudMatcher *cloud.Matcher
+
+// 设置云服务商地址段匹配器，为nil时不识别
+func SetCloudMatcher(m *cloud.Matcher) {
+	cloudMatcher = m
+}
+
+// IP归属查询数据库，通过 -geoip 加载
+var geoDB *geoip.DB
+
+// 设置IP归属查询数据库，为nil时不查询
+func SetGeoIP(db *geoip.DB) {
+	geoDB = db
+}
+
+// HTTP检测：未指定协议时先尝试HTTPS，失败后回退到HTTP
+func checkHTTP(domain string, cfg config.Config) Result {
+	// 如果已经指定了协议，直接使用
+	if strings.HasPrefix(domain, "http://") || strings.HasPrefix(domain, "https://") {
+		return checkSingleDomain(domain, cfg)
 	}
 
+	// 未指定协议，先尝试HTTPS
+	httpsDomain := "https://" + domain
+	httpsResult := newResult(httpsDomain)
+
+	client := sharedHTTPClient(cfg)
+
 	startTime := time.Now()
-	resp, err := client.Get(httpsDomain)
+	httpsResult.CheckedAt = startTime
+	resp, conn, err := doProbe(client, httpsDomain, cfg)
 	responseTime := time.Since(startTime)
 	httpsResult.ResponseTime = responseTime
 
 	if err == nil {
+		httpsResult.IP = conn.remote
+		httpsResult.ClientCert = conn.clientCert
+		if httpsResult.IP == "" {
+			httpsResult.IP = resolveIP(utils.HostFromURL(domain), cfg)
+		}
+		httpsResult.IPFamily = familyOf(httpsResult.IP)
 		defer resp.Body.Close()
 		httpsResult.Status = resp.StatusCode
+		recordTLS(&httpsResult, resp.TLS)
+		httpsResult.FinalURL, httpsResult.RedirectChain = redirectChain(resp)
 
 		// 根据状态码设置状态文本和存活标志
 		httpsResult.StatusText, httpsResult.Alive = getStatusTextAndAlive(resp.StatusCode)
+		if !httpsResult.Alive {
+			httpsResult.ErrorType = ErrorHTTP
+		}
 		httpsResult.Message = http.StatusText(resp.StatusCode)
 
 		// 提取页面信息
-		if resp.StatusCode < 400 {
-			body, err := io.ReadAll(resp.Body)
-			if err == nil {
-				pageContent := string(body)
-				if cfg.ExtractInfo {
-					httpsResult.PageInfo = detectPageType(pageContent)
-				}
-				httpsResult.Title = extractTitle(pageContent)
-			}
-		}
+		analyzeResponse(&httpsResult, resp, cfg)
 
-		// 如果需要截图，使用截图工作池
-		if screenshotPool != nil && (cfg.Screenshot || cfg.ScreenshotAlive) {
-			// 为网站生成唯一的截图文件名
-			screenFilename := generateScreenshotFilename(httpsDomain)
-
-			// 确保截图目录存在
-			if err := os.MkdirAll(cfg.ScreenshotDir, 0755); err == nil {
-				// 提交截图任务到工作池
-				resultCh := screenshotPool.Submit(httpsDomain, screenFilename, cfg.ScreenshotDir)
-
-				// 等待截图结果
-				if screenshotPath := <-resultCh; screenshotPath != "" {
-					// 将完整路径转换为相对路径
-					relPath := filepath.Join("screenshots", filepath.Base(screenshotPath))
-					// 确保使用正斜杠
-					relPath = strings.ReplaceAll(relPath, "\\", "/")
-					httpsResult.Screenshot = relPath
-				}
-			}
+		if cfg.IPFamily == FamilyBoth {
+			httpsResult.Families = probeFamilies(httpsDomain, cfg)
 		}
-
-		resultChan <- httpsResult
-		return
+		return httpsResult
 	}
 
-	// HTTPS请求失败，尝试HTTP
+	// HTTPS请求失败，尝试HTTP；HTTPS因缺少客户端证书失败时在HTTP结果中保留这一信息
 	httpDomain := "http://" + domain
-	checkSingleDomain(httpDomain, cfg, resultChan, screenshotPool)
+	result := checkSingleDomain(httpDomain, cfg)
+	if result.ClientCert == "" {
+		result.ClientCert = conn.clientCert
+	}
+	return result
 }
 
 // 使用指定协议检查单个域名
-func checkSingleDomain(domain string, cfg config.Config, resultChan chan<- Result, screenshotPool *screenshot.ScreenshotPool) {
-	result := Result{
-		Domain: domain,
-		Alive:  false,
-	}
+func checkSingleDomain(domain string, cfg config.Config) Result {
+	result := newResult(domain)
 
-	// 创建一个带有连接池的客户端
-	transport := &http.Transport{
-		MaxIdleConns:        100,
-		MaxIdleConnsPerHost: 10,
-		IdleConnTimeout:     30 * time.Second,
-		DisableKeepAlives:   false, // 启用keep-alive
-	}
-
-	client := &http.Client{
-		Timeout:   time.Duration(cfg.Timeout) * time.Second,
-		Transport: transport,
-	}
-
-	// 处理重定向
-	if !cfg.FollowRedirects {
-		client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
-			return http.ErrUseLastResponse
-		}
-	}
+	client := sharedHTTPClient(cfg)
 
 	startTime := time.Now()
-	resp, err := client.Get(domain)
+	result.CheckedAt = startTime
+	resp, conn, err := doProbe(client, domain, cfg)
 	responseTime := time.Since(startTime)
 	result.ResponseTime = responseTime
+	result.IP = conn.remote
+	result.ClientCert = conn.clientCert
+	if result.IP == "" {
+		result.IP = resolveIP(utils.HostFromURL(domain), cfg)
+	}
+	if cfg.IPFamily == FamilyBoth {
+		result.Families = probeFamilies(domain, cfg)
+	}
 
 	if err != nil {
 		result.Message = err.Error()
+		result.ErrorType = ClassifyError(err)
 		result.StatusText = "无法访问"
-		resultChan <- result
-		return
+		return result
 	}
+	result.IPFamily = familyOf(result.IP)
 	defer resp.Body.Close()
 
 	result.Status = resp.StatusCode
+	recordTLS(&result, resp.TLS)
+	result.FinalURL, result.RedirectChain = redirectChain(resp)
 
 	// 根据状态码设置状态文本和存活标志
 	result.StatusText, result.Alive = getStatusTextAndAlive(resp.StatusCode)
+	if !result.Alive {
+		result.ErrorType = ErrorHTTP
+	}
 	result.Message = http.StatusText(resp.StatusCode)
 
 	// 提取页面信息
-	if resp.StatusCode < 400 {
-		body, err := io.ReadAll(resp.Body)
-		if err == nil {
-			pageContent := string(body)
-			if cfg.ExtractInfo {
-				result.PageInfo = detectPageType(pageContent)
+	analyzeResponse(&result, resp, cfg)
+
+	return result
+}
+
+// 等待截图完成后再发送的结果数
+var pendingScreenshots sync.WaitGroup
+
+// 发送检测结果。需要截图时提交截图任务后立即返回，由后台goroutine在截图完成后补充截图路径并发送结果，
+// 使HTTP检测和截图两类任务重叠进行，而不是让工作者阻塞等待截图；未能建立连接的结果不截图
+func Deliver(result Result, cfg config.Config, resultChan chan<- Result, screenshotPool *screenshot.ScreenshotPool) {
+	url := result.Domain
+	if screenshotPool == nil || !(cfg.Screenshot || cfg.ScreenshotAlive) || result.Status == 0 {
+		resultChan <- result
+		return
+	}
+
+	// 确保截图目录存在
+	dir, relDir := ScreenshotScanDir(cfg)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		resultChan <- result
+		return
+	}
+
+	// 提交截图任务到工作池（工作池队列已满时在此阻塞，避免截图任务无限堆积）
+	filename := allocateScreenshotName(url, dir)
+	resultCh := screenshotPool.Submit(url, filename, dir)
+
+	pendingScreenshots.Add(1)
+	go func() {
+		defer pendingScreenshots.Done()
+		capture := <-resultCh
+		if capture.Path != "" {
+			// 将完整路径转换为报告使用的相对路径（正斜杠），同时记录绝对路径
+			result.Screenshot = filepath.ToSlash(filepath.Join(relDir, filepath.Base(capture.Path)))
+			if abs, err := filepath.Abs(capture.Path); err == nil {
+				result.ScreenshotPath = abs
 			}
-			result.Title = extractTitle(pageContent)
+		} else {
+			releaseScreenshotName(dir, filename)
+		}
+		applyRendered(&result, capture, cfg)
+		applyOCR(&result, capture.Path, cfg)
+		resultChan <- result
+	}()
+}
+
+// 使用浏览器渲染后的标题和DOM补全页面信息：单页应用在JavaScript执行前标题通常为空，
+// 渲染结果可用时以其为准；关键词匹配仍基于原始响应体
+func applyRendered(result *Result, capture screenshot.Capture, cfg config.Config) {
+	if title := strings.TrimSpace(capture.Title); title != "" {
+		result.Title = title
+	} else if capture.HTML != "" && result.Title == "" {
+		result.Title = extractTitle(capture.HTML)
+	}
+	if cfg.ExtractInfo && capture.HTML != "" {
+		if pageInfo := classifyPage(result.pageURL(), result.Title, capture.HTML, result.Status); pageInfo != nil {
+			result.PageInfo = pageInfo
+		}
+	}
+}
+
+// 等待所有截图完成且对应结果已发送
+func WaitPendingScreenshots() {
+	pendingScreenshots.Wait()
+}
+
+// 创建一个带有连接池的客户端
+func newHTTPClient(cfg config.Config) *http.Client {
+	transport := &http.Transport{
+		MaxIdleConns:        100,
+		MaxIdleConnsPerHost: 10,
+		IdleConnTimeout:     30 * time.Second,
+		DisableKeepAlives:   false, // 启用keep-alive
+		DialContext:         dialFunc(cfg.IPFamily, time.Duration(cfg.Timeout)*time.Second),
+		TLSClientConfig:     clientTLSConfig(cfg),
+	}
+
+	client := &http.Client{
+		Timeout: time.Duration(cfg.Timeout) * time.Second,
+		Transport: authTransport{
+			base: recordingTransport{base: transport},
+			ntlmTransport: func() *http.Transport {
+				dedicated := transport.Clone()
+				dedicated.MaxConnsPerHost = 1
+				dedicated.MaxIdleConnsPerHost = 1
+				return dedicated
+			},
+		},
+	}
+
+	// 处理重定向：不跟随时直接返回3xx响应，跟随时超过最大次数后停在最后一个响应
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if !cfg.FollowRedirects || len(via) >= cfg.MaxRedirects {
+			return http.ErrUseLastResponse
+		}
+		// 不跟随到范围外主机的重定向，停在重定向响应上
+		if ok, _ := targetScope.Check(req.URL.Hostname()); !ok {
+			return http.ErrUseLastResponse
 		}
+		return nil
 	}
 
-	// 如果需要截图，使用截图工作池
-	if screenshotPool != nil && (cfg.Screenshot || cfg.ScreenshotAlive) {
-		// 为网站生成唯一的截图文件名
-		screenFilename := generateScreenshotFilename(domain)
+	return client
+}
+
+// 请求的连接信息
+type connInfo struct {
+	remote     string // 首个连接的远端IP，用于判断实际应答的地址
+	clientCert string // 服务器是否请求客户端证书
+}
+
+// 发送GET请求，用于robots、路径探测等附加请求
+func doRequest(client *http.Client, url string, cfg config.Config) (*http.Response, connInfo, error) {
+	return sendRequest(client, http.MethodGet, url, "", "", cfg)
+}
 
-		// 确保截图目录存在
-		if err := os.MkdirAll(cfg.ScreenshotDir, 0755); err == nil {
-			// 提交截图任务到工作池
-			resultCh := screenshotPool.Submit(domain, screenFilename, cfg.ScreenshotDir)
+// 检测请求可用的HTTP方法
+func ValidMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodOptions:
+		return true
+	}
+	return false
+}
 
-			// 等待截图结果
-			if screenshotPath := <-resultCh; screenshotPath != "" {
-				// 将完整路径转换为相对路径
-				relPath := filepath.Join("screenshots", filepath.Base(screenshotPath))
-				// 确保使用正斜杠
-				relPath = strings.ReplaceAll(relPath, "\\", "/")
-				result.Screenshot = relPath
+// 发送检测请求，使用 -method、-data 和 -content-type 指定的方法和请求体
+func doProbe(client *http.Client, url string, cfg config.Config) (*http.Response, connInfo, error) {
+	method := cmp.Or(cfg.Method, http.MethodGet)
+	if cfg.HeadFirst && method == http.MethodGet && cfg.Data == "" {
+		if resp, conn, done, err := headProbe(client, url, cfg); done {
+			return resp, conn, err
+		}
+	}
+	contentType := cfg.ContentType
+	if cfg.Data != "" && contentType == "" {
+		contentType = "application/x-www-form-urlencoded"
+	}
+	return sendRequest(client, method, url, cfg.Data, contentType, cfg)
+}
+
+// 发送请求，附加User-Agent、自定义请求头和Cookie，同时返回连接信息
+func sendRequest(client *http.Client, method, url, body, contentType string, cfg config.Config) (*http.Response, connInfo, error) {
+	var conn connInfo
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			if conn.remote == "" {
+				if host, _, err := net.SplitHostPort(info.Conn.RemoteAddr().String()); err == nil {
+					conn.remote = host
+				}
 			}
+		},
+	}
+	ctx, probe := withClientCertProbe(httptrace.WithClientTrace(context.Background(), trace))
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return nil, conn, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	// 轮换User-Agent，自定义请求头中的User-Agent优先
+	if ua := utils.NextUserAgent(); ua != "" {
+		req.Header.Set("User-Agent", ua)
+	}
+
+	for name, value := range cfg.HeaderValues {
+		if strings.EqualFold(name, "Host") {
+			req.Host = value
+			continue
+		}
+		req.Header.Set(name, value)
+	}
+
+	resp, err := client.Do(req)
+	conn.clientCert = probe.state(err)
+	return resp, conn, err
+}
+
+// 从最终响应回溯重定向链，返回最终URL和每一跳的URL及状态码
+// 没有发生重定向时重定向链为空；未跟随的3xx响应以Location作为最终URL
+func redirectChain(resp *http.Response) (string, []RedirectHop) {
+	finalURL := resp.Request.URL.String()
+
+	var hops []RedirectHop
+	for r := resp.Request.Response; r != nil; r = r.Request.Response {
+		hops = append([]RedirectHop{{URL: r.Request.URL.String(), Status: r.StatusCode}}, hops...)
+	}
+
+	if resp.StatusCode >= 300 && resp.StatusCode < 400 {
+		// 重定向未被跟随（未启用-follow或达到最大次数）
+		if location, err := resp.Location(); err == nil {
+			hops = append(hops, RedirectHop{URL: finalURL, Status: resp.StatusCode})
+			return location.String(), hops
 		}
 	}
 
-	resultChan <- result
+	if len(hops) > 0 {
+		hops = append(hops, RedirectHop{URL: finalURL, Status: resp.StatusCode})
+	}
+	return finalURL, hops
+}
+
+// 解析主机名对应的IP地址（按 -ip-family 选择地址族，默认优先返回IPv4），解析失败返回空字符串
+func resolveIP(host string, cfg config.Config) string {
+	if ip := net.ParseIP(host); ip != nil {
+		return ip.String()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.Timeout)*time.Second)
+	defer cancel()
+
+	ips, err := resolver.lookup(ctx, host)
+	if err != nil || len(ips) == 0 {
+		return ""
+	}
+	wantIPv6 := cfg.IPFamily == FamilyIPv6 || cfg.IPFamily == FamilyPrefer6
+	for _, ip := range ips {
+		if (ip.To4() == nil) == wantIPv6 {
+			return ip.String()
+		}
+	}
+	if cfg.IPFamily == FamilyIPv4 || cfg.IPFamily == FamilyIPv6 {
+		return ""
+	}
+	return ips[0].String()
 }
 
 // 根据状态码返回对应的状态文本和是否存活
@@ -368,6 +794,36 @@ func getStatusTextAndAlive(statusCode int) (string, bool) {
 	}
 }
 
+// 分析页面内容：提取标题、页面类型和关键词命中
+func analyzeBody(result *Result, pageContent string, cfg config.Config) {
+	result.BodyHash, result.SimHash = contentHash(pageContent)
+	if cfg.MatchRegex != "" {
+		result.Matches = findMatches(pageContent, cfg.MatchRegex)
+	}
+
+	// JSON、PDF等非HTML内容不按网页解析，避免误判为登录页面、占位页面等，页面类型按内容类型标注
+	if kind := contentKind(result.ContentType); kind != contentHTML {
+		if kind == contentPDF {
+			result.Title = pdfTitle(pageContent)
+		}
+		if cfg.ExtractInfo {
+			result.PageInfo = matchRules(result.pageURL(), result.Title, pageContent, result.Status)
+			if result.PageInfo == nil {
+				pageType := contentPageTypes[kind]
+				result.PageInfo = &pageType
+			}
+		}
+		return
+	}
+
+	result.Title = extractTitle(pageContent)
+	result.Placeholder = detectPlaceholder(result.pageURL(), result.Title, pageContent, result.Status)
+	detectForms(result, pageContent)
+	if cfg.ExtractInfo {
+		result.PageInfo = classifyPage(result.pageURL(), result.Title, pageContent, result.Status)
+	}
+}
+
 // 检测页面类型
 func detectPageType(content string) *PageType {
 	lowerContent := strings.ToLower(content)
@@ -419,12 +875,15 @@ func detectPageType(content string) *PageType {
 	return nil
 }
 
+// 页面标题正则，预先编译避免每个结果重复编译
+var titleRegex = regexp.MustCompile(`<title[^>]*>(.*?)</title>`)
+
 // 提取页面标题
 func extractTitle(content string) string {
-	titleRegex := regexp.MustCompile(`<title[^>]*>(.*?)</title>`)
 	matches := titleRegex.FindStringSubmatch(content)
 	if len(matches) > 1 {
-		return strings.TrimSpace(matches[1])
+		// 复制标题，避免子串引用使整个响应体无法被回收
+		return strings.Clone(strings.TrimSpace(matches[1]))
 	}
 	return ""
 }
@@ -439,16 +898,6 @@ func containsAny(content string, patterns []string) bool {
 	return false
 }
 
-// 生成截图文件名
-func generateScreenshotFilename(domain string) string {
-	// 将域名中的特殊字符替换为下划线
-	filename := strings.ReplaceAll(domain, "://", "_")
-	filename = strings.ReplaceAll(filename, ".", "_")
-	filename = strings.ReplaceAll(filename, ":", "_")
-	filename = strings.ReplaceAll(filename, "/", "_")
-	return filename + ".png"
-}
-
 // 生成错误图片（当无法截图时）
 func generateErrorImage(filename string, screenshotDir string) error {
 	// 创建截图目录（如果不存在）
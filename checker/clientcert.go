@@ -0,0 +1,102 @@
+package checker
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+
+	"golang.org/x/crypto/pkcs12"
+)
+
+// 服务器在TLS握手中请求客户端证书的情况
+const (
+	ClientCertRequested = "requested" // 服务器请求了客户端证书，握手成功
+	ClientCertRequired  = "required"  // 服务器请求了客户端证书，未提供或证书被拒绝导致握手失败
+)
+
+// 连接目标时使用的客户端证书（-client-cert），为nil时不提供证书
+var clientCert *tls.Certificate
+
+// 设置客户端证书；为nil时服务器请求证书时发送空证书
+func SetClientCertificate(cert *tls.Certificate) {
+	clientCert = cert
+}
+
+// 读取客户端证书：.pfx/.p12 文件使用password解密（可包含证书链），
+// 其他视为PEM证书，keyFile为空时从证书文件中读取私钥
+func LoadClientCertificate(certFile, keyFile, password string) (*tls.Certificate, error) {
+	lower := strings.ToLower(certFile)
+	if strings.HasSuffix(lower, ".pfx") || strings.HasSuffix(lower, ".p12") {
+		data, err := os.ReadFile(certFile)
+		if err != nil {
+			return nil, fmt.Errorf("无法读取客户端证书: %v", err)
+		}
+		blocks, err := pkcs12.ToPEM(data, password)
+		if err != nil {
+			return nil, fmt.Errorf("无法解析PFX客户端证书: %v", err)
+		}
+		var certPEM, keyPEM []byte
+		for _, block := range blocks {
+			if block.Type == "PRIVATE KEY" {
+				keyPEM = append(keyPEM, pem.EncodeToMemory(block)...)
+			} else {
+				certPEM = append(certPEM, pem.EncodeToMemory(block)...)
+			}
+		}
+		cert, err := tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			return nil, fmt.Errorf("无法解析PFX客户端证书: %v", err)
+		}
+		return &cert, nil
+	}
+
+	if keyFile == "" {
+		keyFile = certFile
+	}
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("无法读取客户端证书: %v", err)
+	}
+	return &cert, nil
+}
+
+// 记录请求过程中服务器是否请求了客户端证书，通过请求的context传递到TLS握手
+type clientCertProbe struct {
+	requested atomic.Bool
+}
+
+type clientCertProbeKey struct{}
+
+// 服务器请求客户端证书时回调：记录请求并返回配置的证书
+func getClientCertificate(info *tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	if probe, ok := info.Context().Value(clientCertProbeKey{}).(*clientCertProbe); ok {
+		probe.requested.Store(true)
+	}
+	if clientCert == nil {
+		return &tls.Certificate{}, nil
+	}
+	return clientCert, nil
+}
+
+// 在context中附加证书请求记录
+func withClientCertProbe(ctx context.Context) (context.Context, *clientCertProbe) {
+	probe := &clientCertProbe{}
+	return context.WithValue(ctx, clientCertProbeKey{}, probe), probe
+}
+
+// 根据握手结果得到客户端证书状态，服务器未请求证书时为空。
+// 服务器只在新建连接时请求证书，复用连接的请求不会记录
+func (p *clientCertProbe) state(err error) string {
+	if !p.requested.Load() {
+		return ""
+	}
+	// TLS 1.3中服务器在握手完成后才发送拒绝告警，表现为读取响应失败，因此请求了证书且请求失败即视为必需
+	if err != nil {
+		return ClientCertRequired
+	}
+	return ClientCertRequested
+}
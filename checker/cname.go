@@ -0,0 +1,171 @@
+package checker
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"math/rand"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"subdomain-checker/config"
+	"subdomain-checker/logger"
+	"subdomain-checker/utils"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// CNAME链的最大长度，防止CNAME循环
+const maxCNAMEHops = 10
+
+// 系统DNS配置不可用时（如Windows）使用的DNS服务器
+const fallbackResolver = "8.8.8.8:53"
+
+// 读取 /etc/resolv.conf 中的第一个DNS服务器
+func systemResolver() string {
+	file, err := os.Open("/etc/resolv.conf")
+	if err != nil {
+		return fallbackResolver
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "nameserver" {
+			return net.JoinHostPort(fields[1], "53")
+		}
+	}
+	return fallbackResolver
+}
+
+// 返回CNAME查询使用的DNS服务器地址
+func cnameResolver(cfg config.Config) string {
+	server := cfg.CNAMEResolver
+	if server == "" {
+		return systemResolver()
+	}
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(server, "53")
+	}
+	return server
+}
+
+// 向DNS服务器发送一次查询（UDP）
+func queryDNS(ctx context.Context, server, name string, qtype dnsmessage.Type) (*dnsmessage.Message, error) {
+	qname, err := dnsmessage.NewName(dnsFQDN(name))
+	if err != nil {
+		return nil, err
+	}
+	id := uint16(rand.Intn(1 << 16))
+	query := dnsmessage.Message{
+		Header:    dnsmessage.Header{ID: id, RecursionDesired: true},
+		Questions: []dnsmessage.Question{{Name: qname, Type: qtype, Class: dnsmessage.ClassINET}},
+	}
+	packet, err := query.Pack()
+	if err != nil {
+		return nil, err
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "udp", server)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	if _, err := conn.Write(packet); err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, 4096)
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return nil, err
+		}
+		var response dnsmessage.Message
+		if err := response.Unpack(buf[:n]); err != nil || response.ID != id {
+			continue // 忽略无法解析或不属于本次查询的应答
+		}
+		return &response, nil
+	}
+}
+
+func dnsFQDN(name string) string {
+	if strings.HasSuffix(name, ".") {
+		return name
+	}
+	return name + "."
+}
+
+// CNAME链的查询结果
+type CNAMEInfo struct {
+	Chain    []string // 依次指向的CNAME目标，不含查询的主机名本身
+	Dangling bool     // 链末端的目标不存在（NXDOMAIN），是子域名接管的高危候选
+}
+
+// 逐级查询主机名的CNAME记录，记录完整的CNAME链，并检查链末端的目标是否存在
+func LookupCNAMEChain(host string, cfg config.Config) (CNAMEInfo, error) {
+	var info CNAMEInfo
+	if host == "" || net.ParseIP(host) != nil {
+		return info, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.Timeout)*time.Second)
+	defer cancel()
+	server := cnameResolver(cfg)
+
+	name := host
+	seen := map[string]bool{strings.ToLower(dnsFQDN(host)): true}
+	for len(info.Chain) < maxCNAMEHops {
+		response, err := queryDNS(ctx, server, name, dnsmessage.TypeCNAME)
+		if err != nil {
+			return info, fmt.Errorf("查询 %s 的CNAME记录失败: %v", name, err)
+		}
+		target := ""
+		for _, answer := range response.Answers {
+			if cname, ok := answer.Body.(*dnsmessage.CNAMEResource); ok && strings.EqualFold(answer.Header.Name.String(), dnsFQDN(name)) {
+				target = cname.CNAME.String()
+				break
+			}
+		}
+		if target == "" {
+			break
+		}
+		if seen[strings.ToLower(target)] {
+			return info, fmt.Errorf("CNAME链存在循环: %s", target)
+		}
+		seen[strings.ToLower(target)] = true
+		info.Chain = append(info.Chain, strings.TrimSuffix(target, "."))
+		name = target
+	}
+	if len(info.Chain) == 0 {
+		return info, nil
+	}
+
+	// 链末端的目标返回NXDOMAIN即为悬挂CNAME
+	response, err := queryDNS(ctx, server, name, dnsmessage.TypeA)
+	if err != nil {
+		return info, fmt.Errorf("查询 %s 失败: %v", name, err)
+	}
+	info.Dangling = response.RCode == dnsmessage.RCodeNameError
+	return info, nil
+}
+
+// 为检测结果补充CNAME链；DNS解析失败且CNAME悬挂时，失败类型改为悬挂CNAME以区别于普通的解析失败
+func annotateCNAME(result *Result, cfg config.Config) {
+	info, err := LookupCNAMEChain(utils.HostFromURL(result.Domain), cfg)
+	if err != nil {
+		logger.Debug("CNAME查询失败", "domain", result.Domain, "error", err)
+	}
+	result.CNAMEs = info.Chain
+	result.DanglingCNAME = info.Dangling
+	if info.Dangling && !result.Alive && (result.ErrorType == ErrorDNS || result.ErrorType == ErrorOther) {
+		result.ErrorType = ErrorDangling
+	}
+}
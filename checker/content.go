@@ -0,0 +1,151 @@
+package checker
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"subdomain-checker/config"
+	"subdomain-checker/logger"
+	"subdomain-checker/utils"
+
+	"github.com/andybalholm/brotli"
+)
+
+// 读取并分析响应：解压响应体、识别内容类型后提取页面信息，错误状态码只识别占位页面；
+// 启用 -capture-headers 时同时记录响应头和Cookie
+func analyzeResponse(result *Result, resp *http.Response, cfg config.Config) {
+	if cfg.CaptureHeaders {
+		captureHeaders(result, resp, cfg)
+	}
+	contentType := resp.Header.Get("Content-Type")
+	body, err := decodedBody(resp)
+	if err != nil {
+		// 无法解压的内容不做分析，避免把压缩数据当作页面内容
+		result.ContentType = mediaType(contentType)
+		logger.Debug("响应体无法解压", "url", result.Domain, "error", err)
+		return
+	}
+	if resp.StatusCode >= 400 {
+		analyzeErrorPage(result, body, contentType, cfg)
+		return
+	}
+	page, err := readBody(body)
+	if err != nil {
+		return
+	}
+	result.ContentType = detectContentType(contentType, page)
+	switch contentKind(result.ContentType) {
+	case contentHTML, contentJSON, contentXML, contentText:
+		// 非UTF-8的文本内容转换为UTF-8后再提取标题和匹配关键词
+		page, result.Charset = utils.ToUTF8(page, contentType)
+	}
+	analyzeBody(result, page, cfg)
+	archiveResponse(result, resp, page)
+}
+
+// 按Content-Encoding解压响应体。Transport自动解压的gzip响应直接返回；
+// 自定义了Accept-Encoding请求头时由这里解压gzip、deflate和br，不支持的编码（如zstd）返回错误
+func decodedBody(resp *http.Response) (io.Reader, error) {
+	encoding := strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding")))
+	if resp.Uncompressed || encoding == "" || encoding == "identity" {
+		return resp.Body, nil
+	}
+	switch encoding {
+	case "gzip", "x-gzip":
+		return gzip.NewReader(resp.Body)
+	case "deflate":
+		// deflate按规范是zlib格式，但不少服务器发送不带zlib头的原始deflate数据
+		r := bufio.NewReader(resp.Body)
+		if header, err := r.Peek(2); err == nil && header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+			return zlib.NewReader(r)
+		}
+		return flate.NewReader(r), nil
+	case "br":
+		return brotli.NewReader(resp.Body), nil
+	}
+	return nil, fmt.Errorf("不支持的内容编码 %s", encoding)
+}
+
+// 取出Content-Type中的媒体类型（不含charset等参数）
+func mediaType(contentType string) string {
+	if contentType == "" {
+		return ""
+	}
+	if media, _, err := mime.ParseMediaType(contentType); err == nil {
+		return media
+	}
+	return strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+}
+
+// 识别响应的内容类型：优先使用Content-Type，缺失或为通用类型时根据内容判断；
+// 声明为纯文本但内容是HTML的页面按HTML处理
+func detectContentType(header, body string) string {
+	declared := mediaType(header)
+	sniffed := mediaType(http.DetectContentType([]byte(body)))
+	switch {
+	case declared == "", declared == "application/octet-stream":
+		return sniffed
+	case declared == "text/plain" && sniffed == "text/html":
+		return sniffed
+	}
+	return declared
+}
+
+// 内容类型的类别，决定如何提取页面信息
+const (
+	contentHTML   = "html"
+	contentJSON   = "json"
+	contentXML    = "xml"
+	contentText   = "text"
+	contentPDF    = "pdf"
+	contentImage  = "image"
+	contentBinary = "binary"
+)
+
+func contentKind(media string) string {
+	switch {
+	case media == "" || media == "text/html" || media == "application/xhtml+xml":
+		return contentHTML
+	case media == "application/json" || strings.HasSuffix(media, "+json"):
+		return contentJSON
+	case media == "application/xml" || media == "text/xml" || strings.HasSuffix(media, "+xml"):
+		return contentXML
+	case media == "application/pdf":
+		return contentPDF
+	case strings.HasPrefix(media, "image/"):
+		return contentImage
+	case strings.HasPrefix(media, "text/") || media == "application/javascript":
+		return contentText
+	}
+	return contentBinary
+}
+
+// 非HTML内容的页面类型，分类规则没有命中时使用
+var contentPageTypes = map[string]PageType{
+	contentJSON:   {Type: "API接口", Description: "JSON响应"},
+	contentXML:    {Type: "API接口", Description: "XML响应"},
+	contentText:   {Type: "纯文本", Description: "非HTML的文本内容"},
+	contentPDF:    {Type: "文档", Description: "PDF文件"},
+	contentImage:  {Type: "图片", Description: "图片文件"},
+	contentBinary: {Type: "二进制文件", Description: "非文本内容"},
+}
+
+// PDF文档信息中的标题，如 /Title (年度报告)
+var pdfTitleRegex = regexp.MustCompile(`/Title\s*\(((?:[^()\\]|\\.)*)\)`)
+
+// 提取未压缩的PDF文档信息中的标题，UTF-16等编码的标题不提取
+func pdfTitle(body string) string {
+	matches := pdfTitleRegex.FindStringSubmatch(body)
+	if len(matches) < 2 || strings.HasPrefix(matches[1], "\xfe\xff") {
+		return ""
+	}
+	return strings.Clone(strings.TrimSpace(strings.ReplaceAll(matches[1], `\`, "")))
+}
@@ -0,0 +1,64 @@
+package checker
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"subdomain-checker/archive"
+	"subdomain-checker/config"
+
+	"github.com/andybalholm/brotli"
+)
+
+// br压缩的响应解压后提取标题，归档中保存解压后的响应体且不再声明Content-Encoding
+func TestAnalyzeBrotliResponse(t *testing.T) {
+	var compressed bytes.Buffer
+	w := brotli.NewWriter(&compressed)
+	io.WriteString(w, "<html><head><title>Brotli页面</title></head><body>hello</body></html>")
+	w.Close()
+
+	dir := t.TempDir()
+	a, err := archive.Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetRawArchive(a, 64<<10)
+	defer SetRawArchive(nil, 0)
+
+	resp := &http.Response{
+		Proto:      "HTTP/1.1",
+		Status:     "200 OK",
+		StatusCode: http.StatusOK,
+		Header: http.Header{
+			"Content-Type":     {"text/html; charset=utf-8"},
+			"Content-Encoding": {"br"},
+			"Content-Length":   {"64"},
+		},
+		Body: io.NopCloser(&compressed),
+	}
+	result := Result{Domain: "https://www.example.com:8443", Alive: true}
+	analyzeResponse(&result, resp, config.Config{})
+	if result.Title != "Brotli页面" {
+		t.Errorf("title = %q", result.Title)
+	}
+
+	if result.RawResponse == "" {
+		t.Fatal("response not archived")
+	}
+	saved, err := os.ReadFile(filepath.Join(dir, filepath.Base(result.RawResponse)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := string(saved)
+	if strings.Contains(text, "Content-Encoding") || strings.Contains(text, "Content-Length") {
+		t.Errorf("archive keeps encoding headers of the compressed body:\n%s", text)
+	}
+	if !strings.Contains(text, "<title>Brotli页面</title>") {
+		t.Errorf("archive does not contain the decoded body:\n%s", text)
+	}
+}
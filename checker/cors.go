@@ -0,0 +1,59 @@
+package checker
+
+import (
+	"maps"
+	"strings"
+
+	"subdomain-checker/config"
+)
+
+// -cors 未指定 -cors-origin 时发送的测试Origin，不属于任何真实站点
+const DefaultCORSOrigin = "https://squirrel-cors-check.example"
+
+// CORS配置检测结果（-cors）
+type CORSInfo struct {
+	Origin           string `json:"origin"`                      // 请求中发送的测试Origin
+	AllowOrigin      string `json:"allow_origin,omitempty"`      // 响应的 Access-Control-Allow-Origin
+	AllowCredentials bool   `json:"allow_credentials,omitempty"` // 响应的 Access-Control-Allow-Credentials 为 true
+	Reflected        bool   `json:"reflected,omitempty"`         // Access-Control-Allow-Origin 回显了测试Origin
+	Permissive       bool   `json:"permissive,omitempty"`        // 回显任意Origin且允许携带凭据，任意站点都能以用户身份读取响应
+}
+
+// 以测试Origin重新请求存活主机，记录响应的CORS头。
+// 回显测试Origin说明服务端接受任意来源；同时允许携带凭据时标记为过于宽松。
+// 通配符 * 与凭据同时出现时浏览器会拒绝，不视为过于宽松
+func annotateCORS(result *Result, cfg config.Config) {
+	if !result.Alive {
+		return
+	}
+	origin := cfg.CORSOrigin
+	if origin == "" {
+		origin = DefaultCORSOrigin
+	}
+	// 在自定义请求头之外附加Origin，覆盖 -header 中可能指定的Origin
+	probeCfg := cfg
+	probeCfg.HeaderValues = maps.Clone(cfg.HeaderValues)
+	if probeCfg.HeaderValues == nil {
+		probeCfg.HeaderValues = make(map[string]string)
+	}
+	for name := range probeCfg.HeaderValues {
+		if strings.EqualFold(name, "Origin") {
+			delete(probeCfg.HeaderValues, name)
+		}
+	}
+	probeCfg.HeaderValues["Origin"] = origin
+	resp, _, err := doRequest(sharedHTTPClient(cfg), result.Domain, probeCfg)
+	if err != nil {
+		return
+	}
+	resp.Body.Close()
+
+	info := &CORSInfo{
+		Origin:           origin,
+		AllowOrigin:      resp.Header.Get("Access-Control-Allow-Origin"),
+		AllowCredentials: strings.EqualFold(strings.TrimSpace(resp.Header.Get("Access-Control-Allow-Credentials")), "true"),
+	}
+	info.Reflected = strings.EqualFold(strings.TrimRight(info.AllowOrigin, "/"), strings.TrimRight(origin, "/"))
+	info.Permissive = info.Reflected && info.AllowCredentials
+	result.CORS = info
+}
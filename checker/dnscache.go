@@ -0,0 +1,187 @@
+package checker
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 单次DNS解析的超时时间
+const dnsLookupTimeout = 10 * time.Second
+
+// 解析失败的结果最多缓存的时间，避免临时故障影响整个扫描
+const dnsNegativeTTL = 30 * time.Second
+
+// 进程内DNS缓存：同一主机的HTTPS/HTTP检测、IP记录、地址族探测、路径探测等共用一次解析结果，
+// 并发解析同一主机时只发起一次查询，其他请求等待该查询完成。
+// 缓存的有效期固定为 -dns-cache-ttl，不使用DNS记录自身的TTL（标准库的解析接口不返回TTL）
+type dnsCache struct {
+	mu      sync.Mutex
+	entries map[string]*dnsEntry // 键为 normalizeHost 处理后的主机名
+	ttl     time.Duration        // 为0时不缓存
+	pinned  map[string][]net.IP
+
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+type dnsEntry struct {
+	ready   chan struct{} // 查询完成后关闭
+	ips     []net.IP
+	err     error
+	expires time.Time
+}
+
+var resolver = &dnsCache{entries: make(map[string]*dnsEntry), pinned: make(map[string][]net.IP), ttl: 5 * time.Minute}
+
+// 设置DNS缓存的有效期，为0时不缓存
+func SetDNSCacheTTL(ttl time.Duration) {
+	resolver.mu.Lock()
+	defer resolver.mu.Unlock()
+	resolver.ttl = ttl
+	resolver.entries = make(map[string]*dnsEntry)
+}
+
+// 固定主机名解析到的地址，不再查询DNS。用于虚拟主机探测发现的主机，这些主机名通常没有公开的DNS记录
+func PinHost(host, ip string) {
+	resolver.mu.Lock()
+	defer resolver.mu.Unlock()
+	resolver.pinned[normalizeHost(host)] = []net.IP{net.ParseIP(ip)}
+}
+
+// 缓存键使用的主机名：小写，去掉末尾的点，使 Example.COM 与 example.com. 共用同一条缓存
+func normalizeHost(host string) string {
+	return strings.TrimSuffix(strings.ToLower(host), ".")
+}
+
+// 返回主机名已固定或已缓存且未过期的解析结果，不发起查询；没有可用结果时返回nil。
+// 截图时浏览器据此连接与检测相同的IP
+func CachedIPs(host string) []net.IP {
+	host = normalizeHost(host)
+	resolver.mu.Lock()
+	defer resolver.mu.Unlock()
+	if ips, ok := resolver.pinned[host]; ok {
+		return ips
+	}
+	entry, ok := resolver.entries[host]
+	if !ok {
+		return nil
+	}
+	select {
+	case <-entry.ready:
+	default:
+		return nil
+	}
+	if entry.err != nil || entry.expired() {
+		return nil
+	}
+	return entry.ips
+}
+
+// 解析主机名的所有IP地址（经过DNS缓存），IP地址直接返回
+func Resolve(host string, timeout time.Duration) ([]net.IP, error) {
+	if ip := net.ParseIP(host); ip != nil {
+		return []net.IP{ip}, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return resolver.lookup(ctx, host)
+}
+
+// 返回DNS缓存的命中次数和实际查询次数
+func DNSCacheStats() (hits, misses int64) {
+	return resolver.hits.Load(), resolver.misses.Load()
+}
+
+// 解析主机名的所有IP地址，缓存未过期时直接使用缓存
+func (c *dnsCache) lookup(ctx context.Context, host string) ([]net.IP, error) {
+	host = normalizeHost(host)
+	c.mu.Lock()
+	if ips, ok := c.pinned[host]; ok {
+		c.mu.Unlock()
+		return ips, nil
+	}
+	if c.ttl <= 0 {
+		c.mu.Unlock()
+		return net.DefaultResolver.LookupIP(ctx, "ip", host)
+	}
+	entry, ok := c.entries[host]
+	if ok && !entry.expired() {
+		c.mu.Unlock()
+		c.hits.Add(1)
+		select {
+		case <-entry.ready:
+			return entry.ips, entry.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	entry = &dnsEntry{ready: make(chan struct{})}
+	c.entries[host] = entry
+	ttl := c.ttl
+	c.mu.Unlock()
+	c.misses.Add(1)
+
+	// 查询不使用调用方的context，以免单个请求取消后把取消错误缓存给其他请求
+	lookupCtx, cancel := context.WithTimeout(context.Background(), dnsLookupTimeout)
+	entry.ips, entry.err = net.DefaultResolver.LookupIP(lookupCtx, "ip", host)
+	cancel()
+	if entry.err != nil {
+		ttl = min(ttl, dnsNegativeTTL)
+	}
+	entry.expires = time.Now().Add(ttl)
+	close(entry.ready)
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+		return entry.ips, entry.err
+	}
+}
+
+// 查询完成且已超过有效期；查询进行中的条目不算过期
+func (e *dnsEntry) expired() bool {
+	select {
+	case <-e.ready:
+		return time.Now().After(e.expires)
+	default:
+		return false
+	}
+}
+
+// 通过DNS缓存解析后建立连接：network为tcp4/tcp6时只使用对应地址族的地址，依次尝试各地址直到连接成功
+func dialCached(ctx context.Context, dialer *net.Dialer, network, addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil || net.ParseIP(host) != nil {
+		return dialer.DialContext(ctx, network, addr)
+	}
+	ips, err := resolver.lookup(ctx, host)
+	if err != nil {
+		return nil, &net.OpError{Op: "dial", Net: network, Err: err}
+	}
+
+	var firstErr error
+	for _, ip := range ips {
+		if (network == "tcp4" && ip.To4() == nil) || (network == "tcp6" && ip.To4() != nil) {
+			continue
+		}
+		conn, err := dialer.DialContext(ctx, network, net.JoinHostPort(ip.String(), port))
+		if err == nil {
+			return conn, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	if firstErr == nil {
+		firstErr = &net.OpError{Op: "dial", Net: network, Err: &net.AddrError{Err: "no suitable address found", Addr: host}}
+	}
+	return nil, firstErr
+}
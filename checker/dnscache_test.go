@@ -0,0 +1,35 @@
+package checker
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// 大小写和末尾的点不同的主机名共用同一条缓存，CachedIPs 只返回已缓存的结果
+func TestDNSCacheNormalizesHosts(t *testing.T) {
+	SetDNSCacheTTL(time.Minute)
+	defer SetDNSCacheTTL(5 * time.Minute)
+
+	if ips := CachedIPs("localhost"); ips != nil {
+		t.Fatalf("CachedIPs before lookup = %v", ips)
+	}
+	_, missesBefore := DNSCacheStats()
+	for _, host := range []string{"localhost", "LocalHost", "localhost."} {
+		if _, err := resolver.lookup(context.Background(), host); err != nil {
+			t.Fatalf("lookup %s: %v", host, err)
+		}
+	}
+	if _, misses := DNSCacheStats(); misses-missesBefore != 1 {
+		t.Errorf("%d queries for one host, want 1", misses-missesBefore)
+	}
+	if ips := CachedIPs("LOCALHOST."); len(ips) == 0 {
+		t.Error("CachedIPs did not return the cached addresses")
+	}
+
+	PinHost("Dev.Example.com.", "10.0.0.5")
+	defer delete(resolver.pinned, "dev.example.com")
+	if ips, err := Resolve("dev.example.com", time.Second); err != nil || len(ips) != 1 || ips[0].String() != "10.0.0.5" {
+		t.Errorf("pinned host resolved to %v, %v", ips, err)
+	}
+}
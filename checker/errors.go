@@ -0,0 +1,97 @@
+package checker
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"syscall"
+)
+
+// 检测失败的类型
+type ErrorType string
+
+const (
+	ErrorDNS      ErrorType = "dns"      // DNS解析失败
+	ErrorDangling ErrorType = "dangling" // CNAME指向不存在的域名（悬挂CNAME）
+	ErrorRefused  ErrorType = "refused"  // 连接被拒绝
+	ErrorTimeout  ErrorType = "timeout"  // 连接或响应超时
+	ErrorTLS      ErrorType = "tls"      // TLS握手或证书错误
+	ErrorReset    ErrorType = "reset"    // 连接被重置或意外关闭
+	ErrorHTTP     ErrorType = "http"     // 收到HTTP响应但状态码表示不可用
+	ErrorSoft404  ErrorType = "soft404"  // 页面与不存在路径的响应相同（-soft404）
+	ErrorOther    ErrorType = "other"    // 其他错误
+)
+
+// 返回错误类型的中文名称
+func (t ErrorType) Label() string {
+	switch t {
+	case ErrorDNS:
+		return "DNS解析失败"
+	case ErrorDangling:
+		return "悬挂CNAME"
+	case ErrorRefused:
+		return "连接被拒绝"
+	case ErrorTimeout:
+		return "连接超时"
+	case ErrorTLS:
+		return "TLS错误"
+	case ErrorReset:
+		return "连接被重置"
+	case ErrorHTTP:
+		return "HTTP错误"
+	case ErrorSoft404:
+		return "软404"
+	case ErrorOther:
+		return "其他错误"
+	}
+	return ""
+}
+
+// 判断错误是否表明网络或目标可能过载（超时、连接重置）
+func (t ErrorType) Transient() bool {
+	return t == ErrorTimeout || t == ErrorReset
+}
+
+// 根据请求返回的错误判断失败类型
+func ClassifyError(err error) ErrorType {
+	if err == nil {
+		return ""
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return ErrorDNS
+	}
+	if errors.Is(err, syscall.ECONNREFUSED) {
+		return ErrorRefused
+	}
+
+	var certErr *tls.CertificateVerificationError
+	var recordErr tls.RecordHeaderError
+	var alertErr tls.AlertError
+	var authorityErr x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var invalidErr x509.CertificateInvalidError
+	if errors.As(err, &certErr) || errors.As(err, &recordErr) || errors.As(err, &alertErr) ||
+		errors.As(err, &authorityErr) || errors.As(err, &hostnameErr) || errors.As(err, &invalidErr) {
+		return ErrorTLS
+	}
+
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return ErrorTimeout
+	}
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return ErrorReset
+	}
+
+	// 部分TLS错误没有导出的类型，只能通过消息判断
+	if msg := err.Error(); strings.Contains(msg, "tls:") || strings.Contains(msg, "x509:") {
+		return ErrorTLS
+	}
+	return ErrorOther
+}
@@ -0,0 +1,139 @@
+package checker
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"subdomain-checker/config"
+	"subdomain-checker/utils"
+)
+
+// 地址族选择
+const (
+	FamilyAuto    = "auto"    // 由系统决定（双栈时同时尝试）
+	FamilyIPv4    = "4"       // 只使用IPv4
+	FamilyIPv6    = "6"       // 只使用IPv6
+	FamilyPrefer4 = "prefer4" // 优先IPv4，失败时回退到IPv6
+	FamilyPrefer6 = "prefer6" // 优先IPv6，失败时回退到IPv4
+	FamilyBoth    = "both"    // 分别检测IPv4和IPv6
+)
+
+// 校验地址族参数
+func ValidIPFamily(family string) bool {
+	switch family {
+	case FamilyAuto, FamilyIPv4, FamilyIPv6, FamilyPrefer4, FamilyPrefer6, FamilyBoth:
+		return true
+	}
+	return false
+}
+
+// 单个地址族的检测结果
+type FamilyCheck struct {
+	Family string `json:"family"` // IPv4或IPv6
+	IP     string `json:"ip,omitempty"`
+	Status int    `json:"status"`
+	Alive  bool   `json:"alive"`
+	Error  string `json:"error,omitempty"`
+}
+
+// 返回地址对应的地址族名称
+func familyOf(ip string) string {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return ""
+	}
+	if parsed.To4() != nil {
+		return "IPv4"
+	}
+	return "IPv6"
+}
+
+// 按地址族选择创建拨号函数
+func dialFunc(family string, timeout time.Duration) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: timeout, Control: scopeControl}
+	dial := func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return throttle(dialCached(ctx, dialer, network, addr))
+	}
+	switch family {
+	case FamilyIPv4:
+		return func(ctx context.Context, _, addr string) (net.Conn, error) {
+			return dial(ctx, "tcp4", addr)
+		}
+	case FamilyIPv6:
+		return func(ctx context.Context, _, addr string) (net.Conn, error) {
+			return dial(ctx, "tcp6", addr)
+		}
+	case FamilyPrefer4, FamilyPrefer6:
+		first, second := "tcp4", "tcp6"
+		if family == FamilyPrefer6 {
+			first, second = second, first
+		}
+		return func(ctx context.Context, _, addr string) (net.Conn, error) {
+			conn, err := dial(ctx, first, addr)
+			if err == nil {
+				return conn, nil
+			}
+			if conn, fallbackErr := dial(ctx, second, addr); fallbackErr == nil {
+				return conn, nil
+			}
+			return nil, err
+		}
+	}
+	return dial
+}
+
+// 分别通过IPv4和IPv6检测目标，只检测有对应A/AAAA记录的地址族
+func probeFamilies(target string, cfg config.Config) []FamilyCheck {
+	host := utils.HostFromURL(target)
+	var families []string
+	if ip := net.ParseIP(host); ip != nil {
+		families = []string{familyOf(host)}
+	} else {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.Timeout)*time.Second)
+		defer cancel()
+		ips, _ := resolver.lookup(ctx, host)
+		for _, family := range []string{"IPv4", "IPv6"} {
+			for _, ip := range ips {
+				if familyOf(ip.String()) == family {
+					families = append(families, family)
+					break
+				}
+			}
+		}
+	}
+
+	var checks []FamilyCheck
+	for _, family := range families {
+		familyCfg := cfg
+		familyCfg.IPFamily = map[string]string{"IPv4": FamilyIPv4, "IPv6": FamilyIPv6}[family]
+		check := FamilyCheck{Family: family}
+
+		resp, conn, err := doProbe(sharedHTTPClient(familyCfg), target, familyCfg)
+		if err != nil {
+			check.Error = err.Error()
+		} else {
+			resp.Body.Close()
+			check.Status = resp.StatusCode
+			_, check.Alive = getStatusTextAndAlive(resp.StatusCode)
+		}
+		check.IP = conn.remote
+		checks = append(checks, check)
+	}
+	return checks
+}
+
+// 汇总各地址族的检测结果，如 "IPv4:200; IPv6:失败"
+func FormatFamilies(checks []FamilyCheck) string {
+	parts := make([]string, 0, len(checks))
+	for _, check := range checks {
+		if check.Error != "" {
+			parts = append(parts, check.Family+":失败")
+		} else {
+			parts = append(parts, fmt.Sprintf("%s:%d", check.Family, check.Status))
+		}
+	}
+	return strings.Join(parts, "; ")
+}
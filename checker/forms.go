@@ -0,0 +1,80 @@
+package checker
+
+import (
+	"html"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	passwordInputRe = regexp.MustCompile(`(?i)<input\b[^>]*\btype\s*=\s*["']?password\b`)
+	fileInputRe     = regexp.MustCompile(`(?i)<input\b[^>]*\btype\s*=\s*["']?file\b|<form\b[^>]*\benctype\s*=\s*["']?multipart/form-data`)
+	linkAttrRe      = regexp.MustCompile(`(?i)\b(?:href|action|src|data-url|content)\s*=\s*["']([^"'<>]+)["']`)
+)
+
+// 常用于指定跳转目标的参数名（小写）
+var redirectParamNames = map[string]bool{
+	"redirect": true, "redirect_uri": true, "redirect_url": true, "redirecturl": true, "redirect_to": true,
+	"redir": true, "return": true, "returnurl": true, "return_url": true, "returnto": true, "return_to": true,
+	"next": true, "url": true, "goto": true, "go": true, "dest": true, "destination": true, "continue": true,
+	"target": true, "forward": true, "rurl": true, "callback": true, "to": true, "out": true, "service": true,
+	"success_url": true, "backurl": true, "back_url": true, "jump": true, "jumpurl": true, "link": true,
+}
+
+// 检查初始页面中的登录表单（密码输入框）、文件上传表单，以及值为外部地址的跳转参数，
+// 结果用于确定人工复查的优先级，只对存活页面检查
+func detectForms(result *Result, body string) {
+	if !result.Alive {
+		return
+	}
+	result.LoginForm = passwordInputRe.MatchString(body)
+	result.UploadForm = fileInputRe.MatchString(body)
+
+	candidates := []string{result.Domain, result.FinalURL}
+	for _, hop := range result.RedirectChain {
+		candidates = append(candidates, hop.URL)
+	}
+	for _, m := range linkAttrRe.FindAllStringSubmatch(body, -1) {
+		candidates = append(candidates, html.UnescapeString(m[1]))
+	}
+	params := make(map[string]bool)
+	for _, candidate := range candidates {
+		for _, name := range redirectParams(candidate) {
+			params[name] = true
+		}
+	}
+	for name := range params {
+		result.RedirectParams = append(result.RedirectParams, name)
+	}
+	sort.Strings(result.RedirectParams)
+	result.OpenRedirect = len(result.RedirectParams) > 0
+}
+
+// 返回URL中名称像跳转参数、且值为绝对地址（http(s)://或//开头）的参数名
+func redirectParams(rawURL string) []string {
+	_, query, ok := strings.Cut(rawURL, "?")
+	if !ok {
+		return nil
+	}
+	query, _, _ = strings.Cut(query, "#")
+	values, err := url.ParseQuery(query)
+	if err != nil && len(values) == 0 {
+		return nil
+	}
+	var names []string
+	for name, vals := range values {
+		if !redirectParamNames[strings.ToLower(name)] {
+			continue
+		}
+		for _, v := range vals {
+			v = strings.ToLower(strings.TrimSpace(v))
+			if strings.HasPrefix(v, "http://") || strings.HasPrefix(v, "https://") || strings.HasPrefix(v, "//") {
+				names = append(names, name)
+				break
+			}
+		}
+	}
+	return names
+}
@@ -0,0 +1,240 @@
+package checker
+
+import (
+	"crypto/tls"
+	"io"
+	"math"
+	"mime"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"sort"
+	"sync"
+	"time"
+
+	"subdomain-checker/har"
+)
+
+// 请求记录（-har），为nil时不记录
+var harRecorder *har.Recorder
+
+// 设置请求记录：之后经过HTTP客户端的每个请求（包括重定向的每一跳、robots.txt和路径探测）都会写入HAR；为nil时不记录
+func SetHARRecorder(r *har.Recorder) {
+	harRecorder = r
+}
+
+// 记录请求和响应的Transport，未设置请求记录时直接转发
+type recordingTransport struct {
+	base http.RoundTripper
+}
+
+func (t recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if harRecorder == nil {
+		return t.base.RoundTrip(req)
+	}
+
+	rec := &harRecording{start: time.Now()}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), rec.trace()))
+	resp, err := t.base.RoundTrip(req)
+	rec.mark(&rec.responseStart)
+
+	entry := har.Entry{
+		StartedDateTime: rec.start,
+		Request: har.Request{
+			Method:      req.Method,
+			URL:         req.URL.String(),
+			HTTPVersion: "HTTP/1.1",
+			Cookies:     harCookies(req.Cookies()),
+			Headers:     harHeaders(req.Header),
+			QueryString: harQuery(req),
+			HeadersSize: -1,
+			BodySize:    0,
+		},
+		Response: har.Response{
+			Cookies: []har.Pair{},
+			Headers: []har.Pair{},
+			Content: har.Content{MimeType: "x-unknown"},
+		},
+	}
+	if err != nil {
+		entry.Error = err.Error()
+		entry.Response.HeadersSize, entry.Response.BodySize = -1, -1
+		rec.mu.Lock()
+		entry.ServerIPAddress = rec.remote
+		rec.mu.Unlock()
+		entry.Timings = rec.timings(rec.responseStart)
+		entry.Time = totalTime(entry.Timings)
+		harRecorder.Add(entry)
+		return resp, err
+	}
+
+	entry.Response = har.Response{
+		Status:      resp.StatusCode,
+		StatusText:  http.StatusText(resp.StatusCode),
+		HTTPVersion: resp.Proto,
+		Cookies:     harCookies(resp.Cookies()),
+		Headers:     harHeaders(resp.Header),
+		Content:     har.Content{MimeType: resp.Header.Get("Content-Type")},
+		RedirectURL: resp.Header.Get("Location"),
+		HeadersSize: -1,
+	}
+	if entry.Response.Content.MimeType == "" {
+		entry.Response.Content.MimeType = "x-unknown"
+	} else if mediaType, _, err := mime.ParseMediaType(entry.Response.Content.MimeType); err == nil {
+		entry.Response.Content.MimeType = mediaType
+	}
+	entry.Request.HTTPVersion = resp.Proto
+
+	// 响应体读取完毕或关闭时才能得到接收耗时和大小，此时再写入记录
+	resp.Body = &harBody{ReadCloser: resp.Body, onClose: func(size int) {
+		receiveEnd := time.Now()
+		rec.mu.Lock()
+		entry.ServerIPAddress = rec.remote
+		rec.mu.Unlock()
+		entry.Response.BodySize = size
+		entry.Response.Content.Size = size
+		entry.Timings = rec.timings(receiveEnd)
+		entry.Time = totalTime(entry.Timings)
+		harRecorder.Add(entry)
+	}}
+	return resp, nil
+}
+
+// 单个请求各阶段的时间点
+type harRecording struct {
+	mu                               sync.Mutex
+	start                            time.Time
+	dnsStart, dnsDone                time.Time
+	connectStart, connectDone        time.Time
+	tlsStart, tlsDone                time.Time
+	gotConn, wroteRequest, firstByte time.Time
+	responseStart                    time.Time
+	remote                           string
+}
+
+// 记录时间点（已记录过则保留第一次）
+func (r *harRecording) mark(t *time.Time) {
+	r.mu.Lock()
+	if t.IsZero() {
+		*t = time.Now()
+	}
+	r.mu.Unlock()
+}
+
+func (r *harRecording) trace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		DNSStart:          func(httptrace.DNSStartInfo) { r.mark(&r.dnsStart) },
+		DNSDone:           func(httptrace.DNSDoneInfo) { r.mark(&r.dnsDone) },
+		ConnectStart:      func(string, string) { r.mark(&r.connectStart) },
+		ConnectDone:       func(string, string, error) { r.mark(&r.connectDone) },
+		TLSHandshakeStart: func() { r.mark(&r.tlsStart) },
+		TLSHandshakeDone:  func(tls.ConnectionState, error) { r.mark(&r.tlsDone) },
+		GotConn: func(info httptrace.GotConnInfo) {
+			r.mark(&r.gotConn)
+			if host, _, err := net.SplitHostPort(info.Conn.RemoteAddr().String()); err == nil {
+				r.mu.Lock()
+				r.remote = host
+				r.mu.Unlock()
+			}
+		},
+		WroteRequest:         func(httptrace.WroteRequestInfo) { r.mark(&r.wroteRequest) },
+		GotFirstResponseByte: func() { r.mark(&r.firstByte) },
+	}
+}
+
+// 按HAR的阶段划分计算耗时，end为接收完成（或失败）的时间
+func (r *harRecording) timings(end time.Time) har.Timings {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	span := func(from, to time.Time) float64 {
+		if from.IsZero() || to.IsZero() || to.Before(from) {
+			return -1
+		}
+		return float64(to.Sub(from).Microseconds()) / 1000
+	}
+	t := har.Timings{
+		Blocked: -1,
+		DNS:     span(r.dnsStart, r.dnsDone),
+		Connect: span(r.connectStart, r.connectDone),
+		SSL:     span(r.tlsStart, r.tlsDone),
+		Send:    span(r.gotConn, r.wroteRequest),
+		Wait:    span(r.wroteRequest, r.firstByte),
+		Receive: span(r.firstByte, end),
+	}
+	// HAR规定connect包含ssl
+	if t.Connect >= 0 && t.SSL >= 0 {
+		t.Connect = math.Round((t.Connect+t.SSL)*1000) / 1000
+	}
+	if t.Send < 0 {
+		t.Send = 0
+	}
+	if t.Wait < 0 {
+		// 请求失败时把已经过的时间计入等待，使总耗时与实际一致
+		t.Wait = math.Round((span(r.start, end)-max(t.DNS, 0)-max(t.Connect, 0))*1000) / 1000
+	}
+	if t.Receive < 0 {
+		t.Receive = 0
+	}
+	return t
+}
+
+// HAR总耗时为各阶段之和（不含-1）
+func totalTime(t har.Timings) float64 {
+	total := 0.0
+	for _, v := range []float64{t.Blocked, t.DNS, t.Connect, t.Send, t.Wait, t.Receive} {
+		if v > 0 {
+			total += v
+		}
+	}
+	return total
+}
+
+// 统计读取的字节数，关闭时回调一次
+type harBody struct {
+	io.ReadCloser
+	size    int
+	once    sync.Once
+	onClose func(size int)
+}
+
+func (b *harBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.size += n
+	return n, err
+}
+
+func (b *harBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(func() { b.onClose(b.size) })
+	return err
+}
+
+func harHeaders(header http.Header) []har.Pair {
+	pairs := []har.Pair{}
+	for name, values := range header {
+		for _, value := range values {
+			pairs = append(pairs, har.Pair{Name: name, Value: value})
+		}
+	}
+	sort.SliceStable(pairs, func(i, j int) bool { return pairs[i].Name < pairs[j].Name })
+	return pairs
+}
+
+func harCookies(cookies []*http.Cookie) []har.Pair {
+	pairs := []har.Pair{}
+	for _, cookie := range cookies {
+		pairs = append(pairs, har.Pair{Name: cookie.Name, Value: cookie.Value})
+	}
+	return pairs
+}
+
+func harQuery(req *http.Request) []har.Pair {
+	pairs := []har.Pair{}
+	for name, values := range req.URL.Query() {
+		for _, value := range values {
+			pairs = append(pairs, har.Pair{Name: name, Value: value})
+		}
+	}
+	sort.SliceStable(pairs, func(i, j int) bool { return pairs[i].Name < pairs[j].Name })
+	return pairs
+}
@@ -0,0 +1,80 @@
+package checker
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"hash/fnv"
+	"math/bits"
+	"regexp"
+	"strings"
+)
+
+const (
+	// 参与SimHash计算的最少词数，内容过短时近似哈希不可靠，只按精确哈希聚类
+	minSimHashTokens = 8
+	// 两个页面SimHash的汉明距离不超过该值时视为近似相同
+	SimHashThreshold = 3
+)
+
+var (
+	scriptStyleRegex = regexp.MustCompile(`(?is)<(script|style|noscript)[^>]*>.*?</(script|style|noscript)>`)
+	commentRegex     = regexp.MustCompile(`(?s)<!--.*?-->`)
+	tagRegex         = regexp.MustCompile(`(?s)<[^>]*>`)
+	digitsRegex      = regexp.MustCompile(`[0-9]+`)
+)
+
+// 归一化页面内容：去除脚本、样式、注释和标签，统一大小写和空白，并把数字串替换为0，
+// 使时间戳、计数等动态内容不同的同一页面得到相同的结果
+func normalizeBody(content string) string {
+	text := scriptStyleRegex.ReplaceAllString(content, " ")
+	text = commentRegex.ReplaceAllString(text, " ")
+	text = tagRegex.ReplaceAllString(text, " ")
+	if strings.TrimSpace(text) == "" {
+		// 只有脚本的单页应用外壳，退回使用原始内容，避免所有此类页面被归为同一组
+		text = content
+	}
+	text = digitsRegex.ReplaceAllString(strings.ToLower(text), "0")
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// 计算页面内容的精确哈希（归一化内容的SHA-256）和SimHash
+func contentHash(content string) (string, uint64) {
+	normalized := normalizeBody(content)
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:]), simHash(strings.Fields(normalized))
+}
+
+// 以相邻词对为特征计算64位SimHash，词数不足时返回0
+func simHash(tokens []string) uint64 {
+	if len(tokens) < minSimHashTokens {
+		return 0
+	}
+	var weights [64]int
+	h := fnv.New64a()
+	for i := 0; i+1 < len(tokens); i++ {
+		h.Reset()
+		h.Write([]byte(tokens[i]))
+		h.Write([]byte{' '})
+		h.Write([]byte(tokens[i+1]))
+		feature := h.Sum64()
+		for bit := 0; bit < 64; bit++ {
+			if feature&(1<<uint(bit)) != 0 {
+				weights[bit]++
+			} else {
+				weights[bit]--
+			}
+		}
+	}
+	var fingerprint uint64
+	for bit, weight := range weights {
+		if weight > 0 {
+			fingerprint |= 1 << uint(bit)
+		}
+	}
+	return fingerprint
+}
+
+// 两个SimHash之间的汉明距离
+func SimHashDistance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
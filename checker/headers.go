@@ -0,0 +1,118 @@
+package checker
+
+import (
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"subdomain-checker/config"
+)
+
+// 响应头（-capture-headers），Note 非空表示值得关注及其原因
+type HeaderField struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+	Note  string `json:"note,omitempty"`
+}
+
+// 响应设置的Cookie（-capture-headers）
+type CookieField struct {
+	Name     string `json:"name"`
+	Value    string `json:"value"`
+	Domain   string `json:"domain,omitempty"`
+	Path     string `json:"path,omitempty"`
+	Secure   bool   `json:"secure,omitempty"`
+	HttpOnly bool   `json:"http_only,omitempty"`
+	SameSite string `json:"same_site,omitempty"`
+	Note     string `json:"note,omitempty"` // 值得关注的原因，如Cookie值为JWT
+}
+
+// 泄露服务器软件和技术栈的响应头
+var techHeaders = map[string]string{
+	"Server":              "服务器软件",
+	"X-Powered-By":        "技术栈",
+	"X-Aspnet-Version":    "技术栈",
+	"X-Aspnetmvc-Version": "技术栈",
+	"X-Generator":         "技术栈",
+	"X-Runtime":           "技术栈",
+	"X-Drupal-Cache":      "技术栈",
+	"X-Backend-Server":    "后端信息",
+	"X-Forwarded-Server":  "后端信息",
+	"X-Served-By":         "后端信息",
+	"X-Upstream":          "后端信息",
+	"X-Real-Server":       "后端信息",
+}
+
+// JWT：以 eyJ 开头（Base64URL编码的 {"）的三段式令牌
+var jwtPattern = regexp.MustCompile(`^eyJ[\w-]+\.eyJ[\w-]+\.[\w-]*$`)
+
+// 记录响应头和Set-Cookie，标注泄露服务器软件、技术栈、调试信息的响应头和值为JWT的Cookie。
+// 值的长度超过 -header-value-len 时截断，是否值得关注按完整的值判断
+func captureHeaders(result *Result, resp *http.Response, cfg config.Config) {
+	names := make([]string, 0, len(resp.Header))
+	for name := range resp.Header {
+		if name != "Set-Cookie" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	result.Headers = nil
+	for _, name := range names {
+		value := strings.Join(resp.Header.Values(name), ", ")
+		result.Headers = append(result.Headers, HeaderField{
+			Name:  name,
+			Value: truncateValue(value, cfg.HeaderValueLen),
+			Note:  headerNote(name),
+		})
+	}
+
+	result.Cookies = nil
+	for _, cookie := range resp.Cookies() {
+		field := CookieField{
+			Name:     cookie.Name,
+			Value:    truncateValue(cookie.Value, cfg.HeaderValueLen),
+			Domain:   cookie.Domain,
+			Path:     cookie.Path,
+			Secure:   cookie.Secure,
+			HttpOnly: cookie.HttpOnly,
+			SameSite: sameSiteName(cookie.SameSite),
+		}
+		if jwtPattern.MatchString(cookie.Value) {
+			field.Note = "JWT"
+		}
+		result.Cookies = append(result.Cookies, field)
+	}
+}
+
+// 响应头值得关注的原因，不值得关注时返回空字符串
+func headerNote(name string) string {
+	if note, ok := techHeaders[name]; ok {
+		return note
+	}
+	if strings.Contains(strings.ToLower(name), "debug") {
+		return "调试信息"
+	}
+	return ""
+}
+
+// 截断过长的值，limit为0时不截断
+func truncateValue(value string, limit int) string {
+	if limit <= 0 || len(value) <= limit {
+		return value
+	}
+	return value[:runeBoundary(value, limit)] + "…"
+}
+
+// Cookie的SameSite属性，未设置时为空
+func sameSiteName(mode http.SameSite) string {
+	switch mode {
+	case http.SameSiteLaxMode:
+		return "Lax"
+	case http.SameSiteStrictMode:
+		return "Strict"
+	case http.SameSiteNoneMode:
+		return "None"
+	}
+	return ""
+}
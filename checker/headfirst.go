@@ -0,0 +1,68 @@
+package checker
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"subdomain-checker/config"
+)
+
+// HEAD预检（-head-first）：先发送HEAD请求，只有目标存活时才发送完整的GET请求
+// 下载响应体、提取标题；无法访问的目标直接使用HEAD的结果，省去大部分流量
+
+// 返回这些状态码时认为服务器不支持HEAD，改用GET重新检测（-head-fallback）
+var headFallbackCodes = map[int]bool{400: true, 403: true, 405: true, 501: true}
+
+// HEAD预检后省去GET请求的目标数
+var headSkipped atomic.Int64
+
+// 设置回退到GET的状态码
+func SetHeadFallback(codes map[int]bool) {
+	headFallbackCodes = codes
+}
+
+// HEAD预检后省去GET请求的目标数
+func HeadSkipped() int {
+	return int(headSkipped.Load())
+}
+
+// 发送HEAD预检请求。done为true时HEAD的结果即为最终结果（目标无法访问）；
+// 目标存活、服务器拒绝HEAD或连接被意外关闭时done为false，由调用方发送GET请求
+func headProbe(client *http.Client, url string, cfg config.Config) (resp *http.Response, conn connInfo, done bool, err error) {
+	resp, conn, err = sendRequest(client, http.MethodHead, url, "", "", cfg)
+	if err != nil {
+		// 部分服务器收到HEAD后直接断开连接或返回畸形响应，超时、DNS和TLS错误GET同样会失败
+		switch ClassifyError(err) {
+		case ErrorReset, ErrorOther:
+			return nil, conn, false, err
+		}
+		headSkipped.Add(1)
+		return nil, conn, true, err
+	}
+	if _, alive := getStatusTextAndAlive(resp.StatusCode); alive || headFallbackCodes[resp.StatusCode] {
+		resp.Body.Close()
+		return nil, conn, false, nil
+	}
+	headSkipped.Add(1)
+	return resp, conn, true, nil
+}
+
+// 解析逗号分隔的状态码列表
+func ParseStatusCodes(spec string) (map[int]bool, error) {
+	codes := make(map[int]bool)
+	for _, field := range strings.Split(spec, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		code, err := strconv.Atoi(field)
+		if err != nil || code < 100 || code > 599 {
+			return nil, fmt.Errorf("无效的状态码 %q", field)
+		}
+		codes[code] = true
+	}
+	return codes, nil
+}
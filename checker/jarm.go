@@ -0,0 +1,63 @@
+package checker
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"sync"
+	"time"
+
+	"subdomain-checker/config"
+	"subdomain-checker/jarm"
+	"subdomain-checker/logger"
+)
+
+// 已知的JARM指纹（-jarm-list），指纹 -> 说明
+var knownJARM map[string]string
+
+// 设置已知指纹列表，结果的指纹命中时记为高风险发现
+func SetKnownJARM(known map[string]string) {
+	knownJARM = known
+}
+
+// 已计算的指纹，同一主机和端口只计算一次
+var jarmCache sync.Map // host:port -> *jarmEntry
+
+type jarmEntry struct {
+	once   sync.Once
+	result jarm.Result
+}
+
+// 计算HTTPS目标的JARM和JA3S指纹（-jarm）
+func annotateJARM(result *Result, cfg config.Config) {
+	u, err := url.Parse(result.Domain)
+	if err != nil || u.Scheme != "https" || result.Status == 0 {
+		return
+	}
+	port := u.Port()
+	if port == "" {
+		port = "443"
+	}
+	addr := net.JoinHostPort(u.Hostname(), port)
+
+	value, _ := jarmCache.LoadOrStore(addr, &jarmEntry{})
+	entry := value.(*jarmEntry)
+	entry.once.Do(func() {
+		timeout := time.Duration(cfg.Timeout) * time.Second
+		fingerprint, err := jarm.Fingerprint(context.Background(), dialFunc(cfg.IPFamily, timeout), addr, u.Hostname(), timeout)
+		if err != nil {
+			logger.Debug("JARM指纹计算失败", "target", addr, "error", err)
+		}
+		entry.result = fingerprint
+	})
+
+	result.JARM, result.JA3S = entry.result.JARM, entry.result.JA3S
+	if result.JARM != "" && result.JARM != jarm.Empty {
+		if label, ok := knownJARM[result.JARM]; ok {
+			if label == "" {
+				label = "已知指纹"
+			}
+			result.JARMMatch = label
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package checker
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+const (
+	maxMatchesPerPage = 5  // 每个页面最多记录的命中数
+	matchContextBytes = 60 // 命中前后保留的上下文长度（字节）
+)
+
+// 关键词命中证据，Before/Text/After 分开保存便于报告中高亮显示
+type Match struct {
+	Before string `json:"before"`
+	Text   string `json:"text"`
+	After  string `json:"after"`
+	Source string `json:"source,omitempty"` // 命中来源，为空时为响应内容，ocr为截图中识别出的文字
+}
+
+// 截图文字中的命中
+const MatchSourceOCR = "ocr"
+
+var matchRegexCache sync.Map
+
+// 编译并缓存关键词正则，同一次扫描中的正则只编译一次
+func compileMatchRegex(pattern string) *regexp.Regexp {
+	if re, ok := matchRegexCache.Load(pattern); ok {
+		return re.(*regexp.Regexp)
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil
+	}
+	matchRegexCache.Store(pattern, re)
+	return re
+}
+
+// 在页面内容中查找关键词正则的命中，并截取前后上下文作为证据
+func findMatches(content, pattern string) []Match {
+	re := compileMatchRegex(pattern)
+	if re == nil {
+		return nil
+	}
+
+	var matches []Match
+	for _, loc := range re.FindAllStringIndex(content, maxMatchesPerPage) {
+		if loc[0] == loc[1] {
+			continue
+		}
+		start := runeBoundary(content, loc[0]-matchContextBytes)
+		end := runeBoundary(content, loc[1]+matchContextBytes)
+		matches = append(matches, Match{
+			Before: collapseSpace(content[start:loc[0]]),
+			Text:   collapseSpace(content[loc[0]:loc[1]]),
+			After:  collapseSpace(content[loc[1]:end]),
+		})
+	}
+	return matches
+}
+
+// 将偏移量调整到合法的UTF-8字符边界，避免截断多字节字符
+func runeBoundary(s string, i int) int {
+	if i <= 0 {
+		return 0
+	}
+	if i >= len(s) {
+		return len(s)
+	}
+	for i > 0 && !utf8.RuneStart(s[i]) {
+		i--
+	}
+	return i
+}
+
+var spaceRegex = regexp.MustCompile(`\s+`)
+
+// 合并连续空白字符，使证据片段在报告中更紧凑
+// 没有空白需要替换时ReplaceAllString会返回原子串，这里复制一份，避免命中片段引用使整个响应体无法被回收
+func collapseSpace(s string) string {
+	return strings.Clone(spaceRegex.ReplaceAllString(s, " "))
+}
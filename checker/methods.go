@@ -0,0 +1,89 @@
+package checker
+
+import (
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+
+	"subdomain-checker/config"
+)
+
+// 有风险的HTTP方法：TRACE/TRACK可被用于跨站追踪，PUT/DELETE和WebDAV方法可修改服务器上的文件，CONNECT可被用作代理
+var riskyMethods = []string{"TRACE", "TRACK", "PUT", "DELETE", "CONNECT", "PROPFIND", "PROPPATCH", "MKCOL", "COPY", "MOVE", "LOCK", "UNLOCK"}
+
+// 是否为有风险的HTTP方法
+func IsRiskyMethod(method string) bool {
+	return slices.Contains(riskyMethods, method)
+}
+
+// 探测基准时使用的不存在的HTTP方法
+const bogusMethod = "SQUIRRELPROBE"
+
+// 枚举存活主机允许的HTTP方法：OPTIONS响应的Allow头声明的方法，加上实际探测成功的方法。
+// TRACE请求目标URL，响应回显了请求时视为启用。指定 -methods-write 时PUT和DELETE请求随机的不存在路径，
+// 先以GET和不存在的方法请求同一路径得到基准状态码，PUT/DELETE返回2xx且与基准不同时才视为启用，
+// 避免对任何方法和路径都返回200的站点（单页应用、兜底路由）误报。
+// PUT可能在服务器上创建空文件，之后对同一路径的DELETE探测只有在服务器允许DELETE时才会将其删除
+func annotateMethods(result *Result, cfg config.Config) {
+	if !result.Alive {
+		return
+	}
+	target, err := url.Parse(result.Domain)
+	if err != nil {
+		return
+	}
+	base := *target
+	base.Path, base.RawQuery, base.Fragment = "", "", ""
+	client := sharedHTTPClient(cfg)
+
+	var methods []string
+	if resp, _, err := sendRequest(client, http.MethodOptions, result.Domain, "", "", cfg); err == nil {
+		resp.Body.Close()
+		for _, header := range []string{resp.Header.Get("Allow"), resp.Header.Get("Public")} {
+			for _, method := range strings.Split(header, ",") {
+				if method = strings.ToUpper(strings.TrimSpace(method)); method != "" {
+					methods = appendUnique(methods, method)
+				}
+			}
+		}
+	}
+
+	if resp, _, err := sendRequest(client, http.MethodTrace, result.Domain, "", "", cfg); err == nil {
+		body, _ := readBody(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusOK && strings.Contains(body, "TRACE ") {
+			methods = appendUnique(methods, http.MethodTrace)
+		}
+	}
+
+	if cfg.MethodsWrite {
+		probe := base.String() + randomProbePath()
+		baseline := []int{probeStatus(client, http.MethodGet, probe, cfg), probeStatus(client, bogusMethod, probe, cfg)}
+		for _, method := range []string{http.MethodPut, http.MethodDelete} {
+			status := probeStatus(client, method, probe, cfg)
+			if status >= 200 && status < 300 && !slices.Contains(baseline, status) {
+				methods = appendUnique(methods, method)
+			}
+		}
+	}
+
+	sort.Strings(methods)
+	result.Methods = methods
+	for _, method := range methods {
+		if IsRiskyMethod(method) {
+			result.RiskyMethods = append(result.RiskyMethods, method)
+		}
+	}
+}
+
+// 以指定方法请求URL（无请求体），返回状态码，请求失败时返回0
+func probeStatus(client *http.Client, method, target string, cfg config.Config) int {
+	resp, _, err := sendRequest(client, method, target, "", "", cfg)
+	if err != nil {
+		return 0
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
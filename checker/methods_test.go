@@ -0,0 +1,33 @@
+package checker
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+
+	"subdomain-checker/config"
+)
+
+func TestAnnotateMethodsWriteProbesOptIn(t *testing.T) {
+	var mu sync.Mutex
+	var seen []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		seen = append(seen, r.Method)
+		mu.Unlock()
+		w.Header().Set("Allow", "GET, OPTIONS")
+	}))
+	defer server.Close()
+
+	for _, write := range []bool{false, true} {
+		seen = nil
+		result := Result{Domain: server.URL, Alive: true}
+		annotateMethods(&result, config.Config{Timeout: 5, Methods: true, MethodsWrite: write})
+		sentWrite := slices.Contains(seen, http.MethodPut) || slices.Contains(seen, http.MethodDelete)
+		if sentWrite != write {
+			t.Errorf("MethodsWrite=%v: sent %v", write, seen)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package checker
+
+import (
+	"subdomain-checker/config"
+	"subdomain-checker/logger"
+	"subdomain-checker/ocr"
+)
+
+// 截图文字识别引擎（-ocr），为nil时不识别
+var ocrEngine *ocr.Engine
+
+// 设置截图文字识别引擎
+func SetOCR(engine *ocr.Engine) {
+	ocrEngine = engine
+}
+
+// 识别截图中的文字并保存到结果，关键词正则同样在识别出的文字中查找，
+// 使以图片或canvas渲染内容的页面也能被搜索和匹配
+func applyOCR(result *Result, screenshotPath string, cfg config.Config) {
+	if ocrEngine == nil || screenshotPath == "" {
+		return
+	}
+	text, err := ocrEngine.Extract(screenshotPath)
+	if err != nil {
+		logger.Debug("截图文字识别失败", "target", result.Domain, "error", err)
+		return
+	}
+	result.OCRText = text
+	if cfg.MatchRegex != "" && len(result.Matches) < maxMatchesPerPage {
+		for _, match := range findMatches(text, cfg.MatchRegex) {
+			if len(result.Matches) >= maxMatchesPerPage {
+				break
+			}
+			match.Source = MatchSourceOCR
+			result.Matches = append(result.Matches, match)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package checker
+
+import (
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"subdomain-checker/config"
+	"subdomain-checker/utils"
+)
+
+// 单个主机同时探测的路径数
+const pathProbeWorkers = 4
+
+// 需要在每个存活主机上探测的路径（-paths），为空时不探测
+var probePaths []string
+
+// 设置需要探测的路径，不以 / 开头的路径自动补上
+func SetProbePaths(paths []string) {
+	probePaths = nil
+	for _, path := range paths {
+		if !strings.HasPrefix(path, "/") {
+			path = "/" + path
+		}
+		probePaths = append(probePaths, path)
+	}
+}
+
+// 单个路径的探测结果，作为主机结果的子结果写入报告
+type PathResult struct {
+	Path         string        `json:"path"`
+	Status       int           `json:"status"`                     // 状态码，请求失败时为0
+	Length       int           `json:"length"`                     // 响应体长度（字节），只读取2xx响应
+	Title        string        `json:"title,omitempty"`            // 页面标题
+	ResponseTime time.Duration `json:"response_time_ns,omitempty"` // 响应时间
+	Error        string        `json:"error,omitempty"`            // 请求失败的原因
+	Soft404      bool          `json:"soft_404,omitempty"`         // 响应与不存在路径的响应相同，路径很可能并不存在（-soft404）
+}
+
+// 在存活主机上依次请求 -paths 中的路径，记录每个路径的状态码、长度和标题。
+// soft404不为nil时，与不存在路径的响应相同的路径标记为软404
+func annotatePaths(result *Result, soft404 *soft404Baseline, cfg config.Config) {
+	if !result.Alive {
+		return
+	}
+	base, err := url.Parse(result.Domain)
+	if err != nil {
+		return
+	}
+	base.Path, base.RawQuery, base.Fragment = "", "", ""
+
+	paths := make([]PathResult, len(probePaths))
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, pathProbeWorkers)
+	for i, path := range probePaths {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, path string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			paths[i] = probePath(base.String()+path, path, soft404, cfg)
+		}(i, path)
+	}
+	wg.Wait()
+	result.Paths = paths
+}
+
+// 请求单个路径
+func probePath(target, path string, soft404 *soft404Baseline, cfg config.Config) PathResult {
+	probe := PathResult{Path: path}
+	start := time.Now()
+	resp, _, err := doRequest(sharedHTTPClient(cfg), target, cfg)
+	probe.ResponseTime = time.Since(start)
+	if err != nil {
+		probe.Error = ClassifyError(err).Label()
+		return probe
+	}
+	defer resp.Body.Close()
+	probe.Status = resp.StatusCode
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		if body, err := readBody(resp.Body); err == nil {
+			probe.Length = len(body)
+			body, _ = utils.ToUTF8(body, resp.Header.Get("Content-Type"))
+			probe.Title = extractTitle(body)
+			probe.Soft404 = soft404 != nil && soft404.matches(newSoft404Response(resp.StatusCode, body, path))
+		}
+	} else if resp.ContentLength > 0 {
+		probe.Length = int(resp.ContentLength)
+	}
+	return probe
+}
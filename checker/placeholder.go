@@ -0,0 +1,78 @@
+package checker
+
+import (
+	"io"
+
+	"subdomain-checker/config"
+	"subdomain-checker/utils"
+)
+
+// 占位页面的类别
+const (
+	PlaceholderParked    = "停放域名"
+	PlaceholderRegistrar = "注册商占位页"
+	PlaceholderDefault   = "服务器默认页"
+	PlaceholderCDNError  = "CDN错误页"
+)
+
+// 错误状态码的页面只读取开头部分，足以识别CDN错误页
+const maxErrorPageBytes = 64 << 10
+
+// 占位页面规则：停放域名、注册商占位页、Web服务器默认欢迎页和CDN错误页，
+// 命中的结果标记为占位页面，报告中可以一键排除这类噪音
+var placeholderRules = mustCompileRules([]Rule{
+	{
+		Type:  PlaceholderParked,
+		Title: []string{`(?i)domain (is )?for sale|parked domain|this domain is parked|域名(出售|转让|停放)`},
+	},
+	{
+		Type: PlaceholderParked,
+		Body: []string{`(?i)this domain (is|may be) for sale|buy this domain|sedoparking|parkingcrew|bodis\.com|dan\.com/buy-domain|afternic|hugedomains|above\.com/marketplace|parklogic|domain parking`},
+	},
+	{
+		Type:  PlaceholderRegistrar,
+		Title: []string{`(?i)^\s*(coming soon|under construction|future home of|website coming soon|site not found|account suspended)|网站(建设|升级)中|域名未(备案|绑定)`},
+	},
+	{
+		Type: PlaceholderRegistrar,
+		Body: []string{`(?i)this (web )?page is parked free|courtesy of godaddy|namecheap\.com.*(parking|registered)|is registered at namecheap|registered (with|at) (godaddy|network solutions|tucows|gandi|porkbun)|wixsite.*connect your domain|domain has been registered|该域名已被注册|网站暂时无法访问.*(备案|绑定)`},
+	},
+	{
+		Type:  PlaceholderDefault,
+		Title: []string{`(?i)^\s*(welcome to nginx!?|apache2? (ubuntu|debian|centos)?\s*default page.*|test page for (the )?(apache|nginx).*|iis windows server|iis[0-9. ]*(welcome|windows)?|internet information services|welcome to (centos|tengine|openresty)!?|it works!?|default web site page|lighttpd.*placeholder|caddy works!?|plesk.*default page|web server's default page)\s*$`},
+	},
+	{
+		Type: PlaceholderDefault,
+		Body: []string{`(?i)if you see this page, the nginx web server is successfully installed|this is the default welcome page used to test the correct operation of the apache|<h1>it works!</h1>|iis-85\.png|iisstart\.png|welcome\.png" alt="iis|your caddy web server is working|this page is used to test the proper operation of the (apache|nginx) http server|plesk.*web server's default page`},
+	},
+	{
+		Type:  PlaceholderCDNError,
+		Title: []string{`(?i)^\s*(error 10[0-9]{2}|[0-9a-z.-]+ \| 52[0-9]: .*|attention required! \| cloudflare|error: the request could not be satisfied|fastly error: unknown domain.*|404 - not found \| akamai|edgesuite error)\s*$`},
+	},
+	{
+		Type: PlaceholderCDNError,
+		Body: []string{`(?i)cloudflare ray id|cf-error-details|the request could not be satisfied\.?</h1>|generated by cloudfront|fastly error: unknown domain|akamaighost|reference&#32;&#35;[0-9a-f.]+|errors\.edgesuite\.net|azure front door.*(not found|error)|x-azure-ref|no such app.*heroku|there isn't a github pages site here|the specified bucket does not exist|nosuchbucket`},
+	},
+})
+
+// 判断页面是否为占位页面，返回占位类别，不是占位页面时返回空字符串
+func detectPlaceholder(url, title, body string, status int) string {
+	for i := range placeholderRules {
+		if placeholderRules[i].match(url, title, body, status) {
+			return placeholderRules[i].Type
+		}
+	}
+	return ""
+}
+
+// 错误状态码的页面：不提取标题和页面信息，只读取响应开头识别CDN错误页等占位页面；
+// -extract 时仅按URL和状态码匹配分类规则
+func analyzeErrorPage(result *Result, body io.Reader, contentType string, cfg config.Config) {
+	if head, err := io.ReadAll(io.LimitReader(body, maxErrorPageBytes)); err == nil {
+		page, _ := utils.ToUTF8(string(head), contentType)
+		result.Placeholder = detectPlaceholder(result.pageURL(), extractTitle(page), page, result.Status)
+	}
+	if cfg.ExtractInfo {
+		result.PageInfo = classifyPage(result.pageURL(), "", "", result.Status)
+	}
+}
@@ -0,0 +1,77 @@
+package checker
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"sync"
+
+	"subdomain-checker/config"
+)
+
+// 放回缓冲池的缓冲区最大容量，超大响应的缓冲区直接丢弃，避免长期占用内存
+const maxPooledBufferSize = 1 << 20
+
+// 响应体读取缓冲池，减少大规模扫描时每个结果的内存分配
+var bodyBufferPool = sync.Pool{
+	New: func() any {
+		return bytes.NewBuffer(make([]byte, 0, 32<<10))
+	},
+}
+
+// 使用池化缓冲区读取响应体，最多读取 -max-body-kb 指定的字节数，
+// 返回的字符串是独立的副本，缓冲区可以安全复用
+func readBody(r io.Reader) (string, error) {
+	buf := bodyBufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	defer func() {
+		if buf.Cap() <= maxPooledBufferSize {
+			bodyBufferPool.Put(buf)
+		}
+	}()
+
+	if maxBodyBytes > 0 {
+		r = io.LimitReader(r, maxBodyBytes)
+	}
+	if _, err := buf.ReadFrom(r); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// 影响HTTP客户端行为的配置项，相同配置的检测共用一个客户端（及其连接池）
+// 新增影响客户端或Transport的配置时需要同步加入该结构
+type clientKey struct {
+	timeout         int
+	ipFamily        string
+	followRedirects bool
+	maxRedirects    int
+	tlsMinVersion   string
+	tlsMaxVersion   string
+	tlsCiphers      string
+	sni             string
+	insecure        bool
+}
+
+// 已创建的HTTP客户端缓存
+var clientCache sync.Map
+
+// 获取与配置对应的共享HTTP客户端，避免每次检测都新建Transport
+func sharedHTTPClient(cfg config.Config) *http.Client {
+	key := clientKey{
+		timeout:         cfg.Timeout,
+		ipFamily:        cfg.IPFamily,
+		followRedirects: cfg.FollowRedirects,
+		maxRedirects:    cfg.MaxRedirects,
+		tlsMinVersion:   cfg.TLSMinVersion,
+		tlsMaxVersion:   cfg.TLSMaxVersion,
+		tlsCiphers:      cfg.TLSCiphers,
+		sni:             cfg.SNI,
+		insecure:        cfg.Insecure,
+	}
+	if client, ok := clientCache.Load(key); ok {
+		return client.(*http.Client)
+	}
+	client, _ := clientCache.LoadOrStore(key, newHTTPClient(cfg))
+	return client.(*http.Client)
+}
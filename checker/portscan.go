@@ -0,0 +1,164 @@
+package checker
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"subdomain-checker/config"
+	"subdomain-checker/utils"
+)
+
+// 单个IP同时进行的端口连接数
+const portScanWorkers = 32
+
+// 需要扫描的端口（-ports），为空时不扫描
+var scanPorts []int
+
+// 设置需要扫描的端口
+func SetPorts(ports []int) {
+	scanPorts = ports
+}
+
+// 解析端口列表，支持逗号分隔的端口和范围，如 "80,443,8000-8100"
+func ParsePorts(spec string) ([]int, error) {
+	seen := make(map[int]bool)
+	var ports []int
+	for _, field := range strings.Split(spec, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		first, last, isRange := strings.Cut(field, "-")
+		start, err := strconv.Atoi(strings.TrimSpace(first))
+		end := start
+		if err == nil && isRange {
+			end, err = strconv.Atoi(strings.TrimSpace(last))
+		}
+		if err != nil || start < 1 || end > 65535 || start > end {
+			return nil, fmt.Errorf("无效的端口 %q", field)
+		}
+		for port := start; port <= end; port++ {
+			if !seen[port] {
+				seen[port] = true
+				ports = append(ports, port)
+			}
+		}
+	}
+	sort.Ints(ports)
+	return ports, nil
+}
+
+// 单个IP的扫描结果，多个域名解析到同一IP时只扫描一次
+type ipPorts struct {
+	once     sync.Once
+	open     []int
+	services []Service
+}
+
+var portCache sync.Map // IP -> *ipPorts
+
+// 为检测结果补充开放端口和端口上的服务：默认只扫描存活域名的IP，-port-scan-all 时扫描所有解析到IP的域名
+func annotatePorts(result *Result, cfg config.Config) {
+	if result.IP == "" || (!result.Alive && !cfg.PortScanAll) || !targetScope.AllowsIP(net.ParseIP(result.IP)) {
+		return
+	}
+	value, _ := portCache.LoadOrStore(result.IP, &ipPorts{})
+	entry := value.(*ipPorts)
+	entry.once.Do(func() {
+		timeout := portTimeout(cfg)
+		entry.open = scanIP(result.IP, scanPorts, timeout)
+		entry.services = identifyServices(result.IP, utils.HostFromURL(result.Domain), entry.open, timeout)
+	})
+	result.OpenPorts = entry.open
+	result.Services = entry.services
+}
+
+func portTimeout(cfg config.Config) time.Duration {
+	if cfg.PortTimeout <= 0 {
+		return time.Second
+	}
+	return time.Duration(cfg.PortTimeout) * time.Millisecond
+}
+
+// 对IP的各端口进行TCP连接扫描，返回开放的端口
+func scanIP(ip string, ports []int, timeout time.Duration) []int {
+	var (
+		mu   sync.Mutex
+		open []int
+		wg   sync.WaitGroup
+	)
+	sem := make(chan struct{}, portScanWorkers)
+	for _, port := range ports {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(port int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			conn, err := net.DialTimeout("tcp", net.JoinHostPort(ip, strconv.Itoa(port)), timeout)
+			if err != nil {
+				return
+			}
+			conn.Close()
+			mu.Lock()
+			open = append(open, port)
+			mu.Unlock()
+		}(port)
+	}
+	wg.Wait()
+	sort.Ints(open)
+	return open
+}
+
+// 并发识别各开放端口上的服务
+func identifyServices(ip, host string, ports []int, timeout time.Duration) []Service {
+	services := make([]Service, len(ports))
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, portScanWorkers)
+	for i, port := range ports {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i, port int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			services[i] = identifyService(ip, host, port, timeout)
+		}(i, port)
+	}
+	wg.Wait()
+	return services
+}
+
+// 返回结果的开放端口中识别为HTTP(S)的新检测目标，如 https://example.com:8443，
+// 不包括结果本身已检测的端口；用于 -port-feed 将发现的Web端口交给检测流程
+func WebTargets(result Result) []string {
+	host := utils.HostFromURL(result.Domain)
+	checked := checkedPort(result.Domain)
+
+	var targets []string
+	for _, service := range result.Services {
+		if service.Port != checked && service.Web() {
+			targets = append(targets, service.Name+"://"+net.JoinHostPort(host, strconv.Itoa(service.Port)))
+		}
+	}
+	return targets
+}
+
+// 目标URL使用的端口，未显式指定时为协议的默认端口
+func checkedPort(target string) int {
+	u, err := url.Parse(target)
+	if err != nil {
+		return 0
+	}
+	if port, err := strconv.Atoi(u.Port()); err == nil {
+		return port
+	}
+	if u.Scheme == "https" {
+		return 443
+	}
+	return 80
+}
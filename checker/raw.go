@@ -0,0 +1,48 @@
+package checker
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+
+	"subdomain-checker/archive"
+	"subdomain-checker/logger"
+)
+
+// 原始响应归档（-archive），为nil时不保存
+var rawArchive *archive.Archive
+
+// 归档中保存的响应体最大字节数
+var rawBodyLimit int
+
+// 设置原始响应归档，bodyLimit为每个响应保存的响应体最大字节数；a为nil时不保存
+func SetRawArchive(a *archive.Archive, bodyLimit int) {
+	rawArchive = a
+	rawBodyLimit = bodyLimit
+}
+
+// 保存存活主机的原始响应（状态行、响应头和解压后响应体的前rawBodyLimit字节），并在结果中记录保存位置
+func archiveResponse(result *Result, resp *http.Response, body string) {
+	if rawArchive == nil || !result.Alive {
+		return
+	}
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s %s\r\n", resp.Proto, resp.Status)
+	// 保存的是解压后的响应体，去掉与之不符的Content-Encoding和Content-Length
+	header := resp.Header.Clone()
+	header.Del("Content-Encoding")
+	header.Del("Content-Length")
+	header.Write(&buf)
+	buf.WriteString("\r\n")
+	if len(body) > rawBodyLimit {
+		body = body[:rawBodyLimit]
+	}
+	buf.WriteString(body)
+
+	path, err := rawArchive.Save(safeFileStem(result.Domain), buf.Bytes())
+	if err != nil {
+		logger.Warn("保存原始响应失败", "domain", result.Domain, "error", err)
+		return
+	}
+	result.RawResponse = path
+}
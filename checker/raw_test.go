@@ -0,0 +1,39 @@
+package checker
+
+import (
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"subdomain-checker/archive"
+)
+
+// 带协议和端口的URL归档为各自的文件，文件名不受截图文件名模板影响
+func TestArchiveResponseNames(t *testing.T) {
+	dir := t.TempDir()
+	a, err := archive.Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetRawArchive(a, 1024)
+	defer SetRawArchive(nil, 0)
+	if err := SetScreenshotName("{hash}"); err != nil {
+		t.Fatal(err)
+	}
+	defer SetScreenshotName("")
+
+	tests := map[string]string{
+		"https://www.example.com":            "https_www.example.com_443.http",
+		"http://www.example.com:8080":        "http_www.example.com_8080.http",
+		"https://www.example.com:8443/admin": "https_www.example.com_8443_admin.http",
+		"http://[2001:db8::1]:8080/a?b=c":    "http_2001_db8_1_8080_a_b_c.http",
+	}
+	for target, want := range tests {
+		result := &Result{Domain: target, Alive: true}
+		resp := &http.Response{Proto: "HTTP/1.1", Status: "200 OK", StatusCode: http.StatusOK, Header: http.Header{}}
+		archiveResponse(result, resp, "ok")
+		if got := filepath.Base(result.RawResponse); got != want {
+			t.Errorf("%s archived as %q, want %q", target, got, want)
+		}
+	}
+}
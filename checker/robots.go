@@ -0,0 +1,147 @@
+package checker
+
+import (
+	"bufio"
+	"encoding/xml"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"subdomain-checker/config"
+)
+
+// robots.txt 和 sitemap.xml 中记录的最大条目数
+const maxRobotsEntries = 200
+
+// robots.txt 和 sitemap.xml 的收集结果（-robots）
+type RobotsInfo struct {
+	RobotsTxt   bool     `json:"robots_txt"`             // robots.txt 是否存在
+	Disallowed  []string `json:"disallowed,omitempty"`   // robots.txt 中禁止抓取的路径
+	Sitemap     bool     `json:"sitemap"`                // 是否找到sitemap
+	SitemapURLs []string `json:"sitemap_urls,omitempty"` // sitemap中列出的URL
+}
+
+// 为存活结果获取 robots.txt 和 sitemap.xml，记录禁止抓取的路径和sitemap中的URL
+func annotateRobots(result *Result, cfg config.Config) {
+	if !result.Alive {
+		return
+	}
+	base, err := url.Parse(result.Domain)
+	if err != nil {
+		return
+	}
+	base.Path, base.RawQuery, base.Fragment = "", "", ""
+	client := sharedHTTPClient(cfg)
+	info := &RobotsInfo{}
+
+	// robots.txt 中通过 Sitemap: 指定的sitemap地址，没有时使用默认的 /sitemap.xml
+	var sitemaps []string
+	if body, ok := fetchText(client, base.String()+"/robots.txt", cfg); ok {
+		info.RobotsTxt = true
+		info.Disallowed, sitemaps = parseRobots(body)
+	}
+	if len(sitemaps) == 0 {
+		sitemaps = []string{base.String() + "/sitemap.xml"}
+	}
+	for _, sitemap := range sitemaps {
+		body, ok := fetchText(client, sitemap, cfg)
+		if !ok {
+			continue
+		}
+		urls, valid := parseSitemap(body)
+		if !valid {
+			continue
+		}
+		info.Sitemap = true
+		info.SitemapURLs = appendUnique(info.SitemapURLs, urls...)
+	}
+	result.Robots = info
+}
+
+// 获取文本文件，状态码为200且不是HTML页面时才认为文件存在，
+// 避免把对任意路径都返回首页或错误页的站点误判为存在该文件
+func fetchText(client *http.Client, target string, cfg config.Config) (string, bool) {
+	resp, _, err := doRequest(client, target, cfg)
+	if err != nil {
+		return "", false
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", false
+	}
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType == "text/html" {
+		return "", false
+	}
+	body, err := readBody(resp.Body)
+	if err != nil {
+		return "", false
+	}
+	return body, true
+}
+
+// 解析 robots.txt，返回所有 Disallow 路径和 Sitemap 地址
+func parseRobots(body string) (disallowed, sitemaps []string) {
+	scanner := bufio.NewScanner(strings.NewReader(body))
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "disallow":
+			disallowed = appendUnique(disallowed, value)
+		case "sitemap":
+			sitemaps = appendUnique(sitemaps, value)
+		}
+	}
+	return disallowed, sitemaps
+}
+
+// sitemap文件（urlset）和sitemap索引（sitemapindex）中的地址
+type sitemapDoc struct {
+	XMLName  xml.Name
+	URLs     []string `xml:"url>loc"`
+	Sitemaps []string `xml:"sitemap>loc"`
+}
+
+// 解析sitemap，返回其中的URL；sitemap索引返回子sitemap的地址，不再逐个获取
+func parseSitemap(body string) ([]string, bool) {
+	var doc sitemapDoc
+	if err := xml.Unmarshal([]byte(body), &doc); err != nil {
+		return nil, false
+	}
+	if doc.XMLName.Local != "urlset" && doc.XMLName.Local != "sitemapindex" {
+		return nil, false
+	}
+	var urls []string
+	for _, loc := range append(doc.URLs, doc.Sitemaps...) {
+		urls = appendUnique(urls, strings.TrimSpace(loc))
+	}
+	return urls, true
+}
+
+// 追加不重复的条目，最多保留maxRobotsEntries条
+func appendUnique(list []string, items ...string) []string {
+	for _, item := range items {
+		if len(list) >= maxRobotsEntries {
+			break
+		}
+		exists := false
+		for _, existing := range list {
+			if existing == item {
+				exists = true
+				break
+			}
+		}
+		if !exists {
+			list = append(list, item)
+		}
+	}
+	return list
+}
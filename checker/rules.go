@@ -0,0 +1,182 @@
+package checker
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// 页面分类规则：Title/Body/URL 为正则列表，同一字段内任一正则命中即可，
+// 所有非空字段（含Status）都满足时规则命中，页面被归为Type
+type Rule struct {
+	Type        string   `yaml:"type" json:"type"`
+	Description string   `yaml:"description" json:"description"`
+	Title       []string `yaml:"title" json:"title"`
+	Body        []string `yaml:"body" json:"body"`
+	URL         []string `yaml:"url" json:"url"`
+	Status      []int    `yaml:"status" json:"status"`
+
+	title []*regexp.Regexp
+	body  []*regexp.Regexp
+	url   []*regexp.Regexp
+}
+
+// 规则文件结构
+type RuleSet struct {
+	Rules []Rule `yaml:"rules" json:"rules"`
+}
+
+// 内置扩展规则，在用户规则之后、关键词识别之前匹配
+var builtinRules = mustCompileRules([]Rule{
+	{
+		Type:        "停放域名",
+		Description: "域名停放或待售页面",
+		Title:       []string{`(?i)domain (is )?for sale|parked domain|域名(出售|转让)`},
+	},
+	{
+		Type:        "停放域名",
+		Description: "域名停放或待售页面",
+		Body:        []string{`(?i)this domain (is|may be) for sale|buy this domain|sedoparking|parkingcrew|bodis\.com|dan\.com/buy-domain`},
+	},
+	{
+		Type:        "API文档",
+		Description: "Swagger、ReDoc或GraphQL调试页面",
+		Title:       []string{`(?i)swagger ui|redoc|api documentation|graphiql|graphql playground`},
+	},
+	{
+		Type:        "API文档",
+		Description: "Swagger、ReDoc或GraphQL调试页面",
+		Body:        []string{`swagger-ui|redoc\.standalone|graphiql|graphql-playground`},
+	},
+	{
+		Type:        "错误页面",
+		Description: "返回成功状态码的错误提示页面",
+		Title:       []string{`(?i)^\s*(40\d|50\d)\b|not found|internal server error|bad gateway|service unavailable|页面不存在|找不到页面`},
+	},
+})
+
+// 用户规则，通过 -rules 加载，优先于内置规则
+var customRules []Rule
+
+// 设置用户分类规则
+func SetRules(rules []Rule) {
+	customRules = rules
+}
+
+// 读取并编译规则文件，.json 使用JSON格式，其余按YAML解析
+func LoadRules(path string) ([]Rule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var set RuleSet
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		err = json.Unmarshal(data, &set)
+	} else {
+		err = yaml.Unmarshal(data, &set)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("解析规则文件 %s 失败: %v", path, err)
+	}
+	for i := range set.Rules {
+		if err := set.Rules[i].compile(); err != nil {
+			return nil, fmt.Errorf("规则文件 %s 第%d条规则%v", path, i+1, err)
+		}
+	}
+	return set.Rules, nil
+}
+
+// 编译规则中的正则
+func (r *Rule) compile() error {
+	if strings.TrimSpace(r.Type) == "" {
+		return fmt.Errorf("缺少type")
+	}
+	if len(r.Title) == 0 && len(r.Body) == 0 && len(r.URL) == 0 && len(r.Status) == 0 {
+		return fmt.Errorf("(%s)没有任何匹配条件", r.Type)
+	}
+	var err error
+	if r.title, err = compilePatterns(r.Title); err != nil {
+		return err
+	}
+	if r.body, err = compilePatterns(r.Body); err != nil {
+		return err
+	}
+	r.url, err = compilePatterns(r.URL)
+	return err
+}
+
+func compilePatterns(patterns []string) ([]*regexp.Regexp, error) {
+	res := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("中的正则 %q 无效: %v", pattern, err)
+		}
+		res = append(res, re)
+	}
+	return res, nil
+}
+
+func mustCompileRules(rules []Rule) []Rule {
+	for i := range rules {
+		if err := rules[i].compile(); err != nil {
+			panic(err)
+		}
+	}
+	return rules
+}
+
+// 判断页面是否满足规则的全部条件
+func (r *Rule) match(url, title, body string, status int) bool {
+	if len(r.Status) > 0 && !containsInt(r.Status, status) {
+		return false
+	}
+	return matchAny(r.title, title) && matchAny(r.body, body) && matchAny(r.url, url)
+}
+
+// 正则列表为空时视为满足
+func matchAny(res []*regexp.Regexp, s string) bool {
+	if len(res) == 0 {
+		return true
+	}
+	for _, re := range res {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsInt(values []int, v int) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
+
+// 页面分类：依次匹配用户规则、内置扩展规则，都未命中时使用关键词识别
+func classifyPage(url, title, body string, status int) *PageType {
+	if pageType := matchRules(url, title, body, status); pageType != nil {
+		return pageType
+	}
+	return detectPageType(body)
+}
+
+// 依次匹配用户规则和内置扩展规则，都未命中时返回nil
+func matchRules(url, title, body string, status int) *PageType {
+	for _, rules := range [][]Rule{customRules, builtinRules} {
+		for i := range rules {
+			if rules[i].match(url, title, body, status) {
+				return &PageType{Type: rules[i].Type, Description: rules[i].Description}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package checker
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"subdomain-checker/config"
+)
+
+// 截图文件名模板的默认值，同一主机的不同协议、端口和路径得到不同的文件名
+const DefaultScreenshotName = "{scheme}_{host}_{port}{path}"
+
+// 截图文件名模板可用的占位符
+var screenshotNameFields = []string{"scheme", "host", "domain", "port", "path", "timestamp", "hash"}
+
+var screenshotNamePlaceholder = regexp.MustCompile(`\{(\w+)\}`)
+
+// 文件名中只保留字母、数字和 . _ - ，其他字符替换为下划线
+var unsafeFilenameChars = regexp.MustCompile(`[^\w.-]+`)
+
+// 文件名主体的最大长度，超出时截断并附加URL哈希，留出序号和扩展名的余量
+const maxScreenshotNameLen = 200
+
+var (
+	screenshotNameTemplate = DefaultScreenshotName
+	// 本次扫描的时间戳，{timestamp} 在同一次扫描中保持一致，不同扫描之间不同
+	screenshotStamp = time.Now().Format("20060102-150405")
+
+	// 本次扫描已分配的截图文件名（小写，兼容不区分大小写的文件系统）
+	screenshotNamesMu sync.Mutex
+	screenshotNames   = make(map[string]bool)
+)
+
+// 本次扫描的截图目录和报告中引用截图的相对目录。-screenshot-per-scan 时为截图目录下以扫描开始时间命名的子目录，
+// 如 screenshots/20240501-100000，多次扫描的截图互不混杂，也便于按扫描清理
+func ScreenshotScanDir(cfg config.Config) (string, string) {
+	if cfg.ScreenshotPerScan {
+		return filepath.Join(cfg.ScreenshotDir, screenshotStamp), "screenshots/" + screenshotStamp
+	}
+	return cfg.ScreenshotDir, "screenshots"
+}
+
+// 设置截图文件名模板，可用占位符见 screenshotNameFields，模板不能包含路径分隔符
+func SetScreenshotName(template string) error {
+	if template == "" {
+		template = DefaultScreenshotName
+	}
+	if strings.ContainsAny(template, `/\`) {
+		return fmt.Errorf("截图文件名模板不能包含路径分隔符: %s", template)
+	}
+	for _, m := range screenshotNamePlaceholder.FindAllStringSubmatch(template, -1) {
+		known := false
+		for _, field := range screenshotNameFields {
+			if m[1] == field {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return fmt.Errorf("未知的占位符 {%s}，可用: {%s}", m[1], strings.Join(screenshotNameFields, "} {"))
+		}
+	}
+	screenshotNameTemplate = template
+	return nil
+}
+
+// 按模板生成截图文件名主体（不含扩展名和去重序号）
+func renderScreenshotName(target string) string {
+	return renderFileStem(screenshotNameTemplate, target)
+}
+
+// 按默认模板由URL生成可用作文件名的主体，不受 -screenshot-name 影响；原始响应归档等非截图文件使用
+func safeFileStem(target string) string {
+	return renderFileStem(DefaultScreenshotName, target)
+}
+
+// 按模板生成文件名主体：占位符替换为URL的各部分，不安全的字符替换为下划线，过长时截断并附加URL哈希
+func renderFileStem(template, target string) string {
+	u, err := url.Parse(target)
+	if err != nil || u.Host == "" {
+		u = &url.URL{Scheme: "http", Host: target}
+	}
+	port := u.Port()
+	if port == "" {
+		port = "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+	}
+	path := strings.Trim(u.EscapedPath(), "/")
+	if u.RawQuery != "" {
+		path += "_" + u.RawQuery
+	}
+	if path != "" {
+		path = "_" + path
+	}
+	sum := sha1.Sum([]byte(target))
+	hash := hex.EncodeToString(sum[:])[:10]
+
+	values := map[string]string{
+		"scheme":    u.Scheme,
+		"host":      u.Hostname(),
+		"domain":    u.Hostname(),
+		"port":      port,
+		"path":      path,
+		"timestamp": screenshotStamp,
+		"hash":      hash,
+	}
+	name := screenshotNamePlaceholder.ReplaceAllStringFunc(template, func(placeholder string) string {
+		return values[strings.Trim(placeholder, "{}")]
+	})
+	name = strings.Trim(unsafeFilenameChars.ReplaceAllString(name, "_"), "._")
+	if name == "" {
+		name = hash
+	}
+	if len(name) > maxScreenshotNameLen {
+		name = name[:maxScreenshotNameLen-len(hash)-1] + "_" + hash
+	}
+	return name
+}
+
+// 为目标分配截图文件名：同一次扫描中已分配的名称和截图目录中已存在的文件都不会被复用，
+// 重名时依次加 -2、-3 等序号。分配时在目录中创建空文件占位，使并行的扫描也不会写入同一文件；
+// 截图失败时由 releaseScreenshotName 删除占位文件
+func allocateScreenshotName(target, dir string) string {
+	base := renderScreenshotName(target)
+	screenshotNamesMu.Lock()
+	defer screenshotNamesMu.Unlock()
+	for n := 1; ; n++ {
+		name := base + ".png"
+		if n > 1 {
+			name = fmt.Sprintf("%s-%d.png", base, n)
+		}
+		if screenshotNames[strings.ToLower(name)] {
+			continue
+		}
+		f, err := os.OpenFile(filepath.Join(dir, name), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if os.IsExist(err) {
+			continue
+		}
+		if err == nil {
+			f.Close()
+		}
+		screenshotNames[strings.ToLower(name)] = true
+		return name
+	}
+}
+
+// 截图未生成时删除分配文件名时创建的空占位文件
+func releaseScreenshotName(dir, name string) {
+	path := filepath.Join(dir, name)
+	if info, err := os.Stat(path); err == nil && info.Size() == 0 {
+		os.Remove(path)
+	}
+}
@@ -0,0 +1,143 @@
+package checker
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/url"
+	"strings"
+
+	"subdomain-checker/config"
+	"subdomain-checker/utils"
+)
+
+// 不存在路径的基准响应。主机对随机路径返回2xx时视为软404（所有路径都返回成功），
+// 与基准相同的页面不代表路径真实存在
+type soft404Baseline struct {
+	status    int
+	length    int
+	title     string
+	bodyHash  string
+	simHash   uint64
+	tolerance int // 允许的长度差异，由两次基准请求的差异得到
+}
+
+// 请求随机路径的响应特征，响应中回显的路径已去掉，避免路径不同造成的差异
+type soft404Response struct {
+	status   int
+	length   int
+	title    string
+	bodyHash string
+	simHash  uint64
+}
+
+// 以两个随机的不存在路径请求主机，两次都返回2xx时返回基准响应，否则返回nil
+func probeSoft404(base string, cfg config.Config) *soft404Baseline {
+	var responses [2]soft404Response
+	for i := range responses {
+		path := randomProbePath()
+		resp, ok := requestSoft404(base+path, path, cfg)
+		if !ok || resp.status < 200 || resp.status >= 300 {
+			return nil
+		}
+		responses[i] = resp
+	}
+	diff := responses[0].length - responses[1].length
+	if diff < 0 {
+		diff = -diff
+	}
+	r := responses[0]
+	return &soft404Baseline{
+		status:    r.status,
+		length:    r.length,
+		title:     r.title,
+		bodyHash:  r.bodyHash,
+		simHash:   r.simHash,
+		tolerance: max(2*diff, 32),
+	}
+}
+
+// 请求路径并提取响应特征，echo为需要从响应中去掉的回显文本
+func requestSoft404(target, echo string, cfg config.Config) (soft404Response, bool) {
+	resp, _, err := doRequest(sharedHTTPClient(cfg), target, cfg)
+	if err != nil {
+		return soft404Response{}, false
+	}
+	defer resp.Body.Close()
+	body, err := readBody(resp.Body)
+	if err != nil {
+		return soft404Response{}, false
+	}
+	body, _ = utils.ToUTF8(body, resp.Header.Get("Content-Type"))
+	return newSoft404Response(resp.StatusCode, body, echo), true
+}
+
+func newSoft404Response(status int, body, echo string) soft404Response {
+	if echo != "" {
+		body = strings.ReplaceAll(body, echo, "")
+	}
+	r := soft404Response{status: status, length: len(body), title: extractTitle(body)}
+	r.bodyHash, r.simHash = contentHash(body)
+	return r
+}
+
+// 响应是否与基准相同：状态码一致，且内容相同或相近，或标题相同且长度在容差内
+func (b *soft404Baseline) matches(r soft404Response) bool {
+	if r.status != b.status {
+		return false
+	}
+	if b.sameContent(r.bodyHash, r.simHash) {
+		return true
+	}
+	diff := r.length - b.length
+	if diff < 0 {
+		diff = -diff
+	}
+	return r.title == b.title && diff <= b.tolerance
+}
+
+// 内容是否与基准相同：归一化内容的哈希相同或SimHash相近
+func (b *soft404Baseline) sameContent(bodyHash string, simHash uint64) bool {
+	if bodyHash == b.bodyHash {
+		return true
+	}
+	return simHash != 0 && b.simHash != 0 && SimHashDistance(simHash, b.simHash) <= SimHashThreshold
+}
+
+// 检测存活主机的软404行为，返回基准响应供路径探测比较。
+// 目标本身带有路径且页面与基准相同时，说明该路径并不存在，结果改为不存活
+func annotateSoft404(result *Result, cfg config.Config) *soft404Baseline {
+	if !result.Alive {
+		return nil
+	}
+	target, err := url.Parse(result.Domain)
+	if err != nil {
+		return nil
+	}
+	path := strings.TrimRight(target.Path, "/")
+	base := *target
+	base.Path, base.RawQuery, base.Fragment = "", "", ""
+
+	baseline := probeSoft404(base.String(), cfg)
+	if baseline == nil {
+		return nil
+	}
+	result.Soft404 = true
+	if path == "" || result.BodyHash == "" {
+		return baseline
+	}
+	// 结果中没有保存响应体长度，只按内容哈希比较
+	if result.Status == baseline.status && baseline.sameContent(result.BodyHash, result.SimHash) {
+		result.Alive = false
+		result.StatusText = "未找到"
+		result.ErrorType = ErrorSoft404
+		result.Message = "软404：页面与不存在路径的响应相同"
+	}
+	return baseline
+}
+
+// 随机的不存在路径，用于软404基准和HTTP方法探测
+func randomProbePath() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return "/squirrel-" + hex.EncodeToString(b)
+}
@@ -0,0 +1,87 @@
+package checker
+
+import (
+	"crypto/tls"
+	"fmt"
+	"strings"
+
+	"subdomain-checker/config"
+)
+
+// 可配置的TLS版本
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// 检查TLS相关配置（-tls-min、-tls-max、-tls-ciphers）是否有效
+func ValidateTLSConfig(cfg config.Config) error {
+	_, err := buildTLSConfig(cfg)
+	return err
+}
+
+// 根据配置创建检测请求使用的TLS配置，配置无效时使用默认值（启动时已由ValidateTLSConfig检查）
+func clientTLSConfig(cfg config.Config) *tls.Config {
+	tlsCfg, err := buildTLSConfig(cfg)
+	if err != nil {
+		return &tls.Config{GetClientCertificate: getClientCertificate}
+	}
+	return tlsCfg
+}
+
+func buildTLSConfig(cfg config.Config) (*tls.Config, error) {
+	tlsCfg := &tls.Config{
+		GetClientCertificate: getClientCertificate,
+		InsecureSkipVerify:   cfg.Insecure,
+		ServerName:           cfg.SNI,
+	}
+	for _, v := range []struct {
+		flag, value string
+		target      *uint16
+	}{
+		{"-tls-min", cfg.TLSMinVersion, &tlsCfg.MinVersion},
+		{"-tls-max", cfg.TLSMaxVersion, &tlsCfg.MaxVersion},
+	} {
+		if v.value == "" {
+			continue
+		}
+		version, ok := tlsVersions[v.value]
+		if !ok {
+			return nil, fmt.Errorf("无效的 %s 取值: %s (可选 1.0、1.1、1.2、1.3)", v.flag, v.value)
+		}
+		*v.target = version
+	}
+	if tlsCfg.MinVersion != 0 && tlsCfg.MaxVersion != 0 && tlsCfg.MinVersion > tlsCfg.MaxVersion {
+		return nil, fmt.Errorf("-tls-min (%s) 高于 -tls-max (%s)", cfg.TLSMinVersion, cfg.TLSMaxVersion)
+	}
+
+	if cfg.TLSCiphers != "" {
+		suites := make(map[string]uint16)
+		for _, suite := range append(tls.CipherSuites(), tls.InsecureCipherSuites()...) {
+			suites[suite.Name] = suite.ID
+		}
+		for _, name := range strings.Split(cfg.TLSCiphers, ",") {
+			name = strings.ToUpper(strings.TrimSpace(name))
+			if name == "" {
+				continue
+			}
+			id, ok := suites[name]
+			if !ok {
+				return nil, fmt.Errorf("未知的加密套件: %s", name)
+			}
+			tlsCfg.CipherSuites = append(tlsCfg.CipherSuites, id)
+		}
+	}
+	return tlsCfg, nil
+}
+
+// 记录协商得到的TLS版本和加密套件
+func recordTLS(result *Result, state *tls.ConnectionState) {
+	if state == nil {
+		return
+	}
+	result.TLSVersion = strings.TrimPrefix(tls.VersionName(state.Version), "TLS ")
+	result.TLSCipher = tls.CipherSuiteName(state.CipherSuite)
+}
@@ -0,0 +1,275 @@
+package checker
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"subdomain-checker/config"
+)
+
+// 虚拟主机探测发现的主机
+type VHost struct {
+	Host   string // Host头（及SNI）的值
+	URL    string // 以该主机名访问的地址，如 https://dev.example.com:8443
+	IP     string // 响应该主机名的目标IP
+	Status int
+	Length int
+	Title  string
+}
+
+// 单个Host头的响应特征
+type vhostResponse struct {
+	status   int
+	length   int // 去掉响应中出现的Host值后的长度，避免回显主机名造成的长度差异
+	title    string
+	location string // 同样去掉了Host值
+}
+
+// 未知主机名的基准响应，与之不同的响应视为存在对应的虚拟主机
+type vhostBaseline struct {
+	vhostResponse
+	tolerance int // 允许的长度差异，由两次基准请求的差异得到
+}
+
+// 响应是否与基准不同
+func (b vhostBaseline) differs(r vhostResponse) bool {
+	if r.status != b.status || r.title != b.title || r.location != b.location {
+		return true
+	}
+	diff := r.length - b.length
+	if diff < 0 {
+		diff = -diff
+	}
+	return diff > b.tolerance
+}
+
+// 单个目标的虚拟主机探测结果
+type VHostScan struct {
+	Target string
+	VHosts []VHost // 按主机名排序
+	Err    error   // 目标无法解析、不在扫描范围内或无法访问
+}
+
+// 虚拟主机探测：向目标IP（或主机名解析得到的IP）发送以候选主机名为Host头和SNI的请求，
+// 先以两个随机主机名得到基准响应，状态码、标题、重定向地址或长度与基准不同的候选视为存在的虚拟主机。
+// 目标未指定协议时依次尝试HTTPS和HTTP，使用第一个能够响应的协议
+func DiscoverVHosts(target string, candidates []string, cfg config.Config) ([]VHost, error) {
+	scan := DiscoverVHostsAll([]string{target}, candidates, cfg)[0]
+	return scan.VHosts, scan.Err
+}
+
+// 对多个目标进行虚拟主机探测，所有目标共用一个并发数为 cfg.Concurrency 的工作池：
+// 先并发取得各目标的基准响应，再并发探测所有目标和候选主机名的组合。返回与targets一一对应的结果
+func DiscoverVHostsAll(targets, candidates []string, cfg config.Config) []VHostScan {
+	workers := max(cfg.Concurrency, 1)
+	scans := make([]VHostScan, len(targets))
+	probers := make([]*vhostProber, len(targets))
+	baselines := make([]vhostBaseline, len(targets))
+
+	indexes := make(chan int, len(targets))
+	for i, target := range targets {
+		scans[i].Target = target
+		indexes <- i
+	}
+	close(indexes)
+	var wg sync.WaitGroup
+	for w := 0; w < min(workers, len(targets)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				probers[i], baselines[i], scans[i].Err = prepareVHostProber(targets[i], cfg)
+			}
+		}()
+	}
+	wg.Wait()
+
+	type vhostJob struct {
+		target int
+		host   string
+	}
+	var jobs []vhostJob
+	for i := range targets {
+		if scans[i].Err != nil {
+			continue
+		}
+		for _, host := range candidates {
+			jobs = append(jobs, vhostJob{i, host})
+		}
+	}
+	queue := make(chan vhostJob, len(jobs))
+	for _, job := range jobs {
+		queue <- job
+	}
+	close(queue)
+
+	var mu sync.Mutex
+	for w := 0; w < min(workers, len(jobs)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range queue {
+				probe := probers[job.target]
+				resp, err := probe.request(job.host)
+				if err != nil || !baselines[job.target].differs(resp) {
+					continue
+				}
+				mu.Lock()
+				scans[job.target].VHosts = append(scans[job.target].VHosts, VHost{
+					Host:   job.host,
+					URL:    probe.url(job.host),
+					IP:     probe.ip,
+					Status: resp.status,
+					Length: resp.length,
+					Title:  resp.title,
+				})
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	for i := range scans {
+		found := scans[i].VHosts
+		sort.Slice(found, func(a, b int) bool { return found[a].Host < found[b].Host })
+	}
+	return scans
+}
+
+// 解析目标并取得基准响应，返回可以访问目标的探测器
+func prepareVHostProber(target string, cfg config.Config) (*vhostProber, vhostBaseline, error) {
+	schemes := []string{"https", "http"}
+	hostPort := target
+	if u, err := url.Parse(target); err == nil && u.Host != "" && (u.Scheme == "http" || u.Scheme == "https") {
+		schemes, hostPort = []string{u.Scheme}, u.Host
+	}
+	host, port, err := net.SplitHostPort(hostPort)
+	if err != nil {
+		host = strings.Trim(hostPort, "[]")
+	}
+
+	ip := host
+	if net.ParseIP(host) == nil {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.Timeout)*time.Second)
+		ips, err := resolver.lookup(ctx, host)
+		cancel()
+		if err != nil || len(ips) == 0 {
+			return nil, vhostBaseline{}, fmt.Errorf("无法解析 %s: %v", host, err)
+		}
+		ip = ips[0].String()
+	}
+	// 主机名解析到被排除的地址时，不向其发送任何候选主机名
+	if parsed := net.ParseIP(ip); parsed != nil && !targetScope.AllowsIP(parsed) {
+		return nil, vhostBaseline{}, fmt.Errorf("%s 的IP %s 不在扫描范围内", target, ip)
+	}
+
+	var lastErr error
+	for _, scheme := range schemes {
+		targetPort := port
+		if targetPort == "" {
+			targetPort = map[string]string{"http": "80", "https": "443"}[scheme]
+		}
+		probe := newVHostProber(scheme, ip, targetPort, cfg)
+		baseline, err := probe.baseline()
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		return probe, baseline, nil
+	}
+	return nil, vhostBaseline{}, fmt.Errorf("%s 无法访问: %v", target, lastErr)
+}
+
+// 向固定IP和端口发送请求的探测器，请求URL中的主机名只用于Host头和SNI
+type vhostProber struct {
+	scheme, ip, port string
+	client           *http.Client
+	cfg              config.Config
+}
+
+func newVHostProber(scheme, ip, port string, cfg config.Config) *vhostProber {
+	dialer := &net.Dialer{Timeout: time.Duration(cfg.Timeout) * time.Second, Control: scopeControl}
+	addr := net.JoinHostPort(ip, port)
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			return dialer.DialContext(ctx, network, addr)
+		},
+		// 只比较响应，不验证证书：隐藏的虚拟主机通常使用默认证书
+		TLSClientConfig:     &tls.Config{InsecureSkipVerify: true},
+		MaxIdleConnsPerHost: 1,
+		IdleConnTimeout:     5 * time.Second,
+	}
+	return &vhostProber{
+		scheme: scheme,
+		ip:     ip,
+		port:   port,
+		cfg:    cfg,
+		client: &http.Client{
+			Timeout:   time.Duration(cfg.Timeout) * time.Second,
+			Transport: transport,
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+// 以指定主机名访问的地址，默认端口时省略端口
+func (p *vhostProber) url(host string) string {
+	if (p.scheme == "http" && p.port == "80") || (p.scheme == "https" && p.port == "443") {
+		return p.scheme + "://" + host
+	}
+	return p.scheme + "://" + net.JoinHostPort(host, p.port)
+}
+
+func (p *vhostProber) request(host string) (vhostResponse, error) {
+	resp, _, err := doRequest(p.client, p.url(host)+"/", p.cfg)
+	if err != nil {
+		return vhostResponse{}, err
+	}
+	defer resp.Body.Close()
+	body, err := readBody(resp.Body)
+	if err != nil {
+		return vhostResponse{}, err
+	}
+	return vhostResponse{
+		status:   resp.StatusCode,
+		length:   len(strings.ReplaceAll(body, host, "")),
+		title:    strings.ReplaceAll(extractTitle(body), host, ""),
+		location: strings.ReplaceAll(resp.Header.Get("Location"), host, ""),
+	}, nil
+}
+
+// 以两个随机的不存在主机名请求，得到基准响应和长度容差
+func (p *vhostProber) baseline() (vhostBaseline, error) {
+	var responses [2]vhostResponse
+	for i := range responses {
+		resp, err := p.request(randomVHostName())
+		if err != nil {
+			return vhostBaseline{}, err
+		}
+		responses[i] = resp
+	}
+	diff := responses[0].length - responses[1].length
+	if diff < 0 {
+		diff = -diff
+	}
+	return vhostBaseline{vhostResponse: responses[0], tolerance: max(2*diff, 32)}, nil
+}
+
+// 随机的不存在主机名，.invalid为保留顶级域
+func randomVHostName() string {
+	b := make([]byte, 6)
+	rand.Read(b)
+	return "squirrel-" + hex.EncodeToString(b) + ".invalid"
+}
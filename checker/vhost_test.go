@@ -0,0 +1,101 @@
+package checker
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"subdomain-checker/config"
+	"subdomain-checker/scope"
+)
+
+func TestDiscoverVHostsRespectsExcludedIP(t *testing.T) {
+	var hits atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+	}))
+	defer server.Close()
+
+	s, err := scope.New([]string{"127.0.0.0/8,::1/128"}, nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetScope(s)
+	defer SetScope(nil)
+
+	u, _ := url.Parse(server.URL)
+	cfg := config.Config{Timeout: 5, Concurrency: 2}
+	// 以主机名访问：名称本身在范围内，解析出的IP被排除
+	target := "http://localhost:" + u.Port()
+	if _, err := DiscoverVHosts(target, []string{"dev.example.com", "admin.example.com"}, cfg); err == nil {
+		t.Error("DiscoverVHosts probed an excluded IP")
+	}
+	if n := hits.Load(); n != 0 {
+		t.Errorf("excluded server received %d requests", n)
+	}
+}
+
+// 范围文件列出网段时，主机名目标解析到网段外的IP也不会被连接
+func TestCheckRespectsIncludedNetworks(t *testing.T) {
+	var hits atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+	}))
+	defer server.Close()
+
+	file := filepath.Join(t.TempDir(), "scope.txt")
+	if err := os.WriteFile(file, []byte("localhost\n10.0.0.0/8\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s, err := scope.New(nil, nil, file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetScope(s)
+	defer SetScope(nil)
+
+	u, _ := url.Parse(server.URL)
+	result := Check("http://localhost:"+u.Port(), config.Config{Timeout: 5, Concurrency: 1})
+	if result.Alive || hits.Load() != 0 {
+		t.Errorf("connected to an IP outside the scope file's networks (alive=%v, hits=%d)", result.Alive, hits.Load())
+	}
+}
+
+// 多个目标共用一个工作池：不同目标的请求并发进行，同时进行的请求总数不超过 cfg.Concurrency
+func TestDiscoverVHostsAllSharesWorkers(t *testing.T) {
+	var inFlight, peak atomic.Int64
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+	})
+	var targets []string
+	for i := 0; i < 4; i++ {
+		server := httptest.NewServer(handler)
+		defer server.Close()
+		targets = append(targets, server.URL)
+	}
+
+	scans := DiscoverVHostsAll(targets, []string{"dev.example.com"}, config.Config{Timeout: 5, Concurrency: 3})
+	for i, scan := range scans {
+		if scan.Target != targets[i] || scan.Err != nil || len(scan.VHosts) != 0 {
+			t.Errorf("scan %d = %+v", i, scan)
+		}
+	}
+	if p := peak.Load(); p > 3 {
+		t.Errorf("%d requests in flight, want at most 3", p)
+	} else if p < 2 {
+		t.Error("targets were probed one at a time")
+	}
+}
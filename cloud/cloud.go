@@ -0,0 +1,124 @@
+package cloud
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"os"
+	"sort"
+)
+
+// 内置的云服务商地址段：各服务商主要地址段的精简快照，完整列表通过 squirrel cloud-ranges 下载
+//
+//go:embed ranges.json
+var builtinRanges []byte
+
+// 云服务商地址段列表
+type Ranges struct {
+	Updated   string              `json:"updated"`   // 更新日期
+	Providers map[string][]string `json:"providers"` // 服务商名称 -> CIDR列表
+}
+
+// 解析地址段文件
+func parseRanges(data []byte) (*Ranges, error) {
+	var ranges Ranges
+	if err := json.Unmarshal(data, &ranges); err != nil {
+		return nil, err
+	}
+	return &ranges, nil
+}
+
+// 返回内置的地址段
+func Builtin() *Ranges {
+	ranges, err := parseRanges(builtinRanges)
+	if err != nil {
+		panic("cloud: 内置地址段无效: " + err.Error())
+	}
+	return ranges
+}
+
+// 读取地址段文件，文件不存在时返回内置地址段
+func LoadRanges(path string) (*Ranges, bool, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return Builtin(), false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	ranges, err := parseRanges(data)
+	if err != nil {
+		return nil, false, fmt.Errorf("解析云服务商地址段文件 %s 失败: %v", path, err)
+	}
+	return ranges, true, nil
+}
+
+// 按前缀长度索引的地址段匹配器，查询时从最长前缀开始依次查表
+type Matcher struct {
+	prefixes map[netip.Prefix]string
+	lengths4 []int // IPv4地址段出现过的前缀长度，从长到短
+	lengths6 []int // IPv6地址段出现过的前缀长度，从长到短
+}
+
+// 根据地址段列表创建匹配器，无效的CIDR被忽略
+func NewMatcher(ranges *Ranges) *Matcher {
+	m := &Matcher{prefixes: make(map[netip.Prefix]string)}
+	seen4, seen6 := make(map[int]bool), make(map[int]bool)
+	providers := make([]string, 0, len(ranges.Providers))
+	for provider := range ranges.Providers {
+		providers = append(providers, provider)
+	}
+	sort.Strings(providers) // 同一地址段出现在多个服务商时结果保持确定
+	for _, provider := range providers {
+		for _, cidr := range ranges.Providers[provider] {
+			prefix, err := netip.ParsePrefix(cidr)
+			if err != nil {
+				continue
+			}
+			prefix = prefix.Masked()
+			if _, ok := m.prefixes[prefix]; ok {
+				continue
+			}
+			m.prefixes[prefix] = provider
+			if bits := prefix.Bits(); prefix.Addr().Is4() && !seen4[bits] {
+				seen4[bits] = true
+				m.lengths4 = append(m.lengths4, bits)
+			} else if prefix.Addr().Is6() && !seen6[bits] {
+				seen6[bits] = true
+				m.lengths6 = append(m.lengths6, bits)
+			}
+		}
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(m.lengths4)))
+	sort.Sort(sort.Reverse(sort.IntSlice(m.lengths6)))
+	return m
+}
+
+// 地址段数量
+func (m *Matcher) Len() int {
+	return len(m.prefixes)
+}
+
+// 返回IP所属的云服务商，不属于任何已知地址段时返回空字符串
+func (m *Matcher) Lookup(ip string) string {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return ""
+	}
+	addr = addr.Unmap()
+	lengths := m.lengths4
+	if addr.Is6() {
+		lengths = m.lengths6
+	}
+	for _, bits := range lengths {
+		prefix, err := addr.Prefix(bits)
+		if err != nil {
+			continue
+		}
+		if provider, ok := m.prefixes[prefix]; ok {
+			return provider
+		}
+	}
+	return ""
+}
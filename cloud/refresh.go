@@ -0,0 +1,258 @@
+package cloud
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/netip"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 下载单个地址段列表的大小上限
+const maxRangesBytes = 64 << 20
+
+// 服务商公开发布的地址段列表及其解析方式
+type source struct {
+	provider string
+	urls     []string
+	parse    func(data []byte) ([]string, error)
+}
+
+// Azure的Service Tags文件地址每周变化，需要从下载页面中提取
+const azureDownloadPage = "https://www.microsoft.com/en-us/download/details.aspx?id=56519"
+
+var azureJSONRegex = regexp.MustCompile(`https://download\.microsoft\.com/download/[^"']+?/ServiceTags_Public_\d+\.json`)
+
+var sources = []source{
+	{"AWS", []string{"https://ip-ranges.amazonaws.com/ip-ranges.json"}, parseAWS},
+	{"GCP", []string{"https://www.gstatic.com/ipranges/cloud.json"}, parseGCP},
+	{"Azure", []string{azureDownloadPage}, nil}, // 由fetchAzure处理
+	{"Cloudflare", []string{"https://www.cloudflare.com/ips-v4", "https://www.cloudflare.com/ips-v6"}, parseLines},
+	{"Oracle", []string{"https://docs.oracle.com/en-us/iaas/tools/public_ip_ranges.json"}, parseOracle},
+	{"DigitalOcean", []string{"https://digitalocean.com/geo/google.csv"}, parseCSVFirstColumn},
+	{"Fastly", []string{"https://api.fastly.com/public-ip-list"}, parseFastly},
+}
+
+// 下载各服务商公开发布的地址段并写入path。没有公开列表或下载失败的服务商保留原有地址段
+// （path不存在时为内置地址段），progress用于输出每个服务商的下载结果
+func Refresh(path string, progress func(provider string, count int, err error)) error {
+	ranges, _, err := LoadRanges(path)
+	if err != nil {
+		return err
+	}
+
+	client := &http.Client{Timeout: 60 * time.Second}
+	updated := 0
+	for _, src := range sources {
+		var cidrs []string
+		var err error
+		if src.provider == "Azure" {
+			cidrs, err = fetchAzure(client)
+		} else {
+			cidrs, err = fetchSource(client, src)
+		}
+		if err == nil && len(cidrs) == 0 {
+			err = fmt.Errorf("未获取到地址段")
+		}
+		progress(src.provider, len(cidrs), err)
+		if err != nil {
+			continue
+		}
+		ranges.Providers[src.provider] = cidrs
+		updated++
+	}
+	if updated == 0 {
+		return fmt.Errorf("所有服务商的地址段都下载失败")
+	}
+
+	ranges.Updated = time.Now().Format("2006-01-02")
+	data, err := json.MarshalIndent(ranges, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+func fetchSource(client *http.Client, src source) ([]string, error) {
+	var cidrs []string
+	for _, url := range src.urls {
+		data, err := download(client, url)
+		if err != nil {
+			return nil, err
+		}
+		parsed, err := src.parse(data)
+		if err != nil {
+			return nil, fmt.Errorf("解析 %s 失败: %v", url, err)
+		}
+		cidrs = append(cidrs, parsed...)
+	}
+	return normalize(cidrs), nil
+}
+
+// 从下载页面中找到当前的Service Tags文件并提取所有地址段
+func fetchAzure(client *http.Client) ([]string, error) {
+	page, err := download(client, azureDownloadPage)
+	if err != nil {
+		return nil, err
+	}
+	url := azureJSONRegex.Find(page)
+	if url == nil {
+		return nil, fmt.Errorf("下载页面中未找到Service Tags文件地址")
+	}
+	data, err := download(client, string(url))
+	if err != nil {
+		return nil, err
+	}
+	var tags struct {
+		Values []struct {
+			Properties struct {
+				AddressPrefixes []string `json:"addressPrefixes"`
+			} `json:"properties"`
+		} `json:"values"`
+	}
+	if err := json.Unmarshal(data, &tags); err != nil {
+		return nil, err
+	}
+	var cidrs []string
+	for _, value := range tags.Values {
+		cidrs = append(cidrs, value.Properties.AddressPrefixes...)
+	}
+	return normalize(cidrs), nil
+}
+
+func download(client *http.Client, url string) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0 (compatible; squirrel cloud-ranges)")
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s 返回 HTTP %d", url, resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxRangesBytes))
+}
+
+func parseAWS(data []byte) ([]string, error) {
+	var doc struct {
+		Prefixes []struct {
+			IPPrefix string `json:"ip_prefix"`
+		} `json:"prefixes"`
+		IPv6Prefixes []struct {
+			IPv6Prefix string `json:"ipv6_prefix"`
+		} `json:"ipv6_prefixes"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	var cidrs []string
+	for _, p := range doc.Prefixes {
+		cidrs = append(cidrs, p.IPPrefix)
+	}
+	for _, p := range doc.IPv6Prefixes {
+		cidrs = append(cidrs, p.IPv6Prefix)
+	}
+	return cidrs, nil
+}
+
+func parseGCP(data []byte) ([]string, error) {
+	var doc struct {
+		Prefixes []struct {
+			IPv4Prefix string `json:"ipv4Prefix"`
+			IPv6Prefix string `json:"ipv6Prefix"`
+		} `json:"prefixes"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	var cidrs []string
+	for _, p := range doc.Prefixes {
+		cidrs = append(cidrs, p.IPv4Prefix, p.IPv6Prefix)
+	}
+	return cidrs, nil
+}
+
+func parseOracle(data []byte) ([]string, error) {
+	var doc struct {
+		Regions []struct {
+			CIDRs []struct {
+				CIDR string `json:"cidr"`
+			} `json:"cidrs"`
+		} `json:"regions"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	var cidrs []string
+	for _, region := range doc.Regions {
+		for _, c := range region.CIDRs {
+			cidrs = append(cidrs, c.CIDR)
+		}
+	}
+	return cidrs, nil
+}
+
+func parseFastly(data []byte) ([]string, error) {
+	var doc struct {
+		Addresses     []string `json:"addresses"`
+		IPv6Addresses []string `json:"ipv6_addresses"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return append(doc.Addresses, doc.IPv6Addresses...), nil
+}
+
+// 每行一个CIDR
+func parseLines(data []byte) ([]string, error) {
+	var cidrs []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" && !strings.HasPrefix(line, "#") {
+			cidrs = append(cidrs, line)
+		}
+	}
+	return cidrs, scanner.Err()
+}
+
+// CSV的第一列为CIDR（RFC 8805地理位置格式）
+func parseCSVFirstColumn(data []byte) ([]string, error) {
+	lines, err := parseLines(data)
+	if err != nil {
+		return nil, err
+	}
+	for i, line := range lines {
+		lines[i], _, _ = strings.Cut(line, ",")
+	}
+	return lines, nil
+}
+
+// 去除无效和重复的CIDR并排序
+func normalize(cidrs []string) []string {
+	seen := make(map[string]bool)
+	var result []string
+	for _, cidr := range cidrs {
+		prefix, err := netip.ParsePrefix(strings.TrimSpace(cidr))
+		if err != nil {
+			continue
+		}
+		s := prefix.Masked().String()
+		if !seen[s] {
+			seen[s] = true
+			result = append(result, s)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
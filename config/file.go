@@ -0,0 +1,219 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// 一组选项，键为命令行参数名（如 concurrency、screenshot-alive），值为参数值
+type Profile map[string]interface{}
+
+// 配置文件结构：defaults中的选项总是生效，profiles中为可通过 -profile 选择的命名配置
+type FileConfig struct {
+	Defaults Profile            `yaml:"defaults" toml:"defaults"`
+	Profiles map[string]Profile `yaml:"profiles" toml:"profiles"`
+}
+
+// 内置配置，可被配置文件中的同名配置覆盖
+var builtinProfiles = map[string]Profile{
+	"fast": {
+		"concurrency": 50,
+		"timeout":     5,
+	},
+	"thorough": {
+		"concurrency": 10,
+		"timeout":     20,
+		"follow":      true,
+		"extract":     true,
+		"summary":     "full",
+	},
+	"stealth": {
+		"concurrency": 2,
+		"timeout":     15,
+		"random-ua":   true,
+	},
+}
+
+// 未指定 -config 时依次查找的配置文件
+func defaultConfigPaths() []string {
+	paths := []string{"squirrel.yaml", "squirrel.yml", "squirrel.toml"}
+	if dir, err := os.UserConfigDir(); err == nil {
+		for _, name := range []string{"config.yaml", "config.yml", "config.toml"} {
+			paths = append(paths, filepath.Join(dir, "squirrel", name))
+		}
+	}
+	return paths
+}
+
+// 会执行命令或改变团队策略的选项，只能出现在 -config 显式指定的配置文件中：
+// 自动查找到的配置文件可能来自克隆的仓库等不受信任的目录，在其中运行扫描不应执行任意命令
+var explicitOnlyOptions = []string{"pre-cmd", "post-cmd", "policy-url", "policy-key"}
+
+// 检查自动查找到的配置文件，defaults或任一profile设置了explicitOnlyOptions中的选项时返回错误
+func checkDiscoveredConfig(fc *FileConfig, path string) error {
+	sections := map[string]Profile{"defaults": fc.Defaults}
+	for name, profile := range fc.Profiles {
+		sections[fmt.Sprintf("配置 %q ", name)] = profile
+	}
+	names := make([]string, 0, len(sections))
+	for name := range sections {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, section := range names {
+		for _, option := range explicitOnlyOptions {
+			if _, ok := sections[section][option]; ok {
+				return fmt.Errorf("自动查找到的配置文件 %s 的%s中设置了 %s，该选项会执行命令或改变团队策略，只能在以 -config 显式指定的配置文件中使用", path, section, option)
+			}
+		}
+	}
+	return nil
+}
+
+// 读取配置文件，根据扩展名选择TOML或YAML格式
+func LoadFileConfig(path string) (*FileConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var fc FileConfig
+	if strings.EqualFold(filepath.Ext(path), ".toml") {
+		err = toml.Unmarshal(data, &fc)
+	} else {
+		err = yaml.Unmarshal(data, &fc)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("解析配置文件 %s 失败: %v", path, err)
+	}
+	return &fc, nil
+}
+
+// 加载配置文件并应用defaults和所选profile，命令行中显式指定的参数优先
+// path为空时查找默认位置的配置文件，找不到时只能使用内置配置；返回实际使用的配置文件路径
+// remote为中心配置服务下发的配置，其中的选项和同名profile优先于本地配置文件
+func ApplyConfigFile(path, profile string, remote *FileConfig) (string, error) {
+	var fc *FileConfig
+	if path != "" {
+		var err error
+		if fc, err = LoadFileConfig(path); err != nil {
+			return "", err
+		}
+	} else {
+		for _, candidate := range defaultConfigPaths() {
+			if _, err := os.Stat(candidate); err != nil {
+				continue
+			}
+			var err error
+			if fc, err = LoadFileConfig(candidate); err != nil {
+				return "", err
+			}
+			if err := checkDiscoveredConfig(fc, candidate); err != nil {
+				return "", err
+			}
+			path = candidate
+			break
+		}
+	}
+	if fc == nil {
+		fc = &FileConfig{}
+	}
+	if remote != nil {
+		fc = mergeFileConfig(fc, remote)
+	}
+
+	// 记录命令行中显式指定的参数
+	explicit := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = true
+	})
+
+	if err := applyProfile(fc.Defaults, explicit); err != nil {
+		return path, fmt.Errorf("配置文件defaults中%v", err)
+	}
+	if profile == "" {
+		return path, nil
+	}
+
+	selected, ok := fc.Profiles[profile]
+	if !ok {
+		selected, ok = builtinProfiles[profile]
+	}
+	if !ok {
+		return path, fmt.Errorf("未找到配置 %q，可用配置: %s", profile, strings.Join(ProfileNames(fc), ", "))
+	}
+	if err := applyProfile(selected, explicit); err != nil {
+		return path, fmt.Errorf("配置 %q 中%v", profile, err)
+	}
+	return path, nil
+}
+
+// 返回可用的配置名称（内置配置和配置文件中的配置）
+func ProfileNames(fc *FileConfig) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for name := range builtinProfiles {
+		seen[name] = true
+		names = append(names, name)
+	}
+	if fc != nil {
+		for name := range fc.Profiles {
+			if !seen[name] {
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// 合并两份配置，override中的选项和profile覆盖base中的同名项
+func mergeFileConfig(base, override *FileConfig) *FileConfig {
+	merged := &FileConfig{Defaults: Profile{}, Profiles: make(map[string]Profile)}
+	for _, fc := range []*FileConfig{base, override} {
+		for name, value := range fc.Defaults {
+			merged.Defaults[name] = value
+		}
+		for name, profile := range fc.Profiles {
+			merged.Profiles[name] = profile
+		}
+	}
+	return merged
+}
+
+// 将配置中的选项设置到对应的命令行参数上，跳过命令行中已显式指定的参数
+func applyProfile(profile Profile, explicit map[string]bool) error {
+	names := make([]string, 0, len(profile))
+	for name := range profile {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if name == "config" || name == "profile" || name == "policy-url" || name == "policy-key" {
+			continue
+		}
+		if flag.Lookup(name) == nil {
+			return fmt.Errorf("存在未知选项: %s", name)
+		}
+		if explicit[name] {
+			continue
+		}
+		values, ok := profile[name].([]interface{})
+		if !ok {
+			values = []interface{}{profile[name]}
+		}
+		for _, value := range values {
+			if err := flag.Set(name, fmt.Sprint(value)); err != nil {
+				return fmt.Errorf("选项 %s 的值无效: %v", name, err)
+			}
+		}
+	}
+	return nil
+}
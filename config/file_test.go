@@ -0,0 +1,28 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckDiscoveredConfig(t *testing.T) {
+	safe := &FileConfig{
+		Defaults: Profile{"concurrency": 20},
+		Profiles: map[string]Profile{"ci": {"timeout": 5, "summary": "minimal"}},
+	}
+	if err := checkDiscoveredConfig(safe, "squirrel.yaml"); err != nil {
+		t.Errorf("safe config rejected: %v", err)
+	}
+
+	for _, option := range explicitOnlyOptions {
+		for _, fc := range []*FileConfig{
+			{Defaults: Profile{option: "x"}},
+			{Profiles: map[string]Profile{"ci": {option: "x"}}},
+		} {
+			err := checkDiscoveredConfig(fc, "squirrel.yaml")
+			if err == nil || !strings.Contains(err.Error(), option) {
+				t.Errorf("%s accepted in a discovered config file: %v", option, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package config
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// 下载策略文件和签名的大小上限
+const maxPolicyBytes = 1 << 20
+
+// 团队扫描策略：除与配置文件相同的defaults和profiles外，还包含对所有成员强制生效的规则
+type Policy struct {
+	Defaults Profile            `yaml:"defaults"`
+	Profiles map[string]Profile `yaml:"profiles"`
+	Rules    PolicyRules        `yaml:"policy"`
+}
+
+// 强制规则，优先于命令行参数
+type PolicyRules struct {
+	MaxConcurrency  int      `yaml:"max_concurrency"`  // 并发上限（含自适应并发的上限），0表示不限制
+	Exclude         []string `yaml:"exclude"`          // 范围外的目标：域名（含子域名）、*.域名（仅子域名）、IP或CIDR
+	RequiredHeaders []string `yaml:"required_headers"` // 必须附加的请求头，格式为 "Name: Value"
+}
+
+// 从中心配置服务获取策略，并使用Ed25519公钥校验签名
+// 签名为策略文件原始内容的Ed25519签名（base64编码），从 <url>.sig 获取
+func FetchPolicy(url, keyFile string) (*Policy, error) {
+	if keyFile == "" {
+		return nil, fmt.Errorf("使用 -policy-url 时必须通过 -policy-key 指定签名公钥")
+	}
+	key, err := loadPublicKey(keyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	client := &http.Client{Timeout: 15 * time.Second}
+	data, err := fetchPolicyFile(client, url)
+	if err != nil {
+		return nil, fmt.Errorf("获取策略失败: %v", err)
+	}
+	sigData, err := fetchPolicyFile(client, url+".sig")
+	if err != nil {
+		return nil, fmt.Errorf("获取策略签名失败: %v", err)
+	}
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(sigData)))
+	if err != nil {
+		return nil, fmt.Errorf("策略签名不是有效的base64: %v", err)
+	}
+	if !ed25519.Verify(key, data, sig) {
+		return nil, fmt.Errorf("策略签名校验失败，拒绝使用 %s", url)
+	}
+
+	var policy Policy
+	if err := yaml.Unmarshal(data, &policy); err != nil {
+		return nil, fmt.Errorf("解析策略失败: %v", err)
+	}
+	return &policy, nil
+}
+
+// 读取base64编码的Ed25519公钥文件
+func loadPublicKey(path string) (ed25519.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("无法读取策略公钥: %v", err)
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("策略公钥 %s 不是有效的base64编码Ed25519公钥", path)
+	}
+	return ed25519.PublicKey(key), nil
+}
+
+func fetchPolicyFile(client *http.Client, url string) ([]byte, error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s 返回 HTTP %d", url, resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxPolicyBytes))
+}
+
+// 转换为配置文件结构，与本地配置文件合并
+func (p *Policy) FileConfig() *FileConfig {
+	return &FileConfig{Defaults: p.Defaults, Profiles: p.Profiles}
+}
+
+// 将强制规则应用到配置，返回被调整的项目说明
+func (p *Policy) Enforce(cfg *Config) []string {
+	var changes []string
+	if max := p.Rules.MaxConcurrency; max > 0 {
+		if cfg.Concurrency > max {
+			changes = append(changes, fmt.Sprintf("并发数 %d → %d", cfg.Concurrency, max))
+			cfg.Concurrency = max
+		}
+		if cfg.Adaptive && (cfg.MaxConcurrency <= 0 || cfg.MaxConcurrency > max) {
+			cfg.MaxConcurrency = max
+			changes = append(changes, fmt.Sprintf("自适应并发上限 → %d", max))
+		}
+	}
+
+	changes = append(changes, p.enforceHeaders(cfg)...)
+	return changes
+}
+
+// 附加策略要求的请求头：命令行（-header）中的同名请求头不论大小写一律去掉，统一使用策略的值；
+// 策略要求Cookie请求头时 -cookie 同样被忽略，不会追加到策略的Cookie上
+func (p *Policy) enforceHeaders(cfg *Config) []string {
+	required := make(map[string]string)
+	var names []string
+	for _, header := range p.Rules.RequiredHeaders {
+		name, value, _ := strings.Cut(header, ":")
+		name = strings.TrimSpace(name)
+		key := strings.ToLower(name)
+		if _, ok := required[key]; !ok {
+			names = append(names, name)
+		}
+		required[key] = strings.TrimSpace(value)
+	}
+	if len(required) == 0 {
+		return nil
+	}
+
+	var changes []string
+	present := make(map[string]bool)
+	kept := make(StringList, 0, len(cfg.Headers))
+	for _, header := range cfg.Headers {
+		name, value, _ := strings.Cut(header, ":")
+		key := strings.ToLower(strings.TrimSpace(name))
+		want, ok := required[key]
+		if !ok {
+			kept = append(kept, header)
+			continue
+		}
+		if strings.TrimSpace(value) == want {
+			present[key] = true
+			continue
+		}
+		changes = append(changes, fmt.Sprintf("忽略 -header 中的 %s，使用策略要求的值", strings.TrimSpace(name)))
+	}
+	cfg.Headers = kept
+	for _, name := range names {
+		cfg.Headers = append(cfg.Headers, name+": "+required[strings.ToLower(name)])
+		if !present[strings.ToLower(name)] {
+			changes = append(changes, "附加请求头 "+name)
+		}
+	}
+	if _, ok := required["cookie"]; ok && cfg.Cookie != "" {
+		cfg.Cookie = ""
+		changes = append(changes, "忽略 -cookie，Cookie 使用策略要求的值")
+	}
+	return changes
+}
+
+// 将策略的排除规则转换为 -exclude 的写法：域名同时排除其子域名，*.域名 只排除子域名。
+// 转换后的规则与 -exclude/-scope 合并为同一个扫描范围，在输入、重定向、连接和导入的目标上统一生效
+func (p *Policy) ExcludeRules() []string {
+	if p == nil {
+		return nil
+	}
+	var rules []string
+	for _, pattern := range p.Rules.Exclude {
+		pattern = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(pattern)), ".")
+		switch {
+		case pattern == "":
+			continue
+		case strings.HasPrefix(pattern, "*."), strings.Contains(pattern, "/"), net.ParseIP(pattern) != nil:
+			rules = append(rules, pattern)
+		default:
+			rules = append(rules, pattern, "*."+pattern)
+		}
+	}
+	return rules
+}
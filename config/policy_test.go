@@ -0,0 +1,36 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEnforceRequiredHeaders(t *testing.T) {
+	p := &Policy{Rules: PolicyRules{RequiredHeaders: StringList{"X-Scan-Team: security", "Cookie: scan=team"}}}
+	cfg := &Config{
+		Headers: StringList{"x-scan-team: anything", "Accept: */*", "X-Scan-Team: other"},
+		Cookie:  "session=1",
+	}
+	changes := p.Enforce(cfg)
+
+	want := StringList{"Accept: */*", "X-Scan-Team: security", "Cookie: scan=team"}
+	if !reflect.DeepEqual(cfg.Headers, want) {
+		t.Errorf("headers = %q, want %q", cfg.Headers, want)
+	}
+	if cfg.Cookie != "" {
+		t.Errorf("-cookie kept alongside the policy cookie: %q", cfg.Cookie)
+	}
+	if got, _ := cfg.HeaderMap(); got["X-Scan-Team"] != "security" || got["Cookie"] != "scan=team" {
+		t.Errorf("HeaderMap = %v", got)
+	}
+	if len(changes) != 5 {
+		t.Errorf("changes = %q, want the two overridden -header values, two added headers and -cookie", changes)
+	}
+
+	// 与策略相同的值保持不变，不提示
+	cfg = &Config{Headers: StringList{"x-scan-team: security"}}
+	p = &Policy{Rules: PolicyRules{RequiredHeaders: StringList{"X-Scan-Team: security"}}}
+	if changes := p.Enforce(cfg); len(changes) != 0 || len(cfg.Headers) != 1 {
+		t.Errorf("matching header: changes = %q, headers = %q", changes, cfg.Headers)
+	}
+}
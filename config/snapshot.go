@@ -0,0 +1,80 @@
+package config
+
+import (
+	"flag"
+	"net/url"
+	"strings"
+)
+
+// 配置快照中的一项
+type SnapshotEntry struct {
+	Name     string // 参数名
+	Value    string // 生效的值（已脱敏）
+	Explicit bool   // 是否在命令行中显式指定
+}
+
+// 脱敏后显示的占位符
+const redacted = "***"
+
+// 参数名中包含这些关键字时，其值整体脱敏
+var secretFlagKeywords = []string{"cookie", "webhook", "token", "password", "secret", "anon-key", "auth"}
+
+// 值需要脱敏的请求头
+var secretHeaders = map[string]bool{
+	"authorization":       true,
+	"proxy-authorization": true,
+	"cookie":              true,
+	"x-api-key":           true,
+	"x-auth-token":        true,
+}
+
+// 返回当前生效的全部参数及其值，敏感信息（Cookie、Webhook地址、认证请求头、URL中的账号密码）会被脱敏
+func Snapshot() []SnapshotEntry {
+	explicit := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = true
+	})
+
+	var entries []SnapshotEntry
+	flag.VisitAll(func(f *flag.Flag) {
+		entries = append(entries, SnapshotEntry{
+			Name:     f.Name,
+			Value:    sanitizeFlagValue(f),
+			Explicit: explicit[f.Name],
+		})
+	})
+	return entries
+}
+
+// 对参数值进行脱敏
+func sanitizeFlagValue(f *flag.Flag) string {
+	if list, ok := f.Value.(*StringList); ok && f.Name == "header" {
+		headers := make([]string, 0, len(*list))
+		for _, header := range *list {
+			name, _, _ := strings.Cut(header, ":")
+			if secretHeaders[strings.ToLower(strings.TrimSpace(name))] {
+				header = strings.TrimSpace(name) + ": " + redacted
+			}
+			headers = append(headers, header)
+		}
+		return strings.Join(headers, "; ")
+	}
+
+	value := f.Value.String()
+	if value == "" {
+		return value
+	}
+	lower := strings.ToLower(f.Name)
+	for _, keyword := range secretFlagKeywords {
+		if strings.Contains(lower, keyword) {
+			return redacted
+		}
+	}
+	if strings.Contains(value, "://") {
+		if u, err := url.Parse(value); err == nil && u.User != nil {
+			u.User = nil
+			return u.Scheme + "://" + redacted + "@" + strings.TrimPrefix(u.String(), u.Scheme+"://")
+		}
+	}
+	return value
+}
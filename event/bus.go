@@ -0,0 +1,81 @@
+package event
+
+import (
+	"sync"
+	"time"
+
+	"subdomain-checker/checker"
+)
+
+// 事件类型
+type Type string
+
+const (
+	ResultCompleted Type = "result"     // 一个目标检测完成（已补充截图和备注）
+	FindingRaised   Type = "finding"    // 存活结果识别出值得关注的页面类型或关键词命中
+	ScreenshotSaved Type = "screenshot" // 结果附带了截图
+	ScanFinished    Type = "finish"     // 全部目标检测完成，汇总已输出
+)
+
+// 扫描完成时的汇总信息
+type ScanInfo struct {
+	Total    int
+	Alive    int
+	Dead     int
+	Duration time.Duration
+	Results  []checker.Result
+}
+
+// 事件：ResultCompleted、FindingRaised、ScreenshotSaved 携带Result，
+// ResultCompleted 和 FindingRaised 另带关注原因（ResultCompleted 没有发现时为空），ScanFinished 携带Scan
+type Event struct {
+	Type    Type
+	Result  checker.Result
+	Reasons []string
+	Scan    *ScanInfo
+}
+
+// 事件处理函数
+type Handler func(Event)
+
+// 进程内事件总线：通知、写入器、存储等按事件类型订阅，检测流程只负责发布事件。
+// 事件在发布者的goroutine中按订阅顺序同步分发，同一发布者发布的事件不会并发调用处理函数，
+// 处理函数中耗时的操作应自行转入后台
+type Bus struct {
+	mu       sync.RWMutex
+	handlers map[Type][]Handler
+}
+
+// 创建事件总线
+func NewBus() *Bus {
+	return &Bus{handlers: make(map[Type][]Handler)}
+}
+
+// 订阅指定类型的事件
+func (b *Bus) Subscribe(t Type, h Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[t] = append(b.handlers[t], h)
+}
+
+// 发布事件
+func (b *Bus) Publish(e Event) {
+	b.mu.RLock()
+	handlers := b.handlers[e.Type]
+	b.mu.RUnlock()
+	for _, h := range handlers {
+		h(e)
+	}
+}
+
+// 发布一个检测结果，并根据结果内容派生 ScreenshotSaved 和 FindingRaised 事件，关注原因只计算一次
+func (b *Bus) PublishResult(result checker.Result) {
+	reasons := result.FindingReasons()
+	b.Publish(Event{Type: ResultCompleted, Result: result, Reasons: reasons})
+	if result.Screenshot != "" {
+		b.Publish(Event{Type: ScreenshotSaved, Result: result})
+	}
+	if len(reasons) > 0 {
+		b.Publish(Event{Type: FindingRaised, Result: result, Reasons: reasons})
+	}
+}
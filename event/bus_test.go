@@ -0,0 +1,39 @@
+package event
+
+import (
+	"slices"
+	"testing"
+
+	"subdomain-checker/checker"
+)
+
+func TestPublishResultDerivesEvents(t *testing.T) {
+	bus := NewBus()
+	var got []Type
+	var findingReasons, completedReasons []string
+	for _, typ := range []Type{ResultCompleted, ScreenshotSaved, FindingRaised} {
+		bus.Subscribe(typ, func(e Event) {
+			got = append(got, e.Type)
+			switch e.Type {
+			case FindingRaised:
+				findingReasons = e.Reasons
+			case ResultCompleted:
+				completedReasons = e.Reasons
+			}
+		})
+	}
+
+	bus.PublishResult(checker.Result{Domain: "https://a.example.com", Alive: true, LoginForm: true, Screenshot: "a.png"})
+	if want := []Type{ResultCompleted, ScreenshotSaved, FindingRaised}; !slices.Equal(got, want) {
+		t.Fatalf("events = %v, want %v", got, want)
+	}
+	if len(findingReasons) == 0 || !slices.Equal(findingReasons, completedReasons) {
+		t.Errorf("finding reasons = %v, completed reasons = %v", findingReasons, completedReasons)
+	}
+
+	got = nil
+	bus.PublishResult(checker.Result{Domain: "https://b.example.com", Alive: true})
+	if want := []Type{ResultCompleted}; !slices.Equal(got, want) {
+		t.Errorf("events = %v, want %v", got, want)
+	}
+}
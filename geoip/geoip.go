@@ -0,0 +1,108 @@
+package geoip
+
+import (
+	"net"
+	"strconv"
+	"strings"
+)
+
+// IP地址的归属信息
+type Info struct {
+	ASN     uint   // 自治系统号
+	Org     string // 自治系统所属组织，如 "Amazon.com, Inc."
+	Country string // 国家或地区代码，如 "CN"、"US"
+}
+
+// 由一个或多个MMDB文件组成的查询器，如GeoLite2-ASN加GeoLite2-Country，
+// 或同时包含ASN和国家信息的单个文件（如ipinfo的country_asn.mmdb）
+type DB struct {
+	readers []*Reader
+}
+
+// 打开多个MMDB文件
+func OpenAll(paths []string) (*DB, error) {
+	db := &DB{}
+	for _, path := range paths {
+		r, err := Open(path)
+		if err != nil {
+			return nil, err
+		}
+		db.readers = append(db.readers, r)
+	}
+	return db, nil
+}
+
+// 返回各文件的数据库类型，用于启动提示
+func (db *DB) Types() []string {
+	var types []string
+	for _, r := range db.readers {
+		types = append(types, r.DatabaseType)
+	}
+	return types
+}
+
+// 查询IP地址的ASN、组织和国家，按文件顺序取第一个非空的字段
+func (db *DB) Lookup(addr string) Info {
+	var info Info
+	ip := net.ParseIP(addr)
+	if db == nil || ip == nil {
+		return info
+	}
+	for _, r := range db.readers {
+		record, err := r.Lookup(ip)
+		if err != nil || record == nil {
+			continue
+		}
+		if info.ASN == 0 {
+			info.ASN = recordASN(record)
+		}
+		if info.Org == "" {
+			info.Org = firstString(record, "autonomous_system_organization", "as_name", "organization", "org")
+		}
+		if info.Country == "" {
+			info.Country = recordCountry(record)
+		}
+	}
+	return info
+}
+
+// ASN字段：MaxMind为整数 autonomous_system_number，ipinfo为字符串 "AS13335"
+func recordASN(record map[string]interface{}) uint {
+	for _, key := range []string{"autonomous_system_number", "asn"} {
+		switch v := record[key].(type) {
+		case uint64:
+			return uint(v)
+		case string:
+			if n, err := strconv.ParseUint(strings.TrimPrefix(strings.ToUpper(v), "AS"), 10, 32); err == nil {
+				return uint(n)
+			}
+		}
+	}
+	return 0
+}
+
+// 国家字段：MaxMind为 country.iso_code（缺失时使用 registered_country），ipinfo为字符串 country
+func recordCountry(record map[string]interface{}) string {
+	for _, key := range []string{"country", "registered_country"} {
+		switch v := record[key].(type) {
+		case map[string]interface{}:
+			if code, ok := v["iso_code"].(string); ok && code != "" {
+				return code
+			}
+		case string:
+			if v != "" {
+				return v
+			}
+		}
+	}
+	return firstString(record, "country_code")
+}
+
+func firstString(record map[string]interface{}, keys ...string) string {
+	for _, key := range keys {
+		if s, ok := record[key].(string); ok && s != "" {
+			return s
+		}
+	}
+	return ""
+}
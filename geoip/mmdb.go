@@ -0,0 +1,358 @@
+package geoip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"net"
+	"os"
+)
+
+// MMDB文件中元数据的起始标记
+var metadataMarker = []byte("\xab\xcd\xefMaxMind.com")
+
+// 搜索树与数据区之间的16字节分隔
+const dataSectionSeparator = 16
+
+// MaxMind DB (MMDB) 格式的只读数据库，整个文件读入内存，可并发查询
+// 格式说明见 https://maxmind.github.io/MaxMind-DB/
+type Reader struct {
+	buffer       []byte
+	data         []byte // 数据区
+	nodeCount    uint
+	recordSize   uint
+	ipVersion    uint
+	ipv4Start    uint // IPv6数据库中 ::/96 对应的节点，用于查询IPv4地址
+	DatabaseType string
+}
+
+// 打开MMDB文件
+func Open(path string) (*Reader, error) {
+	buffer, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	r, err := newReader(buffer)
+	if err != nil {
+		return nil, fmt.Errorf("%s 不是有效的MMDB文件: %v", path, err)
+	}
+	return r, nil
+}
+
+func newReader(buffer []byte) (*Reader, error) {
+	start := bytes.LastIndex(buffer, metadataMarker)
+	if start < 0 {
+		return nil, errors.New("找不到元数据")
+	}
+	meta, _, err := (&decoder{buffer: buffer[start+len(metadataMarker):]}).decode(0)
+	if err != nil {
+		return nil, fmt.Errorf("解析元数据失败: %v", err)
+	}
+	metadata, ok := meta.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("元数据格式错误")
+	}
+
+	r := &Reader{buffer: buffer}
+	r.nodeCount = uint(toUint(metadata["node_count"]))
+	r.recordSize = uint(toUint(metadata["record_size"]))
+	r.ipVersion = uint(toUint(metadata["ip_version"]))
+	r.DatabaseType, _ = metadata["database_type"].(string)
+	switch r.recordSize {
+	case 24, 28, 32:
+	default:
+		return nil, fmt.Errorf("不支持的记录大小 %d", r.recordSize)
+	}
+
+	treeSize := r.nodeCount * r.recordSize / 4
+	if treeSize+dataSectionSeparator > uint(start) {
+		return nil, errors.New("搜索树大小超出文件范围")
+	}
+	r.data = buffer[treeSize+dataSectionSeparator : start]
+
+	if r.ipVersion == 6 {
+		node := uint(0)
+		for i := 0; i < 96 && node < r.nodeCount; i++ {
+			node = r.readRecord(node, 0)
+		}
+		r.ipv4Start = node
+	}
+	return r, nil
+}
+
+// 读取节点的左(bit=0)或右(bit=1)记录
+func (r *Reader) readRecord(node uint, bit uint) uint {
+	switch r.recordSize {
+	case 24:
+		offset := node*6 + bit*3
+		b := r.buffer[offset : offset+3]
+		return uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2])
+	case 28:
+		b := r.buffer[node*7 : node*7+7]
+		if bit == 0 {
+			return uint(b[3]&0xF0)<<20 | uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2])
+		}
+		return uint(b[3]&0x0F)<<24 | uint(b[4])<<16 | uint(b[5])<<8 | uint(b[6])
+	default:
+		offset := node*8 + bit*4
+		return uint(binary.BigEndian.Uint32(r.buffer[offset : offset+4]))
+	}
+}
+
+// 查询IP地址对应的记录，未收录时返回nil
+func (r *Reader) Lookup(ip net.IP) (map[string]interface{}, error) {
+	node := uint(0)
+	bits := ip.To4()
+	if bits != nil && r.ipVersion == 6 {
+		node = r.ipv4Start
+	} else if bits == nil {
+		if r.ipVersion == 4 {
+			return nil, nil
+		}
+		bits = ip.To16()
+	}
+
+	for i := 0; i < len(bits)*8 && node < r.nodeCount; i++ {
+		bit := uint(bits[i/8]>>(7-uint(i%8))) & 1
+		node = r.readRecord(node, bit)
+	}
+	if node <= r.nodeCount {
+		return nil, nil
+	}
+
+	offset := node - r.nodeCount - dataSectionSeparator
+	value, _, err := (&decoder{buffer: r.data}).decode(offset)
+	if err != nil {
+		return nil, err
+	}
+	record, _ := value.(map[string]interface{})
+	return record, nil
+}
+
+// 数据区解码器
+type decoder struct {
+	buffer []byte
+}
+
+// 数据字段类型
+const (
+	typeExtended = iota
+	typePointer
+	typeString
+	typeFloat64
+	typeBytes
+	typeUint16
+	typeUint32
+	typeMap
+	typeInt32
+	typeUint64
+	typeUint128
+	typeSlice
+	typeContainer
+	typeEndMarker
+	typeBool
+	typeFloat32
+)
+
+var errCorrupt = errors.New("数据区已损坏")
+
+// map和数组的最大嵌套深度，防止损坏或恶意构造的文件导致无限递归
+const maxDepth = 512
+
+// 解码offset处的值，返回值和下一个字段的偏移
+func (d *decoder) decode(offset uint) (interface{}, uint, error) {
+	return d.decodeAt(offset, 0)
+}
+
+// 解码offset处的值，depth为当前map和数组的嵌套深度
+func (d *decoder) decodeAt(offset uint, depth int) (interface{}, uint, error) {
+	if depth > maxDepth {
+		return nil, 0, errors.New("数据嵌套过深")
+	}
+	ctrl, kind, offset, err := d.control(offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if kind == typePointer {
+		pointer, next, err := d.pointer(ctrl, offset)
+		if err != nil {
+			return nil, 0, err
+		}
+		// 规范规定指针不能指向另一个指针，拒绝指针链以免循环引用
+		ctrl, kind, target, err := d.control(pointer)
+		if err != nil {
+			return nil, 0, err
+		}
+		if kind == typePointer {
+			return nil, 0, errors.New("指针指向了另一个指针")
+		}
+		size, target, err := d.size(ctrl, target)
+		if err != nil {
+			return nil, 0, err
+		}
+		value, _, err := d.value(kind, size, target, depth)
+		return value, next, err
+	}
+
+	size, offset, err := d.size(ctrl, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	return d.value(kind, size, offset, depth)
+}
+
+// 读取offset处字段的控制字节和类型，返回类型之后的偏移
+func (d *decoder) control(offset uint) (byte, uint, uint, error) {
+	if offset >= uint(len(d.buffer)) {
+		return 0, 0, 0, errCorrupt
+	}
+	ctrl := d.buffer[offset]
+	offset++
+	kind := uint(ctrl >> 5)
+	if kind == typeExtended {
+		if offset >= uint(len(d.buffer)) {
+			return 0, 0, 0, errCorrupt
+		}
+		kind = uint(d.buffer[offset]) + 7
+		offset++
+	}
+	return ctrl, kind, offset, nil
+}
+
+// 解析指针，指针的值是相对数据区起始的偏移
+func (d *decoder) pointer(ctrl byte, offset uint) (uint, uint, error) {
+	n := uint(ctrl>>3)&0x3 + 1
+	if offset+n > uint(len(d.buffer)) {
+		return 0, 0, errCorrupt
+	}
+	b := d.buffer[offset : offset+n]
+	var value uint
+	if n != 4 {
+		value = uint(ctrl & 0x7)
+	}
+	for _, c := range b {
+		value = value<<8 | uint(c)
+	}
+	switch n {
+	case 2:
+		value += 2048
+	case 3:
+		value += 526336
+	}
+	return value, offset + n, nil
+}
+
+// 解析字段长度
+func (d *decoder) size(ctrl byte, offset uint) (uint, uint, error) {
+	size := uint(ctrl & 0x1f)
+	if size < 29 {
+		return size, offset, nil
+	}
+	n := size - 28
+	if offset+n > uint(len(d.buffer)) {
+		return 0, 0, errCorrupt
+	}
+	var value uint
+	for _, c := range d.buffer[offset : offset+n] {
+		value = value<<8 | uint(c)
+	}
+	switch size {
+	case 29:
+		value += 29
+	case 30:
+		value += 285
+	default:
+		value += 65821
+	}
+	return value, offset + n, nil
+}
+
+func (d *decoder) value(kind, size, offset uint, depth int) (interface{}, uint, error) {
+	// 每个元素至少占1字节，元素数超过剩余字节数时文件已损坏，避免按损坏的长度分配内存
+	if (kind == typeMap || kind == typeSlice) && size > uint(len(d.buffer))-min(offset, uint(len(d.buffer))) {
+		return nil, 0, errCorrupt
+	}
+	switch kind {
+	case typeMap:
+		m := make(map[string]interface{}, size)
+		for i := uint(0); i < size; i++ {
+			key, next, err := d.decodeAt(offset, depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			value, next, err := d.decodeAt(next, depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			name, _ := key.(string)
+			m[name] = value
+			offset = next
+		}
+		return m, offset, nil
+	case typeSlice:
+		s := make([]interface{}, 0, size)
+		for i := uint(0); i < size; i++ {
+			value, next, err := d.decodeAt(offset, depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			s = append(s, value)
+			offset = next
+		}
+		return s, offset, nil
+	case typeBool:
+		return size != 0, offset, nil
+	}
+
+	if offset+size > uint(len(d.buffer)) {
+		return nil, 0, errCorrupt
+	}
+	b := d.buffer[offset : offset+size]
+	next := offset + size
+	switch kind {
+	case typeString:
+		return string(b), next, nil
+	case typeBytes:
+		return append([]byte(nil), b...), next, nil
+	case typeFloat64:
+		if size != 8 {
+			return nil, 0, errCorrupt
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(b)), next, nil
+	case typeFloat32:
+		if size != 4 {
+			return nil, 0, errCorrupt
+		}
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(b))), next, nil
+	case typeUint16, typeUint32, typeUint64:
+		var value uint64
+		for _, c := range b {
+			value = value<<8 | uint64(c)
+		}
+		return value, next, nil
+	case typeInt32:
+		var value uint32
+		for _, c := range b {
+			value = value<<8 | uint32(c)
+		}
+		return int64(int32(value)), next, nil
+	case typeUint128:
+		return new(big.Int).SetBytes(b), next, nil
+	}
+	return nil, 0, fmt.Errorf("未知的数据类型 %d", kind)
+}
+
+// 将解码得到的整数转换为uint64
+func toUint(value interface{}) uint64 {
+	switch v := value.(type) {
+	case uint64:
+		return v
+	case int64:
+		return uint64(v)
+	}
+	return 0
+}
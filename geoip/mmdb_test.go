@@ -0,0 +1,158 @@
+package geoip
+
+import (
+	"bytes"
+	"net"
+	"testing"
+)
+
+// testdata 中的MMDB文件：
+//   - ipv4-24.mmdb：IPv4数据库，24位记录
+//   - ipv6-28.mmdb、ipv6-32.mmdb：IPv6数据库，28位和32位记录，IPv4网段位于 ::/96 下
+//
+// 都收录 1.1.1.0/24（MaxMind ASN格式，整数ASN和 country.iso_code）和 8.8.8.0/24
+// （ipinfo格式，字符串ASN，country 是指向数据区中共享字符串的指针），IPv6数据库另有 2001:db8::/32（只有 registered_country）
+var fixtures = []string{"testdata/ipv4-24.mmdb", "testdata/ipv6-28.mmdb", "testdata/ipv6-32.mmdb"}
+
+func TestLookup(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want Info
+	}{
+		{"1.1.1.1", Info{ASN: 13335, Org: "Cloudflare, Inc.", Country: "AU"}},
+		{"1.1.1.255", Info{ASN: 13335, Org: "Cloudflare, Inc.", Country: "AU"}},
+		{"8.8.8.8", Info{ASN: 15169, Org: "Google LLC", Country: "US"}},
+		{"1.1.2.1", Info{}},
+		{"9.9.9.9", Info{}},
+		{"not-an-ip", Info{}},
+	}
+	for _, fixture := range fixtures {
+		db, err := OpenAll([]string{fixture})
+		if err != nil {
+			t.Fatalf("%s: %v", fixture, err)
+		}
+		if types := db.Types(); len(types) != 1 || types[0] != "Squirrel-Test-ASN" {
+			t.Errorf("%s: Types() = %v", fixture, types)
+		}
+		for _, tt := range tests {
+			if got := db.Lookup(tt.ip); got != tt.want {
+				t.Errorf("%s: Lookup(%s) = %+v, want %+v", fixture, tt.ip, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestLookupIPv6(t *testing.T) {
+	for _, fixture := range fixtures[1:] {
+		db, err := OpenAll([]string{fixture})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := db.Lookup("2001:db8::1"); got != (Info{Country: "JP"}) {
+			t.Errorf("%s: Lookup(2001:db8::1) = %+v", fixture, got)
+		}
+		if got := db.Lookup("2001:db9::1"); got != (Info{}) {
+			t.Errorf("%s: Lookup(2001:db9::1) = %+v", fixture, got)
+		}
+	}
+
+	// IPv4数据库不收录IPv6地址
+	r, err := Open(fixtures[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record, err := r.Lookup(net.ParseIP("2001:db8::1")); record != nil || err != nil {
+		t.Errorf("Lookup(2001:db8::1) on IPv4 database = %v, %v", record, err)
+	}
+}
+
+func TestLookupFirstNonEmptyField(t *testing.T) {
+	// 各字段取第一个有值的文件
+	db, err := OpenAll([]string{"testdata/ipv6-28.mmdb", "testdata/ipv4-24.mmdb"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := db.Lookup("2001:db8::1"); got != (Info{Country: "JP"}) {
+		t.Errorf("Lookup(2001:db8::1) = %+v", got)
+	}
+	var nilDB *DB
+	if got := nilDB.Lookup("1.1.1.1"); got != (Info{}) {
+		t.Errorf("nil DB Lookup = %+v", got)
+	}
+}
+
+func TestOpenInvalid(t *testing.T) {
+	if _, err := Open("testdata/missing.mmdb"); err == nil {
+		t.Error("Open of a missing file succeeded")
+	}
+	if _, err := newReader([]byte("not a database")); err == nil {
+		t.Error("newReader accepted a buffer without metadata")
+	}
+
+	// 元数据声明的节点数超出文件大小
+	valid, err := Open(fixtures[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := bytes.LastIndex(valid.buffer, metadataMarker)
+	truncated := append([]byte(nil), valid.buffer[start:]...)
+	if _, err := newReader(truncated); err == nil {
+		t.Error("newReader accepted a search tree larger than the file")
+	}
+}
+
+func TestDecodeRejectsPointerToPointer(t *testing.T) {
+	buffer := []byte{
+		0x20, 0x02, // 偏移0：指向偏移2的指针
+		0x20, 0x04, // 偏移2：指向偏移4的指针
+		0x42, 'U', 'S', // 偏移4：字符串 "US"
+	}
+	d := &decoder{buffer: buffer}
+	if value, next, err := d.decode(2); err != nil || value != "US" || next != 4 {
+		t.Errorf("decode(pointer) = %v, %d, %v", value, next, err)
+	}
+	if _, _, err := d.decode(0); err == nil {
+		t.Error("decode accepted a pointer to a pointer")
+	}
+
+	// 指向自身的指针
+	d = &decoder{buffer: []byte{0x20, 0x00}}
+	if _, _, err := d.decode(0); err == nil {
+		t.Error("decode accepted a pointer to itself")
+	}
+}
+
+func TestDecodeDepthLimit(t *testing.T) {
+	// 层层嵌套、每层只有一个元素的数组，最内层为字符串
+	nested := func(depth int) []byte {
+		var buffer []byte
+		for i := 0; i < depth; i++ {
+			buffer = append(buffer, 0x01, typeSlice-7)
+		}
+		return append(buffer, 0x41, 'x')
+	}
+	if _, _, err := (&decoder{buffer: nested(maxDepth)}).decode(0); err != nil {
+		t.Errorf("decode of %d nested arrays: %v", maxDepth, err)
+	}
+	if _, _, err := (&decoder{buffer: nested(maxDepth + 1)}).decode(0); err == nil {
+		t.Errorf("decode accepted %d nested arrays", maxDepth+1)
+	}
+}
+
+func TestDecodeCorrupt(t *testing.T) {
+	tests := map[string][]byte{
+		"empty":            {},
+		"truncated string": {0x45, 'a', 'b'},
+		"truncated size":   {0x5d},
+		"truncated ext":    {0x00},
+		"truncated map":    {0xe2, 0x41, 'a'},
+		"huge map size":    {0xff, 0xff, 0xff, 0xff},
+		"bad float size":   {0x62, 0x00, 0x00},
+		"unknown type":     {0x00, 0x20},
+	}
+	for name, buffer := range tests {
+		if _, _, err := (&decoder{buffer: buffer}).decode(0); err == nil {
+			t.Errorf("%s: decode succeeded", name)
+		}
+	}
+}
@@ -0,0 +1,242 @@
+package grpcapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"subdomain-checker/checker"
+)
+
+// 运行一次扫描：检测完成的结果依次交给emit，扫描结束后返回。
+// ctx被取消时应像按下Ctrl+C一样尽快结束，已完成的结果仍可交给emit
+type Runner func(ctx context.Context, targets, args []string, emit func(checker.Result)) error
+
+// 允许通过接口传入的选项，值表示是否为布尔选项。只允许影响检测本身的选项：
+// 执行命令、加载配置文件或团队策略、读写服务器上的文件、监听端口以及把结果发往其他地址的选项都不在其中
+var allowedArgs = map[string]bool{
+	"timeout": false, "fast-timeout": false, "slow-timeout": false, "quarantine-after": false, "shutdown-timeout": false,
+	"concurrency": false, "adaptive": true, "min-concurrency": false, "max-concurrency": false,
+	"ip-family": false, "method": false, "data": false, "content-type": false, "head-first": true, "head-fallback": false,
+	"follow": true, "max-redirects": false, "max-body-kb": false, "max-bandwidth-kb": false,
+	"header": false, "cookie": false, "random-ua": true, "host-only": true, "exclude": false,
+	"tls-min": false, "tls-max": false, "tls-ciphers": false, "sni": false, "insecure": true,
+	"dns-cache-ttl": false, "cname": true, "cname-resolver": false, "cloud": true,
+	"ports": false, "port-scan-all": true, "port-timeout": false, "port-feed": true, "permute": true, "permute-max": false,
+	"extract": true, "soft404": true, "cors": true, "cors-origin": false, "methods": true,
+	"capture-headers": true, "header-value-len": false, "robots": true, "axfr": true, "whois": true, "jarm": true,
+	"match-code": false, "filter-code": false, "match-title-regex": false, "match-type": false,
+	"min-time": false, "max-time": false, "match-regex": false, "only-alive": true,
+	"time": true, "verbose": true, "silent": true, "log-level": false, "log-format": false,
+	"summary": false, "lang": false, "locale": false, "deterministic": true, "dry-run": true,
+}
+
+// 检查附加选项：只允许allowedArgs中的选项，选项可以写成 -name、--name 或 -name=value，
+// 非布尔选项的值可以是下一个参数。不允许位置参数，否则子进程在第一个位置参数处停止解析选项，
+// 之后追加的 -results-fd 和目标文件都会被当作检测目标
+func checkArgs(args []string) error {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if !strings.HasPrefix(arg, "-") || arg == "-" || arg == "--" {
+			return fmt.Errorf("不允许位置参数 %q，检测目标应放在targets中", arg)
+		}
+		name, value, hasValue := strings.Cut(strings.TrimPrefix(arg[1:], "-"), "=")
+		isBool, ok := allowedArgs[name]
+		if !ok {
+			return fmt.Errorf("不允许通过接口使用 -%s", name)
+		}
+		if !isBool && !hasValue {
+			if i+1 == len(args) {
+				return fmt.Errorf("-%s 缺少值", name)
+			}
+			i++
+			value = args[i]
+		}
+		// -data 以 @ 开头时从文件读取请求体
+		if name == "data" && strings.HasPrefix(value, "@") {
+			return errors.New("不允许通过接口从文件读取 -data")
+		}
+	}
+	return nil
+}
+
+// 以子进程运行扫描的Runner：每次扫描在dir下的独立目录中写入目标文件和日志，并以该目录为工作目录运行子进程，
+// 子进程通过 -results-fd 逐个传回结果。取消时向子进程发送中断信号，等待其写完已完成的结果，
+// 超过一分钟仍未退出时强制结束
+func ProcessRunner(executable, dir string) Runner {
+	var seq atomic.Int64
+	return func(ctx context.Context, targets, args []string, emit func(checker.Result)) error {
+		scanDir := filepath.Join(dir, fmt.Sprintf("%s-%d", time.Now().Format("20060102-150405"), seq.Add(1)))
+		if err := os.MkdirAll(scanDir, 0o755); err != nil {
+			return err
+		}
+		targetsFile := filepath.Join(scanDir, "targets.txt")
+		if err := os.WriteFile(targetsFile, []byte(strings.Join(targets, "\n")+"\n"), 0o644); err != nil {
+			return err
+		}
+		logFile, err := os.Create(filepath.Join(scanDir, "scan.log"))
+		if err != nil {
+			return err
+		}
+		defer logFile.Close()
+
+		reader, writer, err := os.Pipe()
+		if err != nil {
+			return err
+		}
+		defer reader.Close()
+
+		// 子进程的fd 3为ExtraFiles中的第一个文件
+		cmdArgs := append(append([]string{}, args...), "-results-fd", "3", "targets.txt")
+		cmd := exec.CommandContext(ctx, executable, cmdArgs...)
+		cmd.Dir = scanDir
+		cmd.Stdout, cmd.Stderr = logFile, logFile
+		cmd.ExtraFiles = []*os.File{writer}
+		cmd.Cancel = func() error {
+			if err := cmd.Process.Signal(os.Interrupt); err != nil {
+				return cmd.Process.Kill()
+			}
+			return nil
+		}
+		cmd.WaitDelay = time.Minute
+		err = cmd.Start()
+		writer.Close()
+		if err != nil {
+			return err
+		}
+
+		var decodeErr error
+		decoder := json.NewDecoder(reader)
+		for {
+			var result checker.Result
+			if err := decoder.Decode(&result); err != nil {
+				if !errors.Is(err, io.EOF) {
+					decodeErr = fmt.Errorf("解析扫描结果失败: %v", err)
+					cmd.Process.Kill()
+				}
+				break
+			}
+			emit(result)
+		}
+		err = cmd.Wait()
+		if decodeErr != nil {
+			return decodeErr
+		}
+		if err != nil && ctx.Err() == nil {
+			return fmt.Errorf("%v（日志: %s）", err, logFile.Name())
+		}
+		return nil
+	}
+}
+
+// 一次扫描的状态和已完成的结果
+type scan struct {
+	id     string
+	cancel context.CancelFunc
+
+	mu      sync.Mutex
+	state   ScanState
+	err     string
+	results []checker.Result
+	changed chan struct{} // 有新结果或扫描结束时关闭并替换，供StreamResults等待
+}
+
+// 返回从from开始的新结果、当前状态以及下次等待用的通道
+func (s *scan) since(from int) ([]checker.Result, ScanState, <-chan struct{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.results[from:len(s.results):len(s.results)], s.state, s.changed
+}
+
+// 扫描失败的原因
+func (s *scan) failure() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+func (s *scan) add(result checker.Result) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.results = append(s.results, result)
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+func (s *scan) finish(state ScanState, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state = state
+	if err != nil {
+		s.err = err.Error()
+	}
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+// 扫描结束后保留的时间，之后从管理器中移除，不能再查询结果和报告
+const finishedScanTTL = time.Hour
+
+// 扫描管理器：启动、查找和取消扫描
+type manager struct {
+	run  Runner
+	seq  atomic.Int64
+	keep time.Duration // 扫描结束后保留的时间
+
+	mu    sync.Mutex
+	scans map[string]*scan
+}
+
+func newManager(run Runner) *manager {
+	return &manager{run: run, keep: finishedScanTTL, scans: make(map[string]*scan)}
+}
+
+// 在后台启动一次扫描并返回
+func (m *manager) start(targets, args []string) *scan {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &scan{
+		id:      fmt.Sprintf("scan-%s-%d", time.Now().Format("20060102-150405"), m.seq.Add(1)),
+		cancel:  cancel,
+		state:   ScanState_SCAN_STATE_RUNNING,
+		changed: make(chan struct{}),
+	}
+	m.mu.Lock()
+	m.scans[s.id] = s
+	m.mu.Unlock()
+
+	go func() {
+		defer cancel()
+		err := m.run(ctx, targets, args, s.add)
+		switch {
+		case ctx.Err() != nil:
+			s.finish(ScanState_SCAN_STATE_CANCELLED, nil)
+		case err != nil:
+			s.finish(ScanState_SCAN_STATE_FAILED, err)
+		default:
+			s.finish(ScanState_SCAN_STATE_COMPLETED, nil)
+		}
+		time.AfterFunc(m.keep, func() { m.remove(s.id) })
+	}()
+	return s
+}
+
+func (m *manager) get(id string) *scan {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.scans[id]
+}
+
+func (m *manager) remove(id string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.scans, id)
+}
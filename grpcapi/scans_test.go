@@ -0,0 +1,97 @@
+package grpcapi
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"subdomain-checker/checker"
+)
+
+func TestCheckArgs(t *testing.T) {
+	for _, args := range [][]string{
+		{"-timeout", "5", "-follow"},
+		{"-match-regex", "post-cmd"},
+		{"--concurrency=20", "-follow=false", "-header", "X-Scan: 1"},
+		{"-exclude", "-x", "-data", "a=1"},
+	} {
+		if err := checkArgs(args); err != nil {
+			t.Errorf("checkArgs(%q) = %v", args, err)
+		}
+	}
+	for _, args := range [][]string{
+		{"-post-cmd", "id"},
+		{"--pre-cmd=id"},
+		{"-timeout", "5", "-post-cmd=curl x"},
+		{"-config", "grpc-scans/20240501-100000-1/targets.txt"},
+		{"-profile", "fast"},
+		{"-policy-url", "https://attacker.example/policy"},
+		{"-policy-key=key.pub"},
+		{"-output", "/tmp/x.csv"},
+		{"-format", "json=/etc/cron.d/x"},
+		{"-log-file", "x"},
+		{"-har", "x"},
+		{"-archive", "x"},
+		{"-screenshot-dir", "/var/www"},
+		{"-screenshot-alive"},
+		{"-paths", "/etc/passwd"},
+		{"-results-fd", "1"},
+		{"-data", "@/etc/passwd"},
+		{"/etc/hosts"},
+		{"-follow", "/etc/hosts"},
+		{"--", "-timeout"},
+		{"-timeout"},
+	} {
+		if err := checkArgs(args); err == nil {
+			t.Errorf("checkArgs(%q) accepted", args)
+		}
+	}
+}
+
+func TestProcessRunnerWorkingDir(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script as the scanner")
+	}
+	dir := t.TempDir()
+	// 代替squirrel的脚本：把工作目录和参数写入日志
+	script := filepath.Join(dir, "scanner.sh")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\npwd\necho \"$@\"\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	run := ProcessRunner(script, filepath.Join(dir, "scans"))
+	if err := run(context.Background(), []string{"a.example.com"}, []string{"-timeout", "5"}, func(checker.Result) {}); err != nil {
+		t.Fatal(err)
+	}
+	logs, _ := filepath.Glob(filepath.Join(dir, "scans", "*", "scan.log"))
+	if len(logs) != 1 {
+		t.Fatalf("scan logs: %q", logs)
+	}
+	data, err := os.ReadFile(logs[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	scanDir, _ := filepath.EvalSymlinks(filepath.Dir(logs[0]))
+	if want := scanDir + "\n-timeout 5 -results-fd 3 targets.txt\n"; string(data) != want {
+		t.Errorf("scan.log = %q, want %q", data, want)
+	}
+	if targets, _ := os.ReadFile(filepath.Join(scanDir, "targets.txt")); strings.TrimSpace(string(targets)) != "a.example.com" {
+		t.Errorf("targets.txt = %q", targets)
+	}
+}
+
+func TestFinishedScansEvicted(t *testing.T) {
+	m := newManager(func(ctx context.Context, targets, args []string, emit func(checker.Result)) error { return nil })
+	m.keep = 10 * time.Millisecond
+	s := m.start([]string{"a.example.com"}, nil)
+	deadline := time.Now().Add(5 * time.Second)
+	for m.get(s.id) != nil {
+		if time.Now().After(deadline) {
+			t.Fatal("finished scan was not evicted")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
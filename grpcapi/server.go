@@ -0,0 +1,202 @@
+package grpcapi
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative squirrel.proto
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"os"
+	"strings"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"subdomain-checker/checker"
+	"subdomain-checker/view"
+)
+
+// 请求消息的大小上限
+const maxRequestSize = 16 << 20
+
+// gRPC扫描调度服务，接口定义见 squirrel.proto
+type Server struct {
+	UnimplementedScanServiceServer
+
+	scans *manager
+
+	// view包的报告时间等设置是全局的，报告逐个生成
+	reportMu sync.Mutex
+}
+
+// 创建服务，run负责实际运行扫描
+func NewServer(run Runner) *Server {
+	return &Server{scans: newManager(run)}
+}
+
+// 在listener上以明文提供服务，直到listener关闭
+func (s *Server) Serve(listener net.Listener) error {
+	server := grpc.NewServer(grpc.MaxRecvMsgSize(maxRequestSize))
+	RegisterScanServiceServer(server, s)
+	return server.Serve(listener)
+}
+
+func (s *Server) lookup(id string) (*scan, error) {
+	if id == "" {
+		return nil, status.Error(codes.InvalidArgument, "缺少scan_id")
+	}
+	sc := s.scans.get(id)
+	if sc == nil {
+		return nil, status.Errorf(codes.NotFound, "扫描 %s 不存在", id)
+	}
+	return sc, nil
+}
+
+func (s *Server) StartScan(ctx context.Context, req *StartScanRequest) (*StartScanResponse, error) {
+	var targets []string
+	for _, target := range req.GetTargets() {
+		if strings.ContainsAny(target, "\r\n") {
+			return nil, status.Errorf(codes.InvalidArgument, "检测目标中不能包含换行: %q", target)
+		}
+		if target = strings.TrimSpace(target); target != "" {
+			targets = append(targets, target)
+		}
+	}
+	if len(targets) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "至少需要一个检测目标")
+	}
+	if err := checkArgs(req.GetArgs()); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return &StartScanResponse{ScanId: s.scans.start(targets, req.GetArgs()).id}, nil
+}
+
+// 流式推送结果：先发送已完成的结果，之后每有新结果就发送，扫描结束且结果发完后结束流。
+// 扫描失败时以ABORTED状态结束
+func (s *Server) StreamResults(req *StreamResultsRequest, stream ScanService_StreamResultsServer) error {
+	sc, err := s.lookup(req.GetScanId())
+	if err != nil {
+		return err
+	}
+	// 立即发送响应头，客户端在第一个结果到达前即可确认调用已建立
+	if err := stream.SendHeader(nil); err != nil {
+		return err
+	}
+
+	sent := 0
+	for {
+		results, state, changed := sc.since(sent)
+		for _, result := range results {
+			msg, err := resultMessage(result)
+			if err != nil {
+				return status.Errorf(codes.Internal, "编码结果失败: %v", err)
+			}
+			if err := stream.Send(msg); err != nil {
+				return err
+			}
+		}
+		sent += len(results)
+		if state != ScanState_SCAN_STATE_RUNNING {
+			if state == ScanState_SCAN_STATE_FAILED {
+				return status.Errorf(codes.Aborted, "扫描失败: %s", sc.failure())
+			}
+			return nil
+		}
+		select {
+		case <-changed:
+		case <-stream.Context().Done():
+			return status.FromContextError(stream.Context().Err()).Err()
+		}
+	}
+}
+
+// 将检测结果转换为Result消息
+func resultMessage(r checker.Result) (*Result, error) {
+	full, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+	msg := &Result{
+		Domain:         r.Domain,
+		Status:         int32(r.Status),
+		Alive:          r.Alive,
+		StatusText:     r.StatusText,
+		Message:        r.Message,
+		Title:          r.Title,
+		Ip:             r.IP,
+		FinalUrl:       r.FinalURL,
+		ResponseTimeMs: r.ResponseTime.Milliseconds(),
+		Findings:       r.FindingReasons(),
+		Json:           full,
+	}
+	if r.PageInfo != nil {
+		msg.PageType = r.PageInfo.Type
+	}
+	if !r.CheckedAt.IsZero() {
+		msg.CheckedAt = timestamppb.New(r.CheckedAt)
+	}
+	return msg, nil
+}
+
+func (s *Server) GetReport(ctx context.Context, req *GetReportRequest) (*Report, error) {
+	sc, err := s.lookup(req.GetScanId())
+	if err != nil {
+		return nil, err
+	}
+	name := req.GetFormat()
+	if name == "" {
+		name = "json"
+	}
+	format, ok := view.Lookup(name)
+	if !ok {
+		return nil, status.Errorf(codes.InvalidArgument, "未知的输出格式 %q，可用格式: %s", name, strings.Join(view.FormatNames(), ", "))
+	}
+
+	results, state, _ := sc.since(0)
+	content, err := s.render(format, results)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "生成报告失败: %v", err)
+	}
+	return &Report{ScanId: sc.id, State: state, Format: format.Name, Results: int32(len(results)), Content: content}, nil
+}
+
+// 将结果按格式写入临时文件后读回
+func (s *Server) render(format view.Format, results []checker.Result) ([]byte, error) {
+	file, err := os.CreateTemp("", "squirrel-report-*"+format.Extension)
+	if err != nil {
+		return nil, err
+	}
+	file.Close()
+	defer os.Remove(file.Name())
+
+	s.reportMu.Lock()
+	errs := view.RenderResults(results, []view.Output{{Format: format, Filename: file.Name()}}, view.WriteOptions{})
+	s.reportMu.Unlock()
+	if errs[0] != nil {
+		return nil, errs[0]
+	}
+	return os.ReadFile(file.Name())
+}
+
+// 取消扫描并等待其结束，返回结束后的状态；扫描已结束时直接返回当前状态
+func (s *Server) CancelScan(ctx context.Context, req *CancelScanRequest) (*CancelScanResponse, error) {
+	sc, err := s.lookup(req.GetScanId())
+	if err != nil {
+		return nil, err
+	}
+	sc.cancel()
+	for {
+		_, state, changed := sc.since(0)
+		if state != ScanState_SCAN_STATE_RUNNING {
+			return &CancelScanResponse{State: state}, nil
+		}
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return nil, status.FromContextError(ctx.Err()).Err()
+		}
+	}
+}
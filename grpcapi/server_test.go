@@ -0,0 +1,243 @@
+package grpcapi
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"subdomain-checker/checker"
+)
+
+// 测试用的Runner：每个目标在release收到一次信号后产生一个结果
+func gatedRunner(release <-chan struct{}, fail error) Runner {
+	return func(ctx context.Context, targets, args []string, emit func(checker.Result)) error {
+		for i, target := range targets {
+			select {
+			case <-release:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			emit(checker.Result{
+				Domain: target, Status: 200, Alive: true, StatusText: "存活", Title: "page " + strconv.Itoa(i),
+				ResponseTime: 1500 * time.Millisecond,
+				PageInfo:     &checker.PageType{Type: "登录页面"},
+				CheckedAt:    time.Date(2024, 5, 1, 10, i, 0, 500, time.UTC),
+			})
+		}
+		return fail
+	}
+}
+
+// 启动服务并返回连接到它的客户端
+func newTestClient(t *testing.T, run Runner) (ScanServiceClient, *grpc.ClientConn) {
+	listener := bufconn.Listen(1 << 20)
+	go NewServer(run).Serve(listener)
+	t.Cleanup(func() { listener.Close() })
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return NewScanServiceClient(conn), conn
+}
+
+func startScan(t *testing.T, c ScanServiceClient, targets ...string) string {
+	t.Helper()
+	resp, err := c.StartScan(context.Background(), &StartScanRequest{Targets: targets})
+	if err != nil {
+		t.Fatalf("StartScan: %v", err)
+	}
+	return resp.GetScanId()
+}
+
+func streamResults(t *testing.T, c ScanServiceClient, id string) ScanService_StreamResultsClient {
+	t.Helper()
+	stream, err := c.StreamResults(context.Background(), &StreamResultsRequest{ScanId: id})
+	if err != nil {
+		t.Fatalf("StreamResults: %v", err)
+	}
+	// 等待响应头，确认调用已建立
+	if _, err := stream.Header(); err != nil {
+		t.Fatalf("StreamResults header: %v", err)
+	}
+	return stream
+}
+
+func next(t *testing.T, stream ScanService_StreamResultsClient) *Result {
+	t.Helper()
+	msg, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("Recv: %v", err)
+	}
+	return msg
+}
+
+// 读完剩余消息，返回消息数和流结束时的状态码
+func drain(stream ScanService_StreamResultsClient) (int, *status.Status) {
+	n := 0
+	for {
+		_, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return n, status.New(codes.OK, "")
+		}
+		if err != nil {
+			return n, status.Convert(err)
+		}
+		n++
+	}
+}
+
+func TestStreamResultsAndReport(t *testing.T) {
+	release := make(chan struct{})
+	c, _ := newTestClient(t, gatedRunner(release, nil))
+	ctx := context.Background()
+	id := startScan(t, c, "a.example.com", "b.example.com")
+
+	stream := streamResults(t, c, id)
+	release <- struct{}{}
+	first := next(t, stream)
+	if first.Domain != "a.example.com" || first.Status != 200 || !first.Alive || first.Title != "page 0" ||
+		first.ResponseTimeMs != 1500 || first.PageType != "登录页面" {
+		t.Errorf("first result = %v", first)
+	}
+	if len(first.Findings) != 1 || first.Findings[0] != "登录页面" {
+		t.Errorf("findings = %q", first.Findings)
+	}
+	if want := time.Date(2024, 5, 1, 10, 0, 0, 500, time.UTC); !first.CheckedAt.AsTime().Equal(want) {
+		t.Errorf("checked_at = %v, want %v", first.CheckedAt.AsTime(), want)
+	}
+	if !bytes.Contains(first.Json, []byte(`"a.example.com"`)) {
+		t.Errorf("json = %s", first.Json)
+	}
+
+	// 进行中的扫描：报告只包含已完成的结果
+	r, err := c.GetReport(ctx, &GetReportRequest{ScanId: id})
+	if err != nil {
+		t.Fatalf("GetReport: %v", err)
+	}
+	if r.State != ScanState_SCAN_STATE_RUNNING || r.Results != 1 || r.Format != "json" {
+		t.Errorf("running report = %v", r)
+	}
+
+	release <- struct{}{}
+	if second := next(t, stream); second.Domain != "b.example.com" {
+		t.Errorf("second result = %q", second.Domain)
+	}
+	if _, st := drain(stream); st.Code() != codes.OK {
+		t.Errorf("StreamResults: %v", st)
+	}
+
+	// 扫描结束后再次订阅，收到全部结果
+	if n, _ := drain(streamResults(t, c, id)); n != 2 {
+		t.Errorf("replayed %d results, want 2", n)
+	}
+
+	r, err = c.GetReport(ctx, &GetReportRequest{ScanId: id, Format: "csv"})
+	if err != nil {
+		t.Fatalf("GetReport csv: %v", err)
+	}
+	if r.State != ScanState_SCAN_STATE_COMPLETED || r.Results != 2 || r.ScanId != id || !bytes.Contains(r.Content, []byte("b.example.com")) {
+		t.Errorf("completed report = %v", r)
+	}
+}
+
+func TestCancelScan(t *testing.T) {
+	release := make(chan struct{})
+	c, _ := newTestClient(t, gatedRunner(release, nil))
+	ctx := context.Background()
+	id := startScan(t, c, "a.example.com", "b.example.com")
+	stream := streamResults(t, c, id)
+	release <- struct{}{}
+	next(t, stream)
+
+	resp, err := c.CancelScan(ctx, &CancelScanRequest{ScanId: id})
+	if err != nil {
+		t.Fatalf("CancelScan: %v", err)
+	}
+	if resp.State != ScanState_SCAN_STATE_CANCELLED {
+		t.Errorf("state after cancel = %v", resp.State)
+	}
+	if _, st := drain(stream); st.Code() != codes.OK {
+		t.Errorf("StreamResults after cancel: %v", st)
+	}
+	r, err := c.GetReport(ctx, &GetReportRequest{ScanId: id})
+	if err != nil {
+		t.Fatalf("GetReport: %v", err)
+	}
+	if r.State != ScanState_SCAN_STATE_CANCELLED || r.Results != 1 {
+		t.Errorf("cancelled report = %v", r)
+	}
+}
+
+func TestFailedScan(t *testing.T) {
+	release := make(chan struct{}, 1)
+	release <- struct{}{}
+	c, _ := newTestClient(t, gatedRunner(release, errors.New("exit status 1")))
+	id := startScan(t, c, "a.example.com")
+	n, st := drain(streamResults(t, c, id))
+	if n != 1 {
+		t.Errorf("received %d results, want 1", n)
+	}
+	if st.Code() != codes.Aborted || !strings.Contains(st.Message(), "exit status 1") {
+		t.Errorf("status = %v, want ABORTED with the exit status", st)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	c, conn := newTestClient(t, gatedRunner(make(chan struct{}), nil))
+	ctx := context.Background()
+	id := startScan(t, c, "a.example.com")
+
+	tests := []struct {
+		name string
+		call func() error
+		code codes.Code
+	}{
+		{"no targets", func() error {
+			_, err := c.StartScan(ctx, &StartScanRequest{Args: []string{"-timeout", "5"}})
+			return err
+		}, codes.InvalidArgument},
+		{"forbidden option", func() error {
+			_, err := c.StartScan(ctx, &StartScanRequest{Targets: []string{"a.example.com"}, Args: []string{"-post-cmd", "id"}})
+			return err
+		}, codes.InvalidArgument},
+		{"newline in target", func() error {
+			_, err := c.StartScan(ctx, &StartScanRequest{Targets: []string{"a.example.com\nb.example.com"}})
+			return err
+		}, codes.InvalidArgument},
+		{"unknown scan", func() error {
+			_, st := drain(streamResults(t, c, "scan-x"))
+			return st.Err()
+		}, codes.NotFound},
+		{"missing scan id", func() error {
+			_, err := c.CancelScan(ctx, &CancelScanRequest{})
+			return err
+		}, codes.InvalidArgument},
+		{"unknown format", func() error {
+			_, err := c.GetReport(ctx, &GetReportRequest{ScanId: id, Format: "pdf"})
+			return err
+		}, codes.InvalidArgument},
+		{"unknown method", func() error {
+			return conn.Invoke(ctx, "/squirrel.v1.ScanService/Pause", &CancelScanRequest{}, &CancelScanResponse{})
+		}, codes.Unimplemented},
+	}
+	for _, tt := range tests {
+		if code := status.Code(tt.call()); code != tt.code {
+			t.Errorf("%s: status %v, want %v", tt.name, code, tt.code)
+		}
+	}
+}
@@ -0,0 +1,709 @@
+// squirrel grpc 提供的扫描调度接口。
+// 修改本文件后在grpcapi目录中运行 go generate 重新生成 squirrel.pb.go 和 squirrel_grpc.pb.go
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: squirrel.proto
+
+package grpcapi
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type ScanState int32
+
+const (
+	ScanState_SCAN_STATE_UNSPECIFIED ScanState = 0
+	ScanState_SCAN_STATE_RUNNING     ScanState = 1
+	ScanState_SCAN_STATE_COMPLETED   ScanState = 2
+	ScanState_SCAN_STATE_CANCELLED   ScanState = 3
+	ScanState_SCAN_STATE_FAILED      ScanState = 4
+)
+
+// Enum value maps for ScanState.
+var (
+	ScanState_name = map[int32]string{
+		0: "SCAN_STATE_UNSPECIFIED",
+		1: "SCAN_STATE_RUNNING",
+		2: "SCAN_STATE_COMPLETED",
+		3: "SCAN_STATE_CANCELLED",
+		4: "SCAN_STATE_FAILED",
+	}
+	ScanState_value = map[string]int32{
+		"SCAN_STATE_UNSPECIFIED": 0,
+		"SCAN_STATE_RUNNING":     1,
+		"SCAN_STATE_COMPLETED":   2,
+		"SCAN_STATE_CANCELLED":   3,
+		"SCAN_STATE_FAILED":      4,
+	}
+)
+
+func (x ScanState) Enum() *ScanState {
+	p := new(ScanState)
+	*p = x
+	return p
+}
+
+func (x ScanState) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ScanState) Descriptor() protoreflect.EnumDescriptor {
+	return file_squirrel_proto_enumTypes[0].Descriptor()
+}
+
+func (ScanState) Type() protoreflect.EnumType {
+	return &file_squirrel_proto_enumTypes[0]
+}
+
+func (x ScanState) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ScanState.Descriptor instead.
+func (ScanState) EnumDescriptor() ([]byte, []int) {
+	return file_squirrel_proto_rawDescGZIP(), []int{0}
+}
+
+type StartScanRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// 检测目标，每项与输入文件中的一行相同，如 "www.example.com"、"https://example.com:8443/admin # 备注"
+	Targets []string `protobuf:"bytes,1,rep,name=targets,proto3" json:"targets,omitempty"`
+	// 附加的命令行选项，如 ["-timeout", "5", "-follow"]；只允许影响检测本身的选项，
+	// 不允许 -config、-profile、-policy-*、执行命令和读写文件的选项以及位置参数
+	Args          []string `protobuf:"bytes,2,rep,name=args,proto3" json:"args,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StartScanRequest) Reset() {
+	*x = StartScanRequest{}
+	mi := &file_squirrel_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StartScanRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StartScanRequest) ProtoMessage() {}
+
+func (x *StartScanRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_squirrel_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StartScanRequest.ProtoReflect.Descriptor instead.
+func (*StartScanRequest) Descriptor() ([]byte, []int) {
+	return file_squirrel_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *StartScanRequest) GetTargets() []string {
+	if x != nil {
+		return x.Targets
+	}
+	return nil
+}
+
+func (x *StartScanRequest) GetArgs() []string {
+	if x != nil {
+		return x.Args
+	}
+	return nil
+}
+
+type StartScanResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ScanId        string                 `protobuf:"bytes,1,opt,name=scan_id,json=scanId,proto3" json:"scan_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StartScanResponse) Reset() {
+	*x = StartScanResponse{}
+	mi := &file_squirrel_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StartScanResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StartScanResponse) ProtoMessage() {}
+
+func (x *StartScanResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_squirrel_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StartScanResponse.ProtoReflect.Descriptor instead.
+func (*StartScanResponse) Descriptor() ([]byte, []int) {
+	return file_squirrel_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *StartScanResponse) GetScanId() string {
+	if x != nil {
+		return x.ScanId
+	}
+	return ""
+}
+
+type StreamResultsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ScanId        string                 `protobuf:"bytes,1,opt,name=scan_id,json=scanId,proto3" json:"scan_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamResultsRequest) Reset() {
+	*x = StreamResultsRequest{}
+	mi := &file_squirrel_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamResultsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamResultsRequest) ProtoMessage() {}
+
+func (x *StreamResultsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_squirrel_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamResultsRequest.ProtoReflect.Descriptor instead.
+func (*StreamResultsRequest) Descriptor() ([]byte, []int) {
+	return file_squirrel_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *StreamResultsRequest) GetScanId() string {
+	if x != nil {
+		return x.ScanId
+	}
+	return ""
+}
+
+// 一个目标的检测结果，常用字段单独列出，完整结果在json中
+type Result struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Domain         string                 `protobuf:"bytes,1,opt,name=domain,proto3" json:"domain,omitempty"`
+	Status         int32                  `protobuf:"varint,2,opt,name=status,proto3" json:"status,omitempty"`
+	Alive          bool                   `protobuf:"varint,3,opt,name=alive,proto3" json:"alive,omitempty"`
+	StatusText     string                 `protobuf:"bytes,4,opt,name=status_text,json=statusText,proto3" json:"status_text,omitempty"`
+	Message        string                 `protobuf:"bytes,5,opt,name=message,proto3" json:"message,omitempty"`
+	Title          string                 `protobuf:"bytes,6,opt,name=title,proto3" json:"title,omitempty"`
+	Ip             string                 `protobuf:"bytes,7,opt,name=ip,proto3" json:"ip,omitempty"`
+	FinalUrl       string                 `protobuf:"bytes,8,opt,name=final_url,json=finalUrl,proto3" json:"final_url,omitempty"`
+	ResponseTimeMs int64                  `protobuf:"varint,9,opt,name=response_time_ms,json=responseTimeMs,proto3" json:"response_time_ms,omitempty"`
+	PageType       string                 `protobuf:"bytes,10,opt,name=page_type,json=pageType,proto3" json:"page_type,omitempty"`
+	Findings       []string               `protobuf:"bytes,11,rep,name=findings,proto3" json:"findings,omitempty"`
+	CheckedAt      *timestamppb.Timestamp `protobuf:"bytes,12,opt,name=checked_at,json=checkedAt,proto3" json:"checked_at,omitempty"`
+	// 完整结果，与 -format json 输出中的对象相同
+	Json          []byte `protobuf:"bytes,13,opt,name=json,proto3" json:"json,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Result) Reset() {
+	*x = Result{}
+	mi := &file_squirrel_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Result) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Result) ProtoMessage() {}
+
+func (x *Result) ProtoReflect() protoreflect.Message {
+	mi := &file_squirrel_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Result.ProtoReflect.Descriptor instead.
+func (*Result) Descriptor() ([]byte, []int) {
+	return file_squirrel_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Result) GetDomain() string {
+	if x != nil {
+		return x.Domain
+	}
+	return ""
+}
+
+func (x *Result) GetStatus() int32 {
+	if x != nil {
+		return x.Status
+	}
+	return 0
+}
+
+func (x *Result) GetAlive() bool {
+	if x != nil {
+		return x.Alive
+	}
+	return false
+}
+
+func (x *Result) GetStatusText() string {
+	if x != nil {
+		return x.StatusText
+	}
+	return ""
+}
+
+func (x *Result) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *Result) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *Result) GetIp() string {
+	if x != nil {
+		return x.Ip
+	}
+	return ""
+}
+
+func (x *Result) GetFinalUrl() string {
+	if x != nil {
+		return x.FinalUrl
+	}
+	return ""
+}
+
+func (x *Result) GetResponseTimeMs() int64 {
+	if x != nil {
+		return x.ResponseTimeMs
+	}
+	return 0
+}
+
+func (x *Result) GetPageType() string {
+	if x != nil {
+		return x.PageType
+	}
+	return ""
+}
+
+func (x *Result) GetFindings() []string {
+	if x != nil {
+		return x.Findings
+	}
+	return nil
+}
+
+func (x *Result) GetCheckedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CheckedAt
+	}
+	return nil
+}
+
+func (x *Result) GetJson() []byte {
+	if x != nil {
+		return x.Json
+	}
+	return nil
+}
+
+type GetReportRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	ScanId string                 `protobuf:"bytes,1,opt,name=scan_id,json=scanId,proto3" json:"scan_id,omitempty"`
+	// 输出格式名称，与 -format 相同（squirrel -list-formats），默认为 json
+	Format        string `protobuf:"bytes,2,opt,name=format,proto3" json:"format,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetReportRequest) Reset() {
+	*x = GetReportRequest{}
+	mi := &file_squirrel_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetReportRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetReportRequest) ProtoMessage() {}
+
+func (x *GetReportRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_squirrel_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetReportRequest.ProtoReflect.Descriptor instead.
+func (*GetReportRequest) Descriptor() ([]byte, []int) {
+	return file_squirrel_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *GetReportRequest) GetScanId() string {
+	if x != nil {
+		return x.ScanId
+	}
+	return ""
+}
+
+func (x *GetReportRequest) GetFormat() string {
+	if x != nil {
+		return x.Format
+	}
+	return ""
+}
+
+type Report struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	ScanId string                 `protobuf:"bytes,1,opt,name=scan_id,json=scanId,proto3" json:"scan_id,omitempty"`
+	State  ScanState              `protobuf:"varint,2,opt,name=state,proto3,enum=squirrel.v1.ScanState" json:"state,omitempty"`
+	Format string                 `protobuf:"bytes,3,opt,name=format,proto3" json:"format,omitempty"`
+	// 报告中的结果数（扫描进行中时为已完成的结果数）
+	Results       int32  `protobuf:"varint,4,opt,name=results,proto3" json:"results,omitempty"`
+	Content       []byte `protobuf:"bytes,5,opt,name=content,proto3" json:"content,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Report) Reset() {
+	*x = Report{}
+	mi := &file_squirrel_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Report) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Report) ProtoMessage() {}
+
+func (x *Report) ProtoReflect() protoreflect.Message {
+	mi := &file_squirrel_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Report.ProtoReflect.Descriptor instead.
+func (*Report) Descriptor() ([]byte, []int) {
+	return file_squirrel_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Report) GetScanId() string {
+	if x != nil {
+		return x.ScanId
+	}
+	return ""
+}
+
+func (x *Report) GetState() ScanState {
+	if x != nil {
+		return x.State
+	}
+	return ScanState_SCAN_STATE_UNSPECIFIED
+}
+
+func (x *Report) GetFormat() string {
+	if x != nil {
+		return x.Format
+	}
+	return ""
+}
+
+func (x *Report) GetResults() int32 {
+	if x != nil {
+		return x.Results
+	}
+	return 0
+}
+
+func (x *Report) GetContent() []byte {
+	if x != nil {
+		return x.Content
+	}
+	return nil
+}
+
+type CancelScanRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ScanId        string                 `protobuf:"bytes,1,opt,name=scan_id,json=scanId,proto3" json:"scan_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CancelScanRequest) Reset() {
+	*x = CancelScanRequest{}
+	mi := &file_squirrel_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CancelScanRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CancelScanRequest) ProtoMessage() {}
+
+func (x *CancelScanRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_squirrel_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CancelScanRequest.ProtoReflect.Descriptor instead.
+func (*CancelScanRequest) Descriptor() ([]byte, []int) {
+	return file_squirrel_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *CancelScanRequest) GetScanId() string {
+	if x != nil {
+		return x.ScanId
+	}
+	return ""
+}
+
+type CancelScanResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	State         ScanState              `protobuf:"varint,1,opt,name=state,proto3,enum=squirrel.v1.ScanState" json:"state,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CancelScanResponse) Reset() {
+	*x = CancelScanResponse{}
+	mi := &file_squirrel_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CancelScanResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CancelScanResponse) ProtoMessage() {}
+
+func (x *CancelScanResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_squirrel_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CancelScanResponse.ProtoReflect.Descriptor instead.
+func (*CancelScanResponse) Descriptor() ([]byte, []int) {
+	return file_squirrel_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *CancelScanResponse) GetState() ScanState {
+	if x != nil {
+		return x.State
+	}
+	return ScanState_SCAN_STATE_UNSPECIFIED
+}
+
+var File_squirrel_proto protoreflect.FileDescriptor
+
+const file_squirrel_proto_rawDesc = "" +
+	"\n" +
+	"\x0esquirrel.proto\x12\vsquirrel.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"@\n" +
+	"\x10StartScanRequest\x12\x18\n" +
+	"\atargets\x18\x01 \x03(\tR\atargets\x12\x12\n" +
+	"\x04args\x18\x02 \x03(\tR\x04args\",\n" +
+	"\x11StartScanResponse\x12\x17\n" +
+	"\ascan_id\x18\x01 \x01(\tR\x06scanId\"/\n" +
+	"\x14StreamResultsRequest\x12\x17\n" +
+	"\ascan_id\x18\x01 \x01(\tR\x06scanId\"\xfe\x02\n" +
+	"\x06Result\x12\x16\n" +
+	"\x06domain\x18\x01 \x01(\tR\x06domain\x12\x16\n" +
+	"\x06status\x18\x02 \x01(\x05R\x06status\x12\x14\n" +
+	"\x05alive\x18\x03 \x01(\bR\x05alive\x12\x1f\n" +
+	"\vstatus_text\x18\x04 \x01(\tR\n" +
+	"statusText\x12\x18\n" +
+	"\amessage\x18\x05 \x01(\tR\amessage\x12\x14\n" +
+	"\x05title\x18\x06 \x01(\tR\x05title\x12\x0e\n" +
+	"\x02ip\x18\a \x01(\tR\x02ip\x12\x1b\n" +
+	"\tfinal_url\x18\b \x01(\tR\bfinalUrl\x12(\n" +
+	"\x10response_time_ms\x18\t \x01(\x03R\x0eresponseTimeMs\x12\x1b\n" +
+	"\tpage_type\x18\n" +
+	" \x01(\tR\bpageType\x12\x1a\n" +
+	"\bfindings\x18\v \x03(\tR\bfindings\x129\n" +
+	"\n" +
+	"checked_at\x18\f \x01(\v2\x1a.google.protobuf.TimestampR\tcheckedAt\x12\x12\n" +
+	"\x04json\x18\r \x01(\fR\x04json\"C\n" +
+	"\x10GetReportRequest\x12\x17\n" +
+	"\ascan_id\x18\x01 \x01(\tR\x06scanId\x12\x16\n" +
+	"\x06format\x18\x02 \x01(\tR\x06format\"\x9b\x01\n" +
+	"\x06Report\x12\x17\n" +
+	"\ascan_id\x18\x01 \x01(\tR\x06scanId\x12,\n" +
+	"\x05state\x18\x02 \x01(\x0e2\x16.squirrel.v1.ScanStateR\x05state\x12\x16\n" +
+	"\x06format\x18\x03 \x01(\tR\x06format\x12\x18\n" +
+	"\aresults\x18\x04 \x01(\x05R\aresults\x12\x18\n" +
+	"\acontent\x18\x05 \x01(\fR\acontent\",\n" +
+	"\x11CancelScanRequest\x12\x17\n" +
+	"\ascan_id\x18\x01 \x01(\tR\x06scanId\"B\n" +
+	"\x12CancelScanResponse\x12,\n" +
+	"\x05state\x18\x01 \x01(\x0e2\x16.squirrel.v1.ScanStateR\x05state*\x8a\x01\n" +
+	"\tScanState\x12\x1a\n" +
+	"\x16SCAN_STATE_UNSPECIFIED\x10\x00\x12\x16\n" +
+	"\x12SCAN_STATE_RUNNING\x10\x01\x12\x18\n" +
+	"\x14SCAN_STATE_COMPLETED\x10\x02\x12\x18\n" +
+	"\x14SCAN_STATE_CANCELLED\x10\x03\x12\x15\n" +
+	"\x11SCAN_STATE_FAILED\x10\x042\xb4\x02\n" +
+	"\vScanService\x12J\n" +
+	"\tStartScan\x12\x1d.squirrel.v1.StartScanRequest\x1a\x1e.squirrel.v1.StartScanResponse\x12I\n" +
+	"\rStreamResults\x12!.squirrel.v1.StreamResultsRequest\x1a\x13.squirrel.v1.Result0\x01\x12?\n" +
+	"\tGetReport\x12\x1d.squirrel.v1.GetReportRequest\x1a\x13.squirrel.v1.Report\x12M\n" +
+	"\n" +
+	"CancelScan\x12\x1e.squirrel.v1.CancelScanRequest\x1a\x1f.squirrel.v1.CancelScanResponseB\x1bZ\x19subdomain-checker/grpcapib\x06proto3"
+
+var (
+	file_squirrel_proto_rawDescOnce sync.Once
+	file_squirrel_proto_rawDescData []byte
+)
+
+func file_squirrel_proto_rawDescGZIP() []byte {
+	file_squirrel_proto_rawDescOnce.Do(func() {
+		file_squirrel_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_squirrel_proto_rawDesc), len(file_squirrel_proto_rawDesc)))
+	})
+	return file_squirrel_proto_rawDescData
+}
+
+var file_squirrel_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_squirrel_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_squirrel_proto_goTypes = []any{
+	(ScanState)(0),                // 0: squirrel.v1.ScanState
+	(*StartScanRequest)(nil),      // 1: squirrel.v1.StartScanRequest
+	(*StartScanResponse)(nil),     // 2: squirrel.v1.StartScanResponse
+	(*StreamResultsRequest)(nil),  // 3: squirrel.v1.StreamResultsRequest
+	(*Result)(nil),                // 4: squirrel.v1.Result
+	(*GetReportRequest)(nil),      // 5: squirrel.v1.GetReportRequest
+	(*Report)(nil),                // 6: squirrel.v1.Report
+	(*CancelScanRequest)(nil),     // 7: squirrel.v1.CancelScanRequest
+	(*CancelScanResponse)(nil),    // 8: squirrel.v1.CancelScanResponse
+	(*timestamppb.Timestamp)(nil), // 9: google.protobuf.Timestamp
+}
+var file_squirrel_proto_depIdxs = []int32{
+	9, // 0: squirrel.v1.Result.checked_at:type_name -> google.protobuf.Timestamp
+	0, // 1: squirrel.v1.Report.state:type_name -> squirrel.v1.ScanState
+	0, // 2: squirrel.v1.CancelScanResponse.state:type_name -> squirrel.v1.ScanState
+	1, // 3: squirrel.v1.ScanService.StartScan:input_type -> squirrel.v1.StartScanRequest
+	3, // 4: squirrel.v1.ScanService.StreamResults:input_type -> squirrel.v1.StreamResultsRequest
+	5, // 5: squirrel.v1.ScanService.GetReport:input_type -> squirrel.v1.GetReportRequest
+	7, // 6: squirrel.v1.ScanService.CancelScan:input_type -> squirrel.v1.CancelScanRequest
+	2, // 7: squirrel.v1.ScanService.StartScan:output_type -> squirrel.v1.StartScanResponse
+	4, // 8: squirrel.v1.ScanService.StreamResults:output_type -> squirrel.v1.Result
+	6, // 9: squirrel.v1.ScanService.GetReport:output_type -> squirrel.v1.Report
+	8, // 10: squirrel.v1.ScanService.CancelScan:output_type -> squirrel.v1.CancelScanResponse
+	7, // [7:11] is the sub-list for method output_type
+	3, // [3:7] is the sub-list for method input_type
+	3, // [3:3] is the sub-list for extension type_name
+	3, // [3:3] is the sub-list for extension extendee
+	0, // [0:3] is the sub-list for field type_name
+}
+
+func init() { file_squirrel_proto_init() }
+func file_squirrel_proto_init() {
+	if File_squirrel_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_squirrel_proto_rawDesc), len(file_squirrel_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   8,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_squirrel_proto_goTypes,
+		DependencyIndexes: file_squirrel_proto_depIdxs,
+		EnumInfos:         file_squirrel_proto_enumTypes,
+		MessageInfos:      file_squirrel_proto_msgTypes,
+	}.Build()
+	File_squirrel_proto = out.File
+	file_squirrel_proto_goTypes = nil
+	file_squirrel_proto_depIdxs = nil
+}
@@ -0,0 +1,127 @@
+package hook
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+
+	"subdomain-checker/checker"
+	"subdomain-checker/logger"
+	"subdomain-checker/utils"
+)
+
+// 构造通过系统shell执行的命令
+func shellCommand(command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.Command("cmd", "/C", command)
+	}
+	return exec.Command("sh", "-c", command)
+}
+
+// 结果后处理器：对每个结果（或每批结果）执行用户指定的命令，结果JSON通过stdin传入
+type PostProcessor struct {
+	command   string
+	batchSize int
+	verbose   bool
+	results   chan<- checker.Result
+	queue     <-chan checker.Result
+	wg        sync.WaitGroup
+}
+
+// 创建结果后处理器，batchSize<=1时逐个结果执行命令并传入JSON对象，否则每批传入JSON数组
+func NewPostProcessor(command string, batchSize int, verbose bool) *PostProcessor {
+	if batchSize < 1 {
+		batchSize = 1
+	}
+	results, queue := utils.Unbounded[checker.Result]()
+	return &PostProcessor{
+		command:   command,
+		batchSize: batchSize,
+		verbose:   verbose,
+		results:   results,
+		queue:     queue,
+	}
+}
+
+// 启动后处理器，命令按结果到达顺序依次执行
+func (p *PostProcessor) Start() {
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		var batch []checker.Result
+		for result := range p.queue {
+			if p.batchSize == 1 {
+				p.run(result)
+				continue
+			}
+			batch = append(batch, result)
+			if len(batch) >= p.batchSize {
+				p.run(batch)
+				batch = nil
+			}
+		}
+		if len(batch) > 0 {
+			p.run(batch)
+		}
+	}()
+}
+
+// 提交一个结果，结果进入无界队列，不会因为命令执行慢而阻塞调用方
+func (p *PostProcessor) Submit(result checker.Result) {
+	p.results <- result
+}
+
+// 关闭后处理器并等待所有命令执行完成
+func (p *PostProcessor) Stop() {
+	close(p.results)
+	p.wg.Wait()
+}
+
+// 执行一次命令，payload序列化为JSON后写入命令的stdin
+func (p *PostProcessor) run(payload interface{}) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		logger.Warn("序列化结果失败", "error", err)
+		return
+	}
+
+	cmd := shellCommand(p.command)
+	cmd.Stdin = bytes.NewReader(data)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		logger.Warn("后处理命令执行失败", "command", p.command, "error", err, "output", strings.TrimSpace(string(output)))
+		return
+	}
+	if p.verbose && len(output) > 0 {
+		fmt.Fprintf(utils.Console, "\n%s\n", strings.TrimSpace(string(output)))
+	}
+}
+
+// 执行输入预处理命令，将其标准输出的每一行作为额外的检测目标
+// 空行和#开头的注释行会被忽略；命令失败时返回包含stderr内容的错误
+func RunPreCommand(command string) ([]string, error) {
+	cmd := shellCommand(command)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%v: %s", err, msg)
+		}
+		return nil, err
+	}
+
+	var targets []string
+	for _, line := range strings.Split(stdout.String(), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#") {
+			targets = append(targets, line)
+		}
+	}
+	return targets, nil
+}
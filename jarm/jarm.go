@@ -0,0 +1,471 @@
+// JARM主动TLS指纹：向服务器发送10个精心构造的ClientHello，根据服务器选择的加密套件、版本、
+// ALPN和扩展顺序计算62位指纹，算法与 https://github.com/salesforce/jarm 一致
+package jarm
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 服务器未响应任何探测时的指纹
+const Empty = "00000000000000000000000000000000000000000000000000000000000000"
+
+// 单次探测的参数，对应jarm.py中的数组
+type probe struct {
+	version      string // TLS_1.1、TLS_1.2、TLS_1.3
+	ciphers      string // ALL 或 NO1.3
+	cipherOrder  string // FORWARD、REVERSE、TOP_HALF、BOTTOM_HALF、MIDDLE_OUT
+	grease       bool
+	rareALPN     bool
+	support      string // 1.2_SUPPORT、1.3_SUPPORT、NO_SUPPORT
+	extensionOrd string // ALPN和supported_versions的顺序
+}
+
+var probes = []probe{
+	{"TLS_1.2", "ALL", "FORWARD", false, false, "1.2_SUPPORT", "REVERSE"},
+	{"TLS_1.2", "ALL", "REVERSE", false, false, "1.2_SUPPORT", "FORWARD"},
+	{"TLS_1.2", "ALL", "TOP_HALF", false, false, "NO_SUPPORT", "FORWARD"},
+	{"TLS_1.2", "ALL", "BOTTOM_HALF", false, true, "NO_SUPPORT", "FORWARD"},
+	{"TLS_1.2", "ALL", "MIDDLE_OUT", true, true, "NO_SUPPORT", "REVERSE"},
+	{"TLS_1.1", "ALL", "FORWARD", false, false, "NO_SUPPORT", "FORWARD"},
+	{"TLS_1.3", "ALL", "FORWARD", false, false, "1.3_SUPPORT", "REVERSE"},
+	{"TLS_1.3", "ALL", "REVERSE", false, false, "1.3_SUPPORT", "FORWARD"},
+	{"TLS_1.3", "NO1.3", "FORWARD", false, false, "1.3_SUPPORT", "FORWARD"},
+	{"TLS_1.3", "ALL", "MIDDLE_OUT", true, false, "1.3_SUPPORT", "REVERSE"},
+}
+
+// 探测使用的加密套件，顺序与jarm.py一致
+var allCiphers = []uint16{
+	0x0016, 0x0033, 0x0067, 0xc09e, 0xc0a2, 0x009e, 0x0039, 0x006b, 0xc09f, 0xc0a3, 0x009f, 0x0045, 0x00be, 0x0088,
+	0x00c4, 0x009a, 0xc008, 0xc009, 0xc023, 0xc0ac, 0xc0ae, 0xc02b, 0xc00a, 0xc024, 0xc0ad, 0xc0af, 0xc02c, 0xc072,
+	0xc073, 0xcca9, 0x1302, 0x1301, 0xcc14, 0xc007, 0xc012, 0xc013, 0xc027, 0xc02f, 0xc014, 0xc028, 0xc030, 0xc060,
+	0xc061, 0xc076, 0xc077, 0xcca8, 0x1305, 0x1304, 0x1303, 0xcc13, 0xc011, 0x000a, 0x002f, 0x003c, 0xc09c, 0xc0a0,
+	0x009c, 0x0035, 0x003d, 0xc09d, 0xc0a1, 0x009d, 0x0041, 0x00ba, 0x0084, 0x00c0, 0x0007, 0x0004, 0x0005,
+}
+
+// 计算指纹时加密套件的编号，按数值排序
+var cipherIndex = []uint16{
+	0x0004, 0x0005, 0x0007, 0x000a, 0x0016, 0x002f, 0x0033, 0x0035, 0x0039, 0x003c, 0x003d, 0x0041, 0x0045, 0x0067,
+	0x006b, 0x0084, 0x0088, 0x009a, 0x009c, 0x009d, 0x009e, 0x009f, 0x00ba, 0x00be, 0x00c0, 0x00c4, 0xc007, 0xc008,
+	0xc009, 0xc00a, 0xc011, 0xc012, 0xc013, 0xc014, 0xc023, 0xc024, 0xc027, 0xc028, 0xc02b, 0xc02c, 0xc02f, 0xc030,
+	0xc060, 0xc061, 0xc072, 0xc073, 0xc076, 0xc077, 0xc09c, 0xc09d, 0xc09e, 0xc09f, 0xc0a0, 0xc0a1, 0xc0a2, 0xc0a3,
+	0xc0ac, 0xc0ad, 0xc0ae, 0xc0af, 0xcc13, 0xcc14, 0xcca8, 0xcca9, 0x1301, 0x1302, 0x1303, 0x1304, 0x1305,
+}
+
+// 全部ALPN（从弱到强）和去掉h2、http/1.1的少见ALPN
+var (
+	allALPNs  = []string{"http/0.9", "http/1.0", "http/1.1", "spdy/1", "spdy/2", "spdy/3", "h2", "h2c", "hq"}
+	rareALPNs = []string{"http/0.9", "http/1.0", "spdy/1", "spdy/2", "spdy/3", "h2c", "hq"}
+)
+
+// 指纹结果
+type Result struct {
+	JARM string // 62位JARM指纹，服务器不响应时为全0
+	JA3S string // 第一个探测（TLS 1.2正序）的ServerHello的JA3S指纹，服务器不响应时为空
+}
+
+// 连接函数，由调用方提供以复用DNS缓存和扫描范围限制
+type DialFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// 对addr（host:port）计算JARM指纹，host用于SNI。每个探测单独建立连接，
+// 连接失败时返回错误，服务器拒绝探测（返回告警或关闭连接）时该探测记为空
+func Fingerprint(ctx context.Context, dial DialFunc, addr, host string, timeout time.Duration) (Result, error) {
+	var result Result
+	raw := make([]string, len(probes))
+	connected := false
+	var lastErr error
+	for i, p := range probes {
+		data, err := send(ctx, dial, addr, clientHello(p, host), timeout)
+		if err != nil {
+			lastErr = err
+			raw[i] = "|||"
+			continue
+		}
+		connected = true
+		raw[i] = readServerHello(data)
+		if i == 0 {
+			result.JA3S = ja3s(data)
+		}
+	}
+	if !connected {
+		return result, lastErr
+	}
+	result.JARM = hash(raw)
+	return result, nil
+}
+
+// 发送ClientHello并读取服务器的第一个TLS记录（最多1484字节，与jarm.py一致）
+func send(ctx context.Context, dial DialFunc, addr string, hello []byte, timeout time.Duration) ([]byte, error) {
+	dialCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	conn, err := dial(dialCtx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
+	if _, err := conn.Write(hello); err != nil {
+		return nil, nil
+	}
+	buf := make([]byte, 1484)
+	n, err := io.ReadAtLeast(conn, buf, 5)
+	if err != nil {
+		return buf[:n], nil
+	}
+	// 读到完整的记录或读满缓冲区
+	if want := 5 + int(binary.BigEndian.Uint16(buf[3:5])); want > n {
+		m, _ := io.ReadAtLeast(conn, buf[n:], min(want, len(buf))-n)
+		n += m
+	}
+	return buf[:n], nil
+}
+
+// 构造探测的ClientHello记录
+func clientHello(p probe, host string) []byte {
+	var recordVersion, helloVersion []byte
+	switch p.version {
+	case "TLS_1.1":
+		recordVersion, helloVersion = []byte{3, 2}, []byte{3, 2}
+	case "TLS_1.3":
+		recordVersion, helloVersion = []byte{3, 1}, []byte{3, 3}
+	default:
+		recordVersion, helloVersion = []byte{3, 3}, []byte{3, 3}
+	}
+
+	hello := append([]byte{}, helloVersion...)
+	hello = append(hello, randomBytes(32)...)
+	hello = append(hello, 32)
+	hello = append(hello, randomBytes(32)...)
+
+	ciphers := allCiphers
+	if p.ciphers == "NO1.3" {
+		ciphers = nil
+		for _, c := range allCiphers {
+			if c>>8 != 0x13 {
+				ciphers = append(ciphers, c)
+			}
+		}
+	}
+	if p.cipherOrder != "FORWARD" {
+		ciphers = mung(ciphers, p.cipherOrder)
+	}
+	if p.grease {
+		ciphers = append([]uint16{randomGrease()}, ciphers...)
+	}
+	hello = binary.BigEndian.AppendUint16(hello, uint16(len(ciphers)*2))
+	for _, c := range ciphers {
+		hello = binary.BigEndian.AppendUint16(hello, c)
+	}
+	hello = append(hello, 1, 0) // 压缩方法: null
+
+	ext := extensions(p, host)
+	hello = binary.BigEndian.AppendUint16(hello, uint16(len(ext)))
+	hello = append(hello, ext...)
+
+	handshake := []byte{1, 0}
+	handshake = binary.BigEndian.AppendUint16(handshake, uint16(len(hello)))
+	handshake = append(handshake, hello...)
+
+	record := append([]byte{0x16}, recordVersion...)
+	record = binary.BigEndian.AppendUint16(record, uint16(len(handshake)))
+	return append(record, handshake...)
+}
+
+// ClientHello的扩展
+func extensions(p probe, host string) []byte {
+	var ext []byte
+	if p.grease {
+		ext = binary.BigEndian.AppendUint16(ext, randomGrease())
+		ext = append(ext, 0, 0)
+	}
+
+	// server_name
+	ext = append(ext, 0, 0)
+	ext = binary.BigEndian.AppendUint16(ext, uint16(len(host)+5))
+	ext = binary.BigEndian.AppendUint16(ext, uint16(len(host)+3))
+	ext = append(ext, 0)
+	ext = binary.BigEndian.AppendUint16(ext, uint16(len(host)))
+	ext = append(ext, host...)
+
+	ext = append(ext, 0x00, 0x17, 0x00, 0x00)                                                             // extended_master_secret
+	ext = append(ext, 0x00, 0x01, 0x00, 0x01, 0x01)                                                       // max_fragment_length
+	ext = append(ext, 0xff, 0x01, 0x00, 0x01, 0x00)                                                       // renegotiation_info
+	ext = append(ext, 0x00, 0x0a, 0x00, 0x0a, 0x00, 0x08, 0x00, 0x1d, 0x00, 0x17, 0x00, 0x18, 0x00, 0x19) // supported_groups
+	ext = append(ext, 0x00, 0x0b, 0x00, 0x02, 0x01, 0x00)                                                 // ec_point_formats
+	ext = append(ext, 0x00, 0x23, 0x00, 0x00)                                                             // session_ticket
+
+	// application_layer_protocol_negotiation
+	alpns := allALPNs
+	if p.rareALPN {
+		alpns = rareALPNs
+	}
+	if p.extensionOrd != "FORWARD" {
+		alpns = mung(alpns, p.extensionOrd)
+	}
+	var alpnList []byte
+	for _, alpn := range alpns {
+		alpnList = append(alpnList, byte(len(alpn)))
+		alpnList = append(alpnList, alpn...)
+	}
+	ext = append(ext, 0x00, 0x10)
+	ext = binary.BigEndian.AppendUint16(ext, uint16(len(alpnList)+2))
+	ext = binary.BigEndian.AppendUint16(ext, uint16(len(alpnList)))
+	ext = append(ext, alpnList...)
+
+	// signature_algorithms
+	ext = append(ext, 0x00, 0x0d, 0x00, 0x14, 0x00, 0x12, 0x04, 0x03, 0x08, 0x04, 0x04, 0x01, 0x05, 0x03,
+		0x08, 0x05, 0x05, 0x01, 0x08, 0x06, 0x06, 0x01, 0x02, 0x01)
+
+	// key_share
+	var share []byte
+	if p.grease {
+		share = binary.BigEndian.AppendUint16(share, randomGrease())
+		share = append(share, 0, 1, 0)
+	}
+	share = append(share, 0x00, 0x1d, 0x00, 0x20)
+	share = append(share, randomBytes(32)...)
+	ext = append(ext, 0x00, 0x33)
+	ext = binary.BigEndian.AppendUint16(ext, uint16(len(share)+2))
+	ext = binary.BigEndian.AppendUint16(ext, uint16(len(share)))
+	ext = append(ext, share...)
+
+	ext = append(ext, 0x00, 0x2d, 0x00, 0x02, 0x01, 0x01) // psk_key_exchange_modes
+
+	// supported_versions
+	if p.version == "TLS_1.3" || p.support == "1.2_SUPPORT" {
+		versions := []uint16{0x0301, 0x0302, 0x0303}
+		if p.support != "1.2_SUPPORT" {
+			versions = append(versions, 0x0304)
+		}
+		if p.extensionOrd != "FORWARD" {
+			versions = mung(versions, p.extensionOrd)
+		}
+		var list []byte
+		if p.grease {
+			list = binary.BigEndian.AppendUint16(list, randomGrease())
+		}
+		for _, v := range versions {
+			list = binary.BigEndian.AppendUint16(list, v)
+		}
+		ext = append(ext, 0x00, 0x2b)
+		ext = binary.BigEndian.AppendUint16(ext, uint16(len(list)+1))
+		ext = append(ext, byte(len(list)))
+		ext = append(ext, list...)
+	}
+	return ext
+}
+
+// 按探测要求调整列表顺序
+func mung[T any](items []T, order string) []T {
+	n := len(items)
+	var out []T
+	switch order {
+	case "REVERSE":
+		for i := n - 1; i >= 0; i-- {
+			out = append(out, items[i])
+		}
+	case "BOTTOM_HALF":
+		if n%2 == 1 {
+			out = append(out, items[n/2+1:]...)
+		} else {
+			out = append(out, items[n/2:]...)
+		}
+	case "TOP_HALF":
+		// 上半部分倒序，数量为奇数时包含中间项
+		if n%2 == 1 {
+			out = append(out, items[n/2])
+		}
+		out = append(out, mung(mung(items, "REVERSE"), "BOTTOM_HALF")...)
+	case "MIDDLE_OUT":
+		middle := n / 2
+		if n%2 == 1 {
+			out = append(out, items[middle])
+			for i := 1; i <= middle; i++ {
+				out = append(out, items[middle+i], items[middle-i])
+			}
+		} else {
+			for i := 1; i <= middle; i++ {
+				out = append(out, items[middle-1+i], items[middle-i])
+			}
+		}
+	}
+	return out
+}
+
+// 解析ServerHello，返回"加密套件|版本|ALPN|扩展列表"，不是ServerHello时返回"|||"
+func readServerHello(data []byte) string {
+	if len(data) < 44 || data[0] != 0x16 || data[5] != 2 {
+		return "|||"
+	}
+	sessionIDLen := int(data[43])
+	if len(data) < sessionIDLen+46 {
+		return "|||"
+	}
+	cipher := hex.EncodeToString(data[sessionIDLen+44 : sessionIDLen+46])
+	version := hex.EncodeToString(data[9:11])
+	return cipher + "|" + version + "|" + extensionInfo(data, sessionIDLen)
+}
+
+// 服务器扩展部分："ALPN|类型-类型-..."，无法解析时为"|"
+func extensionInfo(data []byte, sessionIDLen int) string {
+	serverHelloLen := int(binary.BigEndian.Uint16(data[3:5]))
+	if len(data) < sessionIDLen+53 {
+		return "|"
+	}
+	if data[sessionIDLen+47] == 11 ||
+		string(data[sessionIDLen+50:sessionIDLen+53]) == "\x0e\xac\x0b" || (len(data) >= 85 && string(data[82:85]) == "\x0f\xf0\x0b") ||
+		sessionIDLen+42 >= serverHelloLen {
+		return "|"
+	}
+
+	types, alpn := extensionTypes(data, sessionIDLen)
+	if types == nil {
+		return "|"
+	}
+	return alpn + "|" + strings.Join(types, "-")
+}
+
+// 解析ServerHello的扩展类型（十六进制）和选择的ALPN，越界时返回nil
+func extensionTypes(data []byte, sessionIDLen int) (types []string, alpn string) {
+	count := 49 + sessionIDLen
+	if len(data) < count {
+		return nil, ""
+	}
+	length := int(binary.BigEndian.Uint16(data[sessionIDLen+47 : sessionIDLen+49]))
+	maximum := length + count - 1
+	types = []string{}
+	for count < maximum {
+		if count+4 > len(data) {
+			return nil, ""
+		}
+		extType := data[count : count+2]
+		extLen := int(binary.BigEndian.Uint16(data[count+2 : count+4]))
+		end := min(count+4+extLen, len(data))
+		value := data[count+4 : end]
+		if string(extType) == "\x00\x10" && alpn == "" && len(value) > 3 {
+			alpn = string(value[3:])
+		}
+		types = append(types, hex.EncodeToString(extType))
+		count += extLen + 4
+	}
+	return types, alpn
+}
+
+// 由10个探测结果计算指纹：每个探测的加密套件编号（2位）和版本（1位），加上ALPN和扩展的SHA256前32位
+func hash(raw []string) string {
+	empty := true
+	for _, r := range raw {
+		if r != "|||" {
+			empty = false
+		}
+	}
+	if empty {
+		return Empty
+	}
+	var fuzzy strings.Builder
+	var alpnsAndExt strings.Builder
+	for _, r := range raw {
+		parts := strings.SplitN(r, "|", 4)
+		for len(parts) < 4 {
+			parts = append(parts, "")
+		}
+		fuzzy.WriteString(cipherByte(parts[0]))
+		fuzzy.WriteString(versionByte(parts[1]))
+		alpnsAndExt.WriteString(parts[2])
+		alpnsAndExt.WriteString(parts[3])
+	}
+	sum := sha256.Sum256([]byte(alpnsAndExt.String()))
+	return fuzzy.String() + hex.EncodeToString(sum[:])[:32]
+}
+
+func cipherByte(cipher string) string {
+	if cipher == "" {
+		return "00"
+	}
+	count := 1
+	for _, c := range cipherIndex {
+		if fmt.Sprintf("%04x", c) == cipher {
+			break
+		}
+		count++
+	}
+	return fmt.Sprintf("%02x", count)
+}
+
+func versionByte(version string) string {
+	if len(version) < 4 {
+		return "0"
+	}
+	n, err := strconv.Atoi(version[3:4])
+	if err != nil || n > 5 {
+		return "0"
+	}
+	return string("abcdef"[n])
+}
+
+// ServerHello的JA3S指纹：MD5("版本,加密套件,扩展类型-扩展类型")，各值为十进制
+func ja3s(data []byte) string {
+	if len(data) < 44 || data[0] != 0x16 || data[5] != 2 {
+		return ""
+	}
+	sessionIDLen := int(data[43])
+	if len(data) < sessionIDLen+49 {
+		return ""
+	}
+	version := binary.BigEndian.Uint16(data[9:11])
+	cipher := binary.BigEndian.Uint16(data[sessionIDLen+44 : sessionIDLen+46])
+	types, _ := extensionTypes(data, sessionIDLen)
+	decimal := make([]string, len(types))
+	for i, t := range types {
+		v, _ := strconv.ParseUint(t, 16, 16)
+		decimal[i] = strconv.FormatUint(v, 10)
+	}
+	sum := md5.Sum([]byte(fmt.Sprintf("%d,%d,%s", version, cipher, strings.Join(decimal, "-"))))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomBytes(n int) []byte {
+	b := make([]byte, n)
+	rand.Read(b)
+	return b
+}
+
+// 随机的GREASE值（0x0a0a、0x1a1a ... 0xfafa）
+func randomGrease() uint16 {
+	b := randomBytes(1)
+	v := uint16(b[0]%16)<<4 | 0x0a
+	return v<<8 | v
+}
+
+// 读取已知指纹列表：每行为"指纹 说明"（如已知C2框架的默认配置），忽略空行和 # 开头的注释
+func LoadKnown(filename string) (map[string]string, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("无法读取JARM指纹列表: %v", err)
+	}
+	known := make(map[string]string)
+	for lineNo, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fingerprint, label, _ := strings.Cut(line, " ")
+		fingerprint = strings.ToLower(fingerprint)
+		if len(fingerprint) != 62 {
+			return nil, fmt.Errorf("JARM指纹列表第 %d 行的指纹长度不是62位: %s", lineNo+1, fingerprint)
+		}
+		known[fingerprint] = strings.TrimSpace(label)
+	}
+	return known, nil
+}
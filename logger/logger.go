@@ -0,0 +1,145 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+)
+
+// 诊断日志：截图失败、通知发送失败等运行过程中的问题通过分级日志输出，
+// 默认以文本形式写到标准错误，可改为JSON格式或写入文件，便于日志采集程序收集。
+// 扫描进度、总结和报告保存提示等面向用户的输出不经过日志
+
+// 当前使用的日志记录器，默认以文本形式输出info及以上级别到标准错误
+var current = slog.New(newTextHandler(os.Stderr, slog.LevelInfo))
+
+// 解析日志级别名称: debug|info|warn|error
+func ParseLevel(name string) (slog.Level, error) {
+	switch strings.ToLower(name) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "info", "":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+	return 0, fmt.Errorf("未知的日志级别 %q，可选: debug, info, warn, error", name)
+}
+
+// 设置日志级别、格式(text|json)和输出文件；file为空时输出到标准错误，否则追加写入该文件
+func Setup(level, format, file string) error {
+	lvl, err := ParseLevel(level)
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stderr
+	if file != "" {
+		f, err := os.OpenFile(file, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return fmt.Errorf("无法打开日志文件: %v", err)
+		}
+		w = f
+	}
+
+	switch format {
+	case "text", "":
+		current = slog.New(newTextHandler(w, lvl))
+	case "json":
+		current = slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{Level: lvl}))
+	default:
+		return fmt.Errorf("未知的日志格式 %q，可选: text, json", format)
+	}
+	return nil
+}
+
+func Debug(msg string, args ...any) { current.Debug(msg, args...) }
+func Info(msg string, args ...any)  { current.Info(msg, args...) }
+func Warn(msg string, args ...any)  { current.Warn(msg, args...) }
+func Error(msg string, args ...any) { current.Error(msg, args...) }
+
+// 各级别在文本日志中的前缀，与控制台其他提示的风格保持一致
+var levelPrefix = map[slog.Level]string{
+	slog.LevelDebug: "🔍",
+	slog.LevelInfo:  "ℹ️ ",
+	slog.LevelWarn:  "⚠️ ",
+	slog.LevelError: "❌",
+}
+
+// 面向终端的文本日志，每条一行: "15:04:05 ⚠️  消息 key=value ..."
+// 输出到终端时先清除当前行，避免与进度显示混在同一行
+type textHandler struct {
+	mu       *sync.Mutex
+	w        io.Writer
+	level    slog.Level
+	terminal bool
+	attrs    []slog.Attr
+}
+
+func newTextHandler(w io.Writer, level slog.Level) *textHandler {
+	h := &textHandler{mu: &sync.Mutex{}, w: w, level: level}
+	if f, ok := w.(*os.File); ok {
+		if info, err := f.Stat(); err == nil {
+			h.terminal = info.Mode()&os.ModeCharDevice != 0
+		}
+	}
+	return h
+}
+
+func (h *textHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level
+}
+
+func (h *textHandler) Handle(_ context.Context, r slog.Record) error {
+	var b strings.Builder
+	if h.terminal {
+		fmt.Fprintf(&b, "\r%-80s\r", " ")
+	}
+	prefix, ok := levelPrefix[r.Level]
+	if !ok {
+		prefix = r.Level.String()
+	}
+	fmt.Fprintf(&b, "%s %s %s", r.Time.Format("15:04:05"), prefix, r.Message)
+	for _, attr := range h.attrs {
+		writeAttr(&b, attr)
+	}
+	r.Attrs(func(attr slog.Attr) bool {
+		writeAttr(&b, attr)
+		return true
+	})
+	b.WriteByte('\n')
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := io.WriteString(h.w, b.String())
+	return err
+}
+
+func (h *textHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.attrs = append(append([]slog.Attr(nil), h.attrs...), attrs...)
+	return &clone
+}
+
+// 文本日志不区分分组，分组中的字段直接展开
+func (h *textHandler) WithGroup(string) slog.Handler {
+	return h
+}
+
+// 写入 " key=value"，值中含有空白时加引号
+func writeAttr(b *strings.Builder, attr slog.Attr) {
+	if attr.Equal(slog.Attr{}) {
+		return
+	}
+	value := attr.Value.Resolve().String()
+	if value == "" || strings.ContainsAny(value, " \t\n\"") {
+		value = fmt.Sprintf("%q", value)
+	}
+	fmt.Fprintf(b, " %s=%s", attr.Key, value)
+}
@@ -3,11 +3,15 @@ package main
 import (
 	"flag"
 	"fmt"
-	"net/url"
+	"io"
+	"net"
 	"os"
 	"os/exec"
 	"os/signal"
+	"regexp"
 	"runtime"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -15,11 +19,30 @@ import (
 	"syscall"
 	"time"
 
+	"subdomain-checker/archive"
+	"subdomain-checker/auth"
+	"subdomain-checker/axfr"
 	"subdomain-checker/checker"
+	"subdomain-checker/cloud"
 	"subdomain-checker/config"
+	"subdomain-checker/event"
+	"subdomain-checker/geoip"
+	"subdomain-checker/grpcapi"
+	"subdomain-checker/har"
+	"subdomain-checker/hook"
+	"subdomain-checker/jarm"
+	"subdomain-checker/logger"
+	"subdomain-checker/notify"
+	"subdomain-checker/ocr"
+	"subdomain-checker/permute"
+	"subdomain-checker/scheduler"
+	"subdomain-checker/scope"
 	"subdomain-checker/screenshot"
+	"subdomain-checker/sink"
+	"subdomain-checker/stats"
 	"subdomain-checker/utils"
 	"subdomain-checker/view"
+	"subdomain-checker/whois"
 )
 
 // 获取系统内存信息（GB）
@@ -76,7 +99,7 @@ func getSystemMemoryGB() float64 {
 
 	// 使用更保守的估算，假设至少16GB内存（现代计算机的常见配置）
 	estimatedMemoryGB := 16.0
-	fmt.Printf("⚠️  无法准确检测系统内存，估算为%.1fGB\n", estimatedMemoryGB)
+	fmt.Fprintf(utils.Console, "⚠️  无法准确检测系统内存，估算为%.1fGB\n", estimatedMemoryGB)
 
 	return estimatedMemoryGB
 }
@@ -88,7 +111,7 @@ func calculateOptimalScreenshotConcurrency(requestedConcurrency int, totalDomain
 	memoryGB := getSystemMemoryGB()
 
 	// 显示系统资源信息
-	fmt.Printf("💻 系统资源: CPU=%d核心, 内存=%.1fGB\n", numCPU, memoryGB)
+	fmt.Fprintf(utils.Console, "💻 系统资源: CPU=%d核心, 内存=%.1fGB\n", numCPU, memoryGB)
 
 	// 基于CPU计算推荐并发数 - 更激进的策略，充分利用多核
 	var cpuBasedConcurrency int
@@ -113,9 +136,9 @@ func calculateOptimalScreenshotConcurrency(requestedConcurrency int, totalDomain
 	if memoryBasedConcurrency < cpuBasedConcurrency {
 		optimalConcurrency = memoryBasedConcurrency
 		limitingFactor = "内存"
-		fmt.Printf("🧠 内存成为限制因素: 内存支持最多%d个Chrome实例\n", memoryBasedConcurrency)
+		fmt.Fprintf(utils.Console, "🧠 内存成为限制因素: 内存支持最多%d个Chrome实例\n", memoryBasedConcurrency)
 	} else {
-		fmt.Printf("⚡ CPU成为限制因素: CPU支持最多%d个Chrome实例\n", cpuBasedConcurrency)
+		fmt.Fprintf(utils.Console, "⚡ CPU成为限制因素: CPU支持最多%d个Chrome实例\n", cpuBasedConcurrency)
 	}
 
 	// 智能并发限制 - 基于系统稳定性和性能的动态调整
@@ -124,29 +147,29 @@ func calculateOptimalScreenshotConcurrency(requestedConcurrency int, totalDomain
 		// 超大规模域名处理，强制降低并发
 		if optimalConcurrency > 15 {
 			optimalConcurrency = 15
-			fmt.Printf("🔥 超大规模处理: 检测到%d个域名，限制为15个并发\n", totalDomains)
-			fmt.Printf("💡 提示: 大量域名处理需要保守的并发数以避免系统崩溃\n")
+			fmt.Fprintf(utils.Console, "🔥 超大规模处理: 检测到%d个域名，限制为15个并发\n", totalDomains)
+			fmt.Fprintf(utils.Console, "💡 提示: 大量域名处理需要保守的并发数以避免系统崩溃\n")
 		}
 	} else if totalDomains > 10000 {
 		// 大规模域名处理
 		if optimalConcurrency > 25 {
 			optimalConcurrency = 25
-			fmt.Printf("🚀 大规模处理: 检测到%d个域名，限制为25个并发\n", totalDomains)
-			fmt.Printf("💡 提示: 大量域名处理时，过高并发会导致网络错误增加\n")
+			fmt.Fprintf(utils.Console, "🚀 大规模处理: 检测到%d个域名，限制为25个并发\n", totalDomains)
+			fmt.Fprintf(utils.Console, "💡 提示: 大量域名处理时，过高并发会导致网络错误增加\n")
 		}
 	} else if optimalConcurrency > 50 {
 		optimalConcurrency = 50
-		fmt.Printf("🚀 高并发限制: 限制为50个并发以避免网络拥塞\n")
-		fmt.Printf("💡 提示: 处理大量域名时，过高并发会导致网络错误增加\n")
+		fmt.Fprintf(utils.Console, "🚀 高并发限制: 限制为50个并发以避免网络拥塞\n")
+		fmt.Fprintf(utils.Console, "💡 提示: 处理大量域名时，过高并发会导致网络错误增加\n")
 	}
 
 	if optimalConcurrency > 30 {
-		fmt.Printf("⚠️  中高并发模式: %d个并发，适合大量域名处理\n", optimalConcurrency)
-		fmt.Printf("💡 建议: 监控网络错误率，如果过高请降低并发数\n")
+		fmt.Fprintf(utils.Console, "⚠️  中高并发模式: %d个并发，适合大量域名处理\n", optimalConcurrency)
+		fmt.Fprintf(utils.Console, "💡 建议: 监控网络错误率，如果过高请降低并发数\n")
 	} else if optimalConcurrency > 20 {
-		fmt.Printf("⚖️  平衡模式: %d个并发 (限制因素: %s)\n", optimalConcurrency, limitingFactor)
+		fmt.Fprintf(utils.Console, "⚖️  平衡模式: %d个并发 (限制因素: %s)\n", optimalConcurrency, limitingFactor)
 	} else {
-		fmt.Printf("✅ 推荐并发数: %d个 (限制因素: %s)\n", optimalConcurrency, limitingFactor)
+		fmt.Fprintf(utils.Console, "✅ 推荐并发数: %d个 (限制因素: %s)\n", optimalConcurrency, limitingFactor)
 	}
 
 	// 如果用户请求的并发数较小，使用用户设置
@@ -155,29 +178,29 @@ func calculateOptimalScreenshotConcurrency(requestedConcurrency int, totalDomain
 	}
 
 	// 显示资源评估结果
-	fmt.Printf("📈 资源评估: CPU支持%d个, 内存支持%d个, 推荐%d个\n",
+	fmt.Fprintf(utils.Console, "📈 资源评估: CPU支持%d个, 内存支持%d个, 推荐%d个\n",
 		cpuBasedConcurrency, memoryBasedConcurrency, optimalConcurrency)
 
 	// 根据最终并发数给出性能预期和建议
 	if optimalConcurrency <= numCPU {
-		fmt.Printf("✅ 稳定模式: %d个工作者 (预期成功率: 95%%+, 速度稳定)\n", optimalConcurrency)
-		fmt.Printf("📈 性能预期: 低资源占用，高成功率，适合长时间运行\n")
+		fmt.Fprintf(utils.Console, "✅ 稳定模式: %d个工作者 (预期成功率: 95%%+, 速度稳定)\n", optimalConcurrency)
+		fmt.Fprintf(utils.Console, "📈 性能预期: 低资源占用，高成功率，适合长时间运行\n")
 	} else if optimalConcurrency <= numCPU*2 {
-		fmt.Printf("⚖️  平衡模式: %d个工作者 (预期成功率: 85-95%%, 速度较快)\n", optimalConcurrency)
-		fmt.Printf("📈 性能预期: 中等资源占用，良好成功率，速度与稳定性平衡\n")
+		fmt.Fprintf(utils.Console, "⚖️  平衡模式: %d个工作者 (预期成功率: 85-95%%, 速度较快)\n", optimalConcurrency)
+		fmt.Fprintf(utils.Console, "📈 性能预期: 中等资源占用，良好成功率，速度与稳定性平衡\n")
 	} else if optimalConcurrency <= numCPU*3 {
-		fmt.Printf("⚡ 高速模式: %d个工作者 (预期成功率: 75-85%%, 高速度)\n", optimalConcurrency)
-		fmt.Printf("📈 性能预期: 高资源占用，中等成功率，最大化处理速度\n")
+		fmt.Fprintf(utils.Console, "⚡ 高速模式: %d个工作者 (预期成功率: 75-85%%, 高速度)\n", optimalConcurrency)
+		fmt.Fprintf(utils.Console, "📈 性能预期: 高资源占用，中等成功率，最大化处理速度\n")
 	} else {
-		fmt.Printf("🚀 极速模式: %d个工作者 (预期成功率: 60-75%%, 极高速度)\n", optimalConcurrency)
-		fmt.Printf("📈 性能预期: 极高资源占用，可能出现更多失败，但处理速度最快\n")
-		fmt.Printf("⚠️  警告: 建议监控系统资源使用情况\n")
+		fmt.Fprintf(utils.Console, "🚀 极速模式: %d个工作者 (预期成功率: 60-75%%, 极高速度)\n", optimalConcurrency)
+		fmt.Fprintf(utils.Console, "📈 性能预期: 极高资源占用，可能出现更多失败，但处理速度最快\n")
+		fmt.Fprintf(utils.Console, "⚠️  警告: 建议监控系统资源使用情况\n")
 	}
 
 	// 如果用户请求的并发数过高，给出警告
 	if requestedConcurrency > optimalConcurrency {
-		fmt.Printf("🔧 智能优化: %d -> %d (基于CPU和内存资源自动调整)\n", requestedConcurrency, optimalConcurrency)
-		fmt.Printf("💡 提示: 系统资源限制，使用推荐值可获得最佳性能\n")
+		fmt.Fprintf(utils.Console, "🔧 智能优化: %d -> %d (基于CPU和内存资源自动调整)\n", requestedConcurrency, optimalConcurrency)
+		fmt.Fprintf(utils.Console, "💡 提示: 系统资源限制，使用推荐值可获得最佳性能\n")
 	}
 
 	// 确保至少有1个工作者
@@ -195,7 +218,7 @@ func calculateOptimalScreenshotConcurrency(requestedConcurrency int, totalDomain
 
 // 清理所有Chrome进程
 func cleanupChromeProcesses() {
-	fmt.Printf("🧹 正在检查并清理Chrome进程...\n")
+	fmt.Fprintf(utils.Console, "🧹 正在检查并清理Chrome进程...\n")
 
 	cleanedCount := 0
 
@@ -216,7 +239,7 @@ func cleanupChromeProcesses() {
 				cmd := exec.Command("taskkill", "/F", "/IM", process)
 				output, err := cmd.CombinedOutput()
 				if err == nil {
-					fmt.Printf("✅ 已清理进程: %s\n", process)
+					fmt.Fprintf(utils.Console, "✅ 已清理进程: %s\n", process)
 					cleanedCount++
 				} else {
 					// 只在真正的错误时显示（不是"进程未找到"）
@@ -224,7 +247,7 @@ func cleanupChromeProcesses() {
 					if !strings.Contains(outputStr, "没有找到进程") &&
 						!strings.Contains(outputStr, "not found") &&
 						!strings.Contains(outputStr, "No tasks") {
-						fmt.Printf("⚠️  清理进程 %s 时出错: %v\n", process, err)
+						logger.Warn("清理进程时出错", "process", process, "error", err)
 					}
 				}
 			}
@@ -242,134 +265,624 @@ func cleanupChromeProcesses() {
 	}
 
 	if cleanedCount > 0 {
-		fmt.Printf("✅ Chrome进程清理完成，清理了 %d 个进程\n", cleanedCount)
+		fmt.Fprintf(utils.Console, "✅ Chrome进程清理完成，清理了 %d 个进程\n", cleanedCount)
 	} else {
-		fmt.Printf("✅ 无需清理，Chrome进程已正常退出\n")
+		fmt.Fprintf(utils.Console, "✅ 无需清理，Chrome进程已正常退出\n")
 	}
 }
 
-// 优雅关闭处理器
-func setupGracefulShutdown(screenshotPool *screenshot.ScreenshotPool) {
-	c := make(chan os.Signal, 1)
+// 优雅关闭处理器：第一次中断时关闭stopping，停止分发新的域名并等待进行中的检测完成；
+// 等待超过timeout或再次中断时关闭force，不再等待，直接以已收到的结果生成报告；第三次中断立即退出
+func setupGracefulShutdown(timeout time.Duration) (stopping, force <-chan struct{}) {
+	c := make(chan os.Signal, 3)
 	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
 
+	stop := make(chan struct{})
+	forced := make(chan struct{})
 	go func() {
 		<-c
-		fmt.Printf("\n🛑 接收到中断信号，正在优雅关闭...\n")
+		fmt.Fprintf(utils.Console, "\n🛑 接收到中断信号，停止分发新的域名，等待进行中的检测完成（最多 %s，再次中断立即生成报告）...\n", timeout)
+		close(stop)
 
-		// 停止截图工作池
-		if screenshotPool != nil {
-			fmt.Printf("📸 正在停止截图工作池...\n")
-			screenshotPool.Stop()
+		select {
+		case <-c:
+			fmt.Fprintf(utils.Console, "\n🛑 再次接收到中断信号，不再等待进行中的检测\n")
+		case <-time.After(timeout):
+			fmt.Fprintf(utils.Console, "\n⏰ 等待进行中的检测超时，不再等待\n")
 		}
+		close(forced)
 
-		// 清理Chrome进程
+		<-c
+		fmt.Fprintf(utils.Console, "\n👋 程序已强制退出\n")
 		cleanupChromeProcesses()
-
-		fmt.Printf("👋 程序已安全退出\n")
-		os.Exit(0)
+		os.Exit(130)
 	}()
+	return stop, forced
 }
 
 func main() {
 	// 确保程序退出时清理资源
 	defer func() {
 		if r := recover(); r != nil {
-			fmt.Printf("🚨 程序异常退出: %v\n", r)
+			fmt.Fprintf(utils.Console, "🚨 程序异常退出: %v\n", r)
 			cleanupChromeProcesses()
 		}
 	}()
 
-	fmt.Println(`
-                               /$$                             /$$
-                              |__/                            | $$
-  /$$$$$$$  /$$$$$$  /$$   /$$ /$$  /$$$$$$  /$$$$$$  /$$$$$$ | $$
- /$$_____/ /$$__  $$| $$  | $$| $$ /$$__  $$/$$__  $$/$$__  $$| $$
-|  $$$$$$ | $$  \ $$| $$  | $$| $$| $$  \__/ $$  \__/ $$$$$$$$| $$
- \____  $$| $$  | $$| $$  | $$| $$| $$     | $$     | $$_____/| $$
- /$$$$$$$/|  $$$$$$$|  $$$$$$/| $$| $$     | $$     |  $$$$$$$| $$
-|_______/  \____  $$ \______/ |__/|__/     |__/      \_______/|__/
-                | $$
-                | $$
-                |__/
-                    松鼠子域名检测工具 v1.3
-`)
+	// 子命令：squirrel view <结果文件>、squirrel render <结果文件>、squirrel grpc、squirrel cloud-ranges、squirrel trend <结果文件>...、squirrel prune-screenshots
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "view":
+			runView(os.Args[2:])
+			return
+		case "render":
+			runRender(os.Args[2:])
+			return
+		case "grpc":
+			runGRPC(os.Args[2:])
+			return
+		case "cloud-ranges":
+			runCloudRanges(os.Args[2:])
+			return
+		case "trend":
+			runTrend(os.Args[2:])
+			return
+		case "prune-screenshots":
+			runPruneScreenshots(os.Args[2:])
+			return
+		}
+	}
 
 	// 解析命令行参数
 	cfg := config.Config{}
 	config.ParseFlags(&cfg)
 
 	// HTML输出选项
-	var htmlOutput, simpleHTML string
+	var htmlOutput, simpleHTML, galleryOutput string
 	flag.StringVar(&htmlOutput, "html", "", "输出结果到HTML文件")
 	flag.StringVar(&simpleHTML, "simple-html", "", "输出结果到简化版HTML文件")
+	flag.StringVar(&galleryOutput, "gallery", "", "输出截图画廊HTML文件（截图网格，延迟加载 screenshots 目录中的图片）")
 	flag.Parse()
 
-	if flag.NArg() < 1 {
-		fmt.Println("用法: squirrel [选项] <域名列表文件或逗号分隔的域名列表>")
-		fmt.Println("\n选项:")
+	// 获取并校验中心配置服务下发的团队策略
+	var policy *config.Policy
+	var remoteConfig *config.FileConfig
+	if cfg.PolicyURL != "" {
+		var err error
+		if policy, err = config.FetchPolicy(cfg.PolicyURL, cfg.PolicyKey); err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %s\n", err)
+			os.Exit(1)
+		}
+		remoteConfig = policy.FileConfig()
+	}
+
+	// 应用配置文件和所选配置，命令行参数优先
+	configPath, err := config.ApplyConfigFile(cfg.ConfigFile, cfg.Profile, remoteConfig)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "错误: %s\n", err)
+		os.Exit(1)
+	}
+
+	// 静默模式下丢弃提示信息，只向标准输出写入存活主机，便于与其他命令行工具组合
+	if cfg.Silent {
+		utils.Console = io.Discard
+	}
+	// 诊断日志：-verbose 且未指定 -log-level 时输出debug级别
+	logLevel := cfg.LogLevel
+	if cfg.Verbose && logLevel == "info" {
+		logLevel = "debug"
+	}
+	if err := logger.Setup(logLevel, cfg.LogFormat, cfg.LogFile); err != nil {
+		fmt.Fprintf(os.Stderr, "错误: %s\n", err)
+		os.Exit(1)
+	}
+
+	printBanner()
+	if configPath != "" {
+		fmt.Fprintf(utils.Console, "📄 已加载配置文件: %s\n", configPath)
+	}
+	if cfg.Profile != "" {
+		fmt.Fprintf(utils.Console, "⚙️  使用配置: %s\n", cfg.Profile)
+	}
+	if policy != nil {
+		fmt.Fprintf(utils.Console, "🏢 已加载团队策略: %s\n", cfg.PolicyURL)
+		for _, change := range policy.Enforce(&cfg) {
+			fmt.Fprintf(utils.Console, "   策略调整: %s\n", change)
+		}
+	}
+
+	if cfg.ListFormats {
+		printFormats()
+		return
+	}
+
+	if flag.NArg() < 1 && cfg.PreCmd == "" {
+		fmt.Fprintln(os.Stderr, "用法: squirrel [选项] <域名列表文件、逗号分隔的域名列表或 - (从标准输入读取)>")
+		fmt.Fprintln(os.Stderr, "\n选项:")
 		flag.PrintDefaults()
 		os.Exit(1)
 	}
 
-	if (cfg.Screenshot || cfg.ScreenshotAlive) && cfg.ExcelFile == "" && htmlOutput == "" && simpleHTML == "" {
-		fmt.Println("错误: 启用截图功能时必须指定 -excel、-html 或 -simple-html 选项")
+	reportFilters, err := view.ReportFilters(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "错误: %s\n", err)
+		os.Exit(1)
+	}
+
+	outputs, err := collectOutputs(cfg, htmlOutput, simpleHTML, galleryOutput)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "错误: %s\n", err)
+		os.Exit(1)
+	}
+
+	if (cfg.Screenshot || cfg.ScreenshotAlive) && !hasScreenshotOutput(outputs) {
+		fmt.Fprintln(os.Stderr, "错误: 启用截图功能时必须指定 -excel、-html、-simple-html 选项或对应的 -format 输出")
+		os.Exit(1)
+	}
+
+	if cfg.SummaryLevel != "full" && cfg.SummaryLevel != "normal" && cfg.SummaryLevel != "minimal" {
+		fmt.Fprintf(os.Stderr, "错误: 无效的 -summary 取值: %s (可选 full、normal、minimal)\n", cfg.SummaryLevel)
+		os.Exit(1)
+	}
+
+	switch cfg.ExcelScreenshots {
+	case view.ExcelScreenshotsEmbed, view.ExcelScreenshotsLink, view.ExcelScreenshotsNone:
+	default:
+		fmt.Fprintf(os.Stderr, "错误: 无效的 -excel-screenshots 取值: %s (可选 embed、link、none)\n", cfg.ExcelScreenshots)
+		os.Exit(1)
+	}
+
+	if err := view.SetLanguage(cfg.Lang); err != nil {
+		fmt.Fprintf(os.Stderr, "错误: -lang %s\n", err)
+		os.Exit(1)
+	}
+	if cfg.Locale == "" {
+		cfg.Locale = view.DefaultLocale(cfg.Lang)
+	}
+	if err := view.SetLocale(cfg.Locale); err != nil {
+		fmt.Fprintf(os.Stderr, "错误: -locale %s\n", err)
+		os.Exit(1)
+	}
+
+	if err := checker.SetScreenshotName(cfg.ScreenshotName); err != nil {
+		fmt.Fprintf(os.Stderr, "错误: -screenshot-name %s\n", err)
+		os.Exit(1)
+	}
+
+	if cfg.OCR {
+		if !cfg.Screenshot && !cfg.ScreenshotAlive {
+			fmt.Fprintln(os.Stderr, "错误: -ocr 需要同时使用 -screenshot 或 -screenshot-alive")
+			os.Exit(1)
+		}
+		engine, err := ocr.New("tesseract", cfg.OCRLang, 60*time.Second)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %s\n", err)
+			os.Exit(1)
+		}
+		checker.SetOCR(engine)
+		fmt.Fprintf(utils.Console, "🔤 已启用截图文字识别 (语言: %s)\n", cfg.OCRLang)
+	}
+
+	if cfg.TemplateDir != "" {
+		if err := view.ValidateTemplateDir(cfg.TemplateDir); err != nil {
+			fmt.Fprintf(os.Stderr, "错误: 自定义模板无效: %s\n", err)
+			os.Exit(1)
+		}
+		view.SetTemplateDir(cfg.TemplateDir)
+		fmt.Fprintf(utils.Console, "🎨 使用自定义报告模板: %s\n", cfg.TemplateDir)
+	}
+
+	if err := checker.ValidateTLSConfig(cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "错误: %s\n", err)
+		os.Exit(1)
+	}
+	if !checker.ValidIPFamily(cfg.IPFamily) {
+		fmt.Fprintf(os.Stderr, "错误: 无效的 -ip-family 取值: %s (可选 auto、4、6、prefer4、prefer6、both)\n", cfg.IPFamily)
+		os.Exit(1)
+	}
+	cfg.Method = strings.ToUpper(cfg.Method)
+	if !checker.ValidMethod(cfg.Method) {
+		fmt.Fprintf(os.Stderr, "错误: 无效的 -method 取值: %s (可选 GET、HEAD、POST、OPTIONS)\n", cfg.Method)
+		os.Exit(1)
+	}
+	if path, ok := strings.CutPrefix(cfg.Data, "@"); ok {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "错误: 无法读取请求体文件: %s\n", err)
+			os.Exit(1)
+		}
+		cfg.Data = string(data)
+	}
+	if cfg.HeadFirst {
+		codes, err := checker.ParseStatusCodes(cfg.HeadFallback)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "错误: 无效的 -head-fallback: %s\n", err)
+			os.Exit(1)
+		}
+		checker.SetHeadFallback(codes)
+		if cfg.Method != "GET" || cfg.Data != "" {
+			fmt.Fprintf(utils.Console, "⚠️  -head-first 只对不带请求体的GET检测生效，已忽略\n")
+		} else {
+			fmt.Fprintf(utils.Console, "🪶 已启用HEAD预检: 只对存活的目标发送GET请求\n")
+		}
+	}
+	checker.SetMaxBodySize(int64(max(cfg.MaxBodyKB, 0)) * 1024)
+	if cfg.MaxBandwidthKB > 0 {
+		checker.SetBandwidthLimit(int64(cfg.MaxBandwidthKB) * 1024)
+		fmt.Fprintf(utils.Console, "🚦 带宽上限: %d KB/s\n", cfg.MaxBandwidthKB)
+	}
+	if cfg.Method != "GET" || cfg.Data != "" {
+		fmt.Fprintf(utils.Console, "📨 检测请求方法: %s", cfg.Method)
+		if cfg.Data != "" {
+			fmt.Fprintf(utils.Console, "，请求体 %d 字节", len(cfg.Data))
+		}
+		fmt.Fprintln(utils.Console)
+	}
+
+	headers, err := cfg.HeaderMap()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "错误: %s\n", err)
+		os.Exit(1)
+	}
+	cfg.HeaderValues = headers
+
+	if cfg.UAFile != "" {
+		userAgents, err := utils.LoadUserAgents(cfg.UAFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "无法读取User-Agent文件: %s\n", err)
+			os.Exit(1)
+		}
+		if len(userAgents) == 0 {
+			fmt.Fprintf(os.Stderr, "错误: User-Agent文件 %s 中没有可用的User-Agent\n", cfg.UAFile)
+			os.Exit(1)
+		}
+		utils.SetUserAgents(userAgents)
+	} else if cfg.RandomUA {
+		utils.SetUserAgents(nil)
+	}
+
+	if cfg.MatchRegex != "" {
+		if _, err := regexp.Compile(cfg.MatchRegex); err != nil {
+			fmt.Fprintf(os.Stderr, "错误: 无效的 -match-regex 正则表达式: %s\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if cfg.RulesFile != "" {
+		rules, err := checker.LoadRules(cfg.RulesFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %s\n", err)
+			os.Exit(1)
+		}
+		checker.SetRules(rules)
+		cfg.ExtractInfo = true
+		fmt.Fprintf(utils.Console, "🧩 已加载 %d 条页面分类规则: %s\n", len(rules), cfg.RulesFile)
+	}
+
+	if len(cfg.GeoIP) > 0 {
+		db, err := geoip.OpenAll(cfg.GeoIP)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %s\n", err)
+			os.Exit(1)
+		}
+		checker.SetGeoIP(db)
+		fmt.Fprintf(utils.Console, "🌍 已加载IP归属数据库: %s\n", strings.Join(db.Types(), ", "))
+	}
+
+	if cfg.Cloud {
+		ranges, fromFile, err := cloud.LoadRanges(cfg.CloudRanges)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %s\n", err)
+			os.Exit(1)
+		}
+		matcher := cloud.NewMatcher(ranges)
+		checker.SetCloudMatcher(matcher)
+		source := "内置地址段"
+		if fromFile {
+			source = cfg.CloudRanges
+		}
+		fmt.Fprintf(utils.Console, "☁️  已加载 %d 个云服务商地址段（%s，更新于 %s）\n", matcher.Len(), source, ranges.Updated)
+	}
+
+	checker.SetDNSCacheTTL(time.Duration(cfg.DNSCacheTTL) * time.Second)
+	screenshot.SetResolver(checker.CachedIPs)
+
+	timeoutOverrides, err := scheduler.LoadTimeoutOverrides(cfg.TimeoutFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "错误: %s\n", err)
+		os.Exit(1)
+	}
+	if timeoutOverrides.Len() > 0 {
+		fmt.Fprintf(utils.Console, "⏱️  已加载 %d 条按主机设置的超时\n", timeoutOverrides.Len())
+	}
+
+	var harRecorder *har.Recorder
+	if cfg.HAR != "" {
+		harRecorder = har.NewRecorder()
+		checker.SetHARRecorder(harRecorder)
+	}
+
+	credentials, err := auth.Load(cfg.Auth, cfg.AuthFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "错误: %s\n", err)
 		os.Exit(1)
 	}
+	if credentials != nil {
+		checker.SetCredentials(credentials)
+		screenshot.SetCredentials(credentials)
+		fmt.Fprintf(utils.Console, "🔑 已启用认证: %s\n", credentials)
+	}
+
+	if cfg.ClientCert != "" {
+		password := cfg.ClientCertPass
+		if name, ok := strings.CutPrefix(password, "env:"); ok {
+			password = os.Getenv(name)
+		}
+		cert, err := checker.LoadClientCertificate(cfg.ClientCert, cfg.ClientKey, password)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %s\n", err)
+			os.Exit(1)
+		}
+		checker.SetClientCertificate(cert)
+		fmt.Fprintf(utils.Console, "🪪 已加载客户端证书: %s\n", cfg.ClientCert)
+	}
+
+	var rawArchive *archive.Archive
+	if cfg.Archive != "" {
+		rawArchive, err = archive.Open(cfg.Archive)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %s\n", err)
+			os.Exit(1)
+		}
+		checker.SetRawArchive(rawArchive, cfg.ArchiveBodyKB*1024)
+		fmt.Fprintf(utils.Console, "🗄️  原始响应将保存到: %s\n", cfg.Archive)
+	}
+
+	if cfg.PathsFile != "" {
+		paths, err := utils.ReadDomainsFromFile(cfg.PathsFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "无法读取路径列表文件: %s\n", err)
+			os.Exit(1)
+		}
+		if len(paths) == 0 {
+			fmt.Fprintf(os.Stderr, "错误: 路径列表文件 %s 中没有可用的路径\n", cfg.PathsFile)
+			os.Exit(1)
+		}
+		checker.SetProbePaths(paths)
+		fmt.Fprintf(utils.Console, "📂 已启用路径探测: 每个存活主机 %d 个路径\n", len(paths))
+	}
+
+	permuteWords := permute.DefaultWords
+	if cfg.PermuteWords != "" {
+		words, err := utils.ReadDomainsFromFile(cfg.PermuteWords)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "无法读取排列关键词文件: %s\n", err)
+			os.Exit(1)
+		}
+		if len(words) == 0 {
+			fmt.Fprintf(os.Stderr, "错误: 排列关键词文件 %s 中没有可用的关键词\n", cfg.PermuteWords)
+			os.Exit(1)
+		}
+		permuteWords = words
+	}
+
+	if cfg.MethodsWrite {
+		cfg.Methods = true
+	}
+
+	if cfg.JARMList != "" {
+		known, err := jarm.LoadKnown(cfg.JARMList)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %s\n", err)
+			os.Exit(1)
+		}
+		checker.SetKnownJARM(known)
+		cfg.JARM = true
+		fmt.Fprintf(utils.Console, "🧬 已加载 %d 个已知JARM指纹\n", len(known))
+	}
+
+	if cfg.Ports != "" {
+		ports, err := checker.ParsePorts(cfg.Ports)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %s\n", err)
+			os.Exit(1)
+		}
+		checker.SetPorts(ports)
+		fmt.Fprintf(utils.Console, "🔌 已启用端口扫描: %d 个端口\n", len(ports))
+	}
 
 	var domains []string
-	var err error
 	arg := flag.Arg(0)
-	if strings.Contains(arg, ",") {
+	if arg == "-" {
+		domains, err = utils.ReadDomains(os.Stdin)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "无法读取标准输入: %s\n", err)
+			os.Exit(1)
+		}
+	} else if strings.Contains(arg, ",") {
 		domains = strings.Split(arg, ",")
-	} else {
+	} else if arg != "" {
 		domains, err = utils.ReadDomainsFromFile(arg)
 		if err != nil {
-			fmt.Printf("无法读取文件: %s\n", err)
+			fmt.Fprintf(os.Stderr, "无法读取文件: %s\n", err)
+			os.Exit(1)
+		}
+	}
+
+	// 执行输入预处理命令，获取额外的检测目标
+	if cfg.PreCmd != "" {
+		fmt.Fprintf(utils.Console, "⚙️  正在执行预处理命令: %s\n", cfg.PreCmd)
+		extraTargets, err := hook.RunPreCommand(cfg.PreCmd)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "错误: 预处理命令执行失败: %s\n", err)
 			os.Exit(1)
 		}
+		fmt.Fprintf(utils.Console, "✅ 预处理命令提供了 %d 个目标\n", len(extraTargets))
+		domains = append(domains, extraTargets...)
 	}
-	// 新增：归一化域名，支持 http(s):// 前缀
-	domainMap := make(map[string]bool)
+	// 归一化输入：URL保留协议/端口/路径，CIDR网段和IP范围展开为单个地址
+	// 主机名统一转为小写并去掉末尾的点，-host-only 时URL只保留主机名，之后去除重复的目标，
+	// 只有裸主机名与同一主机的根路径URL视为重复，保留先出现的写法
+	known := make(utils.TargetSet)   // 已有的检测目标
+	notes := make(map[string]string) // 检测的URL -> 备注（输入中 "host # note" 格式的备注等），由addNote记录
 	var uniqueDomains []string
-	for _, d := range domains {
-		d = strings.TrimSpace(d)
-		if strings.HasPrefix(d, "http://") || strings.HasPrefix(d, "https://") {
-			if u, err := url.Parse(d); err == nil && u.Host != "" {
-				if !domainMap[u.Host] {
-					domainMap[u.Host] = true
-					uniqueDomains = append(uniqueDomains, u.Host)
-				}
-			} else {
-				if !domainMap[d] {
-					domainMap[d] = true
-					uniqueDomains = append(uniqueDomains, d)
-				}
+	duplicates := 0
+	for _, line := range domains {
+		line, note := utils.SplitNote(line)
+		targets, err := utils.ExpandTarget(line)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %s\n", err)
+			os.Exit(1)
+		}
+		for _, d := range targets {
+			if cfg.HostOnly {
+				d = utils.HostOnly(d)
 			}
-		} else {
-			if !domainMap[d] {
-				domainMap[d] = true
+			kept, duplicate := known.Find(d)
+			if duplicate {
+				duplicates++
+			} else {
+				known.Add(d)
+				kept = d
 				uniqueDomains = append(uniqueDomains, d)
 			}
+			if note != "" {
+				addNote(notes, kept, note)
+			}
 		}
 	}
 	domains = uniqueDomains
+	if duplicates > 0 {
+		fmt.Fprintf(utils.Console, "🧹 已去除 %d 个重复的目标（归一化后剩余 %d 个）\n", duplicates, len(domains))
+	}
+
+	// 剔除团队策略、-exclude 和 -scope 之外的目标，跳过的目标在报告中单独列出
+	targetScope, err := scope.New(cfg.Exclude, policy.ExcludeRules(), cfg.ScopeFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "错误: %s\n", err)
+		os.Exit(1)
+	}
+	if !targetScope.Empty() {
+		checker.SetScope(targetScope)
+		screenshot.SetScope(targetScope)
+	}
+	var skipped []view.SkippedTarget
+	inScope := domains[:0]
+	policySkipped := 0
+	for _, d := range domains {
+		if ok, reason := targetScope.Check(utils.HostFromURL(d)); !ok {
+			skipped = append(skipped, view.SkippedTarget{Target: d, Reason: reason})
+			if reason == scope.PolicyExcluded {
+				policySkipped++
+			}
+			continue
+		}
+		inScope = append(inScope, d)
+	}
+	domains = inScope
+	if policySkipped > 0 {
+		fmt.Fprintf(utils.Console, "🚫 %d 个目标不在团队策略允许的范围内，已跳过\n", policySkipped)
+	}
+	if scopeSkipped := len(skipped) - policySkipped; scopeSkipped > 0 {
+		fmt.Fprintf(utils.Console, "🚫 %d 个目标不在扫描范围内（-exclude/-scope），已跳过\n", scopeSkipped)
+	}
+
+	// 域传送：向根域名的权威DNS服务器尝试AXFR，传回的主机名作为新目标检测
+	if cfg.AXFR {
+		roots := rootDomains(domains)
+		fmt.Fprintf(utils.Console, "🧾 正在尝试 %d 个根域名的域传送...\n", len(roots))
+		transfers := axfr.AttemptAll(roots, time.Duration(cfg.Timeout)*time.Second, 4)
+		view.SetZoneTransfers(transfers)
+		imported := 0
+		for _, transfer := range transfers {
+			if !transfer.Vulnerable() {
+				continue
+			}
+			fmt.Fprintf(utils.Console, "🚨 %s 的DNS服务器 %s 允许域传送，传回 %d 条记录\n", transfer.Domain, strings.Join(transfer.Allowed, ", "), transfer.Records)
+			for _, host := range transfer.Hosts {
+				if !known.Add(host) {
+					continue
+				}
+				if ok, reason := targetScope.Check(host); !ok {
+					skipped = append(skipped, view.SkippedTarget{Target: host, Reason: reason})
+					continue
+				}
+				addNote(notes, host, "域传送记录，来自 "+transfer.Allowed[0])
+				domains = append(domains, host)
+				imported++
+			}
+		}
+		if imported > 0 {
+			fmt.Fprintf(utils.Console, "🧾 从域传送记录中导入了 %d 个新目标\n", imported)
+		}
+	}
+	view.SetSkippedTargets(skipped)
 	if len(domains) == 0 {
-		fmt.Println("没有找到需要检测的域名")
+		fmt.Fprintln(os.Stderr, "没有找到需要检测的域名")
 		os.Exit(1)
 	}
 
-	fmt.Printf("总共需要检测 %d 个域名，并发数: %d，超时: %d秒\n",
+	if cfg.DryRun {
+		printDryRun(cfg, domains, len(skipped), targetScope, outputs)
+		return
+	}
+
+	// 虚拟主机探测：发现的虚拟主机固定解析到所在IP，作为新目标与其他目标一起检测
+	if cfg.VHostFile != "" {
+		candidates, err := utils.ReadDomainsFromFile(cfg.VHostFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "无法读取虚拟主机列表文件: %s\n", err)
+			os.Exit(1)
+		}
+		// 被团队策略或 -exclude/-scope 排除的主机名不会作为Host头发送
+		var vhostNames []string
+		excluded := 0
+		for _, name := range candidates {
+			name = strings.ToLower(strings.TrimSuffix(name, "."))
+			if !strings.Contains(name, ".") && cfg.VHostDomain != "" {
+				name += "." + strings.TrimPrefix(cfg.VHostDomain, ".")
+			}
+			if ok, _ := targetScope.Check(name); !ok {
+				excluded++
+				continue
+			}
+			vhostNames = append(vhostNames, name)
+		}
+		if excluded > 0 {
+			fmt.Fprintf(utils.Console, "🚫 %d 个虚拟主机名不在扫描范围内（团队策略/-exclude/-scope），已跳过\n", excluded)
+		}
+		fmt.Fprintf(utils.Console, "🏘️  正在对 %d 个目标探测 %d 个虚拟主机名...\n", len(domains), len(vhostNames))
+		var vhostIPs map[string]string
+		domains, vhostIPs = discoverVHosts(domains, vhostNames, cfg, known, notes)
+		screenshot.SetHostRules(vhostIPs)
+		fmt.Fprintf(utils.Console, "🏘️  发现 %d 个虚拟主机\n", len(vhostIPs))
+	}
+
+	fmt.Fprintf(utils.Console, "总共需要检测 %d 个域名，并发数: %d，超时: %d秒\n",
 		len(domains), cfg.Concurrency, cfg.Timeout)
 
 	startTime := time.Now()
 	totalDomains := len(domains)
 
 	resultChan := make(chan checker.Result, totalDomains*2)
-	domainChan := make(chan string, totalDomains)
 	doneChan := make(chan struct{})
+	progressStop := make(chan struct{})
 	progressDone := make(chan struct{})
-	var wg sync.WaitGroup
+
+	// 根域名注册信息与检测并行查询，生成总结前等待查询完成
+	var whoisDone chan struct{}
+	if cfg.Whois {
+		roots := rootDomains(domains)
+		fmt.Fprintf(utils.Console, "📇 正在查询 %d 个根域名的注册信息...\n", len(roots))
+		whoisDone = make(chan struct{})
+		go func() {
+			defer close(whoisDone)
+			view.SetDomainRecords(whois.LookupAll(roots, time.Duration(cfg.Timeout)*time.Second, 4))
+		}()
+	}
+
+	// 中断时生成已完成部分的报告，而不是丢弃整个扫描
+	stopping, force := setupGracefulShutdown(time.Duration(cfg.ShutdownTimeout) * time.Second)
 
 	var screenshotPool *screenshot.ScreenshotPool
 	if cfg.Screenshot || cfg.ScreenshotAlive {
@@ -378,137 +891,1126 @@ func main() {
 
 		// 设置全局并发数，用于动态调整超时
 		screenshot.SetConcurrency(screenshotWorkers)
+		screenshot.SetExtraHeaders(headers)
 
-		fmt.Printf("🚀 最终截图并发数: %d 个工作者\n", screenshotWorkers)
+		fmt.Fprintf(utils.Console, "🚀 最终截图并发数: %d 个工作者\n", screenshotWorkers)
 		screenshotPool = screenshot.NewScreenshotPool(screenshotWorkers)
 		screenshotPool.Start()
-
-		// 设置优雅关闭处理器
-		setupGracefulShutdown(screenshotPool)
 	}
 
-	var processed int32 = 0
-	go view.ShowProgress(&processed, totalDomains, startTime, doneChan, progressDone)
+	// 扫描统计，由下方的结果处理流程写入，进度显示、总结和报告读取
+	scanStats := stats.New()
+	go view.ShowProgress(scanStats, totalDomains, startTime, progressStop, progressDone)
 
 	var resultsMutex sync.Mutex
 	allResults := make([]checker.Result, 0, totalDomains)
-	var alive, dead int32
-	var pageTypeCountMutex sync.Mutex
-	var pageTypeCount = make(map[string]int)
-	var screenshotCount int32 = 0
 
-	const batchSize = 10
-	resultBatchChan := make(chan []checker.Result, totalDomains/batchSize+1)
-	go func() {
-		for resultBatch := range resultBatchChan {
-			resultsMutex.Lock()
-			for _, result := range resultBatch {
-				if result.Alive {
-					atomic.AddInt32(&alive, 1)
-					if result.PageInfo != nil {
-						pageTypeCountMutex.Lock()
-						pageTypeCount[result.PageInfo.Type]++
-						pageTypeCountMutex.Unlock()
-					}
-				} else {
-					atomic.AddInt32(&dead, 1)
-				}
-				if result.Screenshot != "" {
-					if cfg.ScreenshotAlive {
-						if result.Alive {
-							atomic.AddInt32(&screenshotCount, 1)
-						}
-					} else if cfg.Screenshot {
-						atomic.AddInt32(&screenshotCount, 1)
-					}
-				}
-				allResults = append(allResults, result)
+	// 结构化进度事件（JSON行），供图形界面等外部程序读取
+	var progressHandler view.ProgressHandler
+	if cfg.ProgressFD > 0 {
+		progressHandler = view.JSONLinesProgressHandler(os.NewFile(uintptr(cfg.ProgressFD), "progress"))
+		go view.EmitProgressEvents(scanStats, totalDomains, startTime, time.Second, progressStop, progressHandler)
+	}
+
+	// 事件总线：统计、通知、后处理和存储订阅检测结果事件，扫描结束后由写入器和通知订阅完成事件。
+	// 结果事件只在下方的批处理goroutine中发布，处理函数不会并发执行
+	bus := event.NewBus()
+	bus.Subscribe(event.ResultCompleted, func(e event.Event) {
+		scanStats.Record(e.Result)
+	})
+	bus.Subscribe(event.ScreenshotSaved, func(e event.Event) {
+		if !cfg.ScreenshotAlive || e.Result.Alive {
+			scanStats.RecordScreenshot()
+		}
+	})
+	if cfg.Silent {
+		bus.Subscribe(event.ResultCompleted, func(e event.Event) {
+			if e.Result.Alive {
+				fmt.Fprintln(os.Stdout, e.Result.Domain)
 			}
-			resultsMutex.Unlock()
+		})
+	}
+
+	// 实时报告：扫描期间在浏览器中查看已完成的结果，新结果通过SSE推送到页面
+	var liveReport *view.LiveReport
+	var liveAddr string
+	if cfg.LiveReport != "" {
+		liveReport = view.NewLiveReport("screenshots", len(domains))
+		liveAddr, err = liveReport.Start(cfg.LiveReport)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "错误: 无法启动实时报告: %s\n", err)
+			os.Exit(1)
 		}
-	}()
+		fmt.Fprintf(utils.Console, "📡 实时报告: http://%s/\n", liveAddr)
+		bus.Subscribe(event.ResultCompleted, func(e event.Event) {
+			liveReport.Add(e.Result)
+		})
+		bus.Subscribe(event.ScanFinished, func(event.Event) {
+			liveReport.Finish()
+		})
+	}
 
-	go func() {
-		var resultBatch []checker.Result
-		for result := range resultChan {
-			atomic.AddInt32(&processed, 1)
-			resultBatch = append(resultBatch, result)
-			if len(resultBatch) >= batchSize || atomic.LoadInt32(&processed) == int32(totalDomains) {
-				resultBatchChan <- resultBatch
-				resultBatch = nil
-			}
+	// 结果后处理命令
+	var postProcessor *hook.PostProcessor
+	if cfg.PostCmd != "" {
+		postProcessor = hook.NewPostProcessor(cfg.PostCmd, cfg.PostBatch, cfg.Verbose)
+		postProcessor.Start()
+		bus.Subscribe(event.ResultCompleted, func(e event.Event) {
+			postProcessor.Submit(e.Result)
+		})
+	}
+
+	// Slack/Discord通知
+	var dispatcher *notify.Dispatcher
+	var notifiers []notify.Notifier
+	if cfg.SlackWebhook != "" {
+		notifiers = append(notifiers, &notify.SlackNotifier{WebhookURL: cfg.SlackWebhook})
+	}
+	if cfg.DiscordWebhook != "" {
+		notifiers = append(notifiers, &notify.DiscordNotifier{WebhookURL: cfg.DiscordWebhook})
+	}
+	if len(notifiers) > 0 {
+		interval := time.Duration(cfg.NotifyInterval) * time.Second
+		if interval <= 0 {
+			interval = time.Second
 		}
-		if len(resultBatch) > 0 {
-			resultBatchChan <- resultBatch
+		dispatcher, err = notify.NewDispatcher(notifiers, cfg.NotifyOn, interval)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %s\n", err)
+			os.Exit(1)
 		}
-		close(resultBatchChan)
-		close(doneChan)
-	}()
-
-	for i := 0; i < cfg.Concurrency; i++ {
-		wg.Add(1)
-		go func(workerId int) {
-			defer wg.Done()
-			for domain := range domainChan {
-				checker.CheckDomain(domain, cfg, resultChan, screenshotPool)
+		dispatcher.Start()
+		// 有发现的结果随 FindingRaised 提交，没有发现的存活结果随 ResultCompleted 提交（只在 -notify-on alive 时发送）
+		bus.Subscribe(event.FindingRaised, func(e event.Event) {
+			dispatcher.Submit(e.Result, e.Reasons)
+		})
+		bus.Subscribe(event.ResultCompleted, func(e event.Event) {
+			if len(e.Reasons) == 0 {
+				dispatcher.Submit(e.Result, nil)
 			}
-		}(i)
+		})
 	}
-	for _, domain := range domains {
-		domainChan <- domain
+
+	// 写入Elasticsearch和数据库时标识本次扫描的ID
+	scanID := cfg.ESScanID
+	if scanID == "" {
+		scanID = "scan-" + startTime.Format("20060102-150405")
 	}
-	close(domainChan)
-	wg.Wait()
 
-	// 在所有域名检查完成后，关闭截图工作池
-	if screenshotPool != nil {
-		fmt.Printf("📸 正在停止截图工作池...\n")
-		screenshotPool.Stop()
+	// Elasticsearch/OpenSearch结果写入
+	var elasticSink *sink.ElasticSink
+	if cfg.ESURL != "" {
+		elasticSink = sink.NewElasticSink(cfg.ESURL, cfg.ESIndex, scanID)
+		elasticSink.Start()
+		bus.Subscribe(event.ResultCompleted, func(e event.Event) {
+			elasticSink.Submit(e.Result)
+		})
 	}
 
-	close(resultChan)
-	<-doneChan
-	<-progressDone
+	// PostgreSQL/MySQL结果写入
+	var sqlSink *sink.SQLSink
+	if cfg.DatabaseDSN != "" {
+		sqlSink, err = sink.NewSQLSink(cfg.DatabaseDSN, scanID, version)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %s\n", err)
+			os.Exit(1)
+		}
+		sqlSink.Start()
+		bus.Subscribe(event.ResultCompleted, func(e event.Event) {
+			sqlSink.Submit(e.Result)
+		})
+	}
+
+	// syslog（CEF/RFC5424）结果发送
+	var syslogSink *sink.SyslogSink
+	if cfg.Syslog != "" {
+		syslogSink, err = sink.NewSyslogSink(cfg.Syslog, cfg.SyslogFormat, version)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %s\n", err)
+			os.Exit(1)
+		}
+		syslogSink.Start()
+		bus.Subscribe(event.ResultCompleted, func(e event.Event) {
+			syslogSink.Submit(e.Result)
+		})
+	}
+
+	// 逐个结果的JSON行输出，供调度程序在扫描过程中读取
+	var resultsSink *sink.JSONLinesSink
+	if cfg.ResultsFD > 0 {
+		resultsSink = sink.NewJSONLinesSink(os.NewFile(uintptr(cfg.ResultsFD), "results"))
+		resultsSink.Start()
+		bus.Subscribe(event.ResultCompleted, func(e event.Event) {
+			resultsSink.Submit(e.Result)
+		})
+	}
+
+	const batchSize = 10
+	resultBatchChan := make(chan []checker.Result, totalDomains/batchSize+1)
+	batchDone := make(chan struct{})
+	// 将一批结果加入汇总并发布结果事件，调用方需持有resultsMutex。
+	// 订阅者（后处理命令、Elasticsearch、syslog）把结果放入无界队列后立即返回，不会在持锁期间阻塞
+	collectBatch := func(resultBatch []checker.Result) {
+		for _, result := range resultBatch {
+			result.Note = notes[result.Domain]
+			allResults = append(allResults, result)
+			bus.PublishResult(result)
+		}
+	}
+	go func() {
+		defer close(batchDone)
+		for resultBatch := range resultBatchChan {
+			resultsMutex.Lock()
+			collectBatch(resultBatch)
+			resultsMutex.Unlock()
+		}
+	}()
+
+	go func() {
+		var resultBatch []checker.Result
+		interrupted := false
+		stop := stopping
+		// 结果较少时定期写入未满的批次，使进度中的存活/无法访问计数及时更新
+		flushTicker := time.NewTicker(time.Second)
+		defer flushTicker.Stop()
+		for {
+			select {
+			case result, ok := <-resultChan:
+				if !ok {
+					if len(resultBatch) > 0 {
+						resultBatchChan <- resultBatch
+					}
+					close(resultBatchChan)
+					close(doneChan)
+					return
+				}
+				scanStats.AddProcessed()
+				resultBatch = append(resultBatch, result)
+				if len(resultBatch) >= batchSize || scanStats.Processed() == totalDomains || interrupted {
+					resultBatchChan <- resultBatch
+					resultBatch = nil
+				}
+			case <-flushTicker.C:
+				if len(resultBatch) > 0 {
+					resultBatchChan <- resultBatch
+					resultBatch = nil
+				}
+			case <-stop:
+				// 中断后不再攒批，已收到的结果立即加入汇总，以便随时生成报告
+				interrupted = true
+				stop = nil
+				if len(resultBatch) > 0 {
+					resultBatchChan <- resultBatch
+					resultBatch = nil
+				}
+			}
+		}
+	}()
+
+	// 自适应并发：启动max个工作者，由限制器控制同时进行的请求数
+	workers := cfg.Concurrency
+	var limiter *scheduler.AdaptiveLimiter
+	if cfg.Adaptive {
+		maxConcurrency := cfg.MaxConcurrency
+		if maxConcurrency <= 0 {
+			maxConcurrency = cfg.Concurrency * 4
+		}
+		limiter = scheduler.NewAdaptiveLimiter(cfg.Concurrency, cfg.MinConcurrency, maxConcurrency)
+		limiter.OnAdjust(func(old, new int, errRate float64, avgLatency time.Duration) {
+			logger.Debug("并发数调整", "from", old, "to", new, "error_rate", fmt.Sprintf("%.1f%%", errRate*100), "latency", avgLatency.Round(time.Millisecond))
+		})
+		workers = maxConcurrency
+		fmt.Fprintf(utils.Console, "⚙️  已启用自适应并发: 初始 %d，范围 %d-%d\n", cfg.Concurrency, max(cfg.MinConcurrency, 1), maxConcurrency)
+	}
+
+	var fedTargets atomic.Int64
+
+	// 扫描状态接口：排队、进行中和已完成的数量以及正在检测的域名，用于排查卡住的扫描
+	activeTargets := stats.NewActive()
+	statusSource := view.StatusSource{
+		Stats:     scanStats,
+		Active:    activeTargets,
+		StartTime: startTime,
+		Total:     func() int { return totalDomains + int(fedTargets.Load()) },
+		Concurrency: func() int {
+			if limiter != nil {
+				current, _ := limiter.Stats()
+				return current
+			}
+			return workers
+		},
+	}
+	if liveReport != nil {
+		liveReport.SetStatus(statusSource)
+	}
+	closeStatus := func() {}
+	if cfg.StatusSocket != "" {
+		closeStatus, err = view.ServeStatusSocket(cfg.StatusSocket, statusSource)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "错误: 无法启动状态接口: %s\n", err)
+			os.Exit(1)
+		}
+		fmt.Fprintf(utils.Console, "🩺 扫描状态: curl --unix-socket %s http://localhost/status\n", cfg.StatusSocket)
+	}
+
+	// 双通道调度：快速通道使用较短的超时，超时的域名放入慢速通道，在其他域名完成后使用较长的超时重试，
+	// 避免少数响应极慢的主机拖慢整体进度
+	fastLane := cfg.FastTimeout > 0 && cfg.FastTimeout < cfg.Timeout
+	fastCfg := cfg
+	slowCfg := cfg
+	switch {
+	case cfg.SlowTimeout > 0:
+		slowCfg.Timeout = cfg.SlowTimeout
+	case !fastLane:
+		slowCfg.Timeout = cfg.Timeout * 2
+	}
+	if fastLane {
+		fastCfg.Timeout = cfg.FastTimeout
+		fmt.Fprintf(utils.Console, "⚡ 已启用快速通道: 超时 %d 秒，超时的域名稍后以 %d 秒超时重试\n", cfg.FastTimeout, slowCfg.Timeout)
+	}
+	var slowLaneMutex sync.Mutex
+	var slowLane []string
+	var timedOut, deferred int
+	// 放入慢速通道，counter记录放入的原因
+	deferTarget := func(domain string, counter *int) {
+		slowLaneMutex.Lock()
+		slowLane = append(slowLane, domain)
+		*counter++
+		slowLaneMutex.Unlock()
+	}
+
+	// 慢主机隔离：同一主机反复超时后，其余目标不再占用工作者等待超时，推迟到慢速通道检测
+	quarantine := scheduler.NewQuarantine(cfg.QuarantineAfter)
+
+	// 端口扫描发现的Web端口（-port-feed），在主检测完成后作为新目标检测
+	var feedMutex sync.Mutex
+	var discovered []string
+	// 能解析的主机（-permute），在主检测完成后据此生成排列候选
+	permuteSeeds := make(map[string]bool)
+
+	// 检测单个域名。firstPass为true时表示首轮检测：被隔离主机的域名直接推迟到慢速通道，
+	// 启用快速通道时超时的域名也放入慢速通道而不发送结果
+	checkTarget := func(domain string, laneCfg config.Config, firstPass bool) {
+		host := targetHost(domain)
+		if timeout, ok := timeoutOverrides.Lookup(host); ok {
+			// 单独设置了超时的主机不参与快速通道和隔离
+			laneCfg.Timeout = timeout
+			firstPass = false
+		} else if quarantine.Contains(host) {
+			if firstPass {
+				deferTarget(domain, &deferred)
+				return
+			}
+			laneCfg.Timeout = max(laneCfg.Timeout, slowCfg.Timeout)
+		}
+		if limiter != nil {
+			limiter.Acquire()
+		}
+		result := checker.Check(domain, laneCfg)
+		if limiter != nil {
+			limiter.Release(result.ErrorType.Transient(), result.ResponseTime)
+		}
+		if cfg.PortFeed {
+			for _, target := range checker.WebTargets(result) {
+				feedMutex.Lock()
+				if known.Add(target) {
+					discovered = append(discovered, target)
+				}
+				feedMutex.Unlock()
+			}
+		}
+		if cfg.Permute && result.IP != "" {
+			feedMutex.Lock()
+			permuteSeeds[host] = true
+			feedMutex.Unlock()
+		}
+		if firstPass && result.ErrorType == checker.ErrorTimeout {
+			if quarantine.RecordTimeout(host) {
+				logger.Debug("主机反复超时，已隔离", "host", host, "timeouts", cfg.QuarantineAfter)
+			}
+			if fastLane {
+				deferTarget(domain, &timedOut)
+				return
+			}
+		}
+		checker.Deliver(result, cfg, resultChan, screenshotPool)
+	}
+
+	// 启动工作者检测一组域名，等待全部完成
+	runWorkers := func(targets []string, laneCfg config.Config, firstPass bool) {
+		domainChan := make(chan string, len(targets))
+		for _, domain := range targets {
+			domainChan <- domain
+		}
+		close(domainChan)
+
+		var wg sync.WaitGroup
+		for i := 0; i < min(workers, len(targets)); i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for domain := range domainChan {
+					select {
+					case <-stopping:
+						return
+					default:
+					}
+					activeTargets.Begin(domain)
+					checkTarget(domain, laneCfg, firstPass)
+					activeTargets.End(domain)
+				}
+			}()
+		}
+		wg.Wait()
+	}
+
+	// 检测在单独的goroutine中进行，中断后等待超时时不必等它结束
+	scanDone := make(chan struct{})
+	go func() {
+		defer close(scanDone)
+		runWorkers(domains, fastCfg, true)
+		if hosts := quarantine.Hosts(); len(hosts) > 0 {
+			fmt.Fprintf(utils.Console, "\n🚧 %d 个主机累计超时 %d 次被隔离: %s\n", len(hosts), cfg.QuarantineAfter, strings.Join(hosts, ", "))
+		}
+		if len(slowLane) > 0 {
+			select {
+			case <-stopping:
+				fmt.Fprintf(utils.Console, "\n🐢 扫描已中断，跳过慢速通道中的 %d 个域名\n", len(slowLane))
+			default:
+				var reasons []string
+				if timedOut > 0 {
+					reasons = append(reasons, fmt.Sprintf("%d 个域名在快速通道中超时", timedOut))
+				}
+				if deferred > 0 {
+					reasons = append(reasons, fmt.Sprintf("%d 个域名所在主机被隔离", deferred))
+				}
+				fmt.Fprintf(utils.Console, "\n🐢 %s，正在以 %d 秒超时重试...\n", strings.Join(reasons, "，"), slowCfg.Timeout)
+				runWorkers(slowLane, slowCfg, false)
+			}
+		}
+		if cfg.Permute && len(permuteSeeds) > 0 {
+			select {
+			case <-stopping:
+			default:
+				if permuted := resolvePermutations(permuteSeeds, permuteWords, known, targetScope, workers, cfg); len(permuted) > 0 {
+					fedTargets.Add(int64(len(permuted)))
+					if liveReport != nil {
+						liveReport.AddTotal(len(permuted))
+					}
+					runWorkers(permuted, fastCfg, false)
+				}
+			}
+		}
+		if len(discovered) > 0 {
+			select {
+			case <-stopping:
+			default:
+				fmt.Fprintf(utils.Console, "\n🔌 端口扫描发现 %d 个新的Web服务，正在检测...\n", len(discovered))
+				fedTargets.Add(int64(len(discovered)))
+				if liveReport != nil {
+					liveReport.AddTotal(len(discovered))
+				}
+				runWorkers(discovered, fastCfg, false)
+			}
+		}
+		if skipped := checker.HeadSkipped(); skipped > 0 {
+			fmt.Fprintf(utils.Console, "\n🪶 HEAD预检: %d 个目标无法访问，省去了GET请求\n", skipped)
+		}
+		if limiter != nil {
+			current, peak := limiter.Stats()
+			fmt.Fprintf(utils.Console, "\n📈 自适应并发: 结束时 %d，最高 %d\n", current, peak)
+		}
+
+		// 在所有域名检查完成后，等待剩余截图完成并关闭截图工作池
+		checker.WaitPendingScreenshots()
+		if screenshotPool != nil {
+			fmt.Fprintf(utils.Console, "📸 正在停止截图工作池...\n")
+			screenshotPool.Stop()
+		}
+
+		close(resultChan)
+		<-doneChan
+		<-batchDone
+	}()
+
+	select {
+	case <-scanDone:
+	case <-force:
+		// 不再等待进行中的检测：持有resultsMutex直到程序退出，使结果汇总停止变化，
+		// 已经攒好但尚未加入汇总的批次在这里补上
+		resultsMutex.Lock()
+	drain:
+		for {
+			select {
+			case resultBatch, ok := <-resultBatchChan:
+				if !ok {
+					break drain
+				}
+				collectBatch(resultBatch)
+			default:
+				break drain
+			}
+		}
+	}
+	close(progressStop)
+	<-progressDone
+
+	interrupted := false
+	select {
+	case <-stopping:
+		interrupted = true
+	default:
+	}
+
+	if postProcessor != nil {
+		postProcessor.Stop()
+	}
+	if elasticSink != nil {
+		indexed, failed := elasticSink.Stop()
+		fmt.Fprintf(utils.Console, "\n📦 Elasticsearch写入完成: 成功%d条, 失败%d条 (索引: %s)\n", indexed, failed, cfg.ESIndex)
+	}
+
+	if rawArchive != nil {
+		if err := rawArchive.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "⚠️  关闭原始响应归档失败: %s\n", err)
+		} else {
+			fmt.Fprintf(utils.Console, "🗄️  已保存 %d 个原始响应: %s\n", rawArchive.Count(), cfg.Archive)
+		}
+	}
+
+	if harRecorder != nil {
+		if err := harRecorder.Save(cfg.HAR, version); err != nil {
+			fmt.Fprintf(os.Stderr, "⚠️  保存HAR文件失败: %s\n", err)
+		} else {
+			fmt.Fprintf(utils.Console, "🧾 HAR文件已保存到 %s (%d 个请求)\n", cfg.HAR, harRecorder.Len())
+		}
+	}
+
+	if sqlSink != nil {
+		stored, failed := sqlSink.Stop()
+		fmt.Fprintf(utils.Console, "🗃️  数据库写入完成: 成功%d条, 失败%d条 (%s, scan_id: %s)\n", stored, failed, sqlSink, scanID)
+	}
+
+	if syslogSink != nil {
+		sent, failed := syslogSink.Stop()
+		fmt.Fprintf(utils.Console, "📡 syslog发送完成: 成功%d条, 失败%d条 (%s)\n", sent, failed, cfg.Syslog)
+	}
+
+	if resultsSink != nil {
+		resultsSink.Stop()
+	}
+
+	if progressHandler != nil {
+		progressHandler(view.NewProgressEvent("done", scanStats, totalDomains, startTime))
+	}
 
 	// 程序正常结束时清理资源
 	if cfg.Screenshot || cfg.ScreenshotAlive {
 		cleanupChromeProcesses()
 	}
 
-	fmt.Printf("\r%-80s\r", " ")
+	fmt.Fprintf(utils.Console, "\r%-80s\r", " ")
 	totalTime := time.Since(startTime)
-	view.PrintSummary(len(domains), int(atomic.LoadInt32(&alive)), int(atomic.LoadInt32(&dead)), &cfg, pageTypeCount, &pageTypeCountMutex, atomic.LoadInt32(&screenshotCount), totalTime)
+	if whoisDone != nil {
+		select {
+		case <-whoisDone:
+		case <-force:
+		}
+	}
+	summaryStats := scanStats.Snapshot()
+	scanTotal := len(domains) + int(fedTargets.Load())
+	view.PrintSummary(allResults, scanTotal, summaryStats, &cfg, totalTime)
+	if interrupted {
+		fmt.Fprintf(utils.Console, "⚠️  扫描被中断，报告只包含已完成的 %d/%d 个域名\n", len(allResults), scanTotal)
+	}
 
-	if cfg.OutputFile != "" {
-		err := view.SaveResultsToFile(allResults, cfg.OutputFile)
-		if err != nil {
-			fmt.Printf("保存结果到文件时出错: %s\n", err)
+	// 写入各输出格式；有报告写入失败时，写入完整结果的CSV/JSON备份，确保长时间扫描的数据不会丢失
+	bus.Subscribe(event.ScanFinished, func(e event.Event) {
+		view.SetSizeLimits(cfg.MaxExcelSize<<20, cfg.MaxHTMLSize<<20)
+		view.SetExcelScreenshots(cfg.ExcelScreenshots)
+		view.SetExcelSlowThreshold(cfg.ExcelSlowMs)
+		view.SetExcelPerRoot(cfg.ExcelPerRoot)
+		view.SetAnonymizeKey(cfg.AnonKey)
+		view.SetConfigSnapshot(config.Snapshot())
+		var failedReports []string // 写入失败的报告文件
+		opts := view.WriteOptions{OnlyAlive: cfg.OnlyAlive, Split: cfg.Split, Filters: reportFilters}
+		var writeErrs []error
+		if cfg.Deterministic {
+			// 确定性模式：报告按域名排序并使用扫描开始时间，不修改保存和推送的结果
+			writeErrs = view.RenderDeterministic(e.Scan.Results, startTime, outputs, opts)
 		} else {
-			fmt.Printf("结果已保存到 %s\n", cfg.OutputFile)
+			writeErrs = view.WriteAll(outputs, e.Scan.Results, opts)
+		}
+		for i, output := range outputs {
+			if err := writeErrs[i]; err != nil {
+				failedReports = append(failedReports, output.Filename)
+				logger.Error("保存"+output.Format.Description+"时出错", "file", output.Filename, "error", err)
+			} else {
+				fmt.Fprintf(utils.Console, "%s已保存到 %s\n", output.Format.Description, output.Location(e.Scan.Results, opts))
+			}
+		}
+
+		if len(failedReports) > 0 {
+			fmt.Fprintf(utils.Console, "⚠️  %d 个报告写入失败: %s\n", len(failedReports), strings.Join(failedReports, ", "))
+			if written := view.WriteFallback(e.Scan.Results, failedReports[0]); len(written) > 0 {
+				fmt.Fprintf(utils.Console, "💾 完整结果已备份到 %s\n", strings.Join(written, ", "))
+			}
+		}
+	})
+
+	// 发送扫描完成通知
+	if dispatcher != nil {
+		bus.Subscribe(event.ScanFinished, func(e event.Event) {
+			summary := notify.Summary{
+				Total:     e.Scan.Total,
+				Alive:     e.Scan.Alive,
+				Dead:      e.Scan.Dead,
+				Duration:  e.Scan.Duration,
+				ReportURL: cfg.ReportURL,
+			}
+			if summary.ReportURL == "" {
+				for _, path := range []string{htmlOutput, simpleHTML, cfg.ExcelFile, cfg.OutputFile} {
+					if path != "" {
+						summary.ReportURL = path
+						break
+					}
+				}
+				if summary.ReportURL == "" && len(outputs) > 0 {
+					summary.ReportURL = outputs[0].Filename
+				}
+			}
+			for _, result := range e.Scan.Results {
+				if reasons := result.FindingReasons(); len(reasons) > 0 && len(summary.TopFindings) < 10 {
+					summary.TopFindings = append(summary.TopFindings, notify.FormatFinding(result, reasons))
+				}
+			}
+			dispatcher.Finish(summary)
+		})
+	}
+
+	bus.Publish(event.Event{Type: event.ScanFinished, Scan: &event.ScanInfo{
+		Total:    scanTotal,
+		Alive:    summaryStats.Alive,
+		Dead:     summaryStats.Dead,
+		Duration: totalTime,
+		Results:  allResults,
+	}})
+
+	// 扫描完成后继续提供实时报告，直到用户中断
+	if liveReport != nil && !interrupted {
+		signal.Reset(os.Interrupt, syscall.SIGTERM)
+		c := make(chan os.Signal, 1)
+		signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+		fmt.Fprintf(utils.Console, "📡 扫描已完成，实时报告仍可在 http://%s/ 查看，按 Ctrl+C 退出\n", liveAddr)
+		<-c
+	}
+	closeStatus()
+
+	// 被中断的扫描以非零状态退出，便于脚本区分完整和部分的结果
+	if interrupted {
+		os.Exit(130)
+	}
+}
+
+// 对所有目标探测虚拟主机（共用一个并发数为 cfg.Concurrency 的工作池）。发现的虚拟主机固定解析到所在IP，
+// 作为新目标追加到domains并记录备注；同一主机名出现在多个IP上时只使用第一个。返回追加后的目标和主机名 -> IP
+func discoverVHosts(domains, vhostNames []string, cfg config.Config, known utils.TargetSet, notes map[string]string) ([]string, map[string]string) {
+	vhostIPs := make(map[string]string)
+	for _, scan := range checker.DiscoverVHostsAll(slices.Clone(domains), vhostNames, cfg) {
+		if scan.Err != nil {
+			logger.Warn("虚拟主机探测失败", "target", scan.Target, "error", scan.Err)
+			continue
+		}
+		for _, vhost := range scan.VHosts {
+			if _, ok := vhostIPs[vhost.Host]; ok {
+				continue
+			}
+			vhostIPs[vhost.Host] = vhost.IP
+			checker.PinHost(vhost.Host, vhost.IP)
+			if known.Add(vhost.URL) {
+				domains = append(domains, vhost.URL)
+			}
+			addNote(notes, vhost.URL, "虚拟主机，位于 "+vhost.IP)
+			fmt.Fprintf(utils.Console, "  🏠 %s -> %s (%d, %d 字节) %s\n", vhost.Host, vhost.IP, vhost.Status, vhost.Length, vhost.Title)
 		}
 	}
-	if cfg.ExcelFile != "" {
-		err := view.SaveResultsToExcel(allResults, cfg.ExcelFile, cfg.OnlyAlive)
+	return domains, vhostIPs
+}
+
+// 汇总需要写入的输出：原有的各输出参数按固定顺序在前，-format 指定的输出在后
+func collectOutputs(cfg config.Config, htmlOutput, simpleHTML, galleryOutput string) ([]view.Output, error) {
+	specs := []struct{ name, filename string }{
+		{"csv", cfg.OutputFile},
+		{"targets", cfg.TargetsFile},
+		{"httpx-json", cfg.HttpxJSONFile},
+		{"xml", cfg.XMLFile},
+		{"nmap-xml", cfg.NmapXMLFile},
+		{"excel", cfg.ExcelFile},
+		{"exec-excel", cfg.ExecExcelFile},
+		{"html", htmlOutput},
+		{"simple-html", simpleHTML},
+		{"gallery", galleryOutput},
+	}
+	var outputs []view.Output
+	for _, spec := range specs {
+		if spec.filename == "" {
+			continue
+		}
+		output, err := view.ParseOutput(spec.name + "=" + spec.filename)
 		if err != nil {
-			fmt.Printf("保存结果到Excel文件时出错: %s\n", err)
-		} else {
-			fmt.Printf("结果已保存到 %s\n", cfg.ExcelFile)
+			return nil, err
 		}
+		outputs = append(outputs, output)
 	}
-	if htmlOutput != "" {
-		err := view.SaveResultsToHTML(allResults, htmlOutput, cfg.OnlyAlive)
+	for _, spec := range cfg.Formats {
+		output, err := view.ParseOutput(spec)
 		if err != nil {
-			fmt.Printf("保存结果到HTML文件时出错: %s\n", err)
-		} else {
-			fmt.Printf("HTML报告已保存到 %s\n", htmlOutput)
+			return nil, err
+		}
+		outputs = append(outputs, output)
+	}
+	return outputs, nil
+}
+
+// 目标中出现的根域名（去重并排序），IP目标不计入
+func rootDomains(domains []string) []string {
+	seen := make(map[string]bool)
+	var roots []string
+	for _, d := range domains {
+		host := utils.HostFromURL(d)
+		if net.ParseIP(host) != nil {
+			continue
+		}
+		root := utils.RootDomain(host)
+		if !seen[root] {
+			seen[root] = true
+			roots = append(roots, root)
+		}
+	}
+	sort.Strings(roots)
+	return roots
+}
+
+// 试运行（-dry-run）：对目标进行DNS解析并输出扫描计划，不连接任何目标
+func printDryRun(cfg config.Config, domains []string, skipped int, targetScope *scope.Scope, outputs []view.Output) {
+	fmt.Fprintf(utils.Console, "\n🧪 试运行：以下为将要执行的扫描，未连接任何目标\n")
+	fmt.Fprintln(utils.Console, "----------------------------------------")
+	fmt.Fprintf(utils.Console, "目标: %d 个（已跳过 %d 个范围外的目标）\n", len(domains), skipped)
+
+	// 并发解析去重后的主机名
+	hosts := make(map[string]bool)
+	var names []string
+	for _, d := range domains {
+		host := targetHost(d)
+		if !hosts[host] {
+			hosts[host] = true
+			names = append(names, host)
+		}
+	}
+	type resolution struct {
+		host     string
+		ips      []net.IP
+		err      error
+		excluded bool
+	}
+	resolved := make([]resolution, len(names))
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, max(cfg.Concurrency, 1))
+	for i, host := range names {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			r := resolution{host: host}
+			r.ips, r.err = checker.Resolve(host, time.Duration(cfg.Timeout)*time.Second)
+			if r.err == nil {
+				r.excluded = true
+				for _, ip := range r.ips {
+					if targetScope.AllowsIP(ip) {
+						r.excluded = false
+						break
+					}
+				}
+			}
+			resolved[i] = r
+		}()
+	}
+	wg.Wait()
+
+	var failed, excluded []string
+	ips := make(map[string]bool)
+	for _, r := range resolved {
+		switch {
+		case r.err != nil:
+			failed = append(failed, r.host)
+		case r.excluded:
+			excluded = append(excluded, r.host)
+		default:
+			for _, ip := range r.ips {
+				ips[ip.String()] = true
+			}
+		}
+	}
+	resolverName := "系统DNS配置"
+	if cfg.CNAMEResolver != "" && cfg.CNAME {
+		resolverName += "（CNAME查询使用 " + cfg.CNAMEResolver + "）"
+	}
+	fmt.Fprintf(utils.Console, "DNS解析: %d 个主机，%d 个可解析（%d 个不同IP），%d 个解析失败，解析器: %s\n",
+		len(names), len(names)-len(failed), len(ips), len(failed), resolverName)
+	const maxListed = 20
+	for _, list := range []struct {
+		title string
+		hosts []string
+	}{{"解析失败的主机", failed}, {"只解析到范围外IP的主机（将被拦截）", excluded}} {
+		if len(list.hosts) == 0 {
+			continue
+		}
+		sort.Strings(list.hosts)
+		fmt.Fprintf(utils.Console, "%s (%d):\n", list.title, len(list.hosts))
+		for i, host := range list.hosts {
+			if i >= maxListed {
+				fmt.Fprintf(utils.Console, "  ... 其余 %d 个\n", len(list.hosts)-maxListed)
+				break
+			}
+			fmt.Fprintf(utils.Console, "  %s\n", host)
+		}
+	}
+
+	fmt.Fprintf(utils.Console, "并发数: %d，超时: %d秒，地址族: %s，请求方法: %s\n", cfg.Concurrency, cfg.Timeout, cfg.IPFamily, cfg.Method)
+	if cfg.Adaptive {
+		fmt.Fprintf(utils.Console, "自适应并发: 已启用\n")
+	}
+	if cfg.Ports != "" {
+		ports, _ := checker.ParsePorts(cfg.Ports)
+		fmt.Fprintf(utils.Console, "端口扫描: %d 个端口 (%s)\n", len(ports), cfg.Ports)
+	}
+	if cfg.VHostFile != "" {
+		fmt.Fprintf(utils.Console, "虚拟主机探测: 使用 %s（试运行中不执行）\n", cfg.VHostFile)
+	}
+	if cfg.Screenshot || cfg.ScreenshotAlive {
+		fmt.Fprintf(utils.Console, "截图: 已启用\n")
+	}
+	if len(outputs) > 0 {
+		fmt.Fprintln(utils.Console, "输出:")
+		for _, output := range outputs {
+			fmt.Fprintf(utils.Console, "  %s -> %s\n", output.Format.Description, output.Filename)
+		}
+	}
+	fmt.Fprintln(utils.Console, "----------------------------------------")
+	fmt.Fprintln(utils.Console, "去掉 -dry-run 即可开始扫描")
+}
+
+// 记录目标的备注，已有备注时不覆盖。没有协议的目标检测时会加上 https:// 或 http://，
+// 备注同时按这两种URL记录，使结果能按检测的URL精确找到自己的备注
+func addNote(notes map[string]string, target, note string) {
+	keys := []string{target}
+	if !strings.Contains(target, "://") {
+		keys = append(keys, "https://"+target, "http://"+target)
+	}
+	for _, key := range keys {
+		if notes[key] == "" {
+			notes[key] = note
+		}
+	}
+}
+
+// 根据能解析的主机生成排列候选并解析（-permute），返回能解析且不在已有目标中的新子域名，同时将其加入已有目标。
+// 被团队策略或 -exclude/-scope 排除的候选不解析，存在泛解析的父域下的候选被丢弃
+func resolvePermutations(seeds map[string]bool, words []string, known utils.TargetSet, targetScope *scope.Scope, workers int, cfg config.Config) []string {
+	hosts := make([]string, 0, len(seeds))
+	for host := range seeds {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+	var candidates []string
+	excluded := 0
+	for _, candidate := range permute.Generate(hosts, words, cfg.PermuteMax) {
+		if ok, _ := targetScope.Check(candidate); !ok {
+			excluded++
+			continue
+		}
+		candidates = append(candidates, candidate)
+	}
+	if excluded > 0 {
+		fmt.Fprintf(utils.Console, "🚫 %d 个排列候选不在扫描范围内（团队策略/-exclude/-scope），已跳过\n", excluded)
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+	fmt.Fprintf(utils.Console, "\n🔀 根据 %d 个能解析的主机生成了 %d 个排列候选，正在解析...\n", len(hosts), len(candidates))
+
+	timeout := time.Duration(cfg.Timeout) * time.Second
+	hits, wildcards := permute.Resolve(candidates, workers, func(host string) bool {
+		ips, err := checker.Resolve(host, timeout)
+		return err == nil && len(ips) > 0
+	})
+	if len(wildcards) > 0 {
+		fmt.Fprintf(utils.Console, "⚠️  %d 个父域存在泛解析，已跳过其下的候选: %s\n", len(wildcards), strings.Join(wildcards, ", "))
+	}
+	var fresh []string
+	for _, host := range hits {
+		if known.Add(host) {
+			fresh = append(fresh, host)
+		}
+	}
+	fmt.Fprintf(utils.Console, "🔀 排列发现 %d 个能解析的新子域名\n", len(fresh))
+	return fresh
+}
+
+// 目标的主机名（不含协议、端口和路径），用于按主机设置超时和隔离
+func targetHost(target string) string {
+	host := utils.HostOnly(target)
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.TrimSuffix(strings.ToLower(host), ".")
+}
+
+// 截图只会出现在Excel、HTML报告和截图画廊中
+func hasScreenshotOutput(outputs []view.Output) bool {
+	for _, output := range outputs {
+		switch output.Format.Name {
+		case "excel", "html", "simple-html", "gallery":
+			return true
 		}
 	}
-	if simpleHTML != "" {
-		err := view.SaveResultsToSimpleHTML(allResults, simpleHTML, cfg.OnlyAlive)
+	return false
+}
+
+// 列出所有已注册的输出格式
+func printFormats() {
+	fmt.Fprintln(utils.Console, "可用的输出格式 (-format 名称=文件):")
+	for _, f := range view.Formats() {
+		fmt.Fprintf(utils.Console, "  %-12s %-6s %s\n", f.Name, f.Extension, f.Description)
+	}
+}
+
+// 启动结果查看服务器，在浏览器中查看已保存的JSON结果
+func runView(args []string) {
+	fs := flag.NewFlagSet("view", flag.ExitOnError)
+	addr := fs.String("addr", "127.0.0.1:8080", "监听地址")
+	screenshotDir := fs.String("screenshot-dir", "screenshots", "截图所在目录")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "用法: squirrel view [选项] <JSON结果文件>")
+		fmt.Fprintln(os.Stderr, "\n选项:")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	if err := view.ServeResults(*addr, fs.Arg(0), *screenshotDir); err != nil {
+		fmt.Fprintf(os.Stderr, "错误: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+// 从保存的JSON结果重新生成报告，报告时间取自结果的检测时间，重复生成的报告字节一致
+func runRender(args []string) {
+	fs := flag.NewFlagSet("render", flag.ExitOnError)
+	var formats config.StringList
+	fs.Var(&formats, "format", "按格式名称输出结果，格式为 \"名称=文件\"，可多次指定，可用格式见 squirrel -list-formats")
+	onlyAlive := fs.Bool("only-alive", false, "只导出存活的域名")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "用法: squirrel render -format 名称=文件 [-format ...] <JSON结果文件>")
+		fmt.Fprintln(os.Stderr, "\n选项:")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 || len(formats) == 0 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	var outputs []view.Output
+	for _, spec := range formats {
+		output, err := view.ParseOutput(spec)
 		if err != nil {
-			fmt.Printf("保存结果到简化版HTML文件时出错: %s\n", err)
+			fmt.Fprintf(os.Stderr, "错误: %s\n", err)
+			os.Exit(1)
+		}
+		outputs = append(outputs, output)
+	}
+	errs, err := view.Rerender(fs.Arg(0), outputs, view.WriteOptions{OnlyAlive: *onlyAlive})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "错误: %s\n", err)
+		os.Exit(1)
+	}
+	var failedReports []string
+	for i, output := range outputs {
+		if err := errs[i]; err != nil {
+			failedReports = append(failedReports, output.Filename)
+			logger.Error("保存"+output.Format.Description+"时出错", "file", output.Filename, "error", err)
 		} else {
-			fmt.Printf("简化版HTML报告已保存到 %s\n", simpleHTML)
+			fmt.Fprintf(utils.Console, "%s已保存到 %s\n", output.Format.Description, output.Filename)
+		}
+	}
+	if len(failedReports) > 0 {
+		fmt.Fprintf(utils.Console, "⚠️  %d 个报告写入失败: %s\n", len(failedReports), strings.Join(failedReports, ", "))
+		os.Exit(1)
+	}
+}
+
+// 启动gRPC扫描调度服务，每次扫描以子进程运行
+func runGRPC(args []string) {
+	fs := flag.NewFlagSet("grpc", flag.ExitOnError)
+	addr := fs.String("addr", "127.0.0.1:9090", "监听地址（接口没有认证，不要监听在不受信任的网络上）")
+	dir := fs.String("dir", "grpc-scans", "保存每次扫描的目标文件和日志的目录")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "用法: squirrel grpc [选项]")
+		fmt.Fprintln(os.Stderr, "接口定义见 grpcapi/squirrel.proto")
+		fmt.Fprintln(os.Stderr, "\n选项:")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	executable, err := os.Executable()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "错误: %s\n", err)
+		os.Exit(1)
+	}
+	listener, err := net.Listen("tcp", *addr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "错误: %s\n", err)
+		os.Exit(1)
+	}
+	server := grpcapi.NewServer(grpcapi.ProcessRunner(executable, *dir))
+	fmt.Fprintf(utils.Console, "🛰️  gRPC扫描调度服务已启动: %s （服务 squirrel.v1.ScanService，扫描日志保存在 %s）\n", *addr, *dir)
+	if err := server.Serve(listener); err != nil {
+		fmt.Fprintf(os.Stderr, "错误: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+// 对比多次扫描的JSON结果，生成趋势报告
+func runTrend(args []string) {
+	fs := flag.NewFlagSet("trend", flag.ExitOnError)
+	output := fs.String("o", "trend.html", "输出的HTML文件")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "用法: squirrel trend [-o 文件] <JSON结果文件>...")
+		fmt.Fprintln(os.Stderr, "\n选项:")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() < 2 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	var scans []view.TrendScan
+	for _, filename := range fs.Args() {
+		scan, err := view.LoadTrendScan(filename)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %s\n", err)
+			os.Exit(1)
 		}
+		scans = append(scans, scan)
 	}
+	trend := view.BuildTrend(scans)
+	if err := view.SaveTrendReport(trend, *output); err != nil {
+		fmt.Fprintf(os.Stderr, "错误: %s\n", err)
+		os.Exit(1)
+	}
+	fmt.Fprintf(utils.Console, "📈 已对比 %d 次扫描（%d 个子域名，%d 个状态反复切换），趋势报告已保存到 %s\n",
+		len(scans), len(trend.Hosts), len(trend.Flapping), *output)
+}
+
+// 清理截图目录：删除超过保留天数或未被任何保存的扫描结果引用的截图
+func runPruneScreenshots(args []string) {
+	fs := flag.NewFlagSet("prune-screenshots", flag.ExitOnError)
+	dir := fs.String("dir", "screenshots", "截图目录")
+	days := fs.Int("days", 0, "删除修改时间超过该天数的截图，0表示不按时间清理")
+	dryRun := fs.Bool("dry-run", false, "只列出将被删除的截图，不实际删除")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "用法: squirrel prune-screenshots [选项] [JSON结果文件...]")
+		fmt.Fprintln(os.Stderr, "指定结果文件时删除未被其中任何结果引用的截图；同时指定 -days 时满足任一条件的截图都会被删除")
+		fmt.Fprintln(os.Stderr, "\n选项:")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if *days <= 0 && fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	opts := screenshot.PruneOptions{OlderThan: time.Duration(*days) * 24 * time.Hour, DryRun: *dryRun}
+	if fs.NArg() > 0 {
+		opts.Referenced = make(map[string]bool)
+		for _, filename := range fs.Args() {
+			results, err := view.LoadResultsJSON(filename)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "错误: %s\n", err)
+				os.Exit(1)
+			}
+			for path := range view.ReferencedScreenshots(results, *dir) {
+				opts.Referenced[path] = true
+			}
+		}
+	}
+
+	stats, err := screenshot.Prune(*dir, opts)
+	if *dryRun {
+		for _, file := range stats.Files {
+			fmt.Fprintln(utils.Console, file)
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "错误: %s\n", err)
+		os.Exit(1)
+	}
+	if *dryRun {
+		fmt.Fprintf(utils.Console, "🧹 将删除 %d 个截图（%s），保留 %d 个\n", len(stats.Files), view.FormatBytes(stats.Bytes), stats.Kept)
+		return
+	}
+	fmt.Fprintf(utils.Console, "🧹 已删除 %d 个截图（释放 %s）和 %d 个空目录，保留 %d 个\n", len(stats.Files), view.FormatBytes(stats.Bytes), stats.Removed, stats.Kept)
+}
+
+// 下载各云服务商公开发布的地址段，供 -cloud 使用
+func runCloudRanges(args []string) {
+	fs := flag.NewFlagSet("cloud-ranges", flag.ExitOnError)
+	output := fs.String("o", "cloud-ranges.json", "输出文件")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "用法: squirrel cloud-ranges [-o 文件]")
+		fmt.Fprintln(os.Stderr, "\n选项:")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	fmt.Fprintln(utils.Console, "☁️  正在下载云服务商地址段...")
+	err := cloud.Refresh(*output, func(provider string, count int, err error) {
+		if err != nil {
+			fmt.Fprintf(utils.Console, "  ❌ %s: %v（保留原有地址段）\n", provider, err)
+			return
+		}
+		fmt.Fprintf(utils.Console, "  ✅ %s: %d 个地址段\n", provider, count)
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "错误: %s\n", err)
+		os.Exit(1)
+	}
+	fmt.Fprintf(utils.Console, "💾 地址段已保存到 %s\n", *output)
+}
+
+// 版本号，显示在启动横幅中并写入HAR文件
+const version = "1.3"
+
+// 打印启动横幅
+func printBanner() {
+	fmt.Fprintf(utils.Console, `
+                               /$$                             /$$
+                              |__/                            | $$
+  /$$$$$$$  /$$$$$$  /$$   /$$ /$$  /$$$$$$  /$$$$$$  /$$$$$$ | $$
+ /$$_____/ /$$__  $$| $$  | $$| $$ /$$__  $$/$$__  $$/$$__  $$| $$
+|  $$$$$$ | $$  \ $$| $$  | $$| $$| $$  \__/ $$  \__/ $$$$$$$$| $$
+ \____  $$| $$  | $$| $$  | $$| $$| $$     | $$     | $$_____/| $$
+ /$$$$$$$/|  $$$$$$$|  $$$$$$/| $$| $$     | $$     |  $$$$$$$| $$
+|_______/  \____  $$ \______/ |__/|__/     |__/      \_______/|__/
+                | $$
+                | $$
+                |__/
+                    松鼠子域名检测工具 v%s
+
+`, version)
 }
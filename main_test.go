@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+
+	"subdomain-checker/config"
+	"subdomain-checker/utils"
+)
+
+// 只有Host为dev.example.com时返回不同的页面：只发现该虚拟主机，并作为新目标带备注加入扫描
+func TestDiscoverVHostsAddsTargets(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if host, _, _ := strings.Cut(r.Host, ":"); host == "dev.example.com" {
+			fmt.Fprint(w, "<html><head><title>Dev Console</title></head><body>internal tools</body></html>")
+			return
+		}
+		fmt.Fprint(w, "<html><head><title>Default</title></head><body>default site</body></html>")
+	})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "<html><head><title>Other</title></head></html>")
+	}))
+	defer other.Close()
+
+	domains := []string{server.URL, other.URL}
+	known := make(utils.TargetSet)
+	for _, d := range domains {
+		known.Add(d)
+	}
+	notes := make(map[string]string)
+	cfg := config.Config{Timeout: 5, Concurrency: 3}
+	candidates := []string{"www.example.com", "dev.example.com", "admin.example.com", "mail.example.com"}
+
+	domains, vhostIPs := discoverVHosts(domains, candidates, cfg, known, notes)
+
+	u, _ := url.Parse(server.URL)
+	want := "http://dev.example.com:" + u.Port()
+	if !slices.Equal(domains, []string{server.URL, other.URL, want}) {
+		t.Errorf("domains = %q, want the two inputs followed by %s", domains, want)
+	}
+	if len(vhostIPs) != 1 || vhostIPs["dev.example.com"] != "127.0.0.1" {
+		t.Errorf("vhost IPs = %v, want only dev.example.com -> 127.0.0.1", vhostIPs)
+	}
+	if notes[want] != "虚拟主机，位于 127.0.0.1" {
+		t.Errorf("note for %s = %q", want, notes[want])
+	}
+}
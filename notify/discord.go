@@ -0,0 +1,78 @@
+package notify
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Discord嵌入消息颜色
+const (
+	discordColorInfo    = 0x2056DD
+	discordColorSuccess = 0x4CAF50
+)
+
+// Discord Webhook通知
+type DiscordNotifier struct {
+	WebhookURL string
+}
+
+func (n *DiscordNotifier) Name() string {
+	return "Discord"
+}
+
+// 发送发现列表
+func (n *DiscordNotifier) SendFindings(title string, lines []string, more int) error {
+	description := "• " + strings.Join(lines, "\n• ")
+	if more > 0 {
+		description += fmt.Sprintf("\n…以及另外 %d 个", more)
+	}
+	return postJSON(n.WebhookURL, map[string]interface{}{
+		"embeds": []interface{}{
+			map[string]interface{}{
+				"title":       title,
+				"description": truncateDiscord(description),
+				"color":       discordColorInfo,
+			},
+		},
+	})
+}
+
+// 发送扫描总结卡片
+func (n *DiscordNotifier) SendSummary(summary Summary) error {
+	fields := []map[string]interface{}{
+		{"name": "检测总数", "value": fmt.Sprint(summary.Total), "inline": true},
+		{"name": "存活", "value": fmt.Sprint(summary.Alive), "inline": true},
+		{"name": "无法访问", "value": fmt.Sprint(summary.Dead), "inline": true},
+		{"name": "耗时", "value": fmt.Sprintf("%.1f 秒", summary.Duration.Seconds()), "inline": true},
+	}
+	if len(summary.TopFindings) > 0 {
+		fields = append(fields, map[string]interface{}{
+			"name":  "重点发现",
+			"value": truncateDiscord("• " + strings.Join(summary.TopFindings, "\n• ")),
+		})
+	}
+
+	embed := map[string]interface{}{
+		"title":  "🐿️ Squirrel 扫描完成",
+		"color":  discordColorSuccess,
+		"fields": fields,
+	}
+	if strings.HasPrefix(summary.ReportURL, "http://") || strings.HasPrefix(summary.ReportURL, "https://") {
+		embed["url"] = summary.ReportURL
+	} else if summary.ReportURL != "" {
+		embed["description"] = "报告: " + summary.ReportURL
+	}
+
+	return postJSON(n.WebhookURL, map[string]interface{}{
+		"embeds": []interface{}{embed},
+	})
+}
+
+// Discord嵌入字段长度上限为1024个字符
+func truncateDiscord(s string) string {
+	runes := []rune(s)
+	if len(runes) <= 1000 {
+		return s
+	}
+	return string(runes[:1000]) + "…"
+}
@@ -0,0 +1,173 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"subdomain-checker/checker"
+	"subdomain-checker/logger"
+)
+
+// 每条消息中最多列出的发现数量
+const maxLinesPerMessage = 15
+
+// 扫描总结，用于生成完成通知
+type Summary struct {
+	Total       int
+	Alive       int
+	Dead        int
+	Duration    time.Duration
+	TopFindings []string
+	ReportURL   string // 报告链接或本地路径
+}
+
+// 通知渠道
+type Notifier interface {
+	Name() string
+	SendFindings(title string, lines []string, more int) error
+	SendSummary(summary Summary) error
+}
+
+// 以JSON形式POST到webhook地址
+func postJSON(url string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Post(url, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook返回状态码 %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// 将结果格式化为一行发现描述
+func FormatFinding(result checker.Result, reasons []string) string {
+	line := result.DisplayDomain()
+	if len(reasons) > 0 {
+		line += " [" + strings.Join(reasons, ", ") + "]"
+	}
+	if result.Title != "" {
+		line += " " + result.Title
+	}
+	return line
+}
+
+// 通知分发器：按触发条件收集结果，并按固定间隔合并发送，避免大规模扫描时消息刷屏
+type Dispatcher struct {
+	notifiers []Notifier
+	onFinding bool // 发现页面类型或关键词命中时通知
+	onAlive   bool // 发现存活主机时通知
+	onFinish  bool // 扫描结束时发送总结
+	interval  time.Duration
+
+	mutex   sync.Mutex
+	pending []string
+	stop    chan struct{}
+	wg      sync.WaitGroup
+}
+
+// 创建通知分发器，triggers为逗号分隔的触发条件：finish、finding、alive
+func NewDispatcher(notifiers []Notifier, triggers string, interval time.Duration) (*Dispatcher, error) {
+	d := &Dispatcher{
+		notifiers: notifiers,
+		interval:  interval,
+		stop:      make(chan struct{}),
+	}
+	for _, trigger := range strings.Split(triggers, ",") {
+		switch strings.TrimSpace(trigger) {
+		case "finish":
+			d.onFinish = true
+		case "finding":
+			d.onFinding = true
+		case "alive":
+			d.onAlive = true
+		case "":
+		default:
+			return nil, fmt.Errorf("未知的通知触发条件: %s (可选 finish、finding、alive)", trigger)
+		}
+	}
+	return d, nil
+}
+
+// 启动定时发送
+func (d *Dispatcher) Start() {
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		ticker := time.NewTicker(d.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				d.flush()
+			case <-d.stop:
+				d.flush()
+				return
+			}
+		}
+	}()
+}
+
+// 提交一个检测结果及其关注原因，满足触发条件的会进入待发送队列
+func (d *Dispatcher) Submit(result checker.Result, reasons []string) {
+	if !result.Alive {
+		return
+	}
+	if !d.onAlive && !(d.onFinding && len(reasons) > 0) {
+		return
+	}
+
+	d.mutex.Lock()
+	d.pending = append(d.pending, FormatFinding(result, reasons))
+	d.mutex.Unlock()
+}
+
+// 发送待发送队列中的发现，每个间隔最多发送一条消息
+func (d *Dispatcher) flush() {
+	d.mutex.Lock()
+	lines := d.pending
+	d.pending = nil
+	d.mutex.Unlock()
+
+	if len(lines) == 0 {
+		return
+	}
+
+	more := 0
+	if len(lines) > maxLinesPerMessage {
+		more = len(lines) - maxLinesPerMessage
+		lines = lines[:maxLinesPerMessage]
+	}
+	title := fmt.Sprintf("🐿️ Squirrel 新发现 %d 个", len(lines)+more)
+	for _, notifier := range d.notifiers {
+		if err := notifier.SendFindings(title, lines, more); err != nil {
+			logger.Warn("发送通知失败", "notifier", notifier.Name(), "error", err)
+		}
+	}
+}
+
+// 停止分发器，发送剩余的发现，并在配置了finish触发条件时发送扫描总结
+func (d *Dispatcher) Finish(summary Summary) {
+	close(d.stop)
+	d.wg.Wait()
+
+	if !d.onFinish {
+		return
+	}
+	for _, notifier := range d.notifiers {
+		if err := notifier.SendSummary(summary); err != nil {
+			logger.Warn("发送总结通知失败", "notifier", notifier.Name(), "error", err)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package notify
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Slack Incoming Webhook通知
+type SlackNotifier struct {
+	WebhookURL string
+}
+
+func (n *SlackNotifier) Name() string {
+	return "Slack"
+}
+
+// 发送发现列表
+func (n *SlackNotifier) SendFindings(title string, lines []string, more int) error {
+	text := "• " + strings.Join(lines, "\n• ")
+	if more > 0 {
+		text += fmt.Sprintf("\n…以及另外 %d 个", more)
+	}
+	return postJSON(n.WebhookURL, map[string]interface{}{
+		"text": title,
+		"blocks": []interface{}{
+			slackSection(fmt.Sprintf("*%s*", title)),
+			slackSection(text),
+		},
+	})
+}
+
+// 发送扫描总结卡片
+func (n *SlackNotifier) SendSummary(summary Summary) error {
+	blocks := []interface{}{
+		map[string]interface{}{
+			"type": "header",
+			"text": map[string]string{"type": "plain_text", "text": "🐿️ Squirrel 扫描完成"},
+		},
+		map[string]interface{}{
+			"type": "section",
+			"fields": []map[string]string{
+				{"type": "mrkdwn", "text": fmt.Sprintf("*检测总数*\n%d", summary.Total)},
+				{"type": "mrkdwn", "text": fmt.Sprintf("*存活*\n%d", summary.Alive)},
+				{"type": "mrkdwn", "text": fmt.Sprintf("*无法访问*\n%d", summary.Dead)},
+				{"type": "mrkdwn", "text": fmt.Sprintf("*耗时*\n%.1f 秒", summary.Duration.Seconds())},
+			},
+		},
+	}
+	if len(summary.TopFindings) > 0 {
+		blocks = append(blocks, slackSection("*重点发现*\n• "+strings.Join(summary.TopFindings, "\n• ")))
+	}
+	if summary.ReportURL != "" {
+		blocks = append(blocks, slackSection(fmt.Sprintf("报告: %s", summary.ReportURL)))
+	}
+
+	return postJSON(n.WebhookURL, map[string]interface{}{
+		"text":   fmt.Sprintf("Squirrel 扫描完成: %d 个域名, %d 个存活", summary.Total, summary.Alive),
+		"blocks": blocks,
+	})
+}
+
+func slackSection(text string) map[string]interface{} {
+	return map[string]interface{}{
+		"type": "section",
+		"text": map[string]string{"type": "mrkdwn", "text": text},
+	}
+}
@@ -0,0 +1,68 @@
+package ocr
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// 每张截图保留的文字上限（字符），避免大页面的识别结果撑大报告
+const MaxTextRunes = 4000
+
+// 调用tesseract识别截图中的文字。识别很耗CPU，同时运行的识别进程数不超过CPU核数
+type Engine struct {
+	command string
+	lang    string
+	timeout time.Duration
+	sem     chan struct{}
+}
+
+// 创建识别引擎，command为tesseract可执行文件，lang为语言（如 "eng"、"eng+chi_sim"）
+func New(command, lang string, timeout time.Duration) (*Engine, error) {
+	path, err := exec.LookPath(command)
+	if err != nil {
+		return nil, fmt.Errorf("找不到OCR程序 %s，请安装tesseract: %v", command, err)
+	}
+	return &Engine{
+		command: path,
+		lang:    lang,
+		timeout: timeout,
+		sem:     make(chan struct{}, runtime.NumCPU()),
+	}, nil
+}
+
+// 识别图片中的文字，返回合并空白后的文本
+func (e *Engine) Extract(imagePath string) (string, error) {
+	e.sem <- struct{}{}
+	defer func() { <-e.sem }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), e.timeout)
+	defer cancel()
+	args := []string{imagePath, "stdout"}
+	if e.lang != "" {
+		args = append(args, "-l", e.lang)
+	}
+	cmd := exec.CommandContext(ctx, e.command, args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%v: %s", err, msg)
+		}
+		return "", err
+	}
+	return normalize(stdout.String()), nil
+}
+
+// 合并连续空白并截断到MaxTextRunes
+func normalize(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if runes := []rune(text); len(runes) > MaxTextRunes {
+		text = string(runes[:MaxTextRunes])
+	}
+	return text
+}
@@ -0,0 +1,221 @@
+package permute
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// 默认的排列关键词：常见的环境名和用途前后缀
+var DefaultWords = []string{
+	"dev", "development", "test", "testing", "qa", "uat", "stage", "staging", "stg", "pre", "preprod",
+	"prod", "production", "beta", "demo", "sandbox", "internal", "admin", "api", "old", "new", "backup", "v1", "v2",
+}
+
+// 标签末尾的数字，如 api01 中的 01
+var trailingDigits = regexp.MustCompile(`^(.*?)(\d+)$`)
+
+// 根据已发现的子域名生成候选子域名（类似 altdns/gotator）。对每个主机最左侧的标签：
+//   - 加关键词前后缀：dev-api、api-dev、devapi、apidev，以及作为新标签插入：dev.api.example.com
+//   - 标签中已有关键词时替换为其他关键词：dev-api → staging-api
+//   - 末尾有数字时改变数字（api01 → api02、api00），没有时加数字后缀：api1、api2、api01、api02
+//
+// 根域名本身只生成 关键词.根域名。结果按生成顺序去重，不包含输入的主机，limit大于0时最多返回limit个
+func Generate(hosts, words []string, limit int) []string {
+	normalized := make([]string, 0, len(words))
+	for _, word := range words {
+		if word = normalize(word); word != "" {
+			normalized = append(normalized, word)
+		}
+	}
+	words = normalized
+	known := make(map[string]bool, len(hosts))
+	for _, host := range hosts {
+		known[normalize(host)] = true
+	}
+	var candidates []string
+	add := func(candidate string) bool {
+		if limit > 0 && len(candidates) >= limit {
+			return false
+		}
+		if !known[candidate] && validHost(candidate) {
+			known[candidate] = true
+			candidates = append(candidates, candidate)
+		}
+		return true
+	}
+
+	for _, host := range hosts {
+		host = normalize(host)
+		if host == "" || net.ParseIP(host) != nil {
+			continue
+		}
+		apex, err := publicsuffix.EffectiveTLDPlusOne(host)
+		if err != nil {
+			continue
+		}
+		if host == apex {
+			for _, word := range words {
+				if !add(word + "." + apex) {
+					return candidates
+				}
+			}
+			continue
+		}
+		label, rest, _ := strings.Cut(host, ".")
+		for _, variant := range labelVariants(label, words) {
+			if !add(variant + "." + rest) {
+				return candidates
+			}
+		}
+		for _, word := range words {
+			if !add(word + "." + host) {
+				return candidates
+			}
+		}
+	}
+	return candidates
+}
+
+// 单个标签的所有变体
+func labelVariants(label string, words []string) []string {
+	var variants []string
+	for _, word := range words {
+		if word == label {
+			continue
+		}
+		variants = append(variants, word+"-"+label, label+"-"+word, word+label, label+word)
+	}
+
+	// 替换标签中以 - 分隔的关键词
+	parts := strings.Split(label, "-")
+	for i, part := range parts {
+		if !slices.Contains(words, part) {
+			continue
+		}
+		for _, word := range words {
+			if word == part {
+				continue
+			}
+			replaced := append([]string(nil), parts...)
+			replaced[i] = word
+			variants = append(variants, strings.Join(replaced, "-"))
+		}
+	}
+
+	if m := trailingDigits.FindStringSubmatch(label); m != nil {
+		prefix, digits := m[1], m[2]
+		n, _ := strconv.Atoi(digits)
+		for _, next := range []int{n - 1, n + 1, n + 2} {
+			if next < 0 {
+				continue
+			}
+			// 保持数字的位数，如 01 → 02
+			s := strconv.Itoa(next)
+			if len(s) < len(digits) {
+				s = strings.Repeat("0", len(digits)-len(s)) + s
+			}
+			variants = append(variants, prefix+s)
+		}
+	} else {
+		for _, suffix := range []string{"1", "2", "01", "02", "-1", "-2"} {
+			variants = append(variants, label+suffix)
+		}
+	}
+	return variants
+}
+
+// 并发解析候选域名，返回能解析的域名（保持候选的顺序）和被判定为泛解析的父域。
+// 父域下随机生成的子域名也能解析时为泛解析，其下的候选全部丢弃，避免把每个候选都当作新发现
+func Resolve(candidates []string, workers int, lookup func(host string) bool) (hits, wildcards []string) {
+	parents := make(map[string]bool) // 父域 -> 是否泛解析
+	var parentList []string
+	for _, candidate := range candidates {
+		parent := parentOf(candidate)
+		if _, ok := parents[parent]; !ok {
+			parents[parent] = false
+			parentList = append(parentList, parent)
+		}
+	}
+	wildcard := make([]bool, len(parentList))
+	parallel(len(parentList), workers, func(i int) {
+		wildcard[i] = lookup(randomLabel() + "." + parentList[i])
+	})
+	for i, parent := range parentList {
+		parents[parent] = wildcard[i]
+		if wildcard[i] {
+			wildcards = append(wildcards, parent)
+		}
+	}
+
+	resolved := make([]bool, len(candidates))
+	parallel(len(candidates), workers, func(i int) {
+		if !parents[parentOf(candidates[i])] {
+			resolved[i] = lookup(candidates[i])
+		}
+	})
+	for i, candidate := range candidates {
+		if resolved[i] {
+			hits = append(hits, candidate)
+		}
+	}
+	return hits, wildcards
+}
+
+// 以workers个goroutine对0..n-1执行fn
+func parallel(n, workers int, fn func(i int)) {
+	indexes := make(chan int, n)
+	for i := 0; i < n; i++ {
+		indexes <- i
+	}
+	close(indexes)
+	var wg sync.WaitGroup
+	for w := 0; w < min(max(workers, 1), n); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				fn(i)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func parentOf(host string) string {
+	_, parent, _ := strings.Cut(host, ".")
+	return parent
+}
+
+// 随机的子域名标签，用于检测泛解析
+func randomLabel() string {
+	b := make([]byte, 6)
+	rand.Read(b)
+	return "squirrel-" + hex.EncodeToString(b)
+}
+
+func normalize(host string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(host)), ".")
+}
+
+// 候选域名的每个标签都是合法的主机名标签：1-63个字母、数字或连字符，不以连字符开头或结尾
+func validHost(host string) bool {
+	for _, label := range strings.Split(host, ".") {
+		if len(label) == 0 || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for _, c := range label {
+			if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-') {
+				return false
+			}
+		}
+	}
+	return len(host) <= 253
+}
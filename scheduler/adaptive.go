@@ -0,0 +1,125 @@
+package scheduler
+
+import (
+	"sync"
+	"time"
+)
+
+// 自适应并发调整的参数
+const (
+	adjustInterval  = 2 * time.Second // 两次调整之间的最短间隔
+	minWindowSample = 5               // 每个统计窗口至少需要的请求数
+	backoffErrRate  = 0.2             // 错误率超过该值时减半并发
+	growErrRate     = 0.05            // 错误率低于该值且延迟正常时增加并发
+	latencyFactor   = 2.0             // 平均延迟超过基线的倍数时停止增加并发
+)
+
+// 自适应并发限制器：错误率低时逐步提高并发，超时和连接重置激增时快速回退（AIMD）
+type AdaptiveLimiter struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	limit  int
+	min    int
+	max    int
+	active int
+
+	// 当前统计窗口
+	windowStart time.Time
+	requests    int
+	failures    int
+	latency     time.Duration
+
+	baseline time.Duration // 观测到的最低窗口平均延迟
+	peak     int
+	onAdjust func(old, new int, errRate float64, avgLatency time.Duration)
+}
+
+// 创建自适应并发限制器，initial为初始并发数，并发数在[min, max]范围内调整
+func NewAdaptiveLimiter(initial, min, max int) *AdaptiveLimiter {
+	if min < 1 {
+		min = 1
+	}
+	if max < min {
+		max = min
+	}
+	initial = clamp(initial, min, max)
+	l := &AdaptiveLimiter{
+		limit:       initial,
+		min:         min,
+		max:         max,
+		peak:        initial,
+		windowStart: time.Now(),
+	}
+	l.cond = sync.NewCond(&l.mu)
+	return l
+}
+
+// 设置并发数调整时的回调（用于输出日志）
+func (l *AdaptiveLimiter) OnAdjust(fn func(old, new int, errRate float64, avgLatency time.Duration)) {
+	l.onAdjust = fn
+}
+
+// 获取一个并发槽位，当前活跃请求数达到限制时阻塞
+func (l *AdaptiveLimiter) Acquire() {
+	l.mu.Lock()
+	for l.active >= l.limit {
+		l.cond.Wait()
+	}
+	l.active++
+	l.mu.Unlock()
+}
+
+// 释放槽位并记录本次请求的结果，backoff表示请求因超时、连接重置等过载迹象失败
+func (l *AdaptiveLimiter) Release(backoff bool, latency time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.active--
+	l.requests++
+	l.latency += latency
+	if backoff {
+		l.failures++
+	}
+	l.adjust()
+	l.cond.Broadcast()
+}
+
+// 根据当前窗口的错误率和平均延迟调整并发数
+func (l *AdaptiveLimiter) adjust() {
+	if l.requests < minWindowSample || time.Since(l.windowStart) < adjustInterval {
+		return
+	}
+
+	errRate := float64(l.failures) / float64(l.requests)
+	avgLatency := l.latency / time.Duration(l.requests)
+	if l.baseline == 0 || avgLatency < l.baseline {
+		l.baseline = avgLatency
+	}
+
+	old := l.limit
+	switch {
+	case errRate > backoffErrRate:
+		l.limit = clamp(l.limit/2, l.min, l.max)
+	case errRate < growErrRate && float64(avgLatency) <= float64(l.baseline)*latencyFactor:
+		l.limit = clamp(l.limit+max(1, l.limit/10), l.min, l.max)
+	}
+	l.peak = max(l.peak, l.limit)
+
+	if l.limit != old && l.onAdjust != nil {
+		l.onAdjust(old, l.limit, errRate, avgLatency)
+	}
+
+	l.windowStart = time.Now()
+	l.requests, l.failures, l.latency = 0, 0, 0
+}
+
+// 返回当前并发数和运行期间达到的最高并发数
+func (l *AdaptiveLimiter) Stats() (current, peak int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.limit, l.peak
+}
+
+func clamp(v, lo, hi int) int {
+	return min(max(v, lo), hi)
+}
@@ -0,0 +1,122 @@
+package scheduler
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// 按主机单独设置的超时时间（-timeout-file）
+type TimeoutOverrides struct {
+	exact    map[string]int
+	wildcard map[string]int // 后缀 -> 超时，来自 *.example.com 形式的规则
+}
+
+// 读取超时设置文件：每行为"主机 超时秒数"，主机可以写成 *.example.com 匹配所有子域名，
+// 忽略空行和 # 开头的注释。filename为空时返回空的设置
+func LoadTimeoutOverrides(filename string) (*TimeoutOverrides, error) {
+	o := &TimeoutOverrides{exact: make(map[string]int), wildcard: make(map[string]int)}
+	if filename == "" {
+		return o, nil
+	}
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("无法读取超时设置文件: %v", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("超时设置文件第 %d 行格式错误，应为\"主机 超时秒数\": %s", lineNo, line)
+		}
+		timeout, err := strconv.Atoi(fields[1])
+		if err != nil || timeout <= 0 {
+			return nil, fmt.Errorf("超时设置文件第 %d 行的超时无效: %s", lineNo, fields[1])
+		}
+		host := strings.TrimSuffix(strings.ToLower(fields[0]), ".")
+		if suffix, ok := strings.CutPrefix(host, "*."); ok {
+			o.wildcard[suffix] = timeout
+		} else {
+			o.exact[host] = timeout
+		}
+	}
+	return o, scanner.Err()
+}
+
+// 设置的条数
+func (o *TimeoutOverrides) Len() int {
+	return len(o.exact) + len(o.wildcard)
+}
+
+// 查找主机的超时设置，精确匹配优先，其次是最长的通配符后缀
+func (o *TimeoutOverrides) Lookup(host string) (int, bool) {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if timeout, ok := o.exact[host]; ok {
+		return timeout, true
+	}
+	for rest := host; ; {
+		_, parent, found := strings.Cut(rest, ".")
+		if !found {
+			return 0, false
+		}
+		if timeout, ok := o.wildcard[parent]; ok {
+			return timeout, true
+		}
+		rest = parent
+	}
+}
+
+// 慢主机隔离：记录每个主机的超时次数，达到阈值后该主机被隔离，
+// 其余尚未检测的目标推迟到慢速通道，不再占用工作者等待超时
+type Quarantine struct {
+	mu          sync.Mutex
+	threshold   int
+	timeouts    map[string]int
+	quarantined []string
+}
+
+// 创建慢主机隔离，threshold为触发隔离的超时次数，不大于0时不隔离
+func NewQuarantine(threshold int) *Quarantine {
+	return &Quarantine{threshold: threshold, timeouts: make(map[string]int)}
+}
+
+// 记录主机的一次超时，主机因此次超时被隔离时返回true
+func (q *Quarantine) RecordTimeout(host string) bool {
+	if q.threshold <= 0 {
+		return false
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.timeouts[host]++
+	if q.timeouts[host] != q.threshold {
+		return false
+	}
+	q.quarantined = append(q.quarantined, host)
+	return true
+}
+
+// 主机是否已被隔离
+func (q *Quarantine) Contains(host string) bool {
+	if q.threshold <= 0 {
+		return false
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.timeouts[host] >= q.threshold
+}
+
+// 返回所有被隔离的主机
+func (q *Quarantine) Hosts() []string {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return append([]string(nil), q.quarantined...)
+}
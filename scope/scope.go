@@ -0,0 +1,205 @@
+package scope
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// 扫描范围：范围文件（-scope）列出允许检测的目标，排除规则（-exclude）列出禁止检测的目标。
+// 规则支持以下写法：
+//   - 精确的主机名，如 www.example.com
+//   - 通配符，如 *.example.com（只匹配子域名）、dev-*.example.com
+//   - 正则表达式，以 re: 开头，如 re:^(dev|test)\d*\.example\.com$
+//   - IP地址或CIDR网段，如 10.0.0.5、10.0.0.0/8
+type Scope struct {
+	include []rule
+	exclude []rule
+}
+
+// 被团队策略排除时 Check 返回的原因
+const PolicyExcluded = "团队策略排除"
+
+// 单条规则，按写法只设置其中一个字段
+type rule struct {
+	text    string
+	host    string
+	pattern *regexp.Regexp
+	network *net.IPNet
+	policy  bool // 来自团队策略的排除规则
+}
+
+// 解析单条规则
+func parseRule(text string) (rule, error) {
+	text = strings.TrimSpace(text)
+	r := rule{text: text}
+	lower := strings.TrimSuffix(strings.ToLower(text), ".")
+	switch {
+	case strings.HasPrefix(text, "re:"):
+		re, err := regexp.Compile("(?i)" + strings.TrimPrefix(text, "re:"))
+		if err != nil {
+			return r, fmt.Errorf("无效的正则规则 %q: %v", text, err)
+		}
+		r.pattern = re
+	case strings.Contains(text, "/"):
+		_, network, err := net.ParseCIDR(text)
+		if err != nil {
+			return r, fmt.Errorf("无效的网段 %q", text)
+		}
+		r.network = network
+	case net.ParseIP(text) != nil:
+		ip := net.ParseIP(text)
+		bits := 128
+		if ip.To4() != nil {
+			ip, bits = ip.To4(), 32
+		}
+		r.network = &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}
+	case strings.Contains(lower, "*"):
+		expr := strings.ReplaceAll(regexp.QuoteMeta(lower), `\*`, `.*`)
+		r.pattern = regexp.MustCompile("^" + expr + "$")
+	default:
+		r.host = lower
+	}
+	return r, nil
+}
+
+// 规则是否匹配主机名或IP
+func (r rule) matches(host string, ip net.IP) bool {
+	switch {
+	case r.network != nil:
+		return ip != nil && r.network.Contains(ip)
+	case r.pattern != nil:
+		return r.pattern.MatchString(host)
+	}
+	return host == r.host
+}
+
+// 规则是针对IP的（IP或网段），还是针对主机名的
+func (r rule) forIP() bool {
+	return r.network != nil
+}
+
+// 根据排除规则、团队策略的排除规则和范围文件创建扫描范围，scopeFile为空时不限制范围
+func New(excludes, policyExcludes []string, scopeFile string) (*Scope, error) {
+	s := &Scope{}
+	for _, text := range policyExcludes {
+		r, err := parseRule(text)
+		if err != nil {
+			return nil, fmt.Errorf("团队策略中%s", err)
+		}
+		r.policy = true
+		s.exclude = append(s.exclude, r)
+	}
+	for _, spec := range excludes {
+		for _, text := range strings.Split(spec, ",") {
+			if strings.TrimSpace(text) == "" {
+				continue
+			}
+			r, err := parseRule(text)
+			if err != nil {
+				return nil, err
+			}
+			s.exclude = append(s.exclude, r)
+		}
+	}
+	if scopeFile != "" {
+		if err := s.loadFile(scopeFile); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// 读取范围文件：每行一条允许的规则，以 ! 开头的行为排除规则，忽略空行和 # 开头的注释
+func (s *Scope) loadFile(filename string) error {
+	file, err := os.Open(filename)
+	if err != nil {
+		return fmt.Errorf("无法读取范围文件: %v", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		exclude := strings.HasPrefix(line, "!")
+		r, err := parseRule(strings.TrimPrefix(line, "!"))
+		if err != nil {
+			return err
+		}
+		if exclude {
+			s.exclude = append(s.exclude, r)
+		} else {
+			s.include = append(s.include, r)
+		}
+	}
+	return scanner.Err()
+}
+
+// 是否设置了任何规则
+func (s *Scope) Empty() bool {
+	return s == nil || (len(s.include) == 0 && len(s.exclude) == 0)
+}
+
+// 判断主机名或IP是否在范围内，不在时返回原因。
+// 范围文件中的主机名规则只约束主机名目标，IP和网段规则只约束IP目标；排除规则对两者都生效。
+// 主机名目标实际连接的IP由 AllowsIP 检查
+func (s *Scope) Check(host string) (bool, string) {
+	if s == nil {
+		return true, ""
+	}
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	ip := net.ParseIP(host)
+	for _, r := range s.exclude {
+		if r.matches(host, ip) {
+			if r.policy {
+				return false, PolicyExcluded
+			}
+			return false, "匹配排除规则 " + r.text
+		}
+	}
+
+	constrained := false
+	for _, r := range s.include {
+		if r.forIP() != (ip != nil) {
+			continue
+		}
+		constrained = true
+		if r.matches(host, ip) {
+			return true, ""
+		}
+	}
+	if constrained {
+		return false, "不在范围文件中"
+	}
+	return true, ""
+}
+
+// 判断连接的IP是否在范围内，用于拦截解析到范围外地址的域名：IP不能匹配排除规则中的IP或网段，
+// 范围文件中有IP或网段规则时还必须落在其中之一
+func (s *Scope) AllowsIP(ip net.IP) bool {
+	if s == nil {
+		return true
+	}
+	for _, r := range s.exclude {
+		if r.forIP() && r.matches("", ip) {
+			return false
+		}
+	}
+	constrained := false
+	for _, r := range s.include {
+		if !r.forIP() {
+			continue
+		}
+		constrained = true
+		if r.matches("", ip) {
+			return true
+		}
+	}
+	return !constrained
+}
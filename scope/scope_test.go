@@ -0,0 +1,167 @@
+package scope
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseRule(t *testing.T) {
+	tests := []struct {
+		text  string
+		host  string
+		ip    string
+		match bool
+	}{
+		{"www.example.com", "www.example.com", "", true},
+		{"WWW.Example.com.", "www.example.com", "", true},
+		{"www.example.com", "dev.www.example.com", "", false},
+		{"*.example.com", "a.b.example.com", "", true},
+		{"*.example.com", "example.com", "", false},
+		{"*.example.com", "example.com.evil.net", "", false},
+		{"dev-*.example.com", "dev-api.example.com", "", true},
+		{"dev-*.example.com", "devapi.example.com", "", false},
+		{`re:^(dev|test)\d*\.example\.com$`, "TEST12.example.com", "", true},
+		{`re:^(dev|test)\d*\.example\.com$`, "prod.example.com", "", false},
+		{"10.0.0.0/8", "10.1.2.3", "10.1.2.3", true},
+		{"10.0.0.0/8", "11.0.0.1", "11.0.0.1", false},
+		{"10.0.0.5", "10.0.0.5", "10.0.0.5", true},
+		{"10.0.0.5", "10.0.0.6", "10.0.0.6", false},
+		{"2001:db8::/32", "2001:db8::1", "2001:db8::1", true},
+		{"10.0.0.0/8", "ten.example.com", "", false},
+	}
+	for _, tt := range tests {
+		r, err := parseRule(tt.text)
+		if err != nil {
+			t.Errorf("parseRule(%q): %v", tt.text, err)
+			continue
+		}
+		if got := r.matches(tt.host, net.ParseIP(tt.ip)); got != tt.match {
+			t.Errorf("%q matches %q = %v, want %v", tt.text, tt.host, got, tt.match)
+		}
+	}
+
+	for _, text := range []string{"re:(", "10.0.0.0/33", "example.com/admin"} {
+		if _, err := parseRule(text); err == nil {
+			t.Errorf("parseRule(%q) accepted", text)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "scope.txt")
+	content := "# 授权范围\n*.example.com\n203.0.113.0/24\n!re:^(vpn|mail)\\.example\\.com$\n\n"
+	if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s, err := New([]string{"staging.example.com,203.0.113.66"}, []string{"*.prod.example.com"}, file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		host   string
+		ok     bool
+		reason string
+	}{
+		{"www.example.com", true, ""},
+		{"WWW.EXAMPLE.COM.", true, ""},
+		{"other.net", false, "不在范围文件中"},
+		{"203.0.113.10", true, ""},
+		{"198.51.100.1", false, "不在范围文件中"},
+		// 排除规则优先于范围文件
+		{"vpn.example.com", false, `匹配排除规则 re:^(vpn|mail)\.example\.com$`},
+		{"staging.example.com", false, "匹配排除规则 staging.example.com"},
+		{"203.0.113.66", false, "匹配排除规则 203.0.113.66"},
+		{"db.prod.example.com", false, PolicyExcluded},
+	}
+	for _, tt := range tests {
+		ok, reason := s.Check(tt.host)
+		if ok != tt.ok || reason != tt.reason {
+			t.Errorf("Check(%q) = %v, %q; want %v, %q", tt.host, ok, reason, tt.ok, tt.reason)
+		}
+	}
+}
+
+// 范围文件只列出网段时，域名目标不受限制，反之亦然
+func TestCheckIncludeKinds(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "scope.txt")
+	if err := os.WriteFile(file, []byte("10.0.0.0/8\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s, err := New(nil, nil, file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := s.Check("anything.example.com"); !ok {
+		t.Error("hostname constrained by an IP-only scope file")
+	}
+	if ok, _ := s.Check("192.168.1.1"); ok {
+		t.Error("IP outside the scope file accepted")
+	}
+}
+
+func TestAllowsIP(t *testing.T) {
+	s, err := New([]string{"10.0.0.0/8", "*.internal.example.com", "2001:db8::/32"}, []string{"192.0.2.7"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string]bool{
+		"10.20.30.40":   false,
+		"192.0.2.7":     false,
+		"192.0.2.8":     true,
+		"93.184.216.34": true,
+		"2001:db8::5":   false,
+		"2001:db9::5":   true,
+	}
+	for ip, want := range tests {
+		if got := s.AllowsIP(net.ParseIP(ip)); got != want {
+			t.Errorf("AllowsIP(%s) = %v, want %v", ip, got, want)
+		}
+	}
+
+	var none *Scope
+	if !none.AllowsIP(net.ParseIP("10.0.0.1")) || !none.Empty() {
+		t.Error("nil scope restricts targets")
+	}
+	if ok, _ := none.Check("www.example.com"); !ok {
+		t.Error("nil scope rejected a host")
+	}
+}
+
+// 范围文件列出网段时，主机名目标连接的IP也必须落在其中
+func TestAllowsIPIncludeRules(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "scope.txt")
+	if err := os.WriteFile(file, []byte("*.example.com\n203.0.113.0/24\n2001:db8::/32\n!203.0.113.66\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s, err := New(nil, nil, file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string]bool{
+		"203.0.113.10":  true,
+		"203.0.113.66":  false,
+		"93.184.216.34": false,
+		"2001:db8::5":   true,
+		"2001:db9::5":   false,
+	}
+	for ip, want := range tests {
+		if got := s.AllowsIP(net.ParseIP(ip)); got != want {
+			t.Errorf("AllowsIP(%s) = %v, want %v", ip, got, want)
+		}
+	}
+
+	// 只有主机名规则时不限制连接的IP
+	hostsOnly := filepath.Join(t.TempDir(), "hosts.txt")
+	if err := os.WriteFile(hostsOnly, []byte("*.example.com\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if s, err = New(nil, nil, hostsOnly); err != nil {
+		t.Fatal(err)
+	}
+	if !s.AllowsIP(net.ParseIP("93.184.216.34")) {
+		t.Error("hostname-only scope file restricted connection IPs")
+	}
+}
@@ -0,0 +1,89 @@
+package screenshot
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"subdomain-checker/auth"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/fetch"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+)
+
+// 认证凭据，为nil时浏览器不认证
+var credentials *auth.Store
+
+// 设置截图时使用的认证凭据
+func SetCredentials(store *auth.Store) {
+	credentials = store
+}
+
+// 配置了凭据或扫描范围时拦截浏览器请求：范围外的请求直接失败，不会发出；
+// Bearer凭据只附加到匹配主机的请求上，不会发送给页面引用的第三方资源；
+// Basic和NTLM在服务器质询时由浏览器完成认证，没有匹配凭据的质询直接取消，避免等待认证对话框
+func interceptRequests(ctx context.Context) error {
+	if credentials == nil && scanScope == nil {
+		return nil
+	}
+	chromedp.ListenTarget(ctx, func(ev interface{}) {
+		switch ev := ev.(type) {
+		case *fetch.EventRequestPaused:
+			go continueRequest(ctx, ev)
+		case *fetch.EventAuthRequired:
+			go continueWithAuth(ctx, ev)
+		}
+	})
+	return fetch.Enable().WithHandleAuthRequests(credentials.HasChallengeAuth()).Do(ctx)
+}
+
+// 放行被拦截的请求，需要时附加Bearer令牌；请求范围外的主机时使请求失败
+func continueRequest(ctx context.Context, ev *fetch.EventRequestPaused) {
+	execCtx := cdp.WithExecutor(ctx, chromedp.FromContext(ctx).Target)
+	continueReq := fetch.ContinueRequest(ev.RequestID)
+
+	var host string
+	if u, err := url.Parse(ev.Request.URL); err == nil {
+		host = u.Hostname()
+	}
+	if !allowedHost(host) {
+		_ = fetch.FailRequest(ev.RequestID, network.ErrorReasonBlockedByClient).Do(execCtx)
+		return
+	}
+	if cred := credentials.For(host); cred != nil && cred.Type == auth.Bearer {
+		headers := make([]*fetch.HeaderEntry, 0, len(ev.Request.Headers)+1)
+		authorized := false
+		for name, value := range ev.Request.Headers {
+			authorized = authorized || strings.EqualFold(name, "Authorization")
+			headers = append(headers, &fetch.HeaderEntry{Name: name, Value: fmt.Sprint(value)})
+		}
+		if !authorized {
+			headers = append(headers, &fetch.HeaderEntry{Name: "Authorization", Value: "Bearer " + cred.Token})
+			continueReq = continueReq.WithHeaders(headers)
+		}
+	}
+	_ = continueReq.Do(execCtx)
+}
+
+// 回应服务器的认证质询
+func continueWithAuth(ctx context.Context, ev *fetch.EventAuthRequired) {
+	execCtx := cdp.WithExecutor(ctx, chromedp.FromContext(ctx).Target)
+	response := &fetch.AuthChallengeResponse{Response: fetch.AuthChallengeResponseResponseCancelAuth}
+
+	var host string
+	if u, err := url.Parse(ev.AuthChallenge.Origin); err == nil {
+		host = u.Hostname()
+	}
+	// 代理认证不使用目标站点的凭据
+	if cred := credentials.For(host); cred != nil && cred.Type != auth.Bearer && ev.AuthChallenge.Source != fetch.AuthChallengeSourceProxy {
+		response = &fetch.AuthChallengeResponse{
+			Response: fetch.AuthChallengeResponseResponseProvideCredentials,
+			Username: cred.BrowserUsername(),
+			Password: cred.Password,
+		}
+	}
+	_ = fetch.ContinueWithAuth(ev.RequestID, response).Do(execCtx)
+}
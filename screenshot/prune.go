@@ -0,0 +1,109 @@
+package screenshot
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 清理时只会删除这些扩展名的文件，截图目录中的其他文件始终保留
+var imageExtensions = map[string]bool{".png": true, ".jpg": true, ".jpeg": true, ".webp": true}
+
+// 截图清理条件，两个条件都设置时满足任一条件的截图即被清理
+type PruneOptions struct {
+	OlderThan  time.Duration   // 修改时间早于此时长之前的截图，0表示不按时间清理
+	Referenced map[string]bool // 保存的扫描结果引用的截图绝对路径，nil表示不按引用清理
+	DryRun     bool            // 只列出将被清理的文件，不删除
+}
+
+// 清理结果
+type PruneStats struct {
+	Files   []string // 被清理（或DryRun时将被清理）的文件
+	Bytes   int64    // 释放的空间
+	Kept    int      // 保留的文件数
+	Removed int      // 删除的空目录数
+}
+
+// 清理截图目录：删除过期或未被引用的截图，再删除清理后变空的子目录（如按扫描保存的目录）。
+// dir为当前目录或其上级目录、或其中含有.git目录时拒绝清理，以免误删报告、源码等其他文件
+func Prune(dir string, opts PruneOptions) (PruneStats, error) {
+	var stats PruneStats
+	if err := checkPruneDir(dir); err != nil {
+		return stats, err
+	}
+	cutoff := time.Now().Add(-opts.OlderThan)
+	var dirs []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return fmt.Errorf("%s 中含有.git目录，不像是截图目录，拒绝清理", dir)
+			}
+			if path != dir {
+				dirs = append(dirs, path)
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() || !imageExtensions[strings.ToLower(filepath.Ext(path))] {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return err
+		}
+		expired := opts.OlderThan > 0 && info.ModTime().Before(cutoff)
+		unreferenced := opts.Referenced != nil && !opts.Referenced[abs]
+		if !expired && !unreferenced {
+			stats.Kept++
+			return nil
+		}
+		stats.Files = append(stats.Files, path)
+		stats.Bytes += info.Size()
+		return nil
+	})
+	if err != nil || opts.DryRun {
+		return stats, err
+	}
+	// 遍历完整个目录再删除，发现.git目录时不会删除任何文件
+	for _, file := range stats.Files {
+		if err := os.Remove(file); err != nil {
+			return stats, err
+		}
+	}
+
+	// 由深到浅删除空目录，非空目录删除失败时忽略
+	sort.Sort(sort.Reverse(sort.StringSlice(dirs)))
+	for _, d := range dirs {
+		if os.Remove(d) == nil {
+			stats.Removed++
+		}
+	}
+	return stats, nil
+}
+
+// 检查dir不是当前目录或其上级目录
+func checkPruneDir(dir string) error {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	// 当前目录在dir之内时，相对路径不以 .. 开头
+	if rel, err := filepath.Rel(abs, wd); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("%s 是当前目录或其上级目录，拒绝清理", dir)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package screenshot
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, path string, age time.Duration) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	mtime := time.Now().Add(-age)
+	if err := os.Chtimes(path, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func TestPruneOnlyImages(t *testing.T) {
+	dir := t.TempDir()
+	old := 48 * time.Hour
+	writeFile(t, filepath.Join(dir, "20240501-100000", "https_a.example.com_443.png"), old)
+	writeFile(t, filepath.Join(dir, "b.JPG"), old)
+	writeFile(t, filepath.Join(dir, "new.png"), 0)
+	writeFile(t, filepath.Join(dir, "report.html"), old)
+	writeFile(t, filepath.Join(dir, "notes", "todo.txt"), old)
+
+	stats, err := Prune(dir, PruneOptions{OlderThan: 24 * time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats.Files) != 2 || stats.Kept != 1 || stats.Removed != 1 {
+		t.Errorf("stats = %+v", stats)
+	}
+	for name, want := range map[string]bool{
+		"20240501-100000": false,
+		"b.JPG":           false,
+		"new.png":         true,
+		"report.html":     true,
+		"notes/todo.txt":  true,
+	} {
+		if got := exists(filepath.Join(dir, name)); got != want {
+			t.Errorf("%s exists = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestPruneRefusesUnsafeDirs(t *testing.T) {
+	dir := t.TempDir()
+	shot := filepath.Join(dir, "repo", "screenshots", "a.png")
+	writeFile(t, shot, 48*time.Hour)
+	if err := os.MkdirAll(filepath.Join(dir, "repo", ".git", "objects"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(filepath.Join(dir, "repo", "screenshots")); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	for _, target := range []string{".", "..", filepath.Join(dir, "repo")} {
+		if _, err := Prune(target, PruneOptions{OlderThan: time.Hour}); err == nil {
+			t.Errorf("Prune(%q) accepted", target)
+		}
+	}
+	// 当前目录之外但含有.git目录
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Prune("repo", PruneOptions{OlderThan: time.Hour}); err == nil {
+		t.Error("Prune of a directory containing .git accepted")
+	}
+	if !exists(shot) {
+		t.Error("screenshot deleted by a refused prune")
+	}
+}
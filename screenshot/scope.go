@@ -0,0 +1,191 @@
+package screenshot
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"strings"
+	"sync"
+	"time"
+
+	"subdomain-checker/scope"
+)
+
+// 扫描范围，为nil时浏览器请求不受限制
+var scanScope *scope.Scope
+
+// 设置截图时的扫描范围：浏览器对范围外主机的请求（重定向、图片、脚本等）会被拦截，
+// 所有连接经由本地的范围代理建立，只连接范围内的IP
+func SetScope(s *scope.Scope) {
+	if s.Empty() {
+		s = nil
+	}
+	scanScope = s
+}
+
+// 浏览器请求的主机是否在扫描范围内：主机名需通过范围检查，IP和固定解析的虚拟主机按其IP检查。
+// 其余主机名实际连接的IP由范围代理检查
+func allowedHost(host string) bool {
+	if scanScope == nil {
+		return true
+	}
+	if ok, _ := scanScope.Check(host); !ok {
+		return false
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return scanScope.AllowsIP(ip)
+	}
+	if ip := net.ParseIP(hostIPs[host]); ip != nil {
+		return scanScope.AllowsIP(ip)
+	}
+	return true
+}
+
+// 范围代理的地址，首次使用时启动，所有截图实例共用
+var (
+	proxyOnce sync.Once
+	proxyAddr string
+	proxyErr  error
+)
+
+// 返回范围代理的地址（host:port），需要时启动代理
+func scopeProxyAddr() (string, error) {
+	proxyOnce.Do(func() {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			proxyErr = err
+			return
+		}
+		proxyAddr = listener.Addr().String()
+		go http.Serve(listener, newScopeProxy())
+	})
+	return proxyAddr, proxyErr
+}
+
+// 范围代理：设置扫描范围时浏览器经由此代理建立所有连接。代理自行解析主机名并只连接范围内的IP，
+// 检查的正是实际连接的地址，不会因浏览器与代理分别解析得到不同结果而放过范围外的IP
+type scopeProxy struct {
+	forward *httputil.ReverseProxy
+}
+
+func newScopeProxy() *scopeProxy {
+	transport := &http.Transport{
+		DialContext:         dialInScope,
+		TLSHandshakeTimeout: 10 * time.Second,
+		IdleConnTimeout:     30 * time.Second,
+	}
+	return &scopeProxy{forward: &httputil.ReverseProxy{
+		// 原样转发请求，不添加X-Forwarded-For等代理请求头
+		Rewrite:   func(*httputil.ProxyRequest) {},
+		Transport: transport,
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+		},
+	}}
+}
+
+func (p *scopeProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodConnect {
+		p.tunnel(w, r)
+		return
+	}
+	if r.URL.Host == "" {
+		http.Error(w, "只接受代理请求", http.StatusBadRequest)
+		return
+	}
+	p.forward.ServeHTTP(w, r)
+}
+
+// 处理CONNECT请求（HTTPS、WebSocket），连接范围内的地址后双向转发
+func (p *scopeProxy) tunnel(w http.ResponseWriter, r *http.Request) {
+	upstream, err := dialInScope(r.Context(), "tcp", r.Host)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		upstream.Close()
+		http.Error(w, "不支持CONNECT", http.StatusInternalServerError)
+		return
+	}
+	client, buffered, err := hijacker.Hijack()
+	if err != nil {
+		upstream.Close()
+		return
+	}
+	defer client.Close()
+	defer upstream.Close()
+	if _, err := io.WriteString(client, "HTTP/1.1 200 Connection Established\r\n\r\n"); err != nil {
+		return
+	}
+	done := make(chan struct{})
+	go func() {
+		io.Copy(upstream, buffered)
+		if tcp, ok := upstream.(*net.TCPConn); ok {
+			tcp.CloseWrite()
+		}
+		close(done)
+	}()
+	io.Copy(client, upstream)
+	client.Close()
+	<-done
+}
+
+// 连接范围内的地址：主机名需通过范围检查，解析出的IP逐个检查，只连接范围内的IP，解析失败时拒绝连接
+func dialInScope(ctx context.Context, network, address string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if ok, reason := scanScope.Check(host); !ok {
+		return nil, fmt.Errorf("%s 不在扫描范围内（%s）", host, reason)
+	}
+	ips, err := resolveHost(ctx, host)
+	if err != nil {
+		return nil, fmt.Errorf("无法解析 %s: %w", host, err)
+	}
+	dialer := net.Dialer{Timeout: 10 * time.Second}
+	lastErr := fmt.Errorf("%s 解析到的IP都不在扫描范围内", host)
+	for _, ip := range ips {
+		if !scanScope.AllowsIP(ip) {
+			continue
+		}
+		conn, err := dialer.DialContext(ctx, network, net.JoinHostPort(ip.String(), port))
+		if err == nil {
+			return conn, nil
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+// 解析浏览器请求的主机：IP直接使用，固定解析的虚拟主机使用固定的IP，DNS缓存中有结果时使用缓存，其余主机名查询DNS
+func resolveHost(ctx context.Context, host string) ([]net.IP, error) {
+	if ip := net.ParseIP(host); ip != nil {
+		return []net.IP{ip}, nil
+	}
+	if ip := net.ParseIP(hostIPs[host]); ip != nil {
+		return []net.IP{ip}, nil
+	}
+	if cachedIPs != nil {
+		if ips := cachedIPs(host); len(ips) > 0 {
+			return ips, nil
+		}
+	}
+	lookupCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	addrs, err := net.DefaultResolver.LookupIPAddr(lookupCtx, host)
+	if err != nil {
+		return nil, err
+	}
+	ips := make([]net.IP, len(addrs))
+	for i, addr := range addrs {
+		ips[i] = addr.IP
+	}
+	return ips, nil
+}
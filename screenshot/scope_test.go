@@ -0,0 +1,116 @@
+package screenshot
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"subdomain-checker/scope"
+)
+
+func TestAllowedHost(t *testing.T) {
+	s, err := scope.New([]string{"cdn.example.net,10.0.0.0/8"}, nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetScope(s)
+	SetHostRules(map[string]string{"dev.example.com": "10.0.0.5", "www.example.com": "192.0.2.1"})
+	defer func() {
+		SetScope(nil)
+		SetHostRules(nil)
+	}()
+
+	tests := map[string]bool{
+		"cdn.example.net": false, // 排除的主机名
+		"10.1.1.1":        false, // 排除的IP
+		"192.0.2.1":       true,
+		"dev.example.com": false, // 固定解析到被排除的IP
+		"www.example.com": true,
+	}
+	for host, want := range tests {
+		if got := allowedHost(host); got != want {
+			t.Errorf("allowedHost(%q) = %v, want %v", host, got, want)
+		}
+	}
+
+	SetScope(nil)
+	if !allowedHost("cdn.example.net") {
+		t.Error("request blocked without a scope")
+	}
+}
+
+// 范围代理只连接范围内的IP，主机名解析失败或只解析到范围外的IP时拒绝
+func TestScopeProxy(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "ok")
+	}))
+	defer server.Close()
+	tlsServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "ok")
+	}))
+	defer tlsServer.Close()
+	plainPort := portOf(t, server.URL)
+	tlsPort := portOf(t, tlsServer.URL)
+
+	file := filepath.Join(t.TempDir(), "scope.txt")
+	if err := os.WriteFile(file, []byte("*.example.com\n*.invalid\n127.0.0.0/8\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s, err := scope.New([]string{"127.0.0.2"}, nil, file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetScope(s)
+	SetHostRules(map[string]string{
+		"www.example.com":     "127.0.0.1",
+		"blocked.example.com": "127.0.0.2", // 固定解析到被排除的IP
+		"outside.example.com": "192.0.2.1", // 不在范围文件的网段中
+	})
+	defer func() {
+		SetScope(nil)
+		SetHostRules(nil)
+	}()
+
+	proxy := httptest.NewServer(newScopeProxy())
+	defer proxy.Close()
+	proxyURL, _ := url.Parse(proxy.URL)
+	transport := tlsServer.Client().Transport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyURL(proxyURL)
+	client := &http.Client{Transport: transport}
+
+	tests := []struct {
+		url string
+		ok  bool
+	}{
+		{"http://www.example.com:" + plainPort, true},
+		{"https://www.example.com:" + tlsPort, true},
+		{"http://127.0.0.1:" + plainPort, true},
+		{"http://blocked.example.com:" + plainPort, false},
+		{"https://blocked.example.com:" + tlsPort, false},
+		{"http://outside.example.com:" + plainPort, false},
+		{"http://other.net:" + plainPort, false},        // 主机名不在范围内
+		{"http://squirrel.invalid:" + plainPort, false}, // 解析失败
+	}
+	for _, tt := range tests {
+		resp, err := client.Get(tt.url)
+		ok := err == nil && resp.StatusCode == http.StatusOK
+		if resp != nil {
+			resp.Body.Close()
+		}
+		if ok != tt.ok {
+			t.Errorf("GET %s through the scope proxy: ok = %v, want %v (err: %v)", tt.url, ok, tt.ok, err)
+		}
+	}
+}
+
+func portOf(t *testing.T, raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u.Port()
+}
@@ -6,14 +6,21 @@ import (
 	"image"
 	"image/color"
 	"image/png"
+	"net"
+	neturl "net/url"
 	"os"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"subdomain-checker/logger"
+	"subdomain-checker/utils"
+
+	"github.com/chromedp/cdproto/network"
 	"github.com/chromedp/chromedp"
 	"github.com/fogleman/gg"
 )
@@ -23,7 +30,15 @@ type ScreenshotTask struct {
 	URL      string
 	Filename string
 	Dir      string
-	Result   chan<- string // 返回截图路径或空字符串（失败时）
+	Result   chan<- Capture // 返回截图结果，截图失败时路径为空
+}
+
+// 截图结果，同时携带浏览器渲染后的页面标题和DOM，
+// 用于补全依赖JavaScript渲染、原始响应中标题为空的页面信息
+type Capture struct {
+	Path  string // 截图路径，失败时为空
+	Title string // 渲染后的document.title
+	HTML  string // 渲染后的document.documentElement.outerHTML
 }
 
 // 截图工作池
@@ -38,24 +53,27 @@ type ScreenshotPool struct {
 	totalCount   int64
 }
 
+// 截图队列已满时提交任务的最长等待时间
+const submitTimeout = 60 * time.Second
+
 // 创建新的截图工作池
 func NewScreenshotPool(workers int) *ScreenshotPool {
 	return &ScreenshotPool{
-		tasks:   make(chan ScreenshotTask, workers*2), // 缓冲大小为工作者数量的2倍
+		tasks:   make(chan ScreenshotTask, workers*10), // 缓冲大小为工作者数量的10倍，HTTP检测可以先于截图进行
 		workers: workers,
 	}
 }
 
 // 启动截图工作池 - 高并发优化版本，带重试机制
 func (p *ScreenshotPool) Start() {
-	fmt.Printf("🚀 启动 %d 个截图工作者 (高并发优化版本)\n", p.workers)
+	fmt.Fprintf(utils.Console, "🚀 启动 %d 个截图工作者 (高并发优化版本)\n", p.workers)
 
 	// 启动指定数量的工作者
 	for i := 0; i < p.workers; i++ {
 		p.wg.Add(1)
 		go func(workerId int) {
 			defer p.wg.Done()
-			fmt.Printf("📸 截图工作者 %d 启动\n", workerId)
+			logger.Debug("截图工作者启动", "worker", workerId)
 
 			for task := range p.tasks {
 				atomic.AddInt64(&p.totalCount, 1)
@@ -63,9 +81,9 @@ func (p *ScreenshotPool) Start() {
 
 				// 轻量级资源监控 - 只在极端情况下限制
 				if !resourceMonitor.CanStartTask() {
-					fmt.Printf("⚠️  工作者 %d 系统资源极度不足，跳过任务: %s\n", workerId, task.URL)
+					logger.Warn("系统资源极度不足，跳过截图", "worker", workerId, "url", task.URL)
 					atomic.AddInt64(&p.failureCount, 1)
-					task.Result <- ""
+					task.Result <- Capture{}
 					continue
 				}
 
@@ -79,7 +97,7 @@ func (p *ScreenshotPool) Start() {
 				// 每处理1000个任务进行一次垃圾回收和资源清理
 				if taskCount%1000 == 0 {
 					if time.Since(lastGCTime) > 30*time.Second {
-						fmt.Printf("🧹 工作者 %d 执行资源清理 (已处理%d个任务)\n", workerId, taskCount)
+						logger.Debug("执行资源清理", "worker", workerId, "tasks", taskCount)
 						runtime.GC()
 						lastGCTime = time.Now()
 					}
@@ -87,7 +105,7 @@ func (p *ScreenshotPool) Start() {
 
 				// 每处理5000个任务暂停一下，让系统恢复
 				if taskCount%5000 == 0 {
-					fmt.Printf("⏸️  工作者 %d 短暂休息，让系统恢复 (已处理%d个任务)\n", workerId, taskCount)
+					logger.Info("截图工作者短暂休息，让系统恢复", "worker", workerId, "tasks", taskCount)
 					time.Sleep(2 * time.Second)
 				}
 
@@ -99,17 +117,18 @@ func (p *ScreenshotPool) Start() {
 					if retry > 0 {
 						// 重试前等待更长时间，给网络和系统更多恢复时间
 						waitTime := time.Duration(retry*500) * time.Millisecond
-						fmt.Printf("🔄 工作者 %d 重试截图 %s (第%d次，等待%v)\n", workerId, task.URL, retry+1, waitTime)
+						logger.Debug("重试截图", "worker", workerId, "url", task.URL, "attempt", retry+1, "wait", waitTime)
 						time.Sleep(waitTime)
 					} else {
-						fmt.Printf("🔄 工作者 %d 开始截图: %s\n", workerId, task.URL)
+						logger.Debug("开始截图", "worker", workerId, "url", task.URL)
 					}
 
 					// 尝试截图
-					if err := TakeScreenshotIndependent(task.URL, screenshotPath); err == nil {
+					if page, err := TakeScreenshotRendered(task.URL, screenshotPath); err == nil {
 						atomic.AddInt64(&p.successCount, 1)
-						fmt.Printf("✅ 工作者 %d 截图成功: %s\n", workerId, task.URL)
-						task.Result <- screenshotPath
+						logger.Debug("截图成功", "worker", workerId, "url", task.URL)
+						page.Path = screenshotPath
+						task.Result <- page
 						success = true
 					} else {
 						// 检查是否是网络错误
@@ -124,40 +143,40 @@ func (p *ScreenshotPool) Start() {
 							if isNetworkError {
 								// 网络错误仍然算作成功（生成了错误图片）
 								atomic.AddInt64(&p.successCount, 1)
-								fmt.Printf("🌐 工作者 %d 网络错误，已生成错误图片: %s - %v\n", workerId, task.URL, err)
-								task.Result <- screenshotPath
+								logger.Warn("网络错误，已生成错误图片", "worker", workerId, "url", task.URL, "error", err)
+								task.Result <- Capture{Path: screenshotPath}
 								success = true
 							} else {
 								atomic.AddInt64(&p.failureCount, 1)
-								fmt.Printf("❌ 工作者 %d 截图最终失败: %s - %v\n", workerId, task.URL, err)
-								task.Result <- ""
+								logger.Error("截图失败", "worker", workerId, "url", task.URL, "error", err)
+								task.Result <- Capture{}
 							}
 						} else {
 							if isNetworkError {
-								fmt.Printf("🌐 工作者 %d 网络错误，准备重试: %s - %v\n", workerId, task.URL, err)
+								logger.Debug("网络错误，准备重试", "worker", workerId, "url", task.URL, "error", err)
 							} else {
-								fmt.Printf("⚠️  工作者 %d 截图失败，准备重试: %s - %v\n", workerId, task.URL, err)
+								logger.Debug("截图失败，准备重试", "worker", workerId, "url", task.URL, "error", err)
 							}
 						}
 					}
 				}
 			}
 
-			fmt.Printf("🏁 截图工作者 %d 结束\n", workerId)
+			logger.Debug("截图工作者结束", "worker", workerId)
 		}(i)
 	}
 }
 
 // 提交截图任务 - 高并发优化版本，带队列管理
-func (p *ScreenshotPool) Submit(url, filename, dir string) <-chan string {
-	result := make(chan string, 1)
+func (p *ScreenshotPool) Submit(url, filename, dir string) <-chan Capture {
+	result := make(chan Capture, 1)
 
 	// 检查工作池是否已关闭
 	p.mutex.RLock()
 	if p.closed {
 		p.mutex.RUnlock()
-		fmt.Printf("⚠️  截图工作池已关闭，跳过任务: %s\n", url)
-		result <- ""
+		logger.Warn("截图工作池已关闭，跳过任务", "url", url)
+		result <- Capture{}
 		return result
 	}
 	p.mutex.RUnlock()
@@ -166,8 +185,8 @@ func (p *ScreenshotPool) Submit(url, filename, dir string) <-chan string {
 	defer func() {
 		if r := recover(); r != nil {
 			// 如果发生panic（通常是向已关闭的channel发送数据），返回空结果
-			fmt.Printf("❌ 提交截图任务时发生panic: %s - %v\n", url, r)
-			result <- ""
+			logger.Error("提交截图任务时发生panic", "url", url, "panic", r)
+			result <- Capture{}
 		}
 	}()
 
@@ -179,15 +198,14 @@ func (p *ScreenshotPool) Submit(url, filename, dir string) <-chan string {
 		Result:   result,
 	}
 
-	// 使用带超时的select语句发送任务，避免长时间阻塞
+	// 队列已满时等待，使HTTP检测与截图保持背压；超时仍无法提交则跳过，避免工作池异常时永久阻塞
 	select {
 	case p.tasks <- task:
 		// 成功发送任务
-		fmt.Printf("📋 任务已提交到队列: %s\n", url)
-	case <-time.After(1 * time.Second):
-		// 如果1秒内无法提交任务，说明队列可能已满
-		fmt.Printf("⚠️  截图任务队列繁忙，跳过任务: %s\n", url)
-		result <- ""
+		logger.Debug("截图任务已提交到队列", "url", url)
+	case <-time.After(submitTimeout):
+		logger.Warn("截图任务队列繁忙，跳过任务", "url", url)
+		result <- Capture{}
 	}
 
 	return result
@@ -209,39 +227,103 @@ func (p *ScreenshotPool) Stop() {
 	success := atomic.LoadInt64(&p.successCount)
 	failure := atomic.LoadInt64(&p.failureCount)
 
-	fmt.Printf("📸 截图工作池已停止\n")
+	fmt.Fprintf(utils.Console, "📸 截图工作池已停止\n")
 	if total > 0 {
 		successRate := float64(success) / float64(total) * 100
-		fmt.Printf("📊 截图统计: 总计%d个, 成功%d个, 失败%d个, 成功率%.1f%%\n",
+		fmt.Fprintf(utils.Console, "📊 截图统计: 总计%d个, 成功%d个, 失败%d个, 成功率%.1f%%\n",
 			total, success, failure, successRate)
 
 		// 根据成功率给出性能评估
 		if successRate >= 95 {
-			fmt.Printf("✅ 截图性能优秀: 成功率%.1f%% (≥95%%)\n", successRate)
+			fmt.Fprintf(utils.Console, "✅ 截图性能优秀: 成功率%.1f%% (≥95%%)\n", successRate)
 		} else if successRate >= 85 {
-			fmt.Printf("⚖️  截图性能良好: 成功率%.1f%% (85-95%%)\n", successRate)
+			fmt.Fprintf(utils.Console, "⚖️  截图性能良好: 成功率%.1f%% (85-95%%)\n", successRate)
 		} else if successRate >= 70 {
-			fmt.Printf("⚠️  截图性能一般: 成功率%.1f%% (70-85%%)\n", successRate)
+			fmt.Fprintf(utils.Console, "⚠️  截图性能一般: 成功率%.1f%% (70-85%%)\n", successRate)
 		} else {
-			fmt.Printf("❌ 截图性能较差: 成功率%.1f%% (<70%%)\n", successRate)
+			fmt.Fprintf(utils.Console, "❌ 截图性能较差: 成功率%.1f%% (<70%%)\n", successRate)
 		}
 
 		if failure > 0 {
-			fmt.Printf("⚠️  有%d个截图失败，可能原因：\n", failure)
-			fmt.Printf("   • 网络超时或连接失败\n")
-			fmt.Printf("   • 域名无法访问或DNS解析失败\n")
-			fmt.Printf("   • Chrome进程启动失败或崩溃\n")
-			fmt.Printf("   • 系统资源不足（内存/CPU）\n")
-			fmt.Printf("   • 并发数过高导致资源竞争\n")
+			fmt.Fprintf(utils.Console, "⚠️  有%d个截图失败，可能原因：\n", failure)
+			fmt.Fprintf(utils.Console, "   • 网络超时或连接失败\n")
+			fmt.Fprintf(utils.Console, "   • 域名无法访问或DNS解析失败\n")
+			fmt.Fprintf(utils.Console, "   • Chrome进程启动失败或崩溃\n")
+			fmt.Fprintf(utils.Console, "   • 系统资源不足（内存/CPU）\n")
+			fmt.Fprintf(utils.Console, "   • 并发数过高导致资源竞争\n")
 		}
 	} else {
-		fmt.Printf("📊 没有处理任何截图任务\n")
+		fmt.Fprintf(utils.Console, "📊 没有处理任何截图任务\n")
 	}
 }
 
 // 全局变量存储当前并发数，用于动态调整超时
 var currentConcurrency int = 1
 
+// 全局自定义请求头（包括Cookie），截图时附加到浏览器的每个请求
+var extraHeaders network.Headers
+
+// 设置截图时使用的自定义请求头
+func SetExtraHeaders(headers map[string]string) {
+	extraHeaders = make(network.Headers, len(headers))
+	for name, value := range headers {
+		extraHeaders[name] = value
+	}
+}
+
+// 浏览器的主机名解析规则，使没有DNS记录的虚拟主机也能截图
+var hostResolverRules string
+
+// 固定解析的主机名 -> IP，用于检查浏览器请求是否在扫描范围内
+var hostIPs map[string]string
+
+// 设置截图时固定解析的主机名（主机名 -> IP）
+func SetHostRules(hosts map[string]string) {
+	hostIPs = hosts
+	rules := make([]string, 0, len(hosts))
+	for host, ip := range hosts {
+		if strings.Contains(ip, ":") {
+			ip = "[" + ip + "]"
+		}
+		rules = append(rules, "MAP "+host+" "+ip)
+	}
+	sort.Strings(rules)
+	hostResolverRules = strings.Join(rules, ", ")
+}
+
+// 查询DNS缓存中主机名的解析结果，为nil时浏览器自行解析
+var cachedIPs func(host string) []net.IP
+
+// 设置截图时使用的DNS缓存查询函数：截图目标的主机名解析到缓存中的IP，与检测时连接的地址一致
+func SetResolver(lookup func(host string) []net.IP) {
+	cachedIPs = lookup
+}
+
+// 浏览器的主机名解析规则：固定解析的虚拟主机，以及截图目标在DNS缓存中的第一个IP
+func resolverRules(target string) string {
+	u, err := neturl.Parse(target)
+	if err != nil || cachedIPs == nil {
+		return hostResolverRules
+	}
+	host := strings.ToLower(u.Hostname())
+	if _, pinned := hostIPs[host]; pinned || host == "" || net.ParseIP(host) != nil {
+		return hostResolverRules
+	}
+	ips := cachedIPs(host)
+	if len(ips) == 0 {
+		return hostResolverRules
+	}
+	ip := ips[0].String()
+	if ips[0].To4() == nil {
+		ip = "[" + ip + "]"
+	}
+	rule := "MAP " + host + " " + ip
+	if hostResolverRules == "" {
+		return rule
+	}
+	return hostResolverRules + ", " + rule
+}
+
 // 全局计数器，用于大量域名处理时的资源管理
 var globalTaskCounter int64 = 0
 var lastGCTime time.Time = time.Now()
@@ -311,6 +393,12 @@ func calculateTimeout(concurrency int) time.Duration {
 
 // 完全独立的截图函数 - 动态超时优化
 func TakeScreenshotIndependent(url string, screenshotPath string) error {
+	_, err := TakeScreenshotRendered(url, screenshotPath)
+	return err
+}
+
+// 截图并返回渲染后的页面标题和DOM；网络错误时仍生成错误图片，但不返回页面内容
+func TakeScreenshotRendered(url string, screenshotPath string) (Capture, error) {
 	// 检查URL是否包含协议前缀
 	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
 		url = "http://" + url
@@ -341,6 +429,24 @@ func TakeScreenshotIndependent(url string, screenshotPath string) error {
 		chromedp.WindowSize(1280, 720),             // 减少窗口大小提高速度
 	)
 
+	if rules := resolverRules(url); rules != "" {
+		opts = append(opts, chromedp.Flag("host-resolver-rules", rules))
+	}
+
+	// 设置了扫描范围时所有连接经由范围代理建立，包括本机地址
+	if scanScope != nil {
+		addr, err := scopeProxyAddr()
+		if err != nil {
+			return Capture{}, fmt.Errorf("无法启动范围代理: %w", err)
+		}
+		opts = append(opts, chromedp.ProxyServer("http://"+addr), chromedp.Flag("proxy-bypass-list", "<-loopback>"))
+	}
+
+	// 启用User-Agent轮换时，每个截图实例使用随机UA
+	if ua := utils.NextUserAgent(); ua != "" {
+		opts = append(opts, chromedp.UserAgent(ua))
+	}
+
 	allocCtx, allocCancel := chromedp.NewExecAllocator(context.Background(), opts...)
 	defer allocCancel()
 
@@ -353,9 +459,24 @@ func TakeScreenshotIndependent(url string, screenshotPath string) error {
 	defer timeoutCancel()
 
 	var buf []byte
+	var page Capture
 
 	// 智能截图流程 - 处理网络错误和无效响应
 	err := chromedp.Run(timeoutCtx,
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			// 配置了凭据或扫描范围时拦截请求，完成认证并拦截范围外的请求
+			if err := interceptRequests(ctx); err != nil {
+				return err
+			}
+			// 附加自定义请求头和Cookie
+			if len(extraHeaders) == 0 {
+				return nil
+			}
+			if err := network.Enable().Do(ctx); err != nil {
+				return err
+			}
+			return network.SetExtraHTTPHeaders(extraHeaders).Do(ctx)
+		}),
 		chromedp.Navigate(url),
 		chromedp.Sleep(1*time.Second), // 增加等待时间，给网络更多时间
 		chromedp.ActionFunc(func(ctx context.Context) error {
@@ -370,6 +491,11 @@ func TakeScreenshotIndependent(url string, screenshotPath string) error {
 			// 如果页面有任何内容，就继续截图
 			if titleErr == nil || readyErr == nil {
 				time.Sleep(500 * time.Millisecond) // 等待渲染
+				// 等待后再读取标题和DOM，获取JavaScript渲染后的内容；读取失败不影响截图
+				if err := chromedp.Title(&page.Title).Do(ctx); err != nil {
+					page.Title = title
+				}
+				_ = chromedp.Evaluate(`document.documentElement ? document.documentElement.outerHTML : ""`, &page.HTML).Do(ctx)
 				return nil
 			}
 
@@ -391,21 +517,21 @@ func TakeScreenshotIndependent(url string, screenshotPath string) error {
 			// 对于网络错误，尝试生成一个错误页面截图
 			if len(buf) > 0 {
 				// 如果有部分数据，仍然保存
-				return os.WriteFile(screenshotPath, buf, 0644)
+				return Capture{}, os.WriteFile(screenshotPath, buf, 0644)
 			}
 
 			// 生成错误信息图片
-			return generateNetworkErrorImage(screenshotPath, errStr)
+			return Capture{}, generateNetworkErrorImage(screenshotPath, errStr)
 		}
-		return fmt.Errorf("截图失败: %w", err)
+		return Capture{}, fmt.Errorf("截图失败: %w", err)
 	}
 
 	// 检查截图数据是否有效
 	if len(buf) == 0 {
-		return fmt.Errorf("截图数据为空")
+		return Capture{}, fmt.Errorf("截图数据为空")
 	}
 
-	return os.WriteFile(screenshotPath, buf, 0644)
+	return page, os.WriteFile(screenshotPath, buf, 0644)
 }
 
 // 快速截图模式 - 保持向后兼容
@@ -0,0 +1,42 @@
+package screenshot
+
+import (
+	"net"
+	"testing"
+)
+
+// 截图目标按DNS缓存中的IP解析，固定解析的虚拟主机和IP目标不重复添加规则
+func TestResolverRules(t *testing.T) {
+	SetHostRules(map[string]string{"dev.example.com": "10.0.0.5"})
+	SetResolver(func(host string) []net.IP {
+		switch host {
+		case "www.example.com":
+			return []net.IP{net.ParseIP("192.0.2.1"), net.ParseIP("192.0.2.2")}
+		case "v6.example.com":
+			return []net.IP{net.ParseIP("2001:db8::1")}
+		}
+		return nil
+	})
+	defer func() {
+		SetHostRules(nil)
+		SetResolver(nil)
+	}()
+
+	tests := map[string]string{
+		"https://WWW.example.com:8443/": "MAP dev.example.com 10.0.0.5, MAP www.example.com 192.0.2.1",
+		"http://v6.example.com":         "MAP dev.example.com 10.0.0.5, MAP v6.example.com [2001:db8::1]",
+		"http://dev.example.com":        "MAP dev.example.com 10.0.0.5",
+		"http://other.example.com":      "MAP dev.example.com 10.0.0.5",
+		"http://192.0.2.9":              "MAP dev.example.com 10.0.0.5",
+	}
+	for target, want := range tests {
+		if got := resolverRules(target); got != want {
+			t.Errorf("resolverRules(%q) = %q, want %q", target, got, want)
+		}
+	}
+
+	SetHostRules(nil)
+	if got := resolverRules("http://www.example.com"); got != "MAP www.example.com 192.0.2.1" {
+		t.Errorf("without pinned hosts: %q", got)
+	}
+}
@@ -0,0 +1,60 @@
+package sink
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+
+	"subdomain-checker/checker"
+	"subdomain-checker/logger"
+	"subdomain-checker/utils"
+)
+
+// 将每个结果写为一行JSON的写入器（-results-fd），供调度程序等外部程序在扫描过程中逐个读取结果
+type JSONLinesSink struct {
+	w io.WriteCloser
+
+	results chan<- checker.Result
+	queue   <-chan checker.Result
+	wg      sync.WaitGroup
+	written int
+}
+
+// 创建JSON行写入器，Stop时关闭w
+func NewJSONLinesSink(w io.WriteCloser) *JSONLinesSink {
+	results, queue := utils.Unbounded[checker.Result]()
+	return &JSONLinesSink{w: w, results: results, queue: queue}
+}
+
+// 启动后台写入。读取方关闭后不再写入，剩余结果直接丢弃
+func (s *JSONLinesSink) Start() {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		encoder := json.NewEncoder(s.w)
+		var err error
+		for result := range s.queue {
+			if err != nil {
+				continue
+			}
+			if err = encoder.Encode(result); err != nil {
+				logger.Warn("写入结果JSON行失败", "error", err)
+				continue
+			}
+			s.written++
+		}
+	}()
+}
+
+// 提交一个结果，结果进入无界队列，读取方处理慢时不会阻塞调用方
+func (s *JSONLinesSink) Submit(result checker.Result) {
+	s.results <- result
+}
+
+// 停止写入器，写完剩余结果后关闭输出，返回写入的结果数
+func (s *JSONLinesSink) Stop() int {
+	close(s.results)
+	s.wg.Wait()
+	s.w.Close()
+	return s.written
+}
@@ -0,0 +1,32 @@
+-- 每次扫描一行，结束时更新完成时间和统计
+CREATE TABLE IF NOT EXISTS squirrel_scans (
+	id          VARCHAR(128) PRIMARY KEY,
+	version     VARCHAR(32) NOT NULL,
+	started_at  DATETIME(3) NOT NULL,
+	finished_at DATETIME(3) NULL,
+	total       INT NOT NULL DEFAULT 0,
+	alive       INT NOT NULL DEFAULT 0
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- 每个检测结果一行，常用字段单独成列，完整结果的JSON存入data列
+CREATE TABLE IF NOT EXISTS squirrel_results (
+	id          BIGINT AUTO_INCREMENT PRIMARY KEY,
+	scan_id     VARCHAR(128) NOT NULL,
+	domain      TEXT NOT NULL,
+	host        VARCHAR(255) NOT NULL,
+	alive       BOOLEAN NOT NULL,
+	status      INT NOT NULL,
+	status_text VARCHAR(255) NOT NULL,
+	error_type  VARCHAR(32) NOT NULL,
+	title       TEXT NOT NULL,
+	page_type   VARCHAR(255) NOT NULL,
+	severity    VARCHAR(16) NOT NULL,
+	ip          VARCHAR(45) NOT NULL,
+	final_url   TEXT NOT NULL,
+	response_ms BIGINT NOT NULL,
+	checked_at  DATETIME(3) NULL,
+	data        MEDIUMTEXT NOT NULL,
+	INDEX squirrel_results_scan (scan_id),
+	INDEX squirrel_results_host (host),
+	CONSTRAINT squirrel_results_scan_fk FOREIGN KEY (scan_id) REFERENCES squirrel_scans (id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
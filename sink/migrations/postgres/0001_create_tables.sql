@@ -0,0 +1,32 @@
+-- 每次扫描一行，结束时更新完成时间和统计
+CREATE TABLE IF NOT EXISTS squirrel_scans (
+	id          VARCHAR(128) PRIMARY KEY,
+	version     VARCHAR(32) NOT NULL,
+	started_at  TIMESTAMPTZ NOT NULL,
+	finished_at TIMESTAMPTZ,
+	total       INTEGER NOT NULL DEFAULT 0,
+	alive       INTEGER NOT NULL DEFAULT 0
+);
+
+-- 每个检测结果一行，常用字段单独成列，完整结果的JSON存入data列
+CREATE TABLE IF NOT EXISTS squirrel_results (
+	id          BIGSERIAL PRIMARY KEY,
+	scan_id     VARCHAR(128) NOT NULL REFERENCES squirrel_scans (id),
+	domain      TEXT NOT NULL,
+	host        VARCHAR(255) NOT NULL,
+	alive       BOOLEAN NOT NULL,
+	status      INTEGER NOT NULL,
+	status_text VARCHAR(255) NOT NULL,
+	error_type  VARCHAR(32) NOT NULL,
+	title       TEXT NOT NULL,
+	page_type   VARCHAR(255) NOT NULL,
+	severity    VARCHAR(16) NOT NULL,
+	ip          VARCHAR(45) NOT NULL,
+	final_url   TEXT NOT NULL,
+	response_ms BIGINT NOT NULL,
+	checked_at  TIMESTAMPTZ,
+	data        TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS squirrel_results_scan ON squirrel_results (scan_id);
+CREATE INDEX IF NOT EXISTS squirrel_results_host ON squirrel_results (host);
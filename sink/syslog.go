@@ -0,0 +1,287 @@
+package sink
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"subdomain-checker/checker"
+	"subdomain-checker/logger"
+	"subdomain-checker/utils"
+)
+
+// syslog消息格式
+const (
+	SyslogCEF     = "cef"     // CEF（ArcSight通用事件格式），Splunk、QRadar等均可解析
+	SyslogRFC5424 = "rfc5424" // RFC5424，结果字段写在结构化数据中
+)
+
+// syslog的facility：local0
+const syslogFacility = 16
+
+// 结构化数据ID中使用的私有企业号（RFC5424要求自定义SD-ID带@企业号，32473为文档示例专用号）
+const syslogSDID = "squirrel@32473"
+
+// 将结果以syslog消息发送到SIEM的写入器。UDP每条消息一个数据报，
+// TCP按RFC6587使用"长度 消息"的帧格式，连接断开时重连一次
+type SyslogSink struct {
+	network  string
+	addr     string
+	format   string
+	version  string
+	hostname string
+	conn     net.Conn
+
+	results chan<- checker.Result
+	queue   <-chan checker.Result
+	wg      sync.WaitGroup
+	sent    int
+	failed  int
+}
+
+// 创建syslog写入器，target形如 udp://host:514、tcp://host:601，省略协议时使用UDP，省略端口时使用514
+func NewSyslogSink(target, format, version string) (*SyslogSink, error) {
+	if format != SyslogCEF && format != SyslogRFC5424 {
+		return nil, fmt.Errorf("未知的syslog格式 %q，可用格式: %s, %s", format, SyslogCEF, SyslogRFC5424)
+	}
+	network, addr := "udp", target
+	if strings.Contains(target, "://") {
+		u, err := url.Parse(target)
+		if err != nil {
+			return nil, fmt.Errorf("无效的syslog地址 %q: %v", target, err)
+		}
+		network, addr = u.Scheme, u.Host
+	}
+	if network != "udp" && network != "tcp" {
+		return nil, fmt.Errorf("syslog地址只支持udp://和tcp://: %s", target)
+	}
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(addr, "514")
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "-"
+	}
+	s := &SyslogSink{
+		network:  network,
+		addr:     addr,
+		format:   format,
+		version:  version,
+		hostname: hostname,
+	}
+	if err := s.connect(); err != nil {
+		return nil, fmt.Errorf("无法连接syslog服务器 %s: %v", addr, err)
+	}
+	s.results, s.queue = utils.Unbounded[checker.Result]()
+	return s, nil
+}
+
+func (s *SyslogSink) connect() error {
+	conn, err := net.DialTimeout(s.network, s.addr, 10*time.Second)
+	if err != nil {
+		return err
+	}
+	s.conn = conn
+	return nil
+}
+
+// 启动后台发送
+func (s *SyslogSink) Start() {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		for result := range s.queue {
+			if err := s.send(s.message(result)); err != nil {
+				s.failed++
+				logger.Warn("发送syslog消息失败", "domain", result.Domain, "error", err)
+				continue
+			}
+			s.sent++
+		}
+		s.conn.Close()
+	}()
+}
+
+// 提交一个结果，结果进入无界队列，syslog服务器响应慢时不会阻塞调用方
+func (s *SyslogSink) Submit(result checker.Result) {
+	s.results <- result
+}
+
+// 停止写入器，发送剩余结果并返回成功和失败的消息数
+func (s *SyslogSink) Stop() (sent, failed int) {
+	close(s.results)
+	s.wg.Wait()
+	return s.sent, s.failed
+}
+
+// 发送一条消息，TCP连接断开时重连后重试一次
+func (s *SyslogSink) send(msg string) error {
+	frame := msg
+	if s.network == "tcp" {
+		frame = strconv.Itoa(len(msg)) + " " + msg
+	}
+	s.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	_, err := s.conn.Write([]byte(frame))
+	if err == nil || s.network != "tcp" {
+		return err
+	}
+	s.conn.Close()
+	if err := s.connect(); err != nil {
+		return err
+	}
+	s.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	_, err = s.conn.Write([]byte(frame))
+	return err
+}
+
+// 结果的syslog严重性（0-7）和CEF严重性（0-10）
+func severities(result checker.Result) (syslogSeverity, cefSeverity int) {
+	switch result.Severity() {
+	case checker.SeverityHigh:
+		return 2, 8
+	case checker.SeverityMedium:
+		return 4, 5
+	case checker.SeverityLow:
+		return 5, 3
+	}
+	if result.Alive {
+		return 6, 1
+	}
+	return 6, 0
+}
+
+// 事件类型，作为CEF的签名ID和RFC5424的MSGID
+func eventID(result checker.Result) string {
+	switch {
+	case result.DanglingCNAME:
+		return "dangling-cname"
+	case result.Severity() != "":
+		return "finding"
+	case result.Alive:
+		return "alive"
+	}
+	return "dead"
+}
+
+// 事件名称
+func eventName(result checker.Result) string {
+	reasons := result.FindingReasons()
+	switch {
+	case len(reasons) > 0:
+		return "发现: " + strings.Join(reasons, ", ")
+	case result.Alive:
+		return "主机存活"
+	}
+	return "主机无法访问: " + result.StatusText
+}
+
+// 生成完整的syslog消息：RFC5424头部，消息体为CEF或带结构化数据的文本
+func (s *SyslogSink) message(result checker.Result) string {
+	syslogSeverity, cefSeverity := severities(result)
+	timestamp := result.CheckedAt
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+	header := fmt.Sprintf("<%d>1 %s %s squirrel %d %s",
+		syslogFacility*8+syslogSeverity, timestamp.Format(time.RFC3339Nano), s.hostname, os.Getpid(), eventID(result))
+
+	fields := resultFields(result)
+	if s.format == SyslogCEF {
+		var ext []string
+		for _, f := range fields {
+			if f.cef == "" || f.value == "" {
+				continue
+			}
+			ext = append(ext, f.cef+"="+escapeCEFValue(f.value))
+			if label, ok := cefLabels[f.cef]; ok {
+				ext = append(ext, f.cef+"Label="+escapeCEFValue(label))
+			}
+		}
+		ext = append(ext, "rt="+strconv.FormatInt(timestamp.UnixMilli(), 10))
+		return fmt.Sprintf("%s - CEF:0|Squirrelble|Squirrel|%s|%s|%s|%d|%s", header,
+			escapeCEFHeader(s.version), eventID(result), escapeCEFHeader(eventName(result)), cefSeverity, strings.Join(ext, " "))
+	}
+
+	var sd strings.Builder
+	sd.WriteString("[" + syslogSDID)
+	for _, f := range fields {
+		if f.name != "" && f.value != "" {
+			sd.WriteString(" " + f.name + `="` + escapeSDValue(f.value) + `"`)
+		}
+	}
+	sd.WriteString("]")
+	return fmt.Sprintf("%s %s %s %s", header, sd.String(), result.Domain, eventName(result))
+}
+
+// CEF自定义字段的标签
+var cefLabels = map[string]string{
+	"cn1": "StatusCode", "cn2": "ResponseTimeMs", "cs1": "Title", "cs2": "PageType",
+	"cs3": "StatusText", "cs4": "ErrorType", "cs5": "FinalURL", "c6a3": "Device IPv6 Address",
+}
+
+// 结果字段：RFC5424结构化数据中的参数名和对应的CEF扩展键，为空时不写入对应格式
+type syslogField struct {
+	name, cef, value string
+}
+
+// 提取写入消息的结果字段，值为空的字段不写入
+func resultFields(result checker.Result) []syslogField {
+	host, port, scheme := "", "", ""
+	if u, err := url.Parse(result.Domain); err == nil && u.Host != "" {
+		host, port, scheme = u.Hostname(), u.Port(), u.Scheme
+		if port == "" {
+			port = map[string]string{"http": "80", "https": "443"}[scheme]
+		}
+	}
+	dst := "dst"
+	if ip := net.ParseIP(result.IP); ip != nil && ip.To4() == nil {
+		dst = "c6a3"
+	}
+	pageType := ""
+	if result.PageInfo != nil {
+		pageType = result.PageInfo.Type
+	}
+	status := ""
+	if result.Status > 0 {
+		status = strconv.Itoa(result.Status)
+	}
+	return []syslogField{
+		{"domain", "dhost", host},
+		{"url", "request", result.Domain},
+		{"ip", dst, result.IP},
+		{"port", "dpt", port},
+		{"scheme", "app", scheme},
+		{"alive", "", strconv.FormatBool(result.Alive)},
+		{"", "outcome", map[bool]string{true: "success", false: "failure"}[result.Alive]},
+		{"status", "cn1", status},
+		{"status_text", "cs3", result.StatusText},
+		{"response_ms", "cn2", strconv.FormatInt(result.ResponseTime.Milliseconds(), 10)},
+		{"title", "cs1", result.Title},
+		{"page_type", "cs2", pageType},
+		{"error_type", "cs4", string(result.ErrorType)},
+		{"final_url", "cs5", result.FinalURL},
+		{"severity", "", result.Severity()},
+		{"message", "msg", result.Message},
+	}
+}
+
+// CEF头部字段中需要转义 \ 和 |
+func escapeCEFHeader(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "|", `\|`, "\n", " ", "\r", " ").Replace(s)
+}
+
+// CEF扩展值中需要转义 \ 和 =，换行写为\n
+func escapeCEFValue(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "=", `\=`, "\n", `\n`, "\r", `\r`).Replace(s)
+}
+
+// RFC5424结构化数据参数值中需要转义 \、" 和 ]
+func escapeSDValue(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "]", `\]`, "\n", " ", "\r", " ").Replace(s)
+}
@@ -0,0 +1,64 @@
+package stats
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// 正在检测的目标，用于排查卡住的扫描
+type ActiveTarget struct {
+	Domain  string    `json:"domain"`
+	Started time.Time `json:"started"`
+	Elapsed float64   `json:"elapsed_seconds"`
+}
+
+// 记录正在检测的目标及开始时间，由工作者在检测前后调用Begin和End
+type Active struct {
+	mu      sync.Mutex
+	targets map[string]time.Time
+}
+
+// 创建进行中目标的记录器
+func NewActive() *Active {
+	return &Active{targets: make(map[string]time.Time)}
+}
+
+// 开始检测目标
+func (a *Active) Begin(domain string) {
+	a.mu.Lock()
+	a.targets[domain] = time.Now()
+	a.mu.Unlock()
+}
+
+// 目标检测结束
+func (a *Active) End(domain string) {
+	a.mu.Lock()
+	delete(a.targets, domain)
+	a.mu.Unlock()
+}
+
+// 正在检测的目标数
+func (a *Active) Len() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return len(a.targets)
+}
+
+// 返回正在检测的目标，耗时最长的在前
+func (a *Active) List() []ActiveTarget {
+	now := time.Now()
+	a.mu.Lock()
+	list := make([]ActiveTarget, 0, len(a.targets))
+	for domain, started := range a.targets {
+		list = append(list, ActiveTarget{Domain: domain, Started: started, Elapsed: now.Sub(started).Seconds()})
+	}
+	a.mu.Unlock()
+	sort.Slice(list, func(i, j int) bool {
+		if !list[i].Started.Equal(list[j].Started) {
+			return list[i].Started.Before(list[j].Started)
+		}
+		return list[i].Domain < list[j].Domain
+	})
+	return list
+}
@@ -0,0 +1,128 @@
+package stats
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"subdomain-checker/checker"
+)
+
+// 扫描统计汇总器：计数使用原子操作，分类统计（页面类型、状态码、错误类型）由内部锁保护，
+// 由检测流程持有并写入，进度显示、总结、报告等通过Snapshot读取一致的副本
+type Stats struct {
+	processed   atomic.Int64
+	alive       atomic.Int64
+	dead        atomic.Int64
+	errors      atomic.Int64
+	screenshots atomic.Int64
+
+	mu          sync.Mutex
+	pageTypes   map[string]int
+	statusCodes map[int]int
+	errorTypes  map[checker.ErrorType]int
+	clouds      map[string]int
+}
+
+// 统计快照
+type Snapshot struct {
+	Processed   int                       // 已收到结果的目标数
+	Alive       int                       // 存活数
+	Dead        int                       // 无法访问数
+	Errors      int                       // 请求失败（无HTTP响应）的数量
+	Screenshots int                       // 成功截图数
+	PageTypes   map[string]int            // 存活结果的页面类型分布
+	StatusCodes map[int]int               // 状态码分布，0表示无响应
+	ErrorTypes  map[checker.ErrorType]int // 失败类型分布
+	Clouds      map[string]int            // 云服务商分布（-cloud）
+}
+
+// 创建统计汇总器
+func New() *Stats {
+	return &Stats{
+		pageTypes:   make(map[string]int),
+		statusCodes: make(map[int]int),
+		errorTypes:  make(map[checker.ErrorType]int),
+		clouds:      make(map[string]int),
+	}
+}
+
+// 根据已有结果构建统计，用于报告等只有结果列表的场景
+func FromResults(results []checker.Result) *Stats {
+	s := New()
+	for _, result := range results {
+		s.AddProcessed()
+		s.Record(result)
+	}
+	return s
+}
+
+// 记录收到一个结果（尚未汇总），用于进度显示
+func (s *Stats) AddProcessed() {
+	s.processed.Add(1)
+}
+
+// 汇总一个检测结果
+func (s *Stats) Record(result checker.Result) {
+	if result.Alive {
+		s.alive.Add(1)
+	} else {
+		s.dead.Add(1)
+	}
+	if result.Status == 0 {
+		s.errors.Add(1)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.statusCodes[result.Status]++
+	if result.Alive && result.PageInfo != nil {
+		s.pageTypes[result.PageInfo.Type]++
+	}
+	if result.ErrorType != "" {
+		s.errorTypes[result.ErrorType]++
+	}
+	if result.Cloud != "" {
+		s.clouds[result.Cloud]++
+	}
+}
+
+// 记录一次成功截图
+func (s *Stats) RecordScreenshot() {
+	s.screenshots.Add(1)
+}
+
+// 已收到结果的目标数
+func (s *Stats) Processed() int {
+	return int(s.processed.Load())
+}
+
+// 返回当前统计的副本
+func (s *Stats) Snapshot() Snapshot {
+	snap := Snapshot{
+		Processed:   int(s.processed.Load()),
+		Alive:       int(s.alive.Load()),
+		Dead:        int(s.dead.Load()),
+		Errors:      int(s.errors.Load()),
+		Screenshots: int(s.screenshots.Load()),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	snap.PageTypes = make(map[string]int, len(s.pageTypes))
+	for k, v := range s.pageTypes {
+		snap.PageTypes[k] = v
+	}
+	snap.StatusCodes = make(map[int]int, len(s.statusCodes))
+	for k, v := range s.statusCodes {
+		snap.StatusCodes[k] = v
+	}
+	snap.ErrorTypes = make(map[checker.ErrorType]int, len(s.errorTypes))
+	for k, v := range s.errorTypes {
+		snap.ErrorTypes[k] = v
+	}
+	snap.Clouds = make(map[string]int, len(s.clouds))
+	for k, v := range s.clouds {
+		snap.Clouds[k] = v
+	}
+	return snap
+}
@@ -0,0 +1,120 @@
+package utils
+
+import (
+	"mime"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/saintfish/chardet"
+	htmlcharset "golang.org/x/net/html/charset"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// 在页面开头查找meta声明的字符集：<meta charset="gbk"> 或 <meta http-equiv="Content-Type" content="text/html; charset=big5">
+var metaCharsetRegex = regexp.MustCompile(`(?i)<meta[^>]+charset\s*=\s*["']?\s*([\w.:-]+)`)
+
+// 只在页面开头查找meta声明，与浏览器的预扫描范围一致
+const metaScanBytes = 4096
+
+// 将网页内容转换为UTF-8，返回转换后的内容和使用的字符集名称（已是UTF-8时为空）。
+// 依次使用Content-Type的charset、meta声明和内容检测确定字符集
+func ToUTF8(content, contentType string) (string, string) {
+	if utf8.ValidString(content) {
+		return content, ""
+	}
+	enc, name := declaredCharset(content, contentType)
+	if enc == nil {
+		enc, name = DetectCharset(content)
+	}
+	decoded, err := enc.NewDecoder().String(content)
+	if err != nil {
+		return content, ""
+	}
+	return decoded, name
+}
+
+// 响应头或meta中声明的字符集，未声明、无法识别或声明为UTF-8时返回nil
+func declaredCharset(content, contentType string) (encoding.Encoding, string) {
+	var labels []string
+	if _, params, err := mime.ParseMediaType(contentType); err == nil && params["charset"] != "" {
+		labels = append(labels, params["charset"])
+	}
+	head := content[:min(len(content), metaScanBytes)]
+	if m := metaCharsetRegex.FindStringSubmatch(head); m != nil {
+		labels = append(labels, m[1])
+	}
+	for _, label := range labels {
+		// 声明为UTF-8但内容不是合法UTF-8时，声明不可信，改用内容检测
+		if enc, name := htmlcharset.Lookup(label); enc != nil && name != "utf-8" {
+			return enc, name
+		}
+	}
+	return nil, ""
+}
+
+// 字符集检测器，按HTML处理时忽略标签中的ASCII内容
+var charsetDetector = chardet.NewHtmlDetector()
+
+// chardet把按ISO-8859-2语言模型识别出的中欧语言报告为ISO-8859-1/Windows-1252，需要改回ISO-8859-2/Windows-1250
+var centralEuropean = map[string]bool{"cs": true, "hu": true, "pl": true, "ro": true}
+
+// 置信度相同时优先选择的字符集。只有十几个汉字的标题等短内容，各CJK字符集的置信度都是同一个最低值
+var charsetPreference = []string{"gb18030", "big5", "shift_jis", "euc-jp", "euc-kr"}
+
+// 根据内容检测字符集（基于ICU的检测算法），检测结果按WHATWG编码标准映射为解码器
+// （如ISO-8859-1按其超集Windows-1252解码）。按置信度依次尝试检测结果，跳过解码出无效字符的字符集，
+// 都不可用时按Windows-1252处理
+func DetectCharset(content string) (encoding.Encoding, string) {
+	results, _ := charsetDetector.DetectAll([]byte(content))
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Confidence != results[j].Confidence {
+			return results[i].Confidence > results[j].Confidence
+		}
+		ri, rj := preferenceRank(results[i]), preferenceRank(results[j])
+		if ri != rj {
+			return ri < rj
+		}
+		return charsetLabel(results[i]) < charsetLabel(results[j])
+	})
+	for _, result := range results {
+		enc, err := htmlindex.Get(charsetLabel(result))
+		if err != nil || enc == unicode.UTF8 {
+			continue
+		}
+		if decoded, err := enc.NewDecoder().String(content); err != nil || strings.ContainsRune(decoded, utf8.RuneError) {
+			continue
+		}
+		if name, err := htmlindex.Name(enc); err == nil {
+			return enc, name
+		}
+	}
+	return charmap.Windows1252, "windows-1252"
+}
+
+// 检测结果对应的WHATWG字符集标签
+func charsetLabel(result chardet.Result) string {
+	label := strings.ToLower(result.Charset)
+	switch {
+	case label == "gb-18030":
+		return "gb18030"
+	case centralEuropean[result.Language] && label == "iso-8859-1":
+		return "iso-8859-2"
+	case centralEuropean[result.Language] && label == "windows-1252":
+		return "windows-1250"
+	}
+	return label
+}
+
+// 检测结果在charsetPreference中的位置，不在其中的排在最后
+func preferenceRank(result chardet.Result) int {
+	if i := slices.Index(charsetPreference, charsetLabel(result)); i >= 0 {
+		return i
+	}
+	return len(charsetPreference)
+}
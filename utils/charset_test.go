@@ -0,0 +1,60 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/korean"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/traditionalchinese"
+)
+
+func encode(t *testing.T, enc encoding.Encoding, text string) string {
+	t.Helper()
+	encoded, err := enc.NewEncoder().String(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return encoded
+}
+
+func TestToUTF8Detect(t *testing.T) {
+	tests := []struct {
+		enc  encoding.Encoding
+		name string
+		text string
+	}{
+		{simplifiedchinese.GBK, "gb18030", "<title>欢迎访问我们的网站</title><p>这是一个用于测试字符集检测的中文页面，包含常用的汉字和标点符号。我们提供专业的产品和服务，欢迎联系我们。</p>"},
+		{traditionalchinese.Big5, "big5", "<title>歡迎光臨我們的網站</title><p>這是一個用於測試字元集偵測的繁體中文頁面，包含常用的漢字與標點符號。我們提供專業的產品與服務，歡迎與我們聯絡。</p>"},
+		{japanese.ShiftJIS, "shift_jis", "<title>ようこそ私たちのウェブサイトへ</title><p>これは文字コードの判定をテストするための日本語のページです。私たちは専門的な製品とサービスを提供しています。お気軽にお問い合わせください。</p>"},
+		{korean.EUCKR, "euc-kr", "<title>저희 웹사이트에 오신 것을 환영합니다</title><p>이 페이지는 문자 집합 감지를 테스트하기 위한 한국어 페이지입니다. 저희는 전문적인 제품과 서비스를 제공합니다. 언제든지 문의해 주십시오.</p>"},
+		{charmap.ISO8859_2, "iso-8859-2", "<title>Witamy na naszej stronie</title><p>To jest strona w języku polskim służąca do sprawdzania wykrywania zestawu znaków. Oferujemy profesjonalne usługi i produkty. Zapraszamy do kontaktu, chętnie odpowiemy na wszystkie pytania dotyczące naszej oferty.</p>"},
+		{charmap.Windows1251, "windows-1251", "<title>Добро пожаловать на наш сайт</title><p>Это страница на русском языке для проверки определения кодировки. Мы предлагаем профессиональные услуги и продукты. Свяжитесь с нами, мы с удовольствием ответим на все ваши вопросы.</p>"},
+		{charmap.Windows1252, "windows-1252", "<title>Bienvenue sur notre site</title><p>Ceci est une page en français destinée à vérifier la détection du jeu de caractères. Nous proposons des services et des produits de qualité. N'hésitez pas à nous contacter, nous répondrons à toutes vos questions concernant notre offre.</p>"},
+	}
+	for _, tt := range tests {
+		got, name := ToUTF8(encode(t, tt.enc, tt.text), "text/html")
+		if name != tt.name || got != tt.text {
+			t.Errorf("%s: detected %q, decoded %q", tt.name, name, got)
+		}
+	}
+}
+
+// 响应头和meta声明的字符集优先于内容检测
+func TestToUTF8Declared(t *testing.T) {
+	text := "<title>Добро пожаловать</title>"
+	encoded := encode(t, charmap.KOI8R, text)
+	if got, name := ToUTF8(encoded, "text/html; charset=koi8-r"); name != "koi8-r" || got != text {
+		t.Errorf("header charset: %q, %q", name, got)
+	}
+	meta := `<meta charset="koi8-r">` + encoded
+	if got, name := ToUTF8(meta, "text/html"); name != "koi8-r" || !strings.HasSuffix(got, text) {
+		t.Errorf("meta charset: %q, %q", name, got)
+	}
+	if got, name := ToUTF8(text, "text/html; charset=gbk"); name != "" || got != text {
+		t.Errorf("valid UTF-8 converted as %q", name)
+	}
+}
@@ -0,0 +1,70 @@
+package utils
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/idna"
+)
+
+// 对目标（域名、host:port或URL）中的主机名部分应用fn，其余部分保持不变
+func mapTargetHost(target string, fn func(string) string) string {
+	prefix := ""
+	rest := target
+	if i := strings.Index(target, "://"); i >= 0 {
+		prefix, rest = target[:i+3], target[i+3:]
+	}
+
+	end := strings.IndexAny(rest, "/?#")
+	if end < 0 {
+		end = len(rest)
+	}
+	hostPort, suffix := rest[:end], rest[end:]
+
+	// IPv6地址不需要处理
+	if strings.HasPrefix(hostPort, "[") {
+		return target
+	}
+	host, port := hostPort, ""
+	if i := strings.LastIndex(hostPort, ":"); i >= 0 {
+		host, port = hostPort[:i], hostPort[i:]
+	}
+	return prefix + fn(host) + port + suffix
+}
+
+// 将目标中的国际化域名转换为punycode（如 例子.中国 -> xn--fsqu00a.xn--fiqs8s），用于DNS解析和HTTP请求
+// 无法转换的主机名原样返回，由后续检测报告错误
+func ToASCIITarget(target string) string {
+	if isASCII(target) {
+		return target
+	}
+	return mapTargetHost(target, func(host string) string {
+		if ascii, err := idna.Lookup.ToASCII(host); err == nil {
+			return ascii
+		}
+		return host
+	})
+}
+
+// 将目标中的punycode主机名转换为Unicode形式，用于报告中显示；不含punycode时原样返回
+func ToUnicodeTarget(target string) string {
+	if !strings.Contains(strings.ToLower(target), "xn--") {
+		return target
+	}
+	return mapTargetHost(target, func(host string) string {
+		if unicode, err := idna.Display.ToUnicode(host); err == nil {
+			return unicode
+		}
+		return host
+	})
+}
+
+// 判断字符串是否只包含ASCII字符
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
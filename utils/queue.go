@@ -0,0 +1,34 @@
+package utils
+
+// 创建无界队列：发送到in的值按顺序从out取出，in总能立即接收，不会因为消费者慢而阻塞发送者。
+// 关闭in后，队列中剩余的值取完时out被关闭。用于事件处理函数中把结果转交给后台写入器
+func Unbounded[T any]() (chan<- T, <-chan T) {
+	in := make(chan T)
+	out := make(chan T)
+	go func() {
+		defer close(out)
+		input := in
+		var queue []T
+		for input != nil || len(queue) > 0 {
+			var send chan T
+			var next T
+			if len(queue) > 0 {
+				send, next = out, queue[0]
+			}
+			select {
+			case value, ok := <-input:
+				if !ok {
+					input = nil
+					continue
+				}
+				queue = append(queue, value)
+			case send <- next:
+				queue = queue[1:]
+				if len(queue) == 0 {
+					queue = nil
+				}
+			}
+		}
+	}()
+	return in, out
+}
@@ -0,0 +1,218 @@
+package utils
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// 单个CIDR或IP范围最多展开的地址数，避免误输入 /8 之类的大网段
+const MaxExpandHosts = 65536
+
+// 将一行输入展开为检测目标，支持：
+//   - 域名或 host:port（转为小写并去掉末尾的点）
+//   - 完整URL（保留协议、端口和路径）
+//   - IPv4/IPv6地址，IPv6会加上方括号
+//   - CIDR网段（如 10.0.0.0/24，展开为其中的主机地址）
+//   - IPv4范围（如 10.0.0.1-10.0.0.20 或 10.0.0.1-20）
+//   - 以空白或逗号分隔的多个目标
+//
+// 国际化域名（如 例子.中国）会转换为punycode
+func ExpandTarget(input string) ([]string, error) {
+	var targets []string
+	for _, field := range strings.FieldsFunc(input, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t'
+	}) {
+		expanded, err := expandField(field)
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, expanded...)
+	}
+	return targets, nil
+}
+
+// 展开单个目标
+func expandField(field string) ([]string, error) {
+	// 国际化域名统一转换为punycode
+	field = ToASCIITarget(field)
+	if strings.Contains(field, "://") {
+		return []string{normalizeURL(field)}, nil
+	}
+	if strings.Contains(field, "/") {
+		if prefix, err := netip.ParsePrefix(field); err == nil {
+			return expandPrefix(prefix)
+		}
+	}
+	if strings.Contains(field, "-") {
+		if targets, ok, err := expandRange(field); ok {
+			return targets, err
+		}
+	}
+	if addr, err := netip.ParseAddr(field); err == nil && addr.Is6() {
+		return []string{"[" + addr.String() + "]"}, nil
+	}
+	host, path, _ := strings.Cut(field, "/")
+	if path != "" {
+		return []string{normalizeHost(host) + "/" + path}, nil
+	}
+	return []string{normalizeHost(host)}, nil
+}
+
+// 规范化主机名（可带端口）：转为小写并去掉FQDN末尾的点，如 "WWW.Example.COM.:8080" → "www.example.com:8080"
+func normalizeHost(hostport string) string {
+	host, port, err := net.SplitHostPort(hostport)
+	if err != nil {
+		return strings.TrimSuffix(strings.ToLower(hostport), ".")
+	}
+	return net.JoinHostPort(strings.TrimSuffix(strings.ToLower(host), "."), port)
+}
+
+// 将目标归一化为主机名（保留端口），去掉协议、路径和查询参数，如 "https://a.example.com:8443/login" → "a.example.com:8443"
+func HostOnly(target string) string {
+	if strings.Contains(target, "://") {
+		if u, err := url.Parse(target); err == nil && u.Host != "" {
+			return u.Host
+		}
+	}
+	host, _, _ := strings.Cut(target, "/")
+	return host
+}
+
+// 已有的检测目标，去重键 -> 保留的目标。只有裸主机名与同一主机（含端口）的根路径URL视为同一目标，
+// 如 "a.example.com:8080" 和 "http://a.example.com:8080/"；协议不同的URL（如 "http://a.example.com"
+// 和 "https://a.example.com"）以及带路径或查询参数的URL按原样区分
+type TargetSet map[string]string
+
+// 查找与target重复的已有目标
+func (s TargetSet) Find(target string) (string, bool) {
+	for _, key := range duplicateKeys(target) {
+		if kept, ok := s[key]; ok {
+			return kept, true
+		}
+	}
+	return "", false
+}
+
+// 加入目标，已有重复的目标时返回false
+func (s TargetSet) Add(target string) bool {
+	if _, ok := s.Find(target); ok {
+		return false
+	}
+	s[targetKey(target)] = target
+	return true
+}
+
+// 目标自身的去重键：根路径的URL为 "协议://主机"，其余目标按原样
+func targetKey(target string) string {
+	if u, ok := rootURL(target); ok {
+		return strings.ToLower(u.Scheme) + "://" + u.Host
+	}
+	return target
+}
+
+// 可能与target重复的目标的去重键：裸主机名与其各协议的根路径URL重复，根路径的URL与其裸主机名重复
+func duplicateKeys(target string) []string {
+	if u, ok := rootURL(target); ok {
+		return []string{targetKey(target), u.Host}
+	}
+	if strings.Contains(target, "://") || strings.Contains(target, "/") {
+		return []string{target}
+	}
+	return []string{target, "http://" + target, "https://" + target}
+}
+
+// 解析只有根路径的URL
+func rootURL(target string) (*url.URL, bool) {
+	if !strings.Contains(target, "://") {
+		return nil, false
+	}
+	u, err := url.Parse(target)
+	if err != nil || u.Host == "" || (u.Path != "" && u.Path != "/") || u.RawQuery != "" || u.Fragment != "" {
+		return nil, false
+	}
+	return u, true
+}
+
+// 规范化URL：协议和主机名转为小写并去掉主机名末尾的点，去掉单独的根路径斜杠，其余部分原样保留
+func normalizeURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return raw
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = normalizeHost(u.Host)
+	if u.Path == "/" && u.RawQuery == "" && u.Fragment == "" {
+		u.Path = ""
+	}
+	return u.String()
+}
+
+// 展开CIDR网段，IPv4网段（/31、/32除外）会跳过网络地址和广播地址
+func expandPrefix(prefix netip.Prefix) ([]string, error) {
+	prefix = prefix.Masked()
+	hostBits := prefix.Addr().BitLen() - prefix.Bits()
+	if hostBits > 16 {
+		return nil, fmt.Errorf("网段 %s 过大（最多展开 %d 个地址）", prefix, MaxExpandHosts)
+	}
+
+	var targets []string
+	for addr := prefix.Addr(); addr.IsValid() && prefix.Contains(addr); addr = addr.Next() {
+		targets = append(targets, formatAddr(addr))
+	}
+	if prefix.Addr().Is4() && hostBits >= 2 {
+		targets = targets[1 : len(targets)-1]
+	}
+	return targets, nil
+}
+
+// 展开IPv4地址范围，ok为false表示输入不是IP范围（如带连字符的域名）
+func expandRange(field string) (targets []string, ok bool, err error) {
+	startStr, endStr, _ := strings.Cut(field, "-")
+	start, err := netip.ParseAddr(startStr)
+	if err != nil || !start.Is4() {
+		return nil, false, nil
+	}
+
+	var end netip.Addr
+	if n, convErr := strconv.Atoi(endStr); convErr == nil {
+		// 简写形式：10.0.0.1-20 表示最后一段从1到20
+		if n < 0 || n > 255 {
+			return nil, true, fmt.Errorf("无效的IP范围: %s", field)
+		}
+		b := start.As4()
+		b[3] = byte(n)
+		end = netip.AddrFrom4(b)
+	} else if end, err = netip.ParseAddr(endStr); err != nil || !end.Is4() {
+		return nil, true, fmt.Errorf("无效的IP范围: %s", field)
+	}
+
+	s4, e4 := start.As4(), end.As4()
+	count := int64(binary.BigEndian.Uint32(e4[:])) - int64(binary.BigEndian.Uint32(s4[:])) + 1
+	if count <= 0 {
+		return nil, true, fmt.Errorf("无效的IP范围: %s（起始地址大于结束地址）", field)
+	}
+	if count > MaxExpandHosts {
+		return nil, true, fmt.Errorf("IP范围 %s 过大（最多展开 %d 个地址）", field, MaxExpandHosts)
+	}
+
+	for addr := start; ; addr = addr.Next() {
+		targets = append(targets, addr.String())
+		if addr == end {
+			break
+		}
+	}
+	return targets, true, nil
+}
+
+// 格式化地址，IPv6加上方括号以便拼接URL
+func formatAddr(addr netip.Addr) string {
+	if addr.Is6() {
+		return "[" + addr.String() + "]"
+	}
+	return addr.String()
+}
@@ -0,0 +1,36 @@
+package utils
+
+import "testing"
+
+func TestTargetSet(t *testing.T) {
+	tests := []struct {
+		name    string
+		targets []string
+		kept    []string
+	}{
+		{"裸主机名与根路径URL", []string{"a.example.com", "http://a.example.com/", "https://a.example.com"}, []string{"a.example.com"}},
+		{"根路径URL在前", []string{"https://a.example.com", "a.example.com"}, []string{"https://a.example.com"}},
+		{"不同协议", []string{"http://a.example.com", "https://a.example.com", "a.example.com"}, []string{"http://a.example.com", "https://a.example.com"}},
+		{"端口不同", []string{"a.example.com:8080", "http://a.example.com:8080/", "http://a.example.com"}, []string{"a.example.com:8080", "http://a.example.com"}},
+		{"带路径的URL", []string{"a.example.com", "https://a.example.com/login", "a.example.com/login"}, []string{"a.example.com", "https://a.example.com/login", "a.example.com/login"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			set := make(TargetSet)
+			var kept []string
+			for _, target := range tt.targets {
+				if set.Add(target) {
+					kept = append(kept, target)
+				}
+			}
+			if len(kept) != len(tt.kept) {
+				t.Fatalf("保留了 %v，期望 %v", kept, tt.kept)
+			}
+			for i := range kept {
+				if kept[i] != tt.kept[i] {
+					t.Fatalf("保留了 %v，期望 %v", kept, tt.kept)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package utils
+
+import (
+	"math/rand/v2"
+	"sync"
+)
+
+// 内置的常见浏览器User-Agent列表
+var defaultUserAgents = []string{
+	"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36",
+	"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/123.0.0.0 Safari/537.36 Edg/123.0.2420.81",
+	"Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:125.0) Gecko/20100101 Firefox/125.0",
+	"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36",
+	"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4.1 Safari/605.1.15",
+	"Mozilla/5.0 (Macintosh; Intel Mac OS X 14.4; rv:125.0) Gecko/20100101 Firefox/125.0",
+	"Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36",
+	"Mozilla/5.0 (X11; Ubuntu; Linux x86_64; rv:125.0) Gecko/20100101 Firefox/125.0",
+	"Mozilla/5.0 (iPhone; CPU iPhone OS 17_4_1 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4.1 Mobile/15E148 Safari/604.1",
+	"Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.6367.82 Mobile Safari/537.36",
+}
+
+var (
+	userAgents      []string // 当前生效的UA列表，为空表示不轮换（使用默认UA）
+	userAgentsMutex sync.RWMutex
+)
+
+// 启用User-Agent轮换，list为空时使用内置列表
+func SetUserAgents(list []string) {
+	if len(list) == 0 {
+		list = defaultUserAgents
+	}
+	userAgentsMutex.Lock()
+	userAgents = list
+	userAgentsMutex.Unlock()
+}
+
+// 从文件加载User-Agent列表，每行一个，忽略空行和#开头的注释行
+func LoadUserAgents(filename string) ([]string, error) {
+	return ReadDomainsFromFile(filename)
+}
+
+// 随机返回一个User-Agent，未启用轮换时返回空字符串
+func NextUserAgent() string {
+	userAgentsMutex.RLock()
+	defer userAgentsMutex.RUnlock()
+	if len(userAgents) == 0 {
+		return ""
+	}
+	return userAgents[rand.IntN(len(userAgents))]
+}
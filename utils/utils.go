@@ -2,10 +2,19 @@ package utils
 
 import (
 	"bufio"
+	"io"
+	"net"
+	"net/url"
 	"os"
 	"strings"
+
+	"golang.org/x/net/publicsuffix"
 )
 
+// 横幅、进度和总结等提示信息的输出位置，-silent 时替换为 io.Discard。
+// 有意写入标准输出的内容（如输出路径 /dev/stdout、静默模式下的存活主机）直接使用 os.Stdout，不受影响
+var Console io.Writer = os.Stdout
+
 // 从文件中读取域名
 func ReadDomainsFromFile(filename string) ([]string, error) {
 	file, err := os.Open(filename)
@@ -13,9 +22,13 @@ func ReadDomainsFromFile(filename string) ([]string, error) {
 		return nil, err
 	}
 	defer file.Close()
+	return ReadDomains(file)
+}
 
+// 从输入流中逐行读取域名，忽略空行和#开头的注释行
+func ReadDomains(r io.Reader) ([]string, error) {
 	var domains []string
-	scanner := bufio.NewScanner(file)
+	scanner := bufio.NewScanner(r)
 	for scanner.Scan() {
 		domain := strings.TrimSpace(scanner.Text())
 		if domain != "" && !strings.HasPrefix(domain, "#") {
@@ -30,10 +43,46 @@ func ReadDomainsFromFile(filename string) ([]string, error) {
 	return domains, nil
 }
 
+// 拆分输入行中的备注，格式为 "host # note text"
+// 只有前面带空白的 # 才视为备注分隔符，以免误伤URL中的锚点（如 http://a.com/#/login）
+func SplitNote(line string) (string, string) {
+	for i := 1; i < len(line); i++ {
+		if line[i] == '#' && (line[i-1] == ' ' || line[i-1] == '\t') {
+			return strings.TrimSpace(line[:i]), strings.TrimSpace(line[i+1:])
+		}
+	}
+	return strings.TrimSpace(line), ""
+}
+
 // 截断字符串到指定长度
 func Truncate(s string, maxLen int) string {
 	if len(s) <= maxLen {
 		return s
 	}
 	return s[:maxLen-3] + "..."
-}
\ No newline at end of file
+}
+
+// 从URL或域名中提取主机名（不含端口）
+func HostFromURL(rawURL string) string {
+	if !strings.Contains(rawURL, "://") {
+		rawURL = "http://" + rawURL
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	return u.Hostname()
+}
+
+// 获取主机名对应的根域名（如 a.b.example.com.cn -> example.com.cn），IP地址原样返回
+func RootDomain(host string) string {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if net.ParseIP(host) != nil {
+		return host
+	}
+	root, err := publicsuffix.EffectiveTLDPlusOne(host)
+	if err != nil {
+		return host
+	}
+	return root
+}
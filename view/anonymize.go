@@ -0,0 +1,243 @@
+package view
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"subdomain-checker/checker"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// 匿名化使用的密钥，未设置时每次运行随机生成（同一次导出内假名一致）
+var anonymizeKey []byte
+
+// 设置匿名化密钥，使用相同密钥的多次导出得到相同的假名，便于对比不同数据集
+func SetAnonymizeKey(key string) {
+	if key == "" {
+		anonymizeKey = nil
+		return
+	}
+	anonymizeKey = []byte(key)
+}
+
+// 匹配消息中的IPv4地址
+var ipv4Regex = regexp.MustCompile(`\b\d{1,3}(\.\d{1,3}){3}\b`)
+
+// 基于带密钥哈希的假名生成器：同一输入总是得到同一假名，不持有密钥无法还原
+type Anonymizer struct {
+	key []byte
+}
+
+// 创建假名生成器，key为空时随机生成密钥
+func NewAnonymizer(key []byte) *Anonymizer {
+	if len(key) == 0 {
+		key = make([]byte, 32)
+		rand.Read(key)
+	}
+	return &Anonymizer{key: key}
+}
+
+// 计算带前缀的假名，如 h-1a2b3c4d
+func (a *Anonymizer) token(prefix, value string) string {
+	mac := hmac.New(sha256.New, a.key)
+	mac.Write([]byte(prefix + "\x00" + value))
+	return prefix + "-" + hex.EncodeToString(mac.Sum(nil)[:4])
+}
+
+// 主机名假名：保留公共后缀（如 .com、.com.cn）和标签层级，每个标签按其完整父域计算假名，
+// 使同一父域下的子域名在匿名后仍然属于同一父域；IP地址映射到保留地址段
+func (a *Anonymizer) Host(host string) string {
+	if host == "" {
+		return ""
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return a.IP(host)
+	}
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	suffix, _ := publicsuffix.PublicSuffix(host)
+	rest := strings.TrimSuffix(strings.TrimSuffix(host, suffix), ".")
+	if rest == "" {
+		return host
+	}
+	labels := strings.Split(rest, ".")
+	parent := suffix
+	pseudo := make([]string, len(labels))
+	for i := len(labels) - 1; i >= 0; i-- {
+		parent = labels[i] + "." + parent
+		pseudo[i] = a.token("h", parent)
+	}
+	return strings.Join(pseudo, ".") + "." + suffix
+}
+
+// IP地址假名：IPv4映射到10.0.0.0/8，IPv6映射到fd00::/8
+func (a *Anonymizer) IP(addr string) string {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return addr
+	}
+	mac := hmac.New(sha256.New, a.key)
+	mac.Write([]byte("ip\x00" + ip.String()))
+	sum := mac.Sum(nil)
+	if ip.To4() != nil {
+		return net.IPv4(10, sum[0], sum[1], sum[2]).String()
+	}
+	pseudo := make(net.IP, net.IPv6len)
+	pseudo[0] = 0xfd
+	copy(pseudo[1:], sum[:15])
+	return pseudo.String()
+}
+
+// URL假名：保留协议、端口和路径层级，替换主机名和各级路径，丢弃查询参数和片段
+func (a *Anonymizer) URL(rawURL string) string {
+	if rawURL == "" {
+		return ""
+	}
+	withScheme := rawURL
+	if !strings.Contains(rawURL, "://") {
+		withScheme = "http://" + rawURL
+	}
+	u, err := url.Parse(withScheme)
+	if err != nil {
+		return a.token("u", rawURL)
+	}
+	host := a.Host(u.Hostname())
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	if port := u.Port(); port != "" {
+		host += ":" + port
+	}
+	anonymized := (&url.URL{Scheme: u.Scheme, Host: host, Path: a.Path(u.Path)}).String()
+	if !strings.Contains(rawURL, "://") {
+		anonymized = strings.TrimPrefix(anonymized, u.Scheme+"://")
+	}
+	return anonymized
+}
+
+// 路径假名：保留层级，替换各级路径
+func (a *Anonymizer) Path(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if segment != "" {
+			segments[i] = a.token("p", segment)
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// 匿名化单个结果：替换域名、IP、URL、标题和robots.txt、sitemap中的路径，删除备注、关键词命中、截图、响应头、Cookie、
+// 服务横幅、OCR文字、原始响应文件和ASN等可能泄露范围的字段，保留状态码、耗时、页面类型、错误类型、国家和内容哈希等结构信息
+func (a *Anonymizer) Result(result checker.Result) checker.Result {
+	hostname := hostOf(result.Domain)
+
+	anon := result
+	anon.Domain = a.URL(result.Domain)
+	anon.UnicodeDomain = ""
+	anon.IP = a.IP(result.IP)
+	anon.FinalURL = a.URL(result.FinalURL)
+	if result.Title != "" {
+		anon.Title = a.token("t", result.Title)
+	}
+	anon.Message = a.scrub(result.Message, hostname)
+	anon.Note = ""
+	anon.Matches = nil
+	anon.Screenshot = ""
+	anon.ScreenshotPath = ""
+	anon.ASN = 0
+	anon.ASOrg = ""
+	anon.Headers = nil
+	anon.Cookies = nil
+	anon.OCRText = ""
+	anon.RawResponse = ""
+
+	anon.CNAMEs = nil
+	for _, name := range result.CNAMEs {
+		anon.CNAMEs = append(anon.CNAMEs, a.Host(name))
+	}
+	anon.RedirectChain = nil
+	for _, hop := range result.RedirectChain {
+		anon.RedirectChain = append(anon.RedirectChain, checker.RedirectHop{URL: a.URL(hop.URL), Status: hop.Status})
+	}
+	anon.Families = nil
+	for _, family := range result.Families {
+		family.IP = a.IP(family.IP)
+		family.Error = a.scrub(family.Error, hostname)
+		anon.Families = append(anon.Families, family)
+	}
+	// 横幅中常带有主机名（如SMTP欢迎信息），只保留端口和服务名称
+	anon.Services = nil
+	for _, service := range result.Services {
+		anon.Services = append(anon.Services, checker.Service{Port: service.Port, Name: service.Name})
+	}
+	if result.Robots != nil {
+		robots := checker.RobotsInfo{RobotsTxt: result.Robots.RobotsTxt, Sitemap: result.Robots.Sitemap}
+		for _, path := range result.Robots.Disallowed {
+			robots.Disallowed = append(robots.Disallowed, a.Path(path))
+		}
+		for _, u := range result.Robots.SitemapURLs {
+			robots.SitemapURLs = append(robots.SitemapURLs, a.URL(u))
+		}
+		anon.Robots = &robots
+	}
+	// 探测路径来自扫描使用的路径列表，保留；错误信息中带有目标URL
+	anon.Paths = nil
+	for _, path := range result.Paths {
+		if path.Title != "" {
+			path.Title = a.token("t", path.Title)
+		}
+		path.Error = a.scrub(path.Error, hostname)
+		anon.Paths = append(anon.Paths, path)
+	}
+	// 回显的Origin是扫描使用的测试来源，其他来源可能是目标自己的站点
+	if result.CORS != nil {
+		cors := *result.CORS
+		if cors.AllowOrigin != "*" && cors.AllowOrigin != cors.Origin {
+			cors.AllowOrigin = a.URL(cors.AllowOrigin)
+		}
+		anon.CORS = &cors
+	}
+	return anon
+}
+
+// 替换消息中出现的目标主机名和IPv4地址（如DNS解析错误中的域名和DNS服务器地址）
+func (a *Anonymizer) scrub(message, hostname string) string {
+	if message == "" {
+		return ""
+	}
+	if hostname != "" && net.ParseIP(hostname) == nil {
+		message = strings.ReplaceAll(message, hostname, a.Host(hostname))
+	}
+	return ipv4Regex.ReplaceAllStringFunc(message, a.IP)
+}
+
+// 从目标中提取主机名
+func hostOf(target string) string {
+	if !strings.Contains(target, "://") {
+		target = "http://" + target
+	}
+	if u, err := url.Parse(target); err == nil {
+		return u.Hostname()
+	}
+	return target
+}
+
+// 保存匿名化的结果到JSON文件
+func SaveAnonymizedJSON(results []checker.Result, filename string) error {
+	a := NewAnonymizer(anonymizeKey)
+	anonymized := make([]checker.Result, 0, len(results))
+	for _, result := range results {
+		anonymized = append(anonymized, a.Result(result))
+	}
+	if err := SaveResultsToJSON(anonymized, filename); err != nil {
+		return fmt.Errorf("写入匿名化结果失败: %w", err)
+	}
+	return nil
+}
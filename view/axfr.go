@@ -0,0 +1,78 @@
+package view
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+
+	"subdomain-checker/axfr"
+	"subdomain-checker/utils"
+)
+
+// 允许域传送的根域名（-axfr），为空时报告中不显示
+var zoneTransfers []axfr.Transfer
+
+// 设置报告中显示的域传送尝试结果，只保留允许域传送的根域名
+func SetZoneTransfers(transfers []axfr.Transfer) {
+	zoneTransfers = nil
+	for _, transfer := range transfers {
+		if transfer.Vulnerable() {
+			zoneTransfers = append(zoneTransfers, transfer)
+		}
+	}
+}
+
+// 报告中显示的一条域传送漏洞
+type ZoneTransferRow struct {
+	Domain  string
+	Servers string // 允许域传送的DNS服务器，逗号分隔
+	Records int
+	Hosts   []string
+}
+
+// 转换为报告中显示的行
+func zoneTransferRows() []ZoneTransferRow {
+	rows := make([]ZoneTransferRow, 0, len(zoneTransfers))
+	for _, transfer := range zoneTransfers {
+		rows = append(rows, ZoneTransferRow{
+			Domain:  transfer.Domain,
+			Servers: strings.Join(transfer.Allowed, ", "),
+			Records: transfer.Records,
+			Hosts:   transfer.Hosts,
+		})
+	}
+	return rows
+}
+
+// 写入域传送工作表，没有根域名允许域传送时不生成
+func writeZoneTransferSheet(f *excelize.File, sheet string, headerStyle int) {
+	if len(zoneTransfers) == 0 {
+		return
+	}
+	f.NewSheet(sheet)
+	headers := translateAll([]string{"根域名", "允许域传送的DNS服务器", "记录数", "主机名"})
+	for i, header := range headers {
+		cell, _ := excelize.CoordinatesToCellName(i+1, 1)
+		f.SetCellValue(sheet, cell, header)
+	}
+	f.SetCellStyle(sheet, "A1", "D1", headerStyle)
+	for i, row := range zoneTransferRows() {
+		values := []any{row.Domain, row.Servers, row.Records, strings.Join(row.Hosts, ", ")}
+		for j, value := range values {
+			cell, _ := excelize.CoordinatesToCellName(j+1, i+2)
+			f.SetCellValue(sheet, cell, value)
+		}
+	}
+	f.SetColWidth(sheet, "A", "B", 30)
+	f.SetColWidth(sheet, "C", "C", 10)
+	f.SetColWidth(sheet, "D", "D", 80)
+}
+
+// 控制台总结中的域传送提示
+func printZoneTransfers() {
+	for _, row := range zoneTransferRows() {
+		fmt.Fprintln(utils.Console, "🚨 "+Tf("域传送漏洞: %s 的DNS服务器 %s 允许AXFR，泄露了 %s 条记录（%s 个主机名）",
+			row.Domain, row.Servers, FormatCount(row.Records), FormatCount(len(row.Hosts))))
+	}
+}
@@ -0,0 +1,186 @@
+package view
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"subdomain-checker/checker"
+	"subdomain-checker/logger"
+	"subdomain-checker/stats"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// 响应时间直方图的分段上限，最后一段为超过最大上限的请求
+var responseTimeBuckets = []struct {
+	label string
+	upper time.Duration
+}{
+	{"<100ms", 100 * time.Millisecond},
+	{"100-300ms", 300 * time.Millisecond},
+	{"300-500ms", 500 * time.Millisecond},
+	{"0.5-1s", time.Second},
+	{"1-3s", 3 * time.Second},
+	{"3-10s", 10 * time.Second},
+	{">10s", 0},
+}
+
+// 标签和数量，作为图表的数据行
+type countRow struct {
+	Label string
+	Count int
+}
+
+// 状态码分布，未建立连接的结果归为"无响应"
+func statusDistribution(counts map[int]int) []countRow {
+	codes := make([]int, 0, len(counts))
+	for code := range counts {
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+
+	rows := make([]countRow, 0, len(codes))
+	for _, code := range codes {
+		label := strconv.Itoa(code)
+		if code == 0 {
+			label = T("无响应")
+		}
+		rows = append(rows, countRow{Label: label, Count: counts[code]})
+	}
+	return rows
+}
+
+// 统计有响应的结果的响应时间分布
+func responseTimeHistogram(results []checker.Result) []countRow {
+	rows := make([]countRow, len(responseTimeBuckets))
+	for i, bucket := range responseTimeBuckets {
+		rows[i].Label = bucket.label
+	}
+	for _, result := range results {
+		if result.Status == 0 {
+			continue
+		}
+		i := len(responseTimeBuckets) - 1
+		for j, bucket := range responseTimeBuckets[:i] {
+			if result.ResponseTime < bucket.upper {
+				i = j
+				break
+			}
+		}
+		rows[i].Count++
+	}
+	return rows
+}
+
+// 存活结果的页面类型分布，按数量降序排列
+func pageTypeBreakdown(counts map[string]int) []countRow {
+	rows := make([]countRow, 0, len(counts))
+	for label, count := range counts {
+		rows = append(rows, countRow{Label: label, Count: count})
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Count != rows[j].Count {
+			return rows[i].Count > rows[j].Count
+		}
+		return rows[i].Label < rows[j].Label
+	})
+	return rows
+}
+
+// 写入汇总看板工作表：左侧为汇总指标和图表数据，右侧为存活情况饼图、状态码分布、
+// 响应时间分布和页面类型柱状图，便于直接作为管理层报告使用
+func writeDashboardSheet(f *excelize.File, sheet string, headerStyle int, results []checker.Result) {
+	f.NewSheet(sheet)
+
+	snap := stats.FromResults(results).Snapshot()
+	alive := snap.Alive
+	var times []time.Duration
+	for _, result := range results {
+		if result.Alive {
+			times = append(times, result.ResponseTime)
+		}
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i] < times[j] })
+
+	f.SetCellValue(sheet, "A1", T("指标"))
+	f.SetCellValue(sheet, "B1", T("数值"))
+	f.SetCellStyle(sheet, "A1", "B1", headerStyle)
+	metrics := []struct {
+		name  string
+		value interface{}
+	}{
+		{"报告时间", reportTime()},
+		{"域名总数", len(results)},
+		{"存活", alive},
+		{"无法访问", snap.Dead},
+		{"存活率", "-"},
+		{millisHeader("响应时间P50"), "-"},
+		{millisHeader("响应时间P90"), "-"},
+	}
+	if len(results) > 0 {
+		metrics[4].value = FormatPercent(float64(alive) / float64(len(results)))
+	}
+	if len(times) > 0 {
+		metrics[5].value = percentile(times, 50).Milliseconds()
+		metrics[6].value = percentile(times, 90).Milliseconds()
+	}
+	row := 2
+	for _, metric := range metrics {
+		f.SetCellValue(sheet, fmt.Sprintf("A%d", row), T(metric.name))
+		f.SetCellValue(sheet, fmt.Sprintf("B%d", row), metric.value)
+		row++
+	}
+
+	// 依次写入各图表的数据表，并在右侧对应位置插入图表
+	chartRow := 1
+	addChart := func(title string, chartType excelize.ChartType, rows []countRow) {
+		row++
+		title = T(title)
+		f.SetCellValue(sheet, fmt.Sprintf("A%d", row), title)
+		f.SetCellValue(sheet, fmt.Sprintf("B%d", row), T("数量"))
+		f.SetCellStyle(sheet, fmt.Sprintf("A%d", row), fmt.Sprintf("B%d", row), headerStyle)
+		first := row + 1
+		for _, r := range rows {
+			row++
+			f.SetCellValue(sheet, fmt.Sprintf("A%d", row), T(r.Label))
+			f.SetCellValue(sheet, fmt.Sprintf("B%d", row), r.Count)
+		}
+		row++
+		if len(rows) == 0 {
+			return
+		}
+
+		chart := &excelize.Chart{
+			Type: chartType,
+			Series: []excelize.ChartSeries{{
+				Name:       fmt.Sprintf("'%s'!$A$%d", sheet, first-1),
+				Categories: fmt.Sprintf("'%s'!$A$%d:$A$%d", sheet, first, first+len(rows)-1),
+				Values:     fmt.Sprintf("'%s'!$B$%d:$B$%d", sheet, first, first+len(rows)-1),
+			}},
+			Title:     []excelize.RichTextRun{{Text: title}},
+			Dimension: excelize.ChartDimension{Width: 560, Height: 300},
+			Legend:    excelize.ChartLegend{Position: "none"},
+			PlotArea:  excelize.ChartPlotArea{ShowVal: true},
+		}
+		if chartType == excelize.Pie {
+			chart.Legend.Position = "right"
+			chart.PlotArea = excelize.ChartPlotArea{ShowPercent: true}
+		}
+		if err := f.AddChart(sheet, fmt.Sprintf("D%d", chartRow), chart); err != nil {
+			logger.Warn("添加图表失败", "chart", title, "error", err)
+		}
+		chartRow += 16
+	}
+	addChart("存活情况", excelize.Pie, []countRow{{"存活", alive}, {"无法访问", snap.Dead}})
+	addChart("状态码分布", excelize.Col, statusDistribution(snap.StatusCodes))
+	addChart("响应时间分布", excelize.Col, responseTimeHistogram(results))
+	addChart("页面类型", excelize.Bar, pageTypeBreakdown(snap.PageTypes))
+	if len(snap.Clouds) > 0 {
+		addChart("云服务商分布", excelize.Bar, pageTypeBreakdown(snap.Clouds))
+	}
+
+	f.SetColWidth(sheet, "A", "A", 20)
+	f.SetColWidth(sheet, "B", "B", 20)
+}
@@ -0,0 +1,117 @@
+package view
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"subdomain-checker/checker"
+)
+
+// 报告大小估算时每行数据（不含图片）的近似字节数
+const (
+	excelRowBytes = 400
+	htmlRowBytes  = 2048
+)
+
+// 报告大小上限（字节），为0时不限制
+var (
+	maxExcelBytes int64
+	maxHTMLBytes  int64
+)
+
+// 设置Excel和HTML报告的大小上限（字节），超出时自动切换为链接截图或分片模式
+func SetSizeLimits(excelBytes, htmlBytes int64) {
+	maxExcelBytes = excelBytes
+	maxHTMLBytes = htmlBytes
+}
+
+// 报告大小估算结果
+type SizeEstimate struct {
+	Rows       int
+	Images     int
+	ImageBytes int64
+	Bytes      int64
+}
+
+// 估算报告大小：rowBytes为每行开销，embedRatio为内嵌图片相对原文件的膨胀比例（0表示不内嵌图片）
+func estimateSize(results []checker.Result, rowBytes int64, embedRatio float64) SizeEstimate {
+	var est SizeEstimate
+	for _, result := range results {
+		est.Rows++
+		if size := screenshotSize(result.Screenshot); size > 0 {
+			est.Images++
+			est.ImageBytes += size
+		}
+	}
+	est.Bytes = int64(est.Rows)*rowBytes + int64(float64(est.ImageBytes)*embedRatio)
+	return est
+}
+
+// 估算Excel报告大小（截图以原始字节嵌入）
+func EstimateExcelSize(results []checker.Result) SizeEstimate {
+	return estimateSize(results, excelRowBytes, 1)
+}
+
+// 估算HTML报告大小，embed为true时截图以base64内嵌（约膨胀4/3）
+func EstimateHTMLSize(results []checker.Result, embed bool) SizeEstimate {
+	ratio := 0.0
+	if embed {
+		ratio = 4.0 / 3.0
+	}
+	return estimateSize(results, htmlRowBytes, ratio)
+}
+
+// 获取截图文件大小，文件不存在时返回0
+func screenshotSize(path string) int64 {
+	if path == "" {
+		return 0
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		info, err = os.Stat(filepath.FromSlash(reportScreenshotPath(path)))
+		if err != nil {
+			return 0
+		}
+	}
+	return info.Size()
+}
+
+// 格式化字节数
+func FormatBytes(n int64) string {
+	switch {
+	case n >= 1<<30:
+		return fmt.Sprintf("%.1f GB", float64(n)/(1<<30))
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", n)
+}
+
+// 生成分片文件名，如 report.html -> report-part2.html
+func shardFilename(filename string, part int) string {
+	ext := filepath.Ext(filename)
+	return fmt.Sprintf("%s-part%d%s", filename[:len(filename)-len(ext)], part, ext)
+}
+
+// 结果引用的截图文件的绝对路径，dir为截图目录。同时按记录的绝对路径和报告中的相对路径查找，
+// 兼容没有 screenshot_path 字段的旧结果
+func ReferencedScreenshots(results []checker.Result, dir string) map[string]bool {
+	referenced := make(map[string]bool)
+	for _, result := range results {
+		if result.ScreenshotPath != "" {
+			referenced[filepath.Clean(result.ScreenshotPath)] = true
+		}
+		if result.Screenshot == "" {
+			continue
+		}
+		rel := strings.TrimPrefix(reportScreenshotPath(result.Screenshot), "screenshots/")
+		if abs, err := filepath.Abs(filepath.Join(dir, filepath.FromSlash(rel))); err == nil {
+			referenced[abs] = true
+		}
+	}
+	return referenced
+}
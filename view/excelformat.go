@@ -0,0 +1,75 @@
+package view
+
+import (
+	"fmt"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// Excel主表中响应时间超过该值(毫秒)的存活行标为琥珀色，0表示不标记
+var excelSlowMillis = 1000
+
+// 设置Excel主表中慢响应的阈值(毫秒)
+func SetExcelSlowThreshold(ms int) {
+	excelSlowMillis = ms
+}
+
+// 主表末尾供人工分诊的列及其下拉选项
+const triageHeader = "处理状态"
+
+var triageOptions = []string{"待处理", "已确认", "误报", "已忽略"}
+
+// 为结果主表添加筛选、条件格式和分诊列的数据验证，打开文件即可按状态筛选和标记。
+// 条件格式按状态码判断存活（与检测时的判断一致：1xx-3xx为存活），
+// 状态码和响应时间分别位于第3、4列。需在流式写入Flush之前调用
+func formatResultsSheet(f *excelize.File, sheet string, columns, rows int) error {
+	lastCol, _ := excelize.ColumnNumberToName(columns)
+	lastRow := rows + 1
+	if err := f.AutoFilter(sheet, fmt.Sprintf("A1:%s%d", lastCol, lastRow), nil); err != nil {
+		return err
+	}
+	if rows == 0 {
+		return nil
+	}
+
+	fill := func(color string) (*int, error) {
+		style, err := f.NewConditionalStyle(&excelize.Style{
+			Fill: excelize.Fill{Type: "pattern", Color: []string{color}, Pattern: 1},
+		})
+		return &style, err
+	}
+	dead, err := fill("#F8CBAD")
+	if err != nil {
+		return err
+	}
+	slow, err := fill("#FFE699")
+	if err != nil {
+		return err
+	}
+	alive, err := fill("#C6EFCE")
+	if err != nil {
+		return err
+	}
+
+	// 规则依次匹配，先命中的生效：无法访问为红色，慢响应为琥珀色，其余存活为绿色
+	rules := []excelize.ConditionalFormatOptions{
+		{Type: "formula", Criteria: "OR($C2=0,$C2>=400)", Format: dead, StopIfTrue: true},
+	}
+	if excelSlowMillis > 0 {
+		rules = append(rules, excelize.ConditionalFormatOptions{
+			Type: "formula", Criteria: fmt.Sprintf("$D2>%d", excelSlowMillis), Format: slow, StopIfTrue: true,
+		})
+	}
+	rules = append(rules, excelize.ConditionalFormatOptions{Type: "formula", Criteria: "$C2>0", Format: alive})
+	if err := f.SetConditionalFormat(sheet, fmt.Sprintf("A2:%s%d", lastCol, lastRow), rules); err != nil {
+		return err
+	}
+
+	dv := excelize.NewDataValidation(true)
+	dv.SetSqref(fmt.Sprintf("%s2:%s%d", lastCol, lastCol, lastRow))
+	if err := dv.SetDropList(translateAll(triageOptions)); err != nil {
+		return err
+	}
+	dv.SetError(excelize.DataValidationErrorStyleStop, T(triageHeader), T("请从下拉列表中选择"))
+	return f.AddDataValidation(sheet, dv)
+}
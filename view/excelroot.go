@@ -0,0 +1,84 @@
+package view
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"subdomain-checker/checker"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// Excel结果是否按根域名拆分为多个工作表
+var excelPerRoot bool
+
+// 设置Excel结果是否按根域名拆分工作表，拆分时首个工作表为链接到各根域名工作表的索引
+func SetExcelPerRoot(enabled bool) {
+	excelPerRoot = enabled
+}
+
+// 按根域名分组写入结果：sheetName改为索引表，每个根域名一个结果表（按根域名排序，表内保持结果顺序），
+// 返回有截图的结果供截图表使用
+func writeRootSheets(f *excelize.File, sheetName string, headers []string, results []checker.Result, headerStyle, contentStyle, linkStyle int) ([]checker.Result, error) {
+	groups := make(map[string][]checker.Result)
+	var apexes []string
+	for _, result := range results {
+		apex := resultApex(result)
+		if _, ok := groups[apex]; !ok {
+			apexes = append(apexes, apex)
+		}
+		groups[apex] = append(groups[apex], result)
+	}
+	sort.Strings(apexes)
+
+	index := T("根域名索引")
+	f.SetSheetName(sheetName, index)
+	indexHeaders := translateAll([]string{"根域名", "子域名数", "存活数", "存活率", "工作表"})
+	for i, header := range indexHeaders {
+		cell, _ := excelize.CoordinatesToCellName(i+1, 1)
+		f.SetCellValue(index, cell, header)
+	}
+	f.SetCellStyle(index, "A1", "E1", headerStyle)
+
+	used := map[string]bool{strings.ToLower(index): true}
+	var withScreenshots []checker.Result
+	for i, apex := range apexes {
+		members := groups[apex]
+		sheet := uniqueSheetName(apex, used)
+		if _, err := f.NewSheet(sheet); err != nil {
+			return nil, err
+		}
+		shots, err := writeResultsSheet(f, sheet, headers, members, headerStyle, contentStyle, linkStyle)
+		if err != nil {
+			return nil, err
+		}
+		withScreenshots = append(withScreenshots, shots...)
+
+		alive := 0
+		for _, result := range members {
+			if result.Alive {
+				alive++
+			}
+		}
+		row := i + 2
+		f.SetCellValue(index, fmt.Sprintf("A%d", row), apex)
+		f.SetCellValue(index, fmt.Sprintf("B%d", row), len(members))
+		f.SetCellValue(index, fmt.Sprintf("C%d", row), alive)
+		f.SetCellValue(index, fmt.Sprintf("D%d", row), FormatPercent(float64(alive)/float64(len(members))))
+		f.SetCellValue(index, fmt.Sprintf("E%d", row), sheet)
+		f.SetCellHyperLink(index, fmt.Sprintf("E%d", row), fmt.Sprintf("'%s'!A1", sheet), "Location")
+		f.SetCellStyle(index, fmt.Sprintf("E%d", row), fmt.Sprintf("E%d", row), linkStyle)
+	}
+
+	f.SetColWidth(index, "A", "A", 30)
+	f.SetColWidth(index, "B", "D", 12)
+	f.SetColWidth(index, "E", "E", 30)
+	f.SetPanes(index, &excelize.Panes{
+		Freeze:      true,
+		YSplit:      1,
+		TopLeftCell: "A2",
+		ActivePane:  "bottomLeft",
+	})
+	return withScreenshots, nil
+}
@@ -0,0 +1,234 @@
+package view
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"subdomain-checker/checker"
+	"subdomain-checker/utils"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// 汇总表中每个根域名列出的重点主机数量
+const notableHostLimit = 5
+
+// 单个根域名的汇总
+type ApexSummary struct {
+	Apex     string
+	Total    int
+	Alive    int
+	High     int
+	Medium   int
+	Low      int
+	Notable  []string // 风险等级最高的若干存活主机
+	Results  []checker.Result
+	SheetRef string // 明细工作表名
+}
+
+// 风险等级排序权重
+func severityRank(severity string) int {
+	switch severity {
+	case checker.SeverityHigh:
+		return 3
+	case checker.SeverityMedium:
+		return 2
+	case checker.SeverityLow:
+		return 1
+	}
+	return 0
+}
+
+// 结果所属的根域名，国际化域名显示为Unicode形式
+func resultApex(result checker.Result) string {
+	return utils.ToUnicodeTarget(utils.RootDomain(utils.HostFromURL(result.Domain)))
+}
+
+// 按根域名汇总结果，按高/中风险数量和域名数降序排列
+func SummarizeByApex(results []checker.Result) []*ApexSummary {
+	index := make(map[string]*ApexSummary)
+	var summaries []*ApexSummary
+	for _, result := range results {
+		apex := resultApex(result)
+		summary, ok := index[apex]
+		if !ok {
+			summary = &ApexSummary{Apex: apex}
+			index[apex] = summary
+			summaries = append(summaries, summary)
+		}
+		summary.Total++
+		if result.Alive {
+			summary.Alive++
+		}
+		switch result.Severity() {
+		case checker.SeverityHigh:
+			summary.High++
+		case checker.SeverityMedium:
+			summary.Medium++
+		case checker.SeverityLow:
+			summary.Low++
+		}
+		summary.Results = append(summary.Results, result)
+	}
+
+	for _, summary := range summaries {
+		// 明细按风险等级、存活状态、域名排序
+		sort.SliceStable(summary.Results, func(a, b int) bool {
+			ra, rb := summary.Results[a], summary.Results[b]
+			if sa, sb := severityRank(ra.Severity()), severityRank(rb.Severity()); sa != sb {
+				return sa > sb
+			}
+			if ra.Alive != rb.Alive {
+				return ra.Alive
+			}
+			return ra.Domain < rb.Domain
+		})
+		for _, result := range summary.Results {
+			if len(summary.Notable) >= notableHostLimit || result.Severity() == "" {
+				break
+			}
+			summary.Notable = append(summary.Notable, result.DisplayDomain())
+		}
+	}
+
+	sort.SliceStable(summaries, func(a, b int) bool {
+		sa, sb := summaries[a], summaries[b]
+		if sa.High != sb.High {
+			return sa.High > sb.High
+		}
+		if sa.Medium != sb.Medium {
+			return sa.Medium > sb.Medium
+		}
+		if sa.Total != sb.Total {
+			return sa.Total > sb.Total
+		}
+		return sa.Apex < sb.Apex
+	})
+	return summaries
+}
+
+// 生成合法且不重复的工作表名（Excel限制31个字符，且不能包含 []:*?/\）
+func uniqueSheetName(name string, used map[string]bool) string {
+	name = strings.NewReplacer("[", "_", "]", "_", ":", "_", "*", "_", "?", "_", "/", "_", "\\", "_").Replace(name)
+	if runes := []rune(name); len(runes) > 28 {
+		name = string(runes[:28])
+	}
+	candidate := name
+	for i := 2; used[strings.ToLower(candidate)]; i++ {
+		candidate = fmt.Sprintf("%s~%d", name, i)
+	}
+	used[strings.ToLower(candidate)] = true
+	return candidate
+}
+
+// 保存面向管理层的多根域名汇总工作簿：汇总表每个根域名一行，并链接到各根域名的明细工作表
+func SaveExecutiveWorkbook(results []checker.Result, filename string) error {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	headerStyle, _ := f.NewStyle(&excelize.Style{
+		Font:      &excelize.Font{Bold: true},
+		Fill:      excelize.Fill{Type: "pattern", Color: []string{"#D9D9D9"}, Pattern: 1},
+		Alignment: &excelize.Alignment{Horizontal: "center", Vertical: "center"},
+	})
+	linkStyle, _ := f.NewStyle(&excelize.Style{
+		Font: &excelize.Font{Color: "#0563C1", Underline: "single"},
+	})
+	wrapStyle, _ := f.NewStyle(&excelize.Style{
+		Alignment: &excelize.Alignment{WrapText: true, Vertical: "top"},
+	})
+
+	summarySheet := T("汇总")
+	f.SetSheetName("Sheet1", summarySheet)
+	headers := translateAll([]string{"根域名", "子域名数", "存活数", "存活率", "高风险", "中风险", "低风险", "重点主机", "明细"})
+	for i, header := range headers {
+		cell, _ := excelize.CoordinatesToCellName(i+1, 1)
+		f.SetCellValue(summarySheet, cell, header)
+	}
+	f.SetCellStyle(summarySheet, "A1", "I1", headerStyle)
+
+	summaries := SummarizeByApex(results)
+	used := map[string]bool{strings.ToLower(summarySheet): true}
+	for i, summary := range summaries {
+		summary.SheetRef = uniqueSheetName(summary.Apex, used)
+		row := i + 2
+
+		rate := 0.0
+		if summary.Total > 0 {
+			rate = float64(summary.Alive) / float64(summary.Total)
+		}
+		f.SetCellValue(summarySheet, fmt.Sprintf("A%d", row), summary.Apex)
+		f.SetCellValue(summarySheet, fmt.Sprintf("B%d", row), summary.Total)
+		f.SetCellValue(summarySheet, fmt.Sprintf("C%d", row), summary.Alive)
+		f.SetCellValue(summarySheet, fmt.Sprintf("D%d", row), FormatPercent(rate))
+		f.SetCellValue(summarySheet, fmt.Sprintf("E%d", row), summary.High)
+		f.SetCellValue(summarySheet, fmt.Sprintf("F%d", row), summary.Medium)
+		f.SetCellValue(summarySheet, fmt.Sprintf("G%d", row), summary.Low)
+		f.SetCellValue(summarySheet, fmt.Sprintf("H%d", row), strings.Join(summary.Notable, "\n"))
+		f.SetCellStyle(summarySheet, fmt.Sprintf("H%d", row), fmt.Sprintf("H%d", row), wrapStyle)
+		f.SetCellValue(summarySheet, fmt.Sprintf("I%d", row), T("查看明细"))
+		f.SetCellHyperLink(summarySheet, fmt.Sprintf("I%d", row), fmt.Sprintf("'%s'!A1", summary.SheetRef), "Location")
+		f.SetCellStyle(summarySheet, fmt.Sprintf("I%d", row), fmt.Sprintf("I%d", row), linkStyle)
+
+		writeApexSheet(f, summary, summarySheet, headerStyle, linkStyle)
+	}
+
+	f.SetColWidth(summarySheet, "A", "A", 30)
+	f.SetColWidth(summarySheet, "B", "G", 10)
+	f.SetColWidth(summarySheet, "H", "H", 50)
+	f.SetColWidth(summarySheet, "I", "I", 12)
+	f.SetPanes(summarySheet, &excelize.Panes{
+		Freeze:      true,
+		YSplit:      1,
+		TopLeftCell: "A2",
+		ActivePane:  "bottomLeft",
+	})
+
+	return f.SaveAs(filename)
+}
+
+// 写入单个根域名的明细工作表
+func writeApexSheet(f *excelize.File, summary *ApexSummary, summarySheet string, headerStyle, linkStyle int) {
+	sheet := summary.SheetRef
+	f.NewSheet(sheet)
+
+	f.SetCellValue(sheet, "A1", T("← 返回汇总"))
+	f.SetCellHyperLink(sheet, "A1", fmt.Sprintf("'%s'!A1", summarySheet), "Location")
+	f.SetCellStyle(sheet, "A1", "A1", linkStyle)
+
+	headers := translateAll([]string{"域名", "状态", "状态码", "风险等级", "发现", "页面标题", "IP", "最终URL", "备注", "检测时间"})
+	for i, header := range headers {
+		cell, _ := excelize.CoordinatesToCellName(i+1, 2)
+		f.SetCellValue(sheet, cell, header)
+	}
+	f.SetCellStyle(sheet, "A2", "J2", headerStyle)
+
+	for i, result := range summary.Results {
+		row := i + 3
+		f.SetCellValue(sheet, fmt.Sprintf("A%d", row), result.DisplayDomain())
+		f.SetCellValue(sheet, fmt.Sprintf("B%d", row), T(result.StatusText))
+		f.SetCellValue(sheet, fmt.Sprintf("C%d", row), result.Status)
+		f.SetCellValue(sheet, fmt.Sprintf("D%d", row), T(result.Severity()))
+		f.SetCellValue(sheet, fmt.Sprintf("E%d", row), strings.Join(translateFindings(result.Findings()), T("，")))
+		f.SetCellValue(sheet, fmt.Sprintf("F%d", row), result.Title)
+		f.SetCellValue(sheet, fmt.Sprintf("G%d", row), result.IP)
+		f.SetCellValue(sheet, fmt.Sprintf("H%d", row), result.FinalURL)
+		f.SetCellValue(sheet, fmt.Sprintf("I%d", row), result.Note)
+		f.SetCellValue(sheet, fmt.Sprintf("J%d", row), FormatCheckedAt(result.CheckedAt))
+	}
+
+	f.SetColWidth(sheet, "A", "A", 40)
+	f.SetColWidth(sheet, "B", "D", 10)
+	f.SetColWidth(sheet, "E", "F", 30)
+	f.SetColWidth(sheet, "G", "G", 16)
+	f.SetColWidth(sheet, "H", "H", 40)
+	f.SetColWidth(sheet, "I", "I", 20)
+	f.SetColWidth(sheet, "J", "J", 30)
+	f.SetPanes(sheet, &excelize.Panes{
+		Freeze:      true,
+		YSplit:      2,
+		TopLeftCell: "A3",
+		ActivePane:  "bottomLeft",
+	})
+}
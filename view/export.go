@@ -0,0 +1,198 @@
+package view
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"subdomain-checker/checker"
+)
+
+// httpx JSON输出格式（字段名与httpx -json保持一致）
+type HttpxRecord struct {
+	Timestamp        string   `json:"timestamp"`
+	URL              string   `json:"url"`
+	Input            string   `json:"input"`
+	Host             string   `json:"host"`
+	Port             string   `json:"port"`
+	Scheme           string   `json:"scheme"`
+	Path             string   `json:"path"`
+	Title            string   `json:"title,omitempty"`
+	StatusCode       int      `json:"status_code,omitempty"`
+	Time             string   `json:"time,omitempty"`
+	A                []string `json:"a,omitempty"`
+	FinalURL         string   `json:"final_url,omitempty"`
+	ChainStatusCodes []int    `json:"chain_status_codes,omitempty"`
+	Failed           bool     `json:"failed"`
+}
+
+// 返回结果最终落地的URL（未跟随重定向时为检测的URL），带协议和端口
+func targetURL(result checker.Result) string {
+	if result.FinalURL != "" {
+		return withPort(result.FinalURL)
+	}
+	return checkedURL(result)
+}
+
+// 返回检测的URL，带协议和端口。Domain不带协议时沿用同一主机落地URL的协议，否则为http
+func checkedURL(result checker.Result) string {
+	if strings.Contains(result.Domain, "://") {
+		return withPort(result.Domain)
+	}
+	scheme := "http"
+	if u, err := url.Parse(result.FinalURL); err == nil && u.Host != "" && strings.EqualFold(u.Host, result.Domain) {
+		scheme = u.Scheme
+	}
+	return withPort(scheme + "://" + result.Domain)
+}
+
+// 为URL补上协议的默认端口，如 "https://a.example.com/" → "https://a.example.com:443/"
+func withPort(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" || u.Port() != "" {
+		return raw
+	}
+	switch u.Scheme {
+	case "https":
+		u.Host = net.JoinHostPort(u.Hostname(), "443")
+	case "http":
+		u.Host = net.JoinHostPort(u.Hostname(), "80")
+	default:
+		return raw
+	}
+	return u.String()
+}
+
+// 将结果转换为httpx格式的记录
+func NewHttpxRecord(result checker.Result) HttpxRecord {
+	record := HttpxRecord{
+		Timestamp:  reportTimestamp(),
+		URL:        checkedURL(result),
+		Input:      strings.TrimPrefix(strings.TrimPrefix(result.Domain, "https://"), "http://"),
+		Title:      result.Title,
+		StatusCode: result.Status,
+		Failed:     !result.Alive,
+	}
+	if !result.CheckedAt.IsZero() {
+		record.Timestamp = result.CheckedAt.Format(time.RFC3339Nano)
+	}
+	if result.ResponseTime > 0 {
+		record.Time = result.ResponseTime.String()
+	}
+	if result.IP != "" {
+		record.A = []string{result.IP}
+	}
+	if result.FinalURL != "" && result.FinalURL != result.Domain {
+		record.FinalURL = result.FinalURL
+	}
+	for _, hop := range result.RedirectChain {
+		record.ChainStatusCodes = append(record.ChainStatusCodes, hop.Status)
+	}
+
+	if u, err := url.Parse(targetURL(result)); err == nil {
+		record.Scheme = u.Scheme
+		record.Host = u.Hostname()
+		record.Port = u.Port()
+		record.Path = u.Path
+	}
+	return record
+}
+
+// 返回RFC3339格式的报告时间，确定性模式下使用固定时间
+func reportTimestamp() string {
+	if !fixedReportTime.IsZero() {
+		return fixedReportTime.Format(time.RFC3339)
+	}
+	return time.Now().Format(time.RFC3339)
+}
+
+// 导出存活目标的URL列表（每行一个，带协议和端口），可直接作为nuclei/httpx的输入
+func SaveTargetList(results []checker.Result, filename string) (int, error) {
+	w, err := newTargetListWriter(filename)
+	if err != nil {
+		return 0, err
+	}
+	err = writeEach(w, results)
+	return len(w.seen), err
+}
+
+// 逐条写入存活目标列表，按目标地址去重
+type targetListWriter struct {
+	file *os.File
+	w    *bufio.Writer
+	seen map[string]bool
+}
+
+func newTargetListWriter(filename string) (*targetListWriter, error) {
+	file, err := os.Create(filename)
+	if err != nil {
+		return nil, err
+	}
+	return &targetListWriter{file: file, w: bufio.NewWriter(file), seen: make(map[string]bool)}, nil
+}
+
+func (t *targetListWriter) Write(result checker.Result) error {
+	if !result.Alive {
+		return nil
+	}
+	target := targetURL(result)
+	if t.seen[target] {
+		return nil
+	}
+	t.seen[target] = true
+	_, err := fmt.Fprintln(t.w, target)
+	return err
+}
+
+func (t *targetListWriter) Flush() error {
+	return flushAndClose(t.w, t.file)
+}
+
+// 导出httpx JSON Lines格式的结果
+func SaveHttpxJSON(results []checker.Result, filename string) error {
+	w, err := newHttpxWriter(filename)
+	if err != nil {
+		return err
+	}
+	return writeEach(w, results)
+}
+
+// 逐条写入httpx JSON Lines
+type httpxWriter struct {
+	file    *os.File
+	w       *bufio.Writer
+	encoder *json.Encoder
+}
+
+func newHttpxWriter(filename string) (*httpxWriter, error) {
+	file, err := os.Create(filename)
+	if err != nil {
+		return nil, err
+	}
+	w := bufio.NewWriter(file)
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	return &httpxWriter{file: file, w: w, encoder: encoder}, nil
+}
+
+func (h *httpxWriter) Write(result checker.Result) error {
+	return h.encoder.Encode(NewHttpxRecord(result))
+}
+
+func (h *httpxWriter) Flush() error {
+	return flushAndClose(h.w, h.file)
+}
+
+// 刷新缓冲并关闭文件，返回第一个错误
+func flushAndClose(w *bufio.Writer, file *os.File) error {
+	err := w.Flush()
+	if cerr := file.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -0,0 +1,59 @@
+package view
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+
+	"subdomain-checker/checker"
+	"subdomain-checker/utils"
+)
+
+// 执行报告写入函数，将其中发生的panic转换为错误返回，避免报告生成的bug导致程序崩溃丢失结果
+func SafeWrite(write func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("写入过程中发生异常: %v\n%s", r, debug.Stack())
+		}
+	}()
+	return write()
+}
+
+// 保存结果到JSON文件
+func SaveResultsToJSON(results []checker.Result, filename string) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	encoder := json.NewEncoder(file)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(results); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// 报告写入失败时，在失败的报告文件旁写入完整结果的CSV和JSON备份
+// 如 report.xlsx 写入失败会生成 report.fallback.csv 和 report.fallback.json，返回成功写入的文件列表
+func WriteFallback(results []checker.Result, failedPath string) []string {
+	base := strings.TrimSuffix(failedPath, filepath.Ext(failedPath)) + ".fallback"
+
+	var written []string
+	if err := SaveResultsToFile(results, base+".csv"); err != nil {
+		fmt.Fprintf(utils.Console, "⚠️  写入备份CSV失败: %s\n", err)
+	} else {
+		written = append(written, base+".csv")
+	}
+	if err := SaveResultsToJSON(results, base+".json"); err != nil {
+		fmt.Fprintf(utils.Console, "⚠️  写入备份JSON失败: %s\n", err)
+	} else {
+		written = append(written, base+".json")
+	}
+	return written
+}
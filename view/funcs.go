@@ -0,0 +1,99 @@
+package view
+
+import (
+	"html/template"
+	"os"
+	"path/filepath"
+	"time"
+	"unicode/utf8"
+)
+
+// HTML模板中可用的辅助函数，自定义模板同样可以使用
+var templateFuncs = template.FuncMap{
+	"count":       FormatCount,
+	"millis":      FormatMillis,
+	"duration":    humanizeMillis,
+	"statusClass": statusClass,
+	"truncate":    truncate,
+	"percent":     percentOf,
+	"t":           T,
+	"tf":          Tf,
+	"lang":        Language,
+}
+
+// 返回模板辅助函数的副本，供引用本包渲染自定义模板的程序使用
+func TemplateFuncs() template.FuncMap {
+	funcs := make(template.FuncMap, len(templateFuncs))
+	for name, fn := range templateFuncs {
+		funcs[name] = fn
+	}
+	return funcs
+}
+
+// 以易读的方式显示毫秒数，如 "350 毫秒"、"1.25 秒"
+func humanizeMillis(ms float64) string {
+	return FormatDuration(time.Duration(ms * float64(time.Millisecond)))
+}
+
+// 状态码对应的状态颜色样式：200为绿色，重定向为橙色，其他为红色
+func statusClass(status int) string {
+	switch status {
+	case 200:
+		return "status-200"
+	case 301, 302, 307, 308:
+		return "status-redirect"
+	default:
+		return "status-error"
+	}
+}
+
+// 截断超过n个字符的文本并加省略号，参数顺序便于管道使用，如 {{.Title | truncate 60}}
+func truncate(n int, s string) string {
+	if n <= 0 || utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:n]) + "…"
+}
+
+// part占total的百分比，如 "85.3%"，total为0时为 "0.0%"
+func percentOf(part, total int) string {
+	if total == 0 {
+		return FormatPercent(0)
+	}
+	return FormatPercent(float64(part) / float64(total))
+}
+
+// 自定义模板目录（-template-dir），其中的 *.html 可以用 {{define "名称"}} 覆盖报告的各个部分
+var templateDir string
+
+// 设置自定义模板目录
+func SetTemplateDir(dir string) {
+	templateDir = dir
+}
+
+// 检查自定义模板目录是否存在且能与内置模板一起解析
+func ValidateTemplateDir(dir string) error {
+	if _, err := os.Stat(dir); err != nil {
+		return err
+	}
+	_, err := parseReportTemplate(dir)
+	return err
+}
+
+// 解析HTML报告模板：先解析内置模板，再解析自定义目录中的模板，同名部分以自定义的为准。
+// 内置模板中可覆盖的部分有 summary（统计概览）、gallery（相同截图分组）、table（域名列表和详情）、footer（页脚）
+func parseReportTemplate(dir string) (*template.Template, error) {
+	tmpl, err := template.New("template.html").Funcs(templateFuncs).ParseFiles("view/template.html")
+	if err != nil {
+		return nil, err
+	}
+	if dir == "" {
+		return tmpl, nil
+	}
+	matches, err := filepath.Glob(filepath.Join(dir, "*.html"))
+	if err != nil || len(matches) == 0 {
+		return tmpl, err
+	}
+	return tmpl.ParseFiles(matches...)
+}
@@ -0,0 +1,140 @@
+package view
+
+import (
+	"fmt"
+	"html/template"
+	"os"
+
+	"subdomain-checker/checker"
+)
+
+// 画廊中的一张截图卡片
+type galleryCard struct {
+	Domain     string
+	Link       string
+	Title      string
+	StatusText string
+	Status     int
+	Alive      bool
+	Screenshot string // 相对于报告的截图路径，如 screenshots/xxx.png
+	Same       int    // 截图相同的页面数，大于1时卡片代表这一组页面
+	Members    []string
+}
+
+// 保存截图画廊：以网格展示有截图的结果，标注域名、标题和状态。截图以相对路径引用 screenshots 目录并延迟加载，
+// 不内嵌为base64，结果很多时报告仍能快速打开；视觉上相同的截图只显示一张并标注页面数
+func SaveGallery(results []checker.Result, filename string) error {
+	var withScreenshots []checker.Result
+	for _, result := range results {
+		if result.Screenshot != "" {
+			withScreenshots = append(withScreenshots, result)
+		}
+	}
+
+	cards := make([]galleryCard, len(withScreenshots))
+	for i, result := range withScreenshots {
+		link := result.FinalURL
+		if link == "" {
+			link = result.Domain
+		}
+		cards[i] = galleryCard{
+			Domain:     result.DisplayDomain(),
+			Link:       link,
+			Title:      result.Title,
+			StatusText: result.StatusText,
+			Status:     result.Status,
+			Alive:      result.Alive,
+			Screenshot: reportScreenshotPath(result.Screenshot),
+			Same:       1,
+		}
+	}
+	hidden := make([]bool, len(cards))
+	for _, cluster := range clusterScreenshots(withScreenshots) {
+		rep := &cards[cluster.Rep]
+		rep.Same = len(cluster.Members)
+		for _, i := range cluster.Members {
+			if i != cluster.Rep {
+				rep.Members = append(rep.Members, cards[i].Domain)
+				hidden[i] = true
+			}
+		}
+	}
+	visible := cards[:0]
+	for i, card := range cards {
+		if !hidden[i] {
+			visible = append(visible, card)
+		}
+	}
+
+	data := struct {
+		ReportTime  string
+		Screenshots int
+		Cards       []galleryCard
+	}{
+		ReportTime:  reportTime(),
+		Screenshots: len(withScreenshots),
+		Cards:       visible,
+	}
+
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if err := galleryTemplate.Execute(file, data); err != nil {
+		return fmt.Errorf("执行模板失败: %v", err)
+	}
+	return file.Close()
+}
+
+var galleryTemplate = template.Must(template.New("gallery").Parse(`<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="utf-8">
+    <title>截图画廊</title>
+    <style>
+        body { font-family: Arial, sans-serif; margin: 0; padding: 20px; background: #f5f5f5; }
+        h1 { color: #333; text-align: center; margin-bottom: 5px; }
+        .meta { text-align: center; color: #666; margin-bottom: 15px; }
+        .toolbar { text-align: center; margin-bottom: 20px; }
+        #search { padding: 6px 10px; width: 360px; }
+        .grid { display: grid; grid-template-columns: repeat(auto-fill, minmax(320px, 1fr)); gap: 16px; }
+        .card { position: relative; background: #fff; border-radius: 5px; box-shadow: 0 2px 5px rgba(0,0,0,0.1); overflow: hidden; }
+        .card a.shot { display: block; aspect-ratio: 16 / 10; background: #e8e8e8; }
+        .card img { width: 100%; height: 100%; object-fit: cover; object-position: top; display: block; }
+        .overlay { position: absolute; left: 0; right: 0; bottom: 0; padding: 8px 10px; background: rgba(0,0,0,0.65); color: #fff; font-size: 13px; }
+        .overlay .domain { font-weight: bold; word-break: break-all; }
+        .overlay .title { color: #ddd; white-space: nowrap; overflow: hidden; text-overflow: ellipsis; }
+        .badge { display: inline-block; padding: 1px 6px; border-radius: 3px; font-size: 12px; margin-left: 4px; }
+        .alive { background: #2e7d32; }
+        .dead { background: #c62828; }
+        .same { position: absolute; top: 8px; right: 8px; background: #ff9800; color: #fff; cursor: help; }
+    </style>
+</head>
+<body>
+    <h1>截图画廊</h1>
+    <div class="meta">生成时间: {{.ReportTime}} · {{.Screenshots}} 张截图，视觉上相同的截图已合并为 {{len .Cards}} 张</div>
+    <div class="toolbar"><input id="search" type="text" placeholder="按域名或标题筛选..." oninput="filterCards(this.value)"></div>
+    <div class="grid">
+        {{range .Cards}}
+        <div class="card" data-search="{{.Domain}} {{.Title}}{{range .Members}} {{.}}{{end}}">
+            <a class="shot" href="{{.Screenshot}}" target="_blank"><img src="{{.Screenshot}}" alt="{{.Domain}} 的截图" loading="lazy"></a>
+            {{if gt .Same 1}}<span class="badge same" title="{{range $i, $m := .Members}}{{if $i}}&#10;{{end}}{{$m}}{{end}}">×{{.Same}}</span>{{end}}
+            <div class="overlay">
+                <div class="domain"><a href="{{.Link}}" target="_blank" style="color:#fff">{{.Domain}}</a><span class="badge {{if .Alive}}alive{{else}}dead{{end}}">{{if .Status}}{{.Status}}{{else}}{{.StatusText}}{{end}}</span></div>
+                {{if .Title}}<div class="title">{{.Title}}</div>{{end}}
+            </div>
+        </div>
+        {{end}}
+    </div>
+    <script>
+        function filterCards(query) {
+            query = query.toLowerCase();
+            document.querySelectorAll('.card').forEach(card => {
+                card.style.display = card.dataset.search.toLowerCase().includes(query) ? '' : 'none';
+            });
+        }
+    </script>
+</body>
+</html>
+`))
@@ -0,0 +1,79 @@
+package view
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "重新生成 view/testdata/golden 中的报告")
+
+// 各输出格式以固定的结果和报告时间渲染后与 testdata/golden 中的文件逐字节比较。
+// 修改了报告内容时使用 go test ./view -run TestGoldenReports -update 重新生成，并检查差异。
+// Excel是zip格式且内部顺序不固定，不做比较
+func TestGoldenReports(t *testing.T) {
+	SetAnonymizeKey("golden")
+	defer SetAnonymizeKey("")
+	SetReportTime(time.Date(2024, 5, 1, 12, 0, 0, 0, fixtureZone))
+	defer SetReportTime(time.Time{})
+
+	dir := t.TempDir()
+	for _, format := range Formats() {
+		if format.Extension == ".xlsx" {
+			continue
+		}
+		name := format.Name + format.Extension
+		filename := filepath.Join(dir, name)
+		if err := (Output{Format: format, Filename: filename}).Write(fixtureResults(), WriteOptions{}); err != nil {
+			t.Errorf("%s: %v", format.Name, err)
+			continue
+		}
+		got, err := os.ReadFile(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// 匿名化的结果中不能出现真实的域名、IP和其他能识别目标的内容
+		if format.Name == "anon-json" {
+			for _, leak := range []string{"example", "93.184.", "Example", "Postfix", "internal", "widgets"} {
+				if bytes.Contains(got, []byte(leak)) {
+					t.Errorf("anon-json contains %q", leak)
+				}
+			}
+		}
+
+		golden := filepath.Join("view", "testdata", "golden", name)
+		if *update {
+			if err := os.MkdirAll(filepath.Dir(golden), 0o755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(golden, got, 0o644); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		want, err := os.ReadFile(golden)
+		if err != nil {
+			t.Errorf("%s: %v（新增格式时使用 -update 生成）", format.Name, err)
+			continue
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s differs from %s at line %d", format.Name, golden, firstDiffLine(got, want))
+		}
+	}
+}
+
+// 第一处不同所在的行号，从1开始
+func firstDiffLine(a, b []byte) int {
+	la, lb := strings.Split(string(a), "\n"), strings.Split(string(b), "\n")
+	for i := range min(len(la), len(lb)) {
+		if la[i] != lb[i] {
+			return i + 1
+		}
+	}
+	return min(len(la), len(lb)) + 1
+}
@@ -0,0 +1,155 @@
+package view
+
+import (
+	"sort"
+
+	"subdomain-checker/checker"
+	"subdomain-checker/utils"
+)
+
+// 分组中的单个成员
+type GroupMember struct {
+	Domain     string
+	StatusText string
+	Status     int
+	Alive      bool
+}
+
+// 结果分组（按根域名或IP）
+type ResultGroup struct {
+	Key     string
+	Total   int
+	Alive   int
+	Members []GroupMember
+}
+
+// 按根域名分组
+func GroupByRootDomain(results []checker.Result) []ResultGroup {
+	return groupResults(results, func(r checker.Result) string {
+		return utils.ToUnicodeTarget(utils.RootDomain(utils.HostFromURL(r.Domain)))
+	})
+}
+
+// 按解析到的IP分组，未解析的域名归入"未解析"分组
+func GroupByIP(results []checker.Result) []ResultGroup {
+	return groupResults(results, func(r checker.Result) string {
+		if r.IP == "" {
+			return "未解析"
+		}
+		return r.IP
+	})
+}
+
+// 按失败原因对无法访问的结果分组
+func GroupByErrorCategory(results []checker.Result) []ResultGroup {
+	var dead []checker.Result
+	for _, result := range results {
+		if !result.Alive {
+			dead = append(dead, result)
+		}
+	}
+	return groupResults(dead, errorCategory)
+}
+
+// 按页面内容聚类：归一化内容相同或SimHash近似的存活页面归为一组，只返回包含多个域名的分组，
+// 如大量主机返回同一默认nginx页面时合并为一组；分组名为代表页面的标题和内容哈希前缀
+func GroupByContent(results []checker.Result) []ResultGroup {
+	// 先按精确哈希分组，每个哈希取第一个结果作为代表
+	hashIndex := make(map[string]int)
+	var reps []checker.Result
+	for _, result := range results {
+		if !result.Alive || result.BodyHash == "" {
+			continue
+		}
+		if _, ok := hashIndex[result.BodyHash]; !ok {
+			hashIndex[result.BodyHash] = len(reps)
+			reps = append(reps, result)
+		}
+	}
+
+	// 再合并SimHash近似的代表：距离不超过阈值的两个64位指纹必有一个16位分段完全相同，
+	// 只比较分段相同的候选，避免两两比较
+	parent := make([]int, len(reps))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	buckets := make(map[uint64][]int)
+	for i, rep := range reps {
+		if rep.SimHash == 0 {
+			continue
+		}
+		for band := uint64(0); band < 4; band++ {
+			key := band<<16 | (rep.SimHash>>(band*16))&0xffff
+			for _, j := range buckets[key] {
+				if checker.SimHashDistance(rep.SimHash, reps[j].SimHash) <= checker.SimHashThreshold {
+					if a, b := find(i), find(j); a != b {
+						parent[a] = b
+					}
+				}
+			}
+			buckets[key] = append(buckets[key], i)
+		}
+	}
+
+	groups := groupResults(results, func(r checker.Result) string {
+		if !r.Alive || r.BodyHash == "" {
+			return ""
+		}
+		rep := reps[find(hashIndex[r.BodyHash])]
+		title := rep.Title
+		if title == "" {
+			title = "(无标题)"
+		}
+		return title + " · " + rep.BodyHash[:12]
+	})
+
+	clusters := groups[:0]
+	for _, group := range groups {
+		if group.Key != "" && group.Total > 1 {
+			clusters = append(clusters, group)
+		}
+	}
+	return clusters
+}
+
+// 根据keyFunc对结果分组，按成员数量降序排列
+func groupResults(results []checker.Result, keyFunc func(checker.Result) string) []ResultGroup {
+	index := make(map[string]int)
+	var groups []ResultGroup
+
+	for _, result := range results {
+		key := keyFunc(result)
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, ResultGroup{Key: key})
+		}
+		groups[i].Total++
+		if result.Alive {
+			groups[i].Alive++
+		}
+		groups[i].Members = append(groups[i].Members, GroupMember{
+			Domain:     result.DisplayDomain(),
+			StatusText: result.StatusText,
+			Status:     result.Status,
+			Alive:      result.Alive,
+		})
+	}
+
+	sort.SliceStable(groups, func(a, b int) bool {
+		if groups[a].Total != groups[b].Total {
+			return groups[a].Total > groups[b].Total
+		}
+		return groups[a].Key < groups[b].Key
+	})
+
+	return groups
+}
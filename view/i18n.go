@@ -0,0 +1,336 @@
+package view
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"subdomain-checker/checker"
+)
+
+// 报告和总结的界面语言。文本以中文原文为键，其他语言在消息目录中查找译文，
+// 没有译文的文本按原文显示，新增界面文本时同步补充各语言的目录
+var language = "zh"
+
+// 各语言的消息目录：中文原文 -> 译文
+var catalogs = map[string]map[string]string{
+	"zh": nil,
+	"en": enMessages,
+}
+
+// 设置报告、Excel、CSV和控制台总结使用的界面语言
+func SetLanguage(name string) error {
+	if _, ok := catalogs[name]; !ok {
+		return fmt.Errorf("不支持的语言 %q，可选: %s", name, strings.Join(LanguageNames(), ", "))
+	}
+	language = name
+	return nil
+}
+
+// 当前界面语言
+func Language() string {
+	return language
+}
+
+// 返回支持的界面语言
+func LanguageNames() []string {
+	names := make([]string, 0, len(catalogs))
+	for name := range catalogs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// 语言对应的默认数字和时间格式
+func DefaultLocale(lang string) string {
+	if lang == "en" {
+		return "en-US"
+	}
+	return "zh-CN"
+}
+
+// 翻译界面文本，没有译文时返回原文
+func T(text string) string {
+	if translated, ok := catalogs[language][text]; ok {
+		return translated
+	}
+	return text
+}
+
+// 翻译格式字符串后格式化
+func Tf(format string, args ...any) string {
+	return fmt.Sprintf(T(format), args...)
+}
+
+// 翻译结果的发现原因：先翻译模板再填入参数
+func translateFindings(findings []checker.Finding) []string {
+	translated := make([]string, len(findings))
+	for i, finding := range findings {
+		if len(finding.Args) == 0 {
+			translated[i] = T(finding.Key)
+		} else {
+			translated[i] = Tf(finding.Key, finding.Args...)
+		}
+	}
+	return translated
+}
+
+// 翻译一组文本，如表头
+func translateAll(texts []string) []string {
+	translated := make([]string, len(texts))
+	for i, text := range texts {
+		translated[i] = T(text)
+	}
+	return translated
+}
+
+// 英文消息目录
+var enMessages = map[string]string{
+	// 控制台总结
+	"检测结果 (总结):":                           "Scan results (summary):",
+	"总计: %s 个域名, %s 个存活, %s 个无法访问":         "Total: %s domains, %s alive, %s unreachable",
+	"页面类型统计:":                              "Page types:",
+	"%s: %s 个":                             "%s: %s",
+	"云服务商分布:":                              "Cloud providers:",
+	"成功截图存活网站: %s 个":                       "Screenshots of alive sites: %s",
+	"成功截图: %s 个":                           "Screenshots: %s",
+	"DNS缓存: 命中 %s 次, 查询 %s 次 (命中率 %.1f%%)": "DNS cache: %s hits, %s lookups (hit rate %.1f%%)",
+	"检测耗时: %s":                             "Elapsed: %s",
+	"失败原因统计:":                              "Failure reasons:",
+	"占位页面: %s 个（报告中可用\"排除占位\"过滤）":         "Placeholder pages: %s (hide them with \"Exclude placeholders\" in the report)",
+	"响应时间: P50 %s, P90 %s, P99 %s, 最大 %s": "Response time: P50 %s, P90 %s, P99 %s, max %s",
+	"响应时间分布:":              "Response time distribution:",
+	"响应最慢的主机:":             "Slowest hosts:",
+	"重点发现 (共%s个):":         "Key findings (%s):",
+	"... 其余 %d 个请查看报告":     "... %d more, see the report",
+	"域名注册信息: %s 个根域名":      "Domain registration: %s root domains",
+	"%s 将于 %s 到期（注册商: %s）": "%s expires on %s (registrar: %s)",
+	"%s 注册于 %s（注册商: %s）":   "%s registered on %s (registrar: %s)",
+	"%d 个根域名的注册信息查询失败":     "Registration lookup failed for %d root domains",
+
+	// CSV/Excel列名和工作表
+	"域名":        "Domain",
+	"状态":        "Status",
+	"状态码":       "Status code",
+	"响应时间":      "Response time",
+	"页面类型":      "Page type",
+	"页面标题":      "Title",
+	"消息":        "Message",
+	"截图":        "Screenshot",
+	"备注":        "Note",
+	"最终URL":     "Final URL",
+	"重定向链":      "Redirect chain",
+	"检测时间":      "Checked at",
+	"错误类型":      "Error type",
+	"地址族":       "Address family",
+	"内容哈希":      "Content hash",
+	"CNAME链":    "CNAME chain",
+	"组织":        "Organization",
+	"国家":        "Country",
+	"云服务商":      "Cloud provider",
+	"开放端口":      "Open ports",
+	"服务":        "Services",
+	"路径探测":      "Path probes",
+	"子域名检测结果":   "Results",
+	"页面截图":      "Screenshots",
+	"范围外目标":     "Out of scope",
+	"域名注册信息":    "Domain registration",
+	"汇总看板":      "Dashboard",
+	"分组统计":      "Groups",
+	"扫描配置":      "Scan config",
+	"指标":        "Metric",
+	"数值":        "Value",
+	"数量":        "Count",
+	"报告时间":      "Report time",
+	"域名总数":      "Total domains",
+	"存活率":       "Alive rate",
+	"响应时间P50":   "Response time P50",
+	"响应时间P90":   "Response time P90",
+	"存活情况":      "Alive vs unreachable",
+	"状态码分布":     "Status codes",
+	"无响应":       "No response",
+	"汇总":        "Summary",
+	"子域名数":      "Subdomains",
+	"存活数":       "Alive",
+	"高风险":       "High",
+	"中风险":       "Medium",
+	"低风险":       "Low",
+	"重点主机":      "Notable hosts",
+	"明细":        "Details",
+	"根域名索引":     "Root domains",
+	"工作表":       "Sheet",
+	"查看明细":      "View details",
+	"← 返回汇总":    "← Back to summary",
+	"风险等级":      "Severity",
+	"发现":        "Findings",
+	"处理状态":      "Triage",
+	"待处理":       "To review",
+	"已确认":       "Confirmed",
+	"误报":        "False positive",
+	"已忽略":       "Ignored",
+	"请从下拉列表中选择": "Please choose a value from the list",
+	"高":         "High",
+	"中":         "Medium",
+	"低":         "Low",
+	"无截图":       "No screenshot",
+	"查看截图":      "View screenshot",
+	"无法获取截图":    "Screenshot unavailable",
+	"路径":        "Path",
+	"长度":        "Length",
+	"标题":        "Title",
+	"错误":        "Error",
+	"目标":        "Target",
+	"原因":        "Reason",
+	"根域名":       "Root domain",
+	"注册商":       "Registrar",
+	"注册日期":      "Registered",
+	"到期日期":      "Expires",
+	"提示":        "Notes",
+	"查询错误":      "Lookup error",
+	"即将到期":      "Expiring soon",
+	"新注册":       "Newly registered",
+	"软404":      "Soft 404",
+	"是":         "Yes",
+	"软404主机: %s 个（%s 个探测路径与不存在路径的响应相同）": "Soft-404 hosts: %s (%s probed paths match the response for a nonexistent path)",
+	"、":          ", ",
+	"，":          ", ",
+	"秒":          "s",
+	"毫秒":         "ms",
+	"服务器请求客户端证书": "Server requests a client certificate",
+	"服务器要求客户端证书（握手失败）": "Server requires a client certificate (handshake failed)",
+
+	// 检测结果中的状态、页面类型、失败类型和占位页面类别
+	"存活":      "Alive",
+	"重定向":     "Redirect",
+	"禁止访问":    "Forbidden",
+	"未找到":     "Not found",
+	"服务器错误":   "Server error",
+	"网关错误":    "Bad gateway",
+	"服务不可用":   "Service unavailable",
+	"无法访问":    "Unreachable",
+	"登录页面":    "Login page",
+	"管理后台":    "Admin panel",
+	"API接口":   "API",
+	"API文档":   "API docs",
+	"上传页面":    "Upload page",
+	"错误页面":    "Error page",
+	"纯文本":     "Plain text",
+	"文档":      "Document",
+	"图片":      "Image",
+	"二进制文件":   "Binary file",
+	"停放域名":    "Parked domain",
+	"注册商占位页":  "Registrar placeholder",
+	"服务器默认页":  "Server default page",
+	"CDN错误页":  "CDN error page",
+	"DNS解析失败": "DNS failure",
+	"悬挂CNAME": "Dangling CNAME",
+	"连接被拒绝":   "Connection refused",
+	"连接超时":    "Timeout",
+	"TLS错误":   "TLS error",
+	"连接被重置":   "Connection reset",
+	"HTTP错误":  "HTTP error",
+	"其他错误":    "Other error",
+
+	// HTML报告
+	"检测结果": "Scan results",
+	"检测总数": "Total",
+	"存活数量": "Alive",
+	"生成时间": "Generated",
+	"全部":   "All",
+	"不存活":  "Dead",
+	"排除占位": "Exclude placeholders",
+	"存活且不是停放域名、默认页、CDN错误页等占位页面":     "Alive and not a parked, default or CDN error placeholder page",
+	"输入域名关键词或状态码(如200、404等)进行搜索...": "Search by domain keyword or status code (e.g. 200, 404)...",
+	"切换浅色/深色主题":         "Toggle light/dark theme",
+	"🌓 主题":              "🌓 Theme",
+	"按根域名分组":            "By root domain",
+	"按IP分组":             "By IP",
+	"按失败原因分组":           "By failure reason",
+	"相同页面分组":            "Identical pages",
+	"相同截图分组":            "Identical screenshots",
+	"%d 组":              "%d groups",
+	"%s 个域名, %s 个存活":    "%s domains, %s alive",
+	"%s 个域名":            "%s domains",
+	"%s 个页面截图相同":        "%s pages with the same screenshot",
+	"响应最慢的主机（最大 %s）":    "Slowest hosts (max %s)",
+	"%d 个根域名":           "%d root domains",
+	"查询失败":              "Lookup failed",
+	"%s 即将到期":           "%s expiring soon",
+	"范围外目标（未检测）":        "Out of scope (not checked)",
+	"%d 个":              "%d",
+	"%s 的截图":            "Screenshot of %s",
+	"命中":                "Match",
+	"占位":                "Placeholder",
+	"状态:":               "Status:",
+	"状态码:":              "Status code:",
+	"响应时间:":             "Response time:",
+	"页面类型:":             "Page type:",
+	"页面标题:":             "Title:",
+	"消息:":               "Message:",
+	"地址族:":              "Address family:",
+	"IP归属:":             "IP owner:",
+	"国家/地区:":            "Country/region:",
+	"开放端口:":             "Open ports:",
+	"服务:":               "Services:",
+	"CNAME链:":           "CNAME chain:",
+	"（悬挂CNAME，目标不存在）":   "(dangling CNAME, target does not exist)",
+	"复查提示:":             "Review hints:",
+	"文件上传表单":            "File upload form",
+	"登录表单":              "Login form",
+	"疑似开放重定向参数:":        "Possible open redirect parameters:",
+	"内容类型:":             "Content type:",
+	"占位页面:":             "Placeholder page:",
+	"（命中: %s）":          "(match: %s)",
+	"客户端证书:":            "Client certificate:",
+	"原始响应:":             "Raw response:",
+	"错误类型:":             "Error type:",
+	"检测时间:":             "Checked at:",
+	"最终URL:":            "Final URL:",
+	"重定向链:":             "Redirect chain:",
+	"备注:":               "Note:",
+	"关键词命中 (%d)":        "Keyword matches (%d)",
+	"[截图文字]":            "[screenshot text]",
+	"路径探测 (%d)":         "Path probes (%d)",
+	"存在":                "present",
+	"不存在":               "absent",
+	"禁止抓取的路径 (%d)":      "Disallowed paths (%d)",
+	"sitemap中的URL (%d)": "Sitemap URLs (%d)",
+	"截图文字 (OCR)":        "Screenshot text (OCR)",
+	"截图相同:":             "Same screenshot as:",
+	"软404:":             "Soft 404:",
+	"不存在的路径也返回成功响应":                  "Nonexistent paths also return a success response",
+	"未返回Access-Control-Allow-Origin": "No Access-Control-Allow-Origin returned",
+	"回显任意来源":                         "Reflects any origin",
+	"回显任意来源且允许携带凭据":                  "Reflects any origin with credentials allowed",
+	"CORS允许任意来源携带凭据":                 "CORS allows any origin with credentials",
+	"悬挂CNAME → %s":                   "Dangling CNAME → %s",
+	"JARM指纹命中: %s":                   "JARM fingerprint match: %s",
+	"关键词命中%d处":                       "%d keyword matches",
+	"疑似开放重定向参数: %s":                  "Possible open redirect parameters: %s",
+	"危险的HTTP方法: %s":                  "Risky HTTP methods: %s",
+	"CORS过于宽松: %s 个主机回显任意来源且允许携带凭据": "Overly permissive CORS: %s hosts reflect any origin with credentials allowed",
+	"（危险: %s）":             " (risky: %s)",
+	"HTTP方法":               "HTTP methods",
+	"HTTP方法:":              "HTTP methods:",
+	"危险:":                  "Risky:",
+	"危险HTTP方法: %s 个主机（%s）": "Risky HTTP methods: %s hosts (%s)",
+	"域传送":                  "Zone transfer",
+	"域传送漏洞":                "Zone transfer vulnerability",
+	"允许域传送的DNS服务器":         "Nameservers allowing AXFR",
+	"记录数":                  "Records",
+	"主机名":                  "Hostnames",
+	"泄露的主机名":               "Leaked hostnames",
+	"域传送漏洞: %s 的DNS服务器 %s 允许AXFR，泄露了 %s 条记录（%s 个主机名）": "Zone transfer vulnerability: nameservers %[2]s of %[1]s allow AXFR, leaking %[3]s records (%[4]s hostnames)",
+	"%s 的DNS服务器 %s 允许AXFR，泄露了 %s 条记录（%s 个主机名）":        "nameservers %[2]s of %[1]s allow AXFR, leaking %[3]s records (%[4]s hostnames)",
+	"响应头和Cookie": "Response headers and cookies",
+	"响应头 (%d)":   "Response headers (%d)",
+	"名称":         "Name",
+	"值":          "Value",
+	"属性":         "Attributes",
+	"服务器软件":      "Server software",
+	"技术栈":        "Technology stack",
+	"后端信息":       "Backend information",
+	"调试信息":       "Debug information",
+	"松鼠子域名检测工具 · 生成于 %s · 共 %s 个目标，存活 %s": "Squirrel subdomain checker · generated %s · %s targets, %s alive",
+}
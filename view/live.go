@@ -0,0 +1,264 @@
+package view
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"subdomain-checker/checker"
+	"subdomain-checker/logger"
+)
+
+// 实时报告（-live-report）：扫描期间在本地端口提供HTML报告，
+// 新的检测结果通过SSE推送到已打开的页面，长时间扫描无需等待结束即可开始分析
+type LiveReport struct {
+	screenshotDir string
+
+	mu       sync.Mutex
+	results  []checker.Result
+	total    int
+	finished bool
+	clients  map[chan liveEvent]bool
+	status   http.Handler
+}
+
+// 推送给页面的事件
+type liveEvent struct {
+	name string // result 或 finish
+	data []byte
+}
+
+// 推送的单个结果摘要，完整信息在刷新页面后显示
+type liveResult struct {
+	Domain string `json:"domain"`
+	Status int    `json:"status"`
+	Alive  bool   `json:"alive"`
+	Title  string `json:"title"`
+	Done   int    `json:"done"`
+	Total  int    `json:"total"`
+}
+
+// 创建实时报告，screenshotDir为截图所在目录，以 /screenshots/ 路径提供给页面
+func NewLiveReport(screenshotDir string, total int) *LiveReport {
+	return &LiveReport{
+		screenshotDir: screenshotDir,
+		total:         total,
+		clients:       make(map[chan liveEvent]bool),
+	}
+}
+
+// 在addr上启动服务，监听失败时返回错误，之后在后台提供服务
+func (l *LiveReport) Start(addr string) (string, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return "", err
+	}
+	go func() {
+		if err := http.Serve(listener, l.Handler()); err != nil {
+			logger.Error("实时报告服务停止", "error", err)
+		}
+	}()
+	return listener.Addr().String(), nil
+}
+
+// 加入一个检测结果并推送给已连接的页面
+func (l *LiveReport) Add(result checker.Result) {
+	DecodeTitle(&result)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.results = append(l.results, result)
+	data, _ := json.Marshal(liveResult{
+		Domain: result.Domain,
+		Status: result.Status,
+		Alive:  result.Alive,
+		Title:  result.Title,
+		Done:   len(l.results),
+		Total:  l.total,
+	})
+	l.broadcast(liveEvent{name: "result", data: data})
+}
+
+// 目标总数增加时（如 -feed 追加的目标）更新进度
+func (l *LiveReport) AddTotal(n int) {
+	l.mu.Lock()
+	l.total += n
+	l.mu.Unlock()
+}
+
+// 扫描结束，通知页面不再有新结果
+func (l *LiveReport) Finish() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.finished = true
+	l.broadcast(liveEvent{name: "finish", data: []byte("{}")})
+}
+
+// 推送事件，调用方持有锁；页面处理不及时时丢弃事件，页面刷新后仍能看到全部结果
+func (l *LiveReport) broadcast(e liveEvent) {
+	for ch := range l.clients {
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+}
+
+// 设置 /status 扫描状态接口，未设置时返回404
+func (l *LiveReport) SetStatus(h http.Handler) {
+	l.mu.Lock()
+	l.status = h
+	l.mu.Unlock()
+}
+
+// 路由：/ 为报告页面，/events 为SSE事件流，/status 为扫描状态，/screenshots/ 为截图文件
+func (l *LiveReport) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/screenshots/", http.StripPrefix("/screenshots/", http.FileServer(http.Dir(l.screenshotDir))))
+	mux.HandleFunc("/events", l.serveEvents)
+	mux.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
+		l.mu.Lock()
+		status := l.status
+		l.mu.Unlock()
+		if status == nil {
+			http.NotFound(w, r)
+			return
+		}
+		status.ServeHTTP(w, r)
+	})
+	mux.HandleFunc("/", l.serveReport)
+	return mux
+}
+
+// 渲染当前已完成的结果，并在页面中加入接收推送的脚本
+func (l *LiveReport) serveReport(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	l.mu.Lock()
+	results := append([]checker.Result(nil), l.results...)
+	done, total, finished := len(l.results), l.total, l.finished
+	l.mu.Unlock()
+
+	var buf bytes.Buffer
+	if err := renderHTMLReport(&buf, results, false); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	state, _ := json.Marshal(map[string]any{"done": done, "total": total, "finished": finished})
+	script := fmt.Sprintf(liveScript, state)
+	page := bytes.Replace(buf.Bytes(), []byte("</body>"), []byte(script+"</body>"), 1)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Write(page)
+}
+
+// SSE事件流，定期发送注释行保持连接
+func (l *LiveReport) serveEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "不支持事件流", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	ch := make(chan liveEvent, 256)
+	l.mu.Lock()
+	finished := l.finished
+	if !finished {
+		l.clients[ch] = true
+	}
+	l.mu.Unlock()
+	if finished {
+		fmt.Fprint(w, "event: finish\ndata: {}\n\n")
+		flusher.Flush()
+		return
+	}
+	defer func() {
+		l.mu.Lock()
+		delete(l.clients, ch)
+		l.mu.Unlock()
+	}()
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(15 * time.Second)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": ping\n\n")
+			flusher.Flush()
+		case e := <-ch:
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.name, e.data)
+			flusher.Flush()
+			if e.name == "finish" {
+				return
+			}
+		}
+	}
+}
+
+// 实时报告页面的脚本：显示扫描进度和新到达的结果，点击刷新查看完整详情
+const liveScript = `
+    <style>
+        .live-banner { position: sticky; top: 0; z-index: 10; margin: 0 auto 10px; max-width: 1600px; padding: 10px 15px; border-radius: 5px; background: #2056dd; color: #fff; display: flex; align-items: center; gap: 12px; }
+        .live-banner.finished { background: #4CAF50; }
+        .live-banner button { border: none; border-radius: 4px; padding: 5px 10px; cursor: pointer; }
+        .live-new { max-width: 1600px; margin: 0 auto 10px; max-height: 160px; overflow-y: auto; font-size: 13px; }
+        .live-new div { padding: 2px 15px; }
+    </style>
+    <script>
+        (function() {
+            const state = %s;
+            const banner = document.createElement('div');
+            banner.className = 'live-banner';
+            const text = document.createElement('span');
+            const refresh = document.createElement('button');
+            refresh.textContent = '刷新查看详情';
+            refresh.addEventListener('click', () => location.reload());
+            banner.append(text, refresh);
+            const list = document.createElement('div');
+            list.className = 'live-new';
+            document.body.prepend(banner, list);
+
+            let fresh = 0;
+            function render() {
+                const progress = state.total ? state.done + '/' + state.total : state.done;
+                text.textContent = state.finished
+                    ? '✅ 扫描已完成，共 ' + state.done + ' 个结果'
+                    : '⏳ 扫描进行中: ' + progress + (fresh ? '，页面打开后新增 ' + fresh + ' 个结果' : '');
+                refresh.style.display = fresh ? '' : 'none';
+                banner.classList.toggle('finished', state.finished);
+            }
+            render();
+            if (state.finished) {
+                return;
+            }
+
+            const events = new EventSource('/events');
+            events.addEventListener('result', function(e) {
+                const r = JSON.parse(e.data);
+                state.done = r.done;
+                state.total = r.total;
+                fresh++;
+                const row = document.createElement('div');
+                row.textContent = (r.alive ? '🟢 ' : '🔴 ') + r.domain + ' [' + (r.status || '无响应') + ']' + (r.title ? ' ' + r.title : '');
+                list.prepend(row);
+                render();
+            });
+            events.addEventListener('finish', function() {
+                state.finished = true;
+                events.close();
+                render();
+            });
+        })();
+    </script>
+`
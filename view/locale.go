@@ -0,0 +1,100 @@
+package view
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 报告的数字和时间格式约定
+type Locale struct {
+	Thousands   string // 千位分隔符
+	Decimal     string // 小数点
+	Second      string // 秒的单位
+	Millisecond string // 毫秒的单位
+}
+
+// 支持的区域设置
+var locales = map[string]Locale{
+	"zh-CN": {Thousands: ",", Decimal: ".", Second: "秒", Millisecond: "毫秒"},
+	"en-US": {Thousands: ",", Decimal: ".", Second: "s", Millisecond: "ms"},
+	"de-DE": {Thousands: ".", Decimal: ",", Second: "s", Millisecond: "ms"},
+	"fr-FR": {Thousands: "\u00a0", Decimal: ",", Second: "s", Millisecond: "ms"},
+	"ja-JP": {Thousands: ",", Decimal: ".", Second: "秒", Millisecond: "ミリ秒"},
+}
+
+// 当前使用的区域设置，默认 zh-CN
+var locale = locales["zh-CN"]
+
+// 设置报告、Excel和控制台总结使用的区域设置
+func SetLocale(name string) error {
+	l, ok := locales[name]
+	if !ok {
+		return fmt.Errorf("不支持的区域设置 %q，可选: %s", name, strings.Join(LocaleNames(), ", "))
+	}
+	locale = l
+	return nil
+}
+
+// 返回支持的区域设置名称
+func LocaleNames() []string {
+	names := make([]string, 0, len(locales))
+	for name := range locales {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// 按区域设置格式化数字，decimals为小数位数，如 zh-CN 下 12345.6 -> "12,345.6"，de-DE 下为 "12.345,6"
+func FormatNumber(value float64, decimals int) string {
+	s := strconv.FormatFloat(math.Abs(value), 'f', decimals, 64)
+	integer, fraction, _ := strings.Cut(s, ".")
+
+	var b strings.Builder
+	if value < 0 && strings.Trim(s, "0.") != "" {
+		b.WriteByte('-')
+	}
+	for i, digit := range integer {
+		if i > 0 && (len(integer)-i)%3 == 0 {
+			b.WriteString(locale.Thousands)
+		}
+		b.WriteRune(digit)
+	}
+	if fraction != "" {
+		b.WriteString(locale.Decimal)
+		b.WriteString(fraction)
+	}
+	return b.String()
+}
+
+// 格式化计数，如 "12,345"
+func FormatCount(n int) string {
+	return FormatNumber(float64(n), 0)
+}
+
+// 格式化毫秒数，如 "1,234 毫秒"、"1,234 ms"
+func FormatMillis(ms float64) string {
+	return FormatNumber(ms, 0) + " " + locale.Millisecond
+}
+
+// 格式化耗时：不足1秒时以毫秒显示，否则以秒显示并保留两位小数，如 "12.34 秒"、"12,34 s"
+func FormatDuration(d time.Duration) string {
+	if d < time.Second {
+		return FormatMillis(float64(d) / float64(time.Millisecond))
+	}
+	return FormatNumber(d.Seconds(), 2) + " " + locale.Second
+}
+
+// 格式化百分比，ratio为0到1之间的比例，如 "85.3%"
+func FormatPercent(ratio float64) string {
+	return FormatNumber(ratio*100, 1) + "%"
+}
+
+// 带单位的毫秒列名，如 "响应时间(毫秒)"
+func millisHeader(name string) string {
+	return T(name) + "(" + locale.Millisecond + ")"
+}
@@ -0,0 +1,109 @@
+package view
+
+import (
+	"image"
+	"math/bits"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"sync"
+
+	"golang.org/x/image/draw"
+
+	"subdomain-checker/checker"
+)
+
+// 截图感知哈希（dHash）汉明距离不超过该值时视为视觉上相同的页面
+const screenshotHashThreshold = 4
+
+// 截图文件 -> 感知哈希，同一次运行中生成多份报告时不必重复解码截图
+var screenshotHashes sync.Map
+
+// 计算截图的差值哈希：缩小为9x8灰度图，比较每行相邻像素的亮度得到64位指纹，
+// 对缩放、压缩和细微的文字差异不敏感
+func screenshotHash(path string) (uint64, bool) {
+	if hash, ok := screenshotHashes.Load(path); ok {
+		return hash.(uint64), true
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, false
+	}
+	defer file.Close()
+	img, _, err := image.Decode(file)
+	if err != nil {
+		return 0, false
+	}
+
+	small := image.NewGray(image.Rect(0, 0, 9, 8))
+	draw.ApproxBiLinear.Scale(small, small.Bounds(), img, img.Bounds(), draw.Src, nil)
+	var hash uint64
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			if small.GrayAt(x, y).Y < small.GrayAt(x+1, y).Y {
+				hash |= 1 << (y*8 + x)
+			}
+		}
+	}
+	screenshotHashes.Store(path, hash)
+	return hash, true
+}
+
+// 截图聚类：Rep为代表结果的下标，Members包含代表在内的所有结果下标
+type screenshotCluster struct {
+	Rep     int
+	Members []int
+}
+
+// 按截图的感知哈希将视觉上相同的页面聚类，只返回包含多个结果的聚类，按成员数量降序排列
+func clusterScreenshots(results []checker.Result) []screenshotCluster {
+	// 并发解码截图计算哈希
+	hashes := make([]uint64, len(results))
+	valid := make([]bool, len(results))
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, runtime.NumCPU())
+	for i, result := range results {
+		if result.Screenshot == "" {
+			continue
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, path string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			hashes[i], valid[i] = screenshotHash(path)
+		}(i, filepath.FromSlash(reportScreenshotPath(result.Screenshot)))
+	}
+	wg.Wait()
+
+	// 依次与已有聚类的代表比较，距离在阈值内时加入该聚类
+	var clusters []screenshotCluster
+	for i := range results {
+		if !valid[i] {
+			continue
+		}
+		joined := false
+		for c := range clusters {
+			if bits.OnesCount64(hashes[i]^hashes[clusters[c].Rep]) <= screenshotHashThreshold {
+				clusters[c].Members = append(clusters[c].Members, i)
+				joined = true
+				break
+			}
+		}
+		if !joined {
+			clusters = append(clusters, screenshotCluster{Rep: i, Members: []int{i}})
+		}
+	}
+
+	groups := clusters[:0]
+	for _, cluster := range clusters {
+		if len(cluster.Members) > 1 {
+			groups = append(groups, cluster)
+		}
+	}
+	sort.SliceStable(groups, func(a, b int) bool {
+		return len(groups[a].Members) > len(groups[b].Members)
+	})
+	return groups
+}
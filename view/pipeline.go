@@ -0,0 +1,258 @@
+package view
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"subdomain-checker/checker"
+	"subdomain-checker/config"
+	"subdomain-checker/utils"
+)
+
+// 报告流水线：收集 → 补充 → 过滤 → 排序 → 渲染。
+// 前四个阶段只处理结果列表，与输出格式无关；各输出格式的写入函数只负责渲染处理好的结果
+
+// 补充阶段：为结果补充或修正报告需要的字段
+type Enricher func(result *checker.Result)
+
+// 过滤阶段：返回false的结果不写入报告
+type Filter func(result checker.Result) bool
+
+// 排序阶段：a应排在b之前时返回true
+type Less func(a, b checker.Result) bool
+
+// 由各阶段组成的流水线，零值不做任何处理
+type Pipeline struct {
+	Enrichers []Enricher
+	Filters   []Filter
+	Less      Less // 为nil时保持收集顺序
+}
+
+// 依次执行各阶段，返回新的结果列表，不修改传入的结果
+func (p Pipeline) Run(results []checker.Result) []checker.Result {
+	if len(p.Enrichers) == 0 && len(p.Filters) == 0 && p.Less == nil {
+		return results // 无需处理时直接使用原结果，避免大结果集复制一份
+	}
+	rows := make([]checker.Result, 0, len(results))
+	for _, result := range results {
+		if row, ok := p.process(result); ok {
+			rows = append(rows, row)
+		}
+	}
+	if p.Less != nil {
+		sort.SliceStable(rows, func(i, j int) bool {
+			return p.Less(rows[i], rows[j])
+		})
+	}
+	return rows
+}
+
+// 流水线输出的行数，不保留处理后的结果
+func (p Pipeline) Count(results []checker.Result) int {
+	count := 0
+	for _, result := range results {
+		if _, ok := p.process(result); ok {
+			count++
+		}
+	}
+	return count
+}
+
+// 对单个结果执行补充和过滤阶段
+func (p Pipeline) process(result checker.Result) (checker.Result, bool) {
+	for _, enrich := range p.Enrichers {
+		enrich(&result)
+	}
+	for _, keep := range p.Filters {
+		if !keep(result) {
+			return result, false
+		}
+	}
+	return result, true
+}
+
+// 写入输出时使用的流水线：修正标题编码，-only-alive 时只保留存活结果并应用报告过滤条件（Complete格式除外）
+func (o Output) pipeline(opts WriteOptions) Pipeline {
+	p := Pipeline{Enrichers: []Enricher{DecodeTitle}}
+	if !o.Format.Complete {
+		if opts.OnlyAlive {
+			p.Filters = append(p.Filters, AliveOnly)
+		}
+		p.Filters = append(p.Filters, opts.Filters...)
+	}
+	return p
+}
+
+// 根据 -match-code、-filter-code、-match-title-regex、-match-type、-min-time、-max-time 生成报告过滤条件
+func ReportFilters(cfg config.Config) ([]Filter, error) {
+	var filters []Filter
+	if cfg.MatchCode != "" {
+		codes, err := checker.ParseStatusCodes(cfg.MatchCode)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, CodeIn(codes))
+	}
+	if cfg.FilterCode != "" {
+		codes, err := checker.ParseStatusCodes(cfg.FilterCode)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, CodeNotIn(codes))
+	}
+	if cfg.MatchTitleRegex != "" {
+		re, err := regexp.Compile(cfg.MatchTitleRegex)
+		if err != nil {
+			return nil, fmt.Errorf("无效的标题正则: %v", err)
+		}
+		filters = append(filters, TitleMatches(re))
+	}
+	if cfg.MatchType != "" {
+		filters = append(filters, PageTypeIn(strings.Split(cfg.MatchType, ",")))
+	}
+	if cfg.MinTime > 0 || cfg.MaxTime > 0 {
+		if cfg.MaxTime > 0 && cfg.MinTime > cfg.MaxTime {
+			return nil, fmt.Errorf("-min-time (%d) 不能大于 -max-time (%d)", cfg.MinTime, cfg.MaxTime)
+		}
+		filters = append(filters, TimeBetween(time.Duration(cfg.MinTime)*time.Millisecond, time.Duration(cfg.MaxTime)*time.Millisecond))
+	}
+	return filters, nil
+}
+
+// 标题不是有效的UTF-8时（如早期版本保存的结果）检测字符集后转换为UTF-8
+func DecodeTitle(result *checker.Result) {
+	result.Title, _ = utils.ToUTF8(result.Title, "")
+}
+
+// 只保留存活的结果
+func AliveOnly(result checker.Result) bool {
+	return result.Alive
+}
+
+// 按存活状态过滤：alive只保留存活的，dead只保留无法访问的，其他值不过滤
+func StatusIs(status string) Filter {
+	return func(result checker.Result) bool {
+		switch status {
+		case "alive":
+			return result.Alive
+		case "dead":
+			return !result.Alive
+		}
+		return true
+	}
+}
+
+// 按状态码过滤，code不是有效的状态码时不过滤
+func CodeIs(code string) Filter {
+	statusCode, _ := strconv.Atoi(code)
+	return func(result checker.Result) bool {
+		return statusCode == 0 || result.Status == statusCode
+	}
+}
+
+// 只保留状态码在codes中的结果
+func CodeIn(codes map[int]bool) Filter {
+	return func(result checker.Result) bool {
+		return codes[result.Status]
+	}
+}
+
+// 排除状态码在codes中的结果
+func CodeNotIn(codes map[int]bool) Filter {
+	return func(result checker.Result) bool {
+		return !codes[result.Status]
+	}
+}
+
+// 只保留标题匹配正则的结果
+func TitleMatches(re *regexp.Regexp) Filter {
+	return func(result checker.Result) bool {
+		return re.MatchString(result.Title)
+	}
+}
+
+// 只保留响应时间在[min, max]内的结果，max为0时不限上限；无法访问的结果没有有效的响应时间，不予保留
+func TimeBetween(min, max time.Duration) Filter {
+	return func(result checker.Result) bool {
+		if result.Status == 0 {
+			return false
+		}
+		return result.ResponseTime >= min && (max == 0 || result.ResponseTime <= max)
+	}
+}
+
+// 页面类型的英文别名，便于在命令行中输入
+var pageTypeAliases = map[string]string{
+	"login":  "登录页面",
+	"admin":  "管理后台",
+	"api":    "API接口",
+	"upload": "上传页面",
+	"parked": "停放域名",
+	"apidoc": "API文档",
+	"error":  "错误页面",
+}
+
+// 只保留页面类型为其中之一的结果，类型可以是页面类型名称或英文别名
+func PageTypeIn(types []string) Filter {
+	wanted := make(map[string]bool)
+	for _, name := range types {
+		name = strings.TrimSpace(name)
+		if alias, ok := pageTypeAliases[strings.ToLower(name)]; ok {
+			name = alias
+		}
+		if name != "" {
+			wanted[name] = true
+		}
+	}
+	return func(result checker.Result) bool {
+		return result.PageInfo != nil && wanted[result.PageInfo.Type]
+	}
+}
+
+// 按页面类型过滤，pageType为空时不过滤
+func PageTypeIs(pageType string) Filter {
+	return func(result checker.Result) bool {
+		return pageType == "" || (result.PageInfo != nil && result.PageInfo.Type == pageType)
+	}
+}
+
+// 按关键词过滤，不区分大小写地匹配域名、标题、最终URL和截图文字，关键词为空时不过滤
+func MatchQuery(query string) Filter {
+	query = strings.ToLower(strings.TrimSpace(query))
+	return func(result checker.Result) bool {
+		if query == "" {
+			return true
+		}
+		text := strings.ToLower(result.DisplayDomain() + " " + result.Title + " " + result.FinalURL + " " + result.OCRText)
+		return strings.Contains(text, query)
+	}
+}
+
+// 按域名排序
+func ByDomain(a, b checker.Result) bool {
+	return a.Domain < b.Domain
+}
+
+// 按状态码排序，无法访问的结果（状态码为0）排在最后
+func ByStatus(a, b checker.Result) bool {
+	if (a.Status == 0) != (b.Status == 0) {
+		return b.Status == 0
+	}
+	return a.Status < b.Status
+}
+
+// 按响应时间从慢到快排序
+func BySlowest(a, b checker.Result) bool {
+	return a.ResponseTime > b.ResponseTime
+}
+
+// 排序方式名称，用于查看服务器的 sort 查询参数
+var sortOrders = map[string]Less{
+	"domain": ByDomain,
+	"status": ByStatus,
+	"time":   BySlowest,
+}
@@ -0,0 +1,233 @@
+package view
+
+import (
+	"reflect"
+	"regexp"
+	"slices"
+	"testing"
+	"time"
+
+	"subdomain-checker/checker"
+	"subdomain-checker/config"
+)
+
+func domains(results []checker.Result) []string {
+	var names []string
+	for _, r := range results {
+		names = append(names, r.Domain)
+	}
+	return names
+}
+
+func TestPipelineZeroValue(t *testing.T) {
+	results := fixtureResults()
+	rows := Pipeline{}.Run(results)
+	if len(rows) != len(results) || &rows[0] != &results[0] {
+		t.Error("zero pipeline should return the input slice unchanged")
+	}
+	if n := (Pipeline{}).Count(results); n != len(results) {
+		t.Errorf("Count = %d, want %d", n, len(results))
+	}
+}
+
+func TestPipelineRun(t *testing.T) {
+	results := fixtureResults()
+	var seen []string
+	p := Pipeline{
+		Enrichers: []Enricher{func(r *checker.Result) { r.Title = "[" + r.Title + "]" }},
+		Filters: []Filter{
+			// 过滤阶段看到的是补充后的结果
+			func(r checker.Result) bool { seen = append(seen, r.Title); return true },
+			AliveOnly,
+		},
+		Less: ByDomain,
+	}
+	rows := p.Run(results)
+
+	if got, want := domains(rows), []string{"admin.example.com", "dav.example.com", "www.example.com"}; !slices.Equal(got, want) {
+		t.Errorf("domains = %q, want %q", got, want)
+	}
+	if rows[0].Title != "[管理后台登录]" || seen[0] != "[Example Domain]" {
+		t.Errorf("enricher not applied before filters: rows[0].Title = %q, seen = %q", rows[0].Title, seen)
+	}
+	if results[0].Title != "Example Domain" || results[0].Domain != "www.example.com" {
+		t.Error("Run modified its input")
+	}
+	if n := p.Count(results); n != len(rows) {
+		t.Errorf("Count = %d, want %d", n, len(rows))
+	}
+}
+
+func TestProcessStopsAtFirstRejectingFilter(t *testing.T) {
+	calls := 0
+	p := Pipeline{Filters: []Filter{
+		func(checker.Result) bool { return false },
+		func(checker.Result) bool { calls++; return true },
+	}}
+	if _, ok := p.process(checker.Result{Domain: "a.example.com"}); ok || calls != 0 {
+		t.Errorf("process = %v, later filter called %d times", ok, calls)
+	}
+}
+
+func TestDecodeTitle(t *testing.T) {
+	r := checker.Result{Title: "\xb5\xc7\xc2\xbc\xd2\xb3\xc3\xe6 - \xb9\xdc\xc0\xed\xba\xf3\xcc\xa8\xcf\xb5\xcd\xb3"} // GBK
+	DecodeTitle(&r)
+	if r.Title != "登录页面 - 管理后台系统" {
+		t.Errorf("DecodeTitle = %q", r.Title)
+	}
+	r = checker.Result{Title: "Example Domain"}
+	DecodeTitle(&r)
+	if r.Title != "Example Domain" {
+		t.Errorf("DecodeTitle changed a UTF-8 title to %q", r.Title)
+	}
+}
+
+func TestOutputPipeline(t *testing.T) {
+	opts := WriteOptions{OnlyAlive: true, Filters: []Filter{CodeIn(map[int]bool{200: true})}}
+	filtered := Output{Format: Format{Name: "csv"}}.pipeline(opts).Run(fixtureResults())
+	if got, want := domains(filtered), []string{"www.example.com", "admin.example.com"}; !slices.Equal(got, want) {
+		t.Errorf("filtered = %q, want %q", got, want)
+	}
+	complete := Output{Format: Format{Name: "json", Complete: true}}.pipeline(opts).Run(fixtureResults())
+	if len(complete) != len(fixtureResults()) {
+		t.Errorf("Complete format kept %d of %d results", len(complete), len(fixtureResults()))
+	}
+}
+
+func TestReportFilters(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.Config
+		want []string
+	}{
+		{"none", config.Config{}, []string{"www.example.com", "admin.example.com", "dav.example.com", "old.example.com", "api.example.com"}},
+		{"match code", config.Config{MatchCode: "200, 403"}, []string{"www.example.com", "admin.example.com", "dav.example.com"}},
+		{"filter code", config.Config{FilterCode: "404,403"}, []string{"www.example.com", "admin.example.com", "old.example.com"}},
+		{"title regex", config.Config{MatchTitleRegex: "(?i)example|登录"}, []string{"www.example.com", "admin.example.com"}},
+		{"page type alias", config.Config{MatchType: "login, api"}, []string{"admin.example.com"}},
+		{"min time", config.Config{MinTime: 100}, []string{"www.example.com", "admin.example.com"}},
+		{"max time", config.Config{MaxTime: 60}, []string{"dav.example.com", "api.example.com"}},
+		{"time range", config.Config{MinTime: 60, MaxTime: 120}, []string{"www.example.com", "dav.example.com"}},
+		{"combined", config.Config{MatchCode: "200", MaxTime: 200}, []string{"www.example.com"}},
+	}
+	for _, tt := range tests {
+		filters, err := ReportFilters(tt.cfg)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		got := domains(Pipeline{Filters: filters}.Run(fixtureResults()))
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	for name, cfg := range map[string]config.Config{
+		"bad match code":  {MatchCode: "200,abc"},
+		"bad filter code": {FilterCode: "999"},
+		"bad regex":       {MatchTitleRegex: "("},
+		"min above max":   {MinTime: 500, MaxTime: 100},
+	} {
+		if _, err := ReportFilters(cfg); err == nil {
+			t.Errorf("%s: no error", name)
+		}
+	}
+}
+
+func TestPageTypeIn(t *testing.T) {
+	login := checker.Result{PageInfo: &checker.PageType{Type: "登录页面"}}
+	admin := checker.Result{PageInfo: &checker.PageType{Type: "管理后台"}}
+	none := checker.Result{}
+	tests := []struct {
+		types              []string
+		login, admin, none bool
+	}{
+		{[]string{"login"}, true, false, false},
+		{[]string{" LOGIN ", "Admin"}, true, true, false},
+		{[]string{"登录页面"}, true, false, false},
+		{[]string{"管理后台", "unknown"}, false, true, false},
+		{[]string{"", " "}, false, false, false},
+	}
+	for _, tt := range tests {
+		keep := PageTypeIn(tt.types)
+		if keep(login) != tt.login || keep(admin) != tt.admin || keep(none) != tt.none {
+			t.Errorf("PageTypeIn(%q): login=%v admin=%v none=%v", tt.types, keep(login), keep(admin), keep(none))
+		}
+	}
+}
+
+func TestTimeBetween(t *testing.T) {
+	at := func(status int, d time.Duration) checker.Result {
+		return checker.Result{Status: status, ResponseTime: d}
+	}
+	tests := []struct {
+		min, max time.Duration
+		result   checker.Result
+		want     bool
+	}{
+		{100 * time.Millisecond, 0, at(200, 100*time.Millisecond), true},
+		{100 * time.Millisecond, 0, at(200, 99*time.Millisecond), false},
+		{100 * time.Millisecond, 0, at(200, time.Hour), true},
+		{0, 200 * time.Millisecond, at(200, 200*time.Millisecond), true},
+		{0, 200 * time.Millisecond, at(200, 201*time.Millisecond), false},
+		{0, 200 * time.Millisecond, at(0, 0), false},
+		{0, 0, at(0, 10*time.Millisecond), false},
+	}
+	for _, tt := range tests {
+		if got := TimeBetween(tt.min, tt.max)(tt.result); got != tt.want {
+			t.Errorf("TimeBetween(%v, %v)(%d, %v) = %v, want %v", tt.min, tt.max, tt.result.Status, tt.result.ResponseTime, got, tt.want)
+		}
+	}
+}
+
+func TestQueryFilters(t *testing.T) {
+	results := fixtureResults()
+	tests := []struct {
+		name   string
+		filter Filter
+		want   []string
+	}{
+		{"alive", StatusIs("alive"), []string{"www.example.com", "admin.example.com", "dav.example.com"}},
+		{"dead", StatusIs("dead"), []string{"old.example.com", "api.example.com"}},
+		{"any status", StatusIs("all"), domains(results)},
+		{"code", CodeIs("403"), []string{"dav.example.com"}},
+		{"invalid code", CodeIs("x"), domains(results)},
+		{"page type", PageTypeIs("登录页面"), []string{"admin.example.com"}},
+		{"query title", MatchQuery(" 管理 "), []string{"admin.example.com"}},
+		{"query final url", MatchQuery("/LOGIN"), []string{"admin.example.com"}},
+		{"title", TitleMatches(regexp.MustCompile("^Example")), []string{"www.example.com"}},
+		{"code not in", CodeNotIn(map[int]bool{200: true, 0: true}), []string{"dav.example.com", "api.example.com"}},
+	}
+	for _, tt := range tests {
+		if got := domains(Pipeline{Filters: []Filter{tt.filter}}.Run(results)); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSortOrders(t *testing.T) {
+	tests := []struct {
+		order string
+		want  []string
+	}{
+		{"domain", []string{"admin.example.com", "api.example.com", "dav.example.com", "old.example.com", "www.example.com"}},
+		// 无法访问的结果排在最后，状态码相同时保持原顺序
+		{"status", []string{"www.example.com", "admin.example.com", "dav.example.com", "api.example.com", "old.example.com"}},
+		{"time", []string{"admin.example.com", "www.example.com", "dav.example.com", "api.example.com", "old.example.com"}},
+	}
+	for _, tt := range tests {
+		less, ok := sortOrders[tt.order]
+		if !ok {
+			t.Fatalf("sort order %q not registered", tt.order)
+		}
+		if got := domains(Pipeline{Less: less}.Run(fixtureResults())); !slices.Equal(got, tt.want) {
+			t.Errorf("sort=%s: %q, want %q", tt.order, got, tt.want)
+		}
+	}
+	if got := sortOrders[""]; got != nil {
+		t.Error("empty sort order should keep the collected order")
+	}
+	if !reflect.DeepEqual(domains(Pipeline{Less: sortOrders["unknown"]}.Run(fixtureResults())), domains(fixtureResults())) {
+		t.Error("unknown sort order changed the order")
+	}
+}
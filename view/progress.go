@@ -0,0 +1,187 @@
+package view
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"subdomain-checker/stats"
+	"subdomain-checker/utils"
+)
+
+// 结构化进度事件，供嵌入本工具的图形界面等程序使用
+type ProgressEvent struct {
+	Event     string  `json:"event"` // progress 或 done
+	Processed int     `json:"processed"`
+	Total     int     `json:"total"`
+	Alive     int     `json:"alive"`
+	Dead      int     `json:"dead"`
+	Errors    int     `json:"errors"`
+	Elapsed   float64 `json:"elapsed_seconds"`
+	ETA       float64 `json:"eta_seconds"` // 预计剩余时间，未知时为-1
+	Time      string  `json:"time"`
+}
+
+// 进度事件回调，库模式下可直接传入自定义函数
+type ProgressHandler func(ProgressEvent)
+
+// 根据当前计数生成进度事件
+func NewProgressEvent(event string, scanStats *stats.Stats, total int, startTime time.Time) ProgressEvent {
+	snap := scanStats.Snapshot()
+	processed := snap.Processed
+	elapsed := time.Since(startTime).Seconds()
+
+	eta := -1.0
+	if event == "done" {
+		eta = 0
+	} else if processed > 0 {
+		eta = elapsed / float64(processed) * float64(total-processed)
+	}
+
+	return ProgressEvent{
+		Event:     event,
+		Processed: processed,
+		Total:     total,
+		Alive:     snap.Alive,
+		Dead:      snap.Dead,
+		Errors:    snap.Errors,
+		Elapsed:   elapsed,
+		ETA:       eta,
+		Time:      time.Now().Format(time.RFC3339),
+	}
+}
+
+// 将进度事件以JSON行的形式写入w（如通过 -progress-fd 指定的文件描述符）
+func JSONLinesProgressHandler(w io.Writer) ProgressHandler {
+	var mutex sync.Mutex
+	encoder := json.NewEncoder(w)
+	return func(event ProgressEvent) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		encoder.Encode(event)
+	}
+}
+
+// 每隔interval生成一次进度事件交给handler，直到stop被关闭
+func EmitProgressEvents(scanStats *stats.Stats, total int, startTime time.Time, interval time.Duration, stop <-chan struct{}, handler ProgressHandler) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			handler(NewProgressEvent("progress", scanStats, total, startTime))
+		case <-stop:
+			return
+		}
+	}
+}
+
+// 终端进度行的刷新间隔；标准输出不是终端（如重定向到文件或管道）时改为每隔一段时间输出一整行
+const (
+	progressInterval      = 500 * time.Millisecond
+	plainProgressInterval = 10 * time.Second
+	rateWindow            = 10 * time.Second // 计算速率使用的时间窗口
+)
+
+// 显示进度：已完成数、存活/无法访问/错误计数、最近的检测速率和预计剩余时间。
+// 输出到终端时在同一行刷新，否则每隔10秒输出一行，避免日志文件中充满回车符
+func ShowProgress(scanStats *stats.Stats, totalDomains int, startTime time.Time, doneChan, progressDone chan struct{}) {
+	terminal := isTerminal(os.Stdout)
+	interval := progressInterval
+	if !terminal {
+		interval = plainProgressInterval
+	}
+
+	// 启动进度显示goroutine
+	go func() {
+		defer close(progressDone)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		meter := newRateMeter(startTime)
+		lastWidth := 0
+		for {
+			select {
+			case <-ticker.C:
+				snap := scanStats.Snapshot()
+				if snap.Processed >= totalDomains {
+					return
+				}
+				line := progressLine(snap, totalDomains, startTime, meter.Rate(snap.Processed))
+				if !terminal {
+					fmt.Fprintln(utils.Console, line)
+					continue
+				}
+				// 新的一行比上一行短时用空格覆盖残留字符
+				width := utf8.RuneCountInString(line)
+				fmt.Fprintf(utils.Console, "\r%s%*s", line, max(lastWidth-width, 0), "")
+				lastWidth = width
+			case <-doneChan:
+				return
+			}
+		}
+	}()
+}
+
+// 进度行，如 "进度: 45.20% (4,520/10,000) | 存活 1,200 · 无法访问 3,320 · 错误 800 | 125.3/秒 | 剩余 00:43 | 耗时 36.12 秒"
+func progressLine(snap stats.Snapshot, total int, startTime time.Time, rate float64) string {
+	percent := float64(snap.Processed) / float64(total)
+	eta := "--:--"
+	if rate > 0 {
+		eta = formatClock(time.Duration(float64(total-snap.Processed) / rate * float64(time.Second)))
+	}
+	return fmt.Sprintf("进度: %s (%s/%s) | 存活 %s · 无法访问 %s · 错误 %s | %s/%s | 剩余 %s | 耗时 %s",
+		FormatPercent(percent), FormatCount(snap.Processed), FormatCount(total),
+		FormatCount(snap.Alive), FormatCount(snap.Dead), FormatCount(snap.Errors),
+		FormatNumber(rate, 1), locale.Second, eta, FormatDuration(time.Since(startTime)))
+}
+
+// 以 "mm:ss" 或 "h:mm:ss" 显示时长
+func formatClock(d time.Duration) string {
+	seconds := int(d.Round(time.Second).Seconds())
+	if seconds >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", seconds/3600, seconds/60%60, seconds%60)
+	}
+	return fmt.Sprintf("%02d:%02d", seconds/60, seconds%60)
+}
+
+// 根据最近一段时间内完成的数量计算检测速率，速率随网络状况变化时预计剩余时间能较快跟上
+type rateMeter struct {
+	samples []rateSample
+}
+
+type rateSample struct {
+	at        time.Time
+	processed int
+}
+
+func newRateMeter(startTime time.Time) *rateMeter {
+	return &rateMeter{samples: []rateSample{{at: startTime}}}
+}
+
+// 记录当前完成数，返回时间窗口内的平均速率（个/秒）
+func (m *rateMeter) Rate(processed int) float64 {
+	now := time.Now()
+	m.samples = append(m.samples, rateSample{at: now, processed: processed})
+	// 丢弃窗口之外的样本，保留至少两个样本
+	for len(m.samples) > 2 && now.Sub(m.samples[1].at) >= rateWindow {
+		m.samples = m.samples[1:]
+	}
+	oldest := m.samples[0]
+	elapsed := now.Sub(oldest.at).Seconds()
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(processed-oldest.processed) / elapsed
+}
+
+// 是否为终端
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,216 @@
+package view
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"subdomain-checker/checker"
+)
+
+// 输出选项，传给各输出格式的写入函数
+type WriteOptions struct {
+	OnlyAlive bool     // 只导出存活的域名
+	Split     int      // 每个文件的最大行数，超出时拆分为多个文件，0表示不拆分
+	Filters   []Filter // 报告过滤条件（-match-code 等），与 -only-alive 一样不影响Complete格式
+}
+
+// 输出格式的写入函数，results为经过流水线处理的结果
+type WriteFunc func(results []checker.Result, filename string, opts WriteOptions) error
+
+// 输出格式：各格式在init中通过Register注册，命令行据此发现可用格式，
+// 新增格式（包括引用本包的外部程序）只需注册，无需修改main中的输出流程
+type Format struct {
+	Name        string        // 格式名称，用于 -format name=path
+	Extension   string        // 默认扩展名，如 ".csv"
+	Description string        // 输出提示中使用的说明，如 "CSV结果"
+	Splittable  bool          // 是否支持按行数拆分为多个文件
+	Complete    bool          // 总是导出完整结果，不受 -only-alive 影响
+	Write       WriteFunc     // 整体写入函数，与NewWriter至少提供一个
+	NewWriter   WriterFactory // 逐条写入器，WriteAll可一次遍历写入多个此类输出
+}
+
+var (
+	formatsMu sync.RWMutex
+	formats   = make(map[string]Format)
+)
+
+// 注册输出格式，名称重复时后注册的覆盖先注册的，便于分支替换内置实现
+func Register(f Format) {
+	if f.Name == "" || (f.Write == nil && f.NewWriter == nil) {
+		panic("view: 输出格式缺少名称或写入函数")
+	}
+	formatsMu.Lock()
+	defer formatsMu.Unlock()
+	formats[f.Name] = f
+}
+
+// 按名称查找输出格式
+func Lookup(name string) (Format, bool) {
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+	f, ok := formats[name]
+	return f, ok
+}
+
+// 返回所有已注册的输出格式，按名称排序
+func Formats() []Format {
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+	list := make([]Format, 0, len(formats))
+	for _, f := range formats {
+		list = append(list, f)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
+
+// 返回已注册的格式名称，用于错误提示
+func FormatNames() []string {
+	var names []string
+	for _, f := range Formats() {
+		names = append(names, f.Name)
+	}
+	return names
+}
+
+// 一个待写入的输出：格式及目标文件
+type Output struct {
+	Format   Format
+	Filename string
+}
+
+// 解析 "name=path" 形式的输出参数；省略路径时使用 "results" 加格式的默认扩展名
+func ParseOutput(spec string) (Output, error) {
+	name, filename, _ := strings.Cut(spec, "=")
+	name = strings.TrimSpace(name)
+	f, ok := Lookup(name)
+	if !ok {
+		return Output{}, fmt.Errorf("未知的输出格式 %q，可用格式: %s", name, strings.Join(FormatNames(), ", "))
+	}
+	filename = strings.TrimSpace(filename)
+	if filename == "" {
+		filename = "results" + f.Extension
+	}
+	return Output{Format: f, Filename: filename}, nil
+}
+
+// 写入输出：结果经流水线处理后交给格式的写入函数渲染，写入过程中的panic被转换为错误；
+// 指定了拆分行数且格式支持时拆分为多个文件
+func (o Output) Write(results []checker.Result, opts WriteOptions) error {
+	return SafeWrite(func() error {
+		rows := o.pipeline(opts).Run(results)
+		if opts.Split > 0 && o.Format.Splittable {
+			return writeSplit(o, rows, opts)
+		}
+		return o.Format.writeRows(rows, o.Filename, opts)
+	})
+}
+
+func init() {
+	Register(Format{
+		Name:        "csv",
+		Extension:   ".csv",
+		Description: "CSV结果",
+		Splittable:  true,
+		Write: func(results []checker.Result, filename string, opts WriteOptions) error {
+			return SaveResultsToFile(results, filename)
+		},
+	})
+	Register(Format{
+		Name:        "json",
+		Extension:   ".json",
+		Description: "JSON结果",
+		Complete:    true,
+		Write: func(results []checker.Result, filename string, opts WriteOptions) error {
+			return SaveResultsToJSON(results, filename)
+		},
+	})
+	Register(Format{
+		Name:        "anon-json",
+		Extension:   ".json",
+		Description: "匿名化JSON结果",
+		Write: func(results []checker.Result, filename string, opts WriteOptions) error {
+			return SaveAnonymizedJSON(results, filename)
+		},
+	})
+	Register(Format{
+		Name:        "targets",
+		Extension:   ".txt",
+		Description: "存活目标列表",
+		NewWriter: func(filename string, opts WriteOptions) (ResultWriter, error) {
+			return newTargetListWriter(filename)
+		},
+	})
+	Register(Format{
+		Name:        "httpx-json",
+		Extension:   ".jsonl",
+		Description: "httpx JSON结果",
+		NewWriter: func(filename string, opts WriteOptions) (ResultWriter, error) {
+			return newHttpxWriter(filename)
+		},
+	})
+	Register(Format{
+		Name:        "xml",
+		Extension:   ".xml",
+		Description: "XML结果",
+		Write: func(results []checker.Result, filename string, opts WriteOptions) error {
+			return SaveResultsToXML(results, filename)
+		},
+	})
+	Register(Format{
+		Name:        "nmap-xml",
+		Extension:   ".xml",
+		Description: "nmap XML结果",
+		Write: func(results []checker.Result, filename string, opts WriteOptions) error {
+			return SaveNmapXML(results, filename)
+		},
+	})
+	Register(Format{
+		Name:        "excel",
+		Extension:   ".xlsx",
+		Description: "Excel结果",
+		Splittable:  true,
+		Write: func(results []checker.Result, filename string, opts WriteOptions) error {
+			return SaveResultsToExcel(results, filename)
+		},
+	})
+	Register(Format{
+		Name:        "exec-excel",
+		Extension:   ".xlsx",
+		Description: "汇总工作簿",
+		Complete:    true,
+		Write: func(results []checker.Result, filename string, opts WriteOptions) error {
+			return SaveExecutiveWorkbook(results, filename)
+		},
+	})
+	Register(Format{
+		Name:        "html",
+		Extension:   ".html",
+		Description: "HTML报告",
+		Splittable:  true,
+		Write: func(results []checker.Result, filename string, opts WriteOptions) error {
+			return SaveResultsToHTML(results, filename)
+		},
+	})
+	Register(Format{
+		Name:        "simple-html",
+		Extension:   ".html",
+		Description: "简化版HTML报告",
+		Splittable:  true,
+		Write: func(results []checker.Result, filename string, opts WriteOptions) error {
+			return SaveResultsToSimpleHTML(results, filename)
+		},
+	})
+	Register(Format{
+		Name:        "gallery",
+		Extension:   ".html",
+		Description: "截图画廊",
+		Write: func(results []checker.Result, filename string, opts WriteOptions) error {
+			return SaveGallery(results, filename)
+		},
+	})
+}
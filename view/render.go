@@ -0,0 +1,67 @@
+package view
+
+import (
+	"os"
+	"sort"
+	"time"
+
+	"subdomain-checker/checker"
+)
+
+// 已保存结果的扫描时间：结果中最晚的检测时间，都没有检测时间时为零值
+func ScanTime(results []checker.Result) time.Time {
+	var latest time.Time
+	for _, result := range results {
+		if result.CheckedAt.After(latest) {
+			latest = result.CheckedAt
+		}
+	}
+	return latest
+}
+
+// 从保存的JSON结果重新生成报告：结果按域名排序，报告时间取结果中最晚的检测时间而不是当前时间
+// （旧版本的结果没有检测时间时使用结果文件的修改时间），同一个结果文件无论何时重新生成，报告都字节一致。
+// 返回与outputs一一对应的写入错误
+func Rerender(filename string, outputs []Output, opts WriteOptions) ([]error, error) {
+	results, err := LoadResultsJSON(filename)
+	if err != nil {
+		return nil, err
+	}
+	scanTime := ScanTime(results)
+	if scanTime.IsZero() {
+		info, err := os.Stat(filename)
+		if err != nil {
+			return nil, err
+		}
+		scanTime = info.ModTime()
+	}
+	return renderAt(results, scanTime, outputs, opts), nil
+}
+
+// 与Rerender相同，但结果已在内存中；结果都没有检测时间时报告时间为当前时间
+func RenderResults(results []checker.Result, outputs []Output, opts WriteOptions) []error {
+	return renderAt(results, ScanTime(results), outputs, opts)
+}
+
+// 确定性模式（-deterministic）下写入本次扫描的报告：结果按域名排序，报告时间和每条结果显示的检测时间都使用扫描开始时间，
+// 相同的结果生成字节一致的报告。只有写入的报告使用扫描开始时间，传入的结果保留实际的检测时间
+func RenderDeterministic(results []checker.Result, scanStart time.Time, outputs []Output, opts WriteOptions) []error {
+	stamped := make([]checker.Result, len(results))
+	for i, result := range results {
+		result.CheckedAt = scanStart
+		stamped[i] = result
+	}
+	return renderAt(stamped, scanStart, outputs, opts)
+}
+
+// 按域名排序后以reportAt作为报告时间写入各输出，不修改传入的结果
+func renderAt(results []checker.Result, reportAt time.Time, outputs []Output, opts WriteOptions) []error {
+	sorted := make([]checker.Result, len(results))
+	copy(sorted, results)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Domain < sorted[j].Domain
+	})
+	SetReportTime(reportAt)
+	defer SetReportTime(time.Time{})
+	return WriteAll(outputs, sorted, opts)
+}
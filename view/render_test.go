@@ -0,0 +1,212 @@
+package view
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"subdomain-checker/checker"
+)
+
+// HTML模板按相对于仓库根目录的路径加载，测试在根目录下运行
+func TestMain(m *testing.M) {
+	if err := os.Chdir(".."); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+var fixtureZone = time.FixedZone("CST", 8*3600)
+
+// 固定的一组检测结果，覆盖存活、重定向、错误、页面类型和发现项
+func fixtureResults() []checker.Result {
+	at := func(minute int) time.Time {
+		return time.Date(2024, 5, 1, 10, minute, 0, 0, fixtureZone)
+	}
+	return []checker.Result{
+		{
+			Domain: "www.example.com", Status: 200, Alive: true, StatusText: "存活", Message: "OK",
+			ResponseTime: 120 * time.Millisecond, Title: "Example Domain", IP: "93.184.216.34", IPFamily: "IPv4",
+			FinalURL: "https://www.example.com/", ContentType: "text/html", CheckedAt: at(1),
+			OpenPorts: []int{25, 443},
+			Services: []checker.Service{
+				{Port: 25, Name: "smtp", Banner: "220 mail.example.com ESMTP Postfix"},
+				{Port: 443, Name: "https", Banner: "ECS (dcb/7F84)"},
+			},
+			Robots: &checker.RobotsInfo{
+				RobotsTxt: true, Disallowed: []string{"/internal/", "/billing/export"},
+				Sitemap: true, SitemapURLs: []string{"https://www.example.com/products/widgets"},
+			},
+			Paths: []checker.PathResult{
+				{Path: "/admin", Status: 200, Length: 512, Title: "Example Admin", ResponseTime: 40 * time.Millisecond},
+				{Path: "/.git/config", Error: `Get "https://www.example.com/.git/config": dial tcp 93.184.216.34:443: i/o timeout`},
+			},
+			OCRText:     "Welcome to Example Corp intranet",
+			RawResponse: "archive/https_www.example.com_443.txt",
+		},
+		{
+			Domain: "admin.example.com", Status: 200, Alive: true, StatusText: "存活", Message: "OK",
+			ResponseTime: 480 * time.Millisecond, Title: "管理后台登录", IP: "93.184.216.35", IPFamily: "IPv4",
+			PageInfo: &checker.PageType{Type: "登录页面", Description: "包含密码输入框"}, LoginForm: true,
+			Matches:  []checker.Match{{Before: "<b>", Text: "password", After: "</b>"}},
+			FinalURL: "https://admin.example.com/login", CheckedAt: at(3),
+			RedirectChain: []checker.RedirectHop{{URL: "https://admin.example.com/", Status: 302}},
+		},
+		{
+			Domain: "dav.example.com", Status: 403, Alive: true, StatusText: "403", Message: "Forbidden",
+			ResponseTime: 60 * time.Millisecond, IP: "10.0.0.8", IPFamily: "IPv4",
+			RiskyMethods: []string{"PUT", "DELETE"}, Methods: []string{"GET", "PUT", "DELETE"}, CheckedAt: at(2),
+		},
+		{
+			Domain: "old.example.com", StatusText: "DNS错误", Message: "no such host", ErrorType: "dns",
+			CNAMEs: []string{"old-app.herokuapp.com"}, DanglingCNAME: true, CheckedAt: at(0),
+		},
+		{
+			Domain: "api.example.com", Status: 404, Alive: false, StatusText: "404", Message: "Not Found",
+			ResponseTime: 30 * time.Millisecond, IP: "93.184.216.36", IPFamily: "IPv4",
+			ContentType: "application/json", CheckedAt: at(2),
+		},
+	}
+}
+
+func TestScanTime(t *testing.T) {
+	want := time.Date(2024, 5, 1, 10, 3, 0, 0, fixtureZone)
+	if got := ScanTime(fixtureResults()); !got.Equal(want) {
+		t.Errorf("ScanTime = %v, want %v", got, want)
+	}
+	if got := ScanTime([]checker.Result{{Domain: "a.example.com"}}); !got.IsZero() {
+		t.Errorf("ScanTime without CheckedAt = %v, want zero", got)
+	}
+}
+
+// 重新生成的报告：每种格式写入dir下的同名文件
+func rerenderAll(t *testing.T, source, dir string) map[string][]byte {
+	t.Helper()
+	var outputs []Output
+	for _, f := range Formats() {
+		outputs = append(outputs, Output{Format: f, Filename: filepath.Join(dir, "report-"+f.Name+f.Extension)})
+	}
+	errs, err := Rerender(source, outputs, WriteOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := make(map[string][]byte)
+	for i, o := range outputs {
+		if errs[i] != nil {
+			t.Fatalf("%s: %v", o.Format.Name, errs[i])
+		}
+		data, err := os.ReadFile(o.Filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[o.Format.Name] = data
+	}
+	return files
+}
+
+// xlsx文件中除[Content_Types].xml外各部分的内容，依次拼接。
+// excelize按map顺序写入[Content_Types].xml中的图片扩展名，文件本身不是字节稳定的
+func xlsxParts(t *testing.T, data []byte) []byte {
+	t.Helper()
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var parts bytes.Buffer
+	for _, file := range reader.File {
+		if file.Name == "[Content_Types].xml" {
+			continue
+		}
+		rc, err := file.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		parts.WriteString(file.Name + "\n")
+		io.Copy(&parts, rc)
+		rc.Close()
+	}
+	return parts.Bytes()
+}
+
+func TestRerenderByteIdentical(t *testing.T) {
+	SetAnonymizeKey("fixture")
+	defer SetAnonymizeKey("")
+
+	// 两个结果文件内容相同但顺序不同，分别在不同时间重新生成
+	dir := t.TempDir()
+	results := fixtureResults()
+	first := filepath.Join(dir, "first.json")
+	if err := SaveResultsToJSON(results, first); err != nil {
+		t.Fatal(err)
+	}
+	for i, j := 0, len(results)-1; i < j; i, j = i+1, j-1 {
+		results[i], results[j] = results[j], results[i]
+	}
+	second := filepath.Join(dir, "second.json")
+	if err := SaveResultsToJSON(results, second); err != nil {
+		t.Fatal(err)
+	}
+
+	firstDir, secondDir := filepath.Join(dir, "a"), filepath.Join(dir, "b")
+	os.Mkdir(firstDir, 0o755)
+	os.Mkdir(secondDir, 0o755)
+	want := rerenderAll(t, first, firstDir)
+	time.Sleep(1100 * time.Millisecond) // 报告时间精确到秒，跨过一秒以免巧合相同
+	got := rerenderAll(t, second, secondDir)
+
+	for name, data := range want {
+		if strings.HasSuffix(name, "excel") {
+			data, got[name] = xlsxParts(t, data), xlsxParts(t, got[name])
+		}
+		if !bytes.Equal(data, got[name]) {
+			t.Errorf("%s: re-rendered report differs", name)
+		}
+	}
+	if html := string(want["html"]); !strings.Contains(html, "2024-05-01 10:03:00") {
+		t.Error("html report does not use the latest CheckedAt as report time")
+	}
+}
+
+// 确定性模式的报告使用扫描开始时间，传入的结果保留实际的检测时间和顺序
+func TestRenderDeterministic(t *testing.T) {
+	results := fixtureResults()
+	original := make([]checker.Result, len(results))
+	copy(original, results)
+	start := time.Date(2024, 5, 1, 9, 59, 0, 0, fixtureZone)
+
+	dir := t.TempDir()
+	output, err := ParseOutput("csv=" + filepath.Join(dir, "a.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	outputs := []Output{output}
+	if errs := RenderDeterministic(results, start, outputs, WriteOptions{}); errs[0] != nil {
+		t.Fatal(errs[0])
+	}
+	for i := range results {
+		if results[i].Domain != original[i].Domain || !results[i].CheckedAt.Equal(original[i].CheckedAt) {
+			t.Fatalf("result %d modified: %s %v", i, results[i].Domain, results[i].CheckedAt)
+		}
+	}
+
+	for i, j := 0, len(results)-1; i < j; i, j = i+1, j-1 {
+		results[i], results[j] = results[j], results[i]
+	}
+	outputs[0].Filename = filepath.Join(dir, "b.csv")
+	if errs := RenderDeterministic(results, start, outputs, WriteOptions{}); errs[0] != nil {
+		t.Fatal(errs[0])
+	}
+	a, _ := os.ReadFile(filepath.Join(dir, "a.csv"))
+	b, _ := os.ReadFile(filepath.Join(dir, "b.csv"))
+	if !bytes.Equal(a, b) {
+		t.Error("reports differ for the same results in a different order")
+	}
+	if !strings.Contains(string(a), FormatCheckedAt(start)) || strings.Contains(string(a), FormatCheckedAt(original[0].CheckedAt)) {
+		t.Error("report does not use the scan start as check time")
+	}
+}
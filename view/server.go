@@ -0,0 +1,117 @@
+package view
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"subdomain-checker/checker"
+	"subdomain-checker/utils"
+)
+
+// 读取 -json 或 -format json 保存的结果文件
+func LoadResultsJSON(filename string) ([]checker.Result, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var results []checker.Result
+	if err := json.Unmarshal(data, &results); err != nil {
+		return nil, fmt.Errorf("解析结果文件 %s 失败: %v", filename, err)
+	}
+	return results, nil
+}
+
+// 结果查看服务器：在浏览器中交互式查看已保存的扫描结果，无需重新生成静态HTML。
+// 结果文件修改后（如再次扫描覆盖）刷新页面即可看到新结果
+type ReportServer struct {
+	filename      string
+	screenshotDir string
+
+	mu      sync.Mutex
+	modTime time.Time
+	results []checker.Result
+}
+
+// 创建结果查看服务器，screenshotDir为截图所在目录，以 /screenshots/ 路径提供给页面
+func NewReportServer(filename, screenshotDir string) (*ReportServer, error) {
+	s := &ReportServer{filename: filename, screenshotDir: screenshotDir}
+	if _, err := s.load(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// 读取结果文件，文件未修改时使用缓存
+func (s *ReportServer) load() ([]checker.Result, error) {
+	info, err := os.Stat(s.filename)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.results != nil && info.ModTime().Equal(s.modTime) {
+		return s.results, nil
+	}
+	results, err := LoadResultsJSON(s.filename)
+	if err != nil {
+		return nil, err
+	}
+	s.results = results
+	s.modTime = info.ModTime()
+	return results, nil
+}
+
+// 路由：/ 为报告页面，/screenshots/ 为截图文件
+func (s *ReportServer) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/screenshots/", http.StripPrefix("/screenshots/", http.FileServer(http.Dir(s.screenshotDir))))
+	mux.HandleFunc("/", s.serveReport)
+	return mux
+}
+
+// 渲染报告页面，支持通过查询参数预先筛选和排序结果：
+// q=关键词（匹配域名、标题、最终URL和截图文字），status=alive|dead，code=状态码，type=页面类型，
+// sort=domain|status|time（time为按响应时间从慢到快）
+func (s *ReportServer) serveReport(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	results, err := s.load()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("读取结果文件失败: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	query := r.URL.Query()
+	pipeline := Pipeline{
+		Enrichers: []Enricher{DecodeTitle},
+		Filters: []Filter{
+			StatusIs(query.Get("status")),
+			CodeIs(query.Get("code")),
+			PageTypeIs(query.Get("type")),
+			MatchQuery(query.Get("q")),
+		},
+		Less: sortOrders[query.Get("sort")],
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := renderHTMLReport(w, pipeline.Run(results), false); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// 启动结果查看服务器，阻塞直到出错
+func ServeResults(addr, filename, screenshotDir string) error {
+	server, err := NewReportServer(filename, screenshotDir)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(utils.Console, "🌐 结果查看服务器已启动: http://%s/ （结果文件: %s）\n", addr, filename)
+	fmt.Fprintf(utils.Console, "   可使用查询参数筛选，如 /?status=alive&q=admin 、/?code=403 、/?type=登录页面 、/?sort=time\n")
+	return http.ListenAndServe(addr, server.Handler())
+}
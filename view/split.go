@@ -0,0 +1,124 @@
+package view
+
+import (
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+
+	"subdomain-checker/checker"
+	"subdomain-checker/utils"
+)
+
+// 拆分后的一个分片
+type splitPart struct {
+	Number   int
+	Filename string // 相对索引页的文件名
+	Rows     int
+	Alive    int
+	First    string // 分片中第一个域名
+	Last     string // 分片中最后一个域名
+}
+
+// 分片索引页
+var splitIndexTemplate = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="utf-8">
+    <title>检测结果索引</title>
+    <style>
+        body { font-family: Arial, sans-serif; margin: 0; padding: 20px; background: #f5f5f5; }
+        .container { max-width: 1000px; margin: 0 auto; background: #fff; padding: 20px; border-radius: 5px; box-shadow: 0 2px 5px rgba(0,0,0,0.1); }
+        h1 { color: #333; text-align: center; }
+        table { width: 100%; border-collapse: collapse; }
+        th, td { padding: 8px 12px; border-bottom: 1px solid #eee; text-align: left; }
+        th { background: #f0f0f0; }
+        a { color: #2056dd; text-decoration: none; }
+        a:hover { text-decoration: underline; }
+    </style>
+</head>
+<body>
+<div class="container">
+    <h1>检测结果索引</h1>
+    <p>生成时间: {{.ReportTime}} · 共 {{.Total}} 条结果，拆分为 {{len .Parts}} 个文件，每个文件最多 {{.Split}} 条</p>
+    <table>
+        <tr><th>分片</th><th>结果数</th><th>存活</th><th>域名范围</th></tr>
+        {{range .Parts}}
+        <tr>
+            <td><a href="{{.Filename}}">第 {{.Number}} 部分</a></td>
+            <td>{{.Rows}}</td>
+            <td>{{.Alive}}</td>
+            <td>{{.First}} — {{.Last}}</td>
+        </tr>
+        {{end}}
+    </table>
+</div>
+</body>
+</html>
+`))
+
+// 输出写入的位置，用于提示：拆分写入且没有索引页时为分片文件名的范围
+func (o Output) Location(results []checker.Result, opts WriteOptions) string {
+	if opts.Split <= 0 || !o.Format.Splittable || o.Format.Extension == ".html" {
+		return o.Filename
+	}
+	rows := o.pipeline(opts).Count(results)
+	if rows <= opts.Split {
+		return o.Filename
+	}
+	parts := (rows + opts.Split - 1) / opts.Split
+	return fmt.Sprintf("%s … %s", shardFilename(o.Filename, 1), shardFilename(o.Filename, parts))
+}
+
+// 按每个文件最多split行拆分写入，文件名为 name-partN.ext；HTML格式另在原文件名处生成索引页。
+// exported为经过流水线处理的结果
+func writeSplit(o Output, exported []checker.Result, opts WriteOptions) error {
+	if len(exported) <= opts.Split {
+		return o.Format.writeRows(exported, o.Filename, opts)
+	}
+
+	var parts []splitPart
+	for start := 0; start < len(exported); start += opts.Split {
+		end := min(start+opts.Split, len(exported))
+		chunk := exported[start:end]
+		part := splitPart{
+			Number:   len(parts) + 1,
+			Filename: shardFilename(o.Filename, len(parts)+1),
+			Rows:     len(chunk),
+			First:    chunk[0].DisplayDomain(),
+			Last:     chunk[len(chunk)-1].DisplayDomain(),
+		}
+		for _, result := range chunk {
+			if result.Alive {
+				part.Alive++
+			}
+		}
+		if err := o.Format.writeRows(chunk, part.Filename, opts); err != nil {
+			return fmt.Errorf("写入分片 %s 失败: %w", part.Filename, err)
+		}
+		fmt.Fprintf(utils.Console, "📄 已写入分片 %s（%d条结果）\n", part.Filename, part.Rows)
+		part.Filename = filepath.Base(part.Filename)
+		parts = append(parts, part)
+	}
+
+	if o.Format.Extension != ".html" {
+		return nil
+	}
+	return writeSplitIndex(o.Filename, parts, len(exported), opts.Split)
+}
+
+// 写入HTML分片的索引页
+func writeSplitIndex(filename string, parts []splitPart, total, split int) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return splitIndexTemplate.Execute(file, struct {
+		ReportTime string
+		Total      int
+		Split      int
+		Parts      []splitPart
+	}{reportTime(), total, split, parts})
+}
@@ -0,0 +1,116 @@
+package view
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"subdomain-checker/logger"
+	"subdomain-checker/stats"
+)
+
+// 扫描状态，供排查卡住的扫描时查询（实时报告的 /status 和 -status-socket）
+type ScanStatus struct {
+	Total       int                  `json:"total"`
+	Queued      int                  `json:"queued"`
+	InFlight    int                  `json:"in_flight"`
+	Completed   int                  `json:"completed"`
+	Alive       int                  `json:"alive"`
+	Dead        int                  `json:"dead"`
+	Errors      int                  `json:"errors"`
+	ErrorRate   float64              `json:"error_rate"` // 请求失败数占已完成数的比例
+	ErrorTypes  map[string]int       `json:"error_types,omitempty"`
+	Concurrency int                  `json:"concurrency"`
+	Rate        float64              `json:"rate"` // 平均每秒完成数
+	Elapsed     float64              `json:"elapsed_seconds"`
+	Active      []stats.ActiveTarget `json:"active"` // 正在检测的目标，耗时最长的在前
+	Time        string               `json:"time"`
+}
+
+// 扫描状态的数据来源，Total和Concurrency在扫描过程中可能变化（-port-feed、自适应并发）
+type StatusSource struct {
+	Stats       *stats.Stats
+	Active      *stats.Active
+	StartTime   time.Time
+	Total       func() int
+	Concurrency func() int
+}
+
+// 生成当前扫描状态，active为列出的进行中目标数上限，0表示不限制
+func (s StatusSource) Status(active int) ScanStatus {
+	snap := s.Stats.Snapshot()
+	elapsed := time.Since(s.StartTime).Seconds()
+	status := ScanStatus{
+		Total:       s.Total(),
+		Completed:   snap.Processed,
+		Alive:       snap.Alive,
+		Dead:        snap.Dead,
+		Errors:      snap.Errors,
+		Concurrency: s.Concurrency(),
+		Elapsed:     elapsed,
+		Active:      s.Active.List(),
+		Time:        time.Now().Format(time.RFC3339),
+	}
+	status.InFlight = len(status.Active)
+	// 结果从检测完成到计入统计之间有短暂间隔，排队数可能短暂偏小
+	status.Queued = max(status.Total-status.Completed-status.InFlight, 0)
+	if status.Completed > 0 {
+		status.ErrorRate = float64(status.Errors) / float64(status.Completed)
+	}
+	if elapsed > 0 {
+		status.Rate = float64(status.Completed) / elapsed
+	}
+	if len(snap.ErrorTypes) > 0 {
+		status.ErrorTypes = make(map[string]int, len(snap.ErrorTypes))
+		for errorType, count := range snap.ErrorTypes {
+			status.ErrorTypes[string(errorType)] = count
+		}
+	}
+	if active > 0 && len(status.Active) > active {
+		status.Active = status.Active[:active]
+	}
+	return status
+}
+
+// 以JSON返回扫描状态，?active=N 限制列出的进行中目标数
+func (s StatusSource) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	active, _ := strconv.Atoi(r.URL.Query().Get("active"))
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(s.Status(active))
+}
+
+// 在unix socket上提供状态接口（/status），返回关闭服务并删除socket文件的函数。
+// 已存在的同名socket文件视为上次扫描遗留，会被替换
+func ServeStatusSocket(path string, handler http.Handler) (func(), error) {
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode()&fs.ModeSocket == 0 {
+			return nil, fmt.Errorf("%s 已存在且不是socket文件", path)
+		}
+		os.Remove(path)
+	}
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/status", handler)
+	server := &http.Server{Handler: mux}
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error("状态接口服务停止", "error", err)
+		}
+	}()
+	return func() {
+		server.Close()
+		os.Remove(path)
+	}, nil
+}
@@ -0,0 +1,265 @@
+package view
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"subdomain-checker/checker"
+	"subdomain-checker/utils"
+)
+
+// 重点发现最多输出的条数
+const maxTopFindings = 10
+
+// 总结和报告中列出的最慢主机数
+const maxSlowHosts = 10
+
+// 返回失败原因分类，HTTP错误按状态码细分
+func errorCategory(result checker.Result) string {
+	switch result.ErrorType {
+	case checker.ErrorHTTP:
+		return fmt.Sprintf("HTTP %d", result.Status)
+	case "":
+		return checker.ErrorOther.Label()
+	}
+	return result.ErrorType.Label()
+}
+
+// 输出无法访问域名的失败原因分类
+func printErrorBreakdown(results []checker.Result) {
+	counts := make(map[string]int)
+	for _, result := range results {
+		if !result.Alive {
+			counts[errorCategory(result)]++
+		}
+	}
+	if len(counts) == 0 {
+		return
+	}
+
+	categories := make([]string, 0, len(counts))
+	for category := range counts {
+		categories = append(categories, category)
+	}
+	sort.Slice(categories, func(i, j int) bool {
+		if counts[categories[i]] != counts[categories[j]] {
+			return counts[categories[i]] > counts[categories[j]]
+		}
+		return categories[i] < categories[j]
+	})
+
+	fmt.Fprintln(utils.Console, T("失败原因统计:"))
+	for _, category := range categories {
+		fmt.Fprintln(utils.Console, "  "+Tf("%s: %s 个", T(category), FormatCount(counts[category])))
+	}
+}
+
+// 占位页面统计：停放域名、默认页等可以忽略的页面数量
+func printPlaceholders(results []checker.Result) {
+	counts := make(map[string]int)
+	total := 0
+	for _, result := range results {
+		if result.Placeholder != "" {
+			counts[result.Placeholder]++
+			total++
+		}
+	}
+	if total == 0 {
+		return
+	}
+	fmt.Fprintln(utils.Console, Tf("占位页面: %s 个（报告中可用\"排除占位\"过滤）", FormatCount(total)))
+	for _, row := range pageTypeBreakdown(counts) {
+		fmt.Fprintln(utils.Console, "  "+Tf("%s: %s 个", T(row.Label), FormatCount(row.Count)))
+	}
+}
+
+// 软404统计：对不存在的路径也返回成功的主机，以及因此判为不存活的带路径目标
+func printSoft404(results []checker.Result) {
+	hosts, paths := 0, 0
+	for _, result := range results {
+		if result.Soft404 {
+			hosts++
+		}
+		for _, path := range result.Paths {
+			if path.Soft404 {
+				paths++
+			}
+		}
+	}
+	if hosts == 0 {
+		return
+	}
+	fmt.Fprintln(utils.Console, Tf("软404主机: %s 个（%s 个探测路径与不存在路径的响应相同）", FormatCount(hosts), FormatCount(paths)))
+}
+
+// 列出CORS过于宽松（回显任意来源且允许携带凭据）的主机
+func printCORS(results []checker.Result) {
+	var hosts []string
+	for _, result := range results {
+		if result.CORS != nil && result.CORS.Permissive {
+			hosts = append(hosts, result.DisplayDomain())
+		}
+	}
+	if len(hosts) == 0 {
+		return
+	}
+	fmt.Fprintln(utils.Console, Tf("CORS过于宽松: %s 个主机回显任意来源且允许携带凭据", FormatCount(len(hosts))))
+	for _, host := range hosts {
+		fmt.Fprintf(utils.Console, "  - %s\n", host)
+	}
+}
+
+// 按方法统计启用了危险HTTP方法的主机数
+func printRiskyMethods(results []checker.Result) {
+	hosts := 0
+	counts := make(map[string]int)
+	for _, result := range results {
+		if len(result.RiskyMethods) > 0 {
+			hosts++
+		}
+		for _, method := range result.RiskyMethods {
+			counts[method]++
+		}
+	}
+	if hosts == 0 {
+		return
+	}
+	methods := make([]string, 0, len(counts))
+	for method := range counts {
+		methods = append(methods, method)
+	}
+	sort.Slice(methods, func(i, j int) bool {
+		if counts[methods[i]] != counts[methods[j]] {
+			return counts[methods[i]] > counts[methods[j]]
+		}
+		return methods[i] < methods[j]
+	})
+	parts := make([]string, len(methods))
+	for i, method := range methods {
+		parts[i] = fmt.Sprintf("%s %s", method, FormatCount(counts[method]))
+	}
+	fmt.Fprintln(utils.Console, Tf("危险HTTP方法: %s 个主机（%s）", FormatCount(hosts), strings.Join(parts, T("，"))))
+}
+
+// 计算已排序耗时列表的分位数（最近秩法）
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	index := int(float64(len(sorted))*p/100+0.5) - 1
+	if index < 0 {
+		index = 0
+	}
+	if index >= len(sorted) {
+		index = len(sorted) - 1
+	}
+	return sorted[index]
+}
+
+// 响应时间统计：存活域名的分位数、最慢的主机和有响应的结果的分布
+type LatencyStats struct {
+	P50, P90, P99, Max string
+	Slowest            []SlowHost
+	Histogram          []HistogramBar
+}
+
+// 响应最慢的主机
+type SlowHost struct {
+	Domain       string
+	ResponseTime string
+	Status       int
+}
+
+// 直方图的一段，Percent为占最多一段的比例，用于绘制条形宽度
+type HistogramBar struct {
+	Label   string
+	Count   int
+	Percent float64
+}
+
+// 计算响应时间统计，没有存活域名时返回nil
+func latencyStats(results []checker.Result) *LatencyStats {
+	var alive []checker.Result
+	for _, result := range results {
+		if result.Alive {
+			alive = append(alive, result)
+		}
+	}
+	if len(alive) == 0 {
+		return nil
+	}
+	sort.SliceStable(alive, func(i, j int) bool { return alive[i].ResponseTime < alive[j].ResponseTime })
+	times := make([]time.Duration, len(alive))
+	for i, result := range alive {
+		times[i] = result.ResponseTime
+	}
+
+	stats := &LatencyStats{
+		P50: FormatDuration(percentile(times, 50)),
+		P90: FormatDuration(percentile(times, 90)),
+		P99: FormatDuration(percentile(times, 99)),
+		Max: FormatDuration(times[len(times)-1]),
+	}
+	for i := len(alive) - 1; i >= 0 && len(stats.Slowest) < maxSlowHosts; i-- {
+		stats.Slowest = append(stats.Slowest, SlowHost{
+			Domain:       alive[i].DisplayDomain(),
+			ResponseTime: FormatDuration(alive[i].ResponseTime),
+			Status:       alive[i].Status,
+		})
+	}
+
+	rows := responseTimeHistogram(results)
+	peak := 0
+	for _, row := range rows {
+		peak = max(peak, row.Count)
+	}
+	for _, row := range rows {
+		stats.Histogram = append(stats.Histogram, HistogramBar{Label: row.Label, Count: row.Count, Percent: float64(row.Count) * 100 / float64(peak)})
+	}
+	return stats
+}
+
+// 输出存活域名响应时间的分位数、最慢的主机和响应时间分布
+func printResponseTimePercentiles(results []checker.Result) {
+	stats := latencyStats(results)
+	if stats == nil {
+		return
+	}
+	fmt.Fprintln(utils.Console, Tf("响应时间: P50 %s, P90 %s, P99 %s, 最大 %s", stats.P50, stats.P90, stats.P99, stats.Max))
+
+	fmt.Fprintln(utils.Console, T("响应时间分布:"))
+	for _, bar := range stats.Histogram {
+		fmt.Fprintf(utils.Console, "  %-10s %-30s %s\n", bar.Label, strings.Repeat("█", int(bar.Percent*30/100+0.5)), FormatCount(bar.Count))
+	}
+
+	fmt.Fprintln(utils.Console, T("响应最慢的主机:"))
+	for _, host := range stats.Slowest {
+		fmt.Fprintf(utils.Console, "  %s %s (%d)\n", host.ResponseTime, host.Domain, host.Status)
+	}
+}
+
+// 输出重点发现：识别出页面类型或命中关键词的存活域名，以及悬挂CNAME
+func printTopFindings(results []checker.Result) {
+	var lines []string
+	for _, result := range results {
+		findings := result.Findings()
+		if len(findings) == 0 {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("  %s [%s] %s", result.DisplayDomain(), strings.Join(translateFindings(findings), ", "), result.Title))
+	}
+	if len(lines) == 0 {
+		return
+	}
+
+	fmt.Fprintln(utils.Console, Tf("重点发现 (共%s个):", FormatCount(len(lines))))
+	for i, line := range lines {
+		if i >= maxTopFindings {
+			fmt.Fprintln(utils.Console, "  "+Tf("... 其余 %d 个请查看报告", len(lines)-maxTopFindings))
+			break
+		}
+		fmt.Fprintln(utils.Console, line)
+	}
+}
@@ -0,0 +1,357 @@
+package view
+
+import (
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"subdomain-checker/checker"
+	"subdomain-checker/utils"
+)
+
+// 一次扫描的结果，用于多次扫描的趋势对比
+type TrendScan struct {
+	Label   string    // 结果文件名
+	Time    time.Time // 扫描时间：结果中最早的检测时间，没有时为文件修改时间
+	Results []checker.Result
+}
+
+// 读取JSON结果文件作为一次扫描
+func LoadTrendScan(filename string) (TrendScan, error) {
+	results, err := LoadResultsJSON(filename)
+	if err != nil {
+		return TrendScan{}, err
+	}
+	scan := TrendScan{Label: filepath.Base(filename), Results: results}
+	for _, result := range results {
+		if !result.CheckedAt.IsZero() && (scan.Time.IsZero() || result.CheckedAt.Before(scan.Time)) {
+			scan.Time = result.CheckedAt
+		}
+	}
+	if scan.Time.IsZero() {
+		if info, err := os.Stat(filename); err == nil {
+			scan.Time = info.ModTime()
+		}
+	}
+	return scan, nil
+}
+
+// 单个子域名在各次扫描中的状态
+type TrendHost struct {
+	Domain    string
+	Root      string
+	History   []string // 按扫描顺序: alive、dead，未出现在该次扫描中时为空
+	FirstSeen time.Time
+	LastSeen  time.Time
+	LastAlive time.Time // 最后一次存活的扫描时间，从未存活时为零值
+	Flaps     int       // 存活与无法访问之间切换的次数
+}
+
+// 根域名在各次扫描中的存活数
+type TrendRoot struct {
+	Root   string
+	Alive  []int
+	Totals []int
+}
+
+// 多次扫描的趋势
+type Trend struct {
+	Scans    []TrendScan
+	Roots    []TrendRoot
+	Hosts    []TrendHost
+	Flapping []TrendHost // 状态反复切换（至少两次）的主机
+}
+
+// 判定为状态反复切换的最少切换次数，如 存活→无法访问→存活
+const flapThreshold = 2
+
+// 按扫描时间排序后汇总各根域名的存活数和每个子域名的历史状态
+func BuildTrend(scans []TrendScan) Trend {
+	sort.SliceStable(scans, func(i, j int) bool {
+		return scans[i].Time.Before(scans[j].Time)
+	})
+	trend := Trend{Scans: scans}
+
+	hosts := make(map[string]*TrendHost)
+	roots := make(map[string]*TrendRoot)
+	for i, scan := range scans {
+		for _, result := range scan.Results {
+			host, ok := hosts[result.Domain]
+			if !ok {
+				host = &TrendHost{
+					Domain:    result.DisplayDomain(),
+					Root:      utils.ToUnicodeTarget(utils.RootDomain(utils.HostFromURL(result.Domain))),
+					History:   make([]string, len(scans)),
+					FirstSeen: scan.Time,
+				}
+				hosts[result.Domain] = host
+			}
+			if host.History[i] != "" {
+				continue // 同一次扫描中重复的目标只计一次
+			}
+			host.LastSeen = scan.Time
+			host.History[i] = "dead"
+			if result.Alive {
+				host.History[i] = "alive"
+				host.LastAlive = scan.Time
+			}
+
+			root, ok := roots[host.Root]
+			if !ok {
+				root = &TrendRoot{Root: host.Root, Alive: make([]int, len(scans)), Totals: make([]int, len(scans))}
+				roots[host.Root] = root
+			}
+			root.Totals[i]++
+			if result.Alive {
+				root.Alive[i]++
+			}
+		}
+	}
+
+	for _, host := range hosts {
+		previous := ""
+		for _, state := range host.History {
+			if state == "" {
+				continue
+			}
+			if previous != "" && state != previous {
+				host.Flaps++
+			}
+			previous = state
+		}
+		trend.Hosts = append(trend.Hosts, *host)
+		if host.Flaps >= flapThreshold {
+			trend.Flapping = append(trend.Flapping, *host)
+		}
+	}
+	sort.Slice(trend.Hosts, func(i, j int) bool {
+		if trend.Hosts[i].Root != trend.Hosts[j].Root {
+			return trend.Hosts[i].Root < trend.Hosts[j].Root
+		}
+		return trend.Hosts[i].Domain < trend.Hosts[j].Domain
+	})
+	sort.Slice(trend.Flapping, func(i, j int) bool {
+		if trend.Flapping[i].Flaps != trend.Flapping[j].Flaps {
+			return trend.Flapping[i].Flaps > trend.Flapping[j].Flaps
+		}
+		return trend.Flapping[i].Domain < trend.Flapping[j].Domain
+	})
+	for _, root := range roots {
+		trend.Roots = append(trend.Roots, *root)
+	}
+	sort.Slice(trend.Roots, func(i, j int) bool {
+		return trend.Roots[i].Root < trend.Roots[j].Root
+	})
+	return trend
+}
+
+// 存活数折线图（内联SVG）的尺寸
+const (
+	sparklineWidth  = 160
+	sparklineHeight = 32
+)
+
+// 生成存活数的折线图
+func sparkline(values []int) template.HTML {
+	if len(values) == 0 {
+		return ""
+	}
+	peak := 1
+	for _, v := range values {
+		peak = max(peak, v)
+	}
+	var points []string
+	for i, v := range values {
+		x := float64(sparklineWidth) / 2
+		if len(values) > 1 {
+			x = float64(i) * float64(sparklineWidth-4) / float64(len(values)-1)
+		}
+		y := float64(sparklineHeight-2) - float64(v)/float64(peak)*float64(sparklineHeight-4)
+		points = append(points, fmt.Sprintf("%.1f,%.1f", x+2, y))
+	}
+	return template.HTML(fmt.Sprintf(`<svg width="%d" height="%d"><polyline fill="none" stroke="#2e7d32" stroke-width="2" points="%s"/></svg>`,
+		sparklineWidth, sparklineHeight, strings.Join(points, " ")))
+}
+
+// 趋势报告中的日期
+func trendDate(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
+
+// 趋势报告模板中的一行扫描
+type trendScanRow struct {
+	Number int
+	Time   string
+	Label  string
+	Total  string
+	Alive  string
+}
+
+// 趋势报告模板中的一行根域名
+type trendRootRow struct {
+	Root      string
+	Sparkline template.HTML
+	Cells     []string // 各次扫描的 "存活/总数"，未出现时为 "-"
+}
+
+// 趋势报告模板中的一行子域名
+type trendHostRow struct {
+	Domain    string
+	Root      string
+	History   []string
+	FirstSeen string
+	LastSeen  string
+	LastAlive string
+	Flaps     int
+}
+
+func newTrendHostRows(hosts []TrendHost) []trendHostRow {
+	rows := make([]trendHostRow, 0, len(hosts))
+	for _, host := range hosts {
+		rows = append(rows, trendHostRow{
+			Domain:    host.Domain,
+			Root:      host.Root,
+			History:   host.History,
+			FirstSeen: trendDate(host.FirstSeen),
+			LastSeen:  trendDate(host.LastSeen),
+			LastAlive: trendDate(host.LastAlive),
+			Flaps:     host.Flaps,
+		})
+	}
+	return rows
+}
+
+// 将趋势渲染为HTML报告
+func SaveTrendReport(trend Trend, filename string) error {
+	data := struct {
+		ReportTime string
+		Scans      []trendScanRow
+		Roots      []trendRootRow
+		Flapping   []trendHostRow
+		Hosts      []trendHostRow
+	}{
+		ReportTime: reportTime(),
+		Flapping:   newTrendHostRows(trend.Flapping),
+		Hosts:      newTrendHostRows(trend.Hosts),
+	}
+	for i, scan := range trend.Scans {
+		alive := 0
+		for _, result := range scan.Results {
+			if result.Alive {
+				alive++
+			}
+		}
+		data.Scans = append(data.Scans, trendScanRow{
+			Number: i + 1,
+			Time:   trendDate(scan.Time),
+			Label:  scan.Label,
+			Total:  FormatCount(len(scan.Results)),
+			Alive:  FormatCount(alive),
+		})
+	}
+	for _, root := range trend.Roots {
+		row := trendRootRow{Root: root.Root, Sparkline: sparkline(root.Alive)}
+		for i := range root.Alive {
+			cell := "-"
+			if root.Totals[i] > 0 {
+				cell = FormatCount(root.Alive[i]) + "/" + FormatCount(root.Totals[i])
+			}
+			row.Cells = append(row.Cells, cell)
+		}
+		data.Roots = append(data.Roots, row)
+	}
+
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if err := trendTemplate.Execute(file, data); err != nil {
+		return fmt.Errorf("执行模板失败: %v", err)
+	}
+	return file.Close()
+}
+
+var trendTemplate = template.Must(template.New("trend").Parse(`<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="utf-8">
+    <title>多次扫描趋势报告</title>
+    <style>
+        body { font-family: Arial, sans-serif; margin: 0; padding: 20px; background: #f5f5f5; }
+        .container { max-width: 1400px; margin: 0 auto; background: #fff; padding: 20px; border-radius: 5px; box-shadow: 0 2px 5px rgba(0,0,0,0.1); }
+        h1 { color: #333; text-align: center; }
+        h2 { color: #444; border-bottom: 2px solid #eee; padding-bottom: 6px; margin-top: 30px; }
+        table { width: 100%; border-collapse: collapse; font-size: 14px; }
+        th, td { padding: 6px 10px; border-bottom: 1px solid #eee; text-align: left; white-space: nowrap; }
+        th { background: #f0f0f0; position: sticky; top: 0; }
+        .num { text-align: right; }
+        .history span { display: inline-block; width: 14px; text-align: center; }
+        .alive { color: #2e7d32; }
+        .dead { color: #c62828; }
+        .absent { color: #bbb; }
+        #search { padding: 6px 10px; width: 300px; margin-bottom: 10px; }
+    </style>
+</head>
+<body>
+<div class="container">
+    <h1>多次扫描趋势报告</h1>
+    <p>生成时间: {{.ReportTime}} · 共 {{len .Scans}} 次扫描，{{len .Hosts}} 个子域名，{{len .Flapping}} 个状态反复切换</p>
+
+    <h2>扫描列表</h2>
+    <table>
+        <tr><th>#</th><th>扫描时间</th><th>结果文件</th><th class="num">结果数</th><th class="num">存活</th></tr>
+        {{range .Scans}}
+        <tr><td>{{.Number}}</td><td>{{.Time}}</td><td>{{.Label}}</td><td class="num">{{.Total}}</td><td class="num">{{.Alive}}</td></tr>
+        {{end}}
+    </table>
+
+    <h2>各根域名存活数</h2>
+    <table>
+        <tr><th>根域名</th><th>存活趋势</th>{{range .Scans}}<th class="num">#{{.Number}}</th>{{end}}</tr>
+        {{range .Roots}}
+        <tr><td>{{.Root}}</td><td>{{.Sparkline}}</td>{{range .Cells}}<td class="num">{{.}}</td>{{end}}</tr>
+        {{end}}
+    </table>
+
+    <h2>状态反复切换的主机</h2>
+    {{if .Flapping}}
+    <table>
+        <tr><th>子域名</th><th>历史（● 存活 ○ 无法访问 · 未出现）</th><th class="num">切换次数</th><th>最后存活</th></tr>
+        {{range .Flapping}}
+        <tr><td>{{.Domain}}</td><td class="history">{{template "history" .History}}</td><td class="num">{{.Flaps}}</td><td>{{.LastAlive}}</td></tr>
+        {{end}}
+    </table>
+    {{else}}
+    <p>没有状态反复切换的主机。</p>
+    {{end}}
+
+    <h2>全部子域名</h2>
+    <input id="search" type="text" placeholder="搜索子域名..." oninput="filterHosts(this.value)">
+    <table id="hosts">
+        <tr><th>子域名</th><th>根域名</th><th>历史（● 存活 ○ 无法访问 · 未出现）</th><th>首次出现</th><th>最后出现</th><th>最后存活</th></tr>
+        {{range .Hosts}}
+        <tr><td>{{.Domain}}</td><td>{{.Root}}</td><td class="history">{{template "history" .History}}</td><td>{{.FirstSeen}}</td><td>{{.LastSeen}}</td><td>{{.LastAlive}}</td></tr>
+        {{end}}
+    </table>
+</div>
+<script>
+function filterHosts(query) {
+    query = query.toLowerCase();
+    var rows = document.querySelectorAll('#hosts tr');
+    for (var i = 1; i < rows.length; i++) {
+        rows[i].style.display = rows[i].cells[0].textContent.toLowerCase().indexOf(query) >= 0 ? '' : 'none';
+    }
+}
+</script>
+</body>
+</html>
+{{define "history"}}{{range .}}{{if eq . "alive"}}<span class="alive">●</span>{{else if eq . "dead"}}<span class="dead">○</span>{{else}}<span class="absent">·</span>{{end}}{{end}}{{end}}
+`))
@@ -1,24 +1,30 @@
 package view
 
 import (
+	"bytes"
 	"encoding/base64"
 	"fmt"
 	"html/template"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
 	"io"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
-	"sync"
-	"sync/atomic"
 	"time"
-	"unicode/utf8"
 
 	"subdomain-checker/checker"
 	"subdomain-checker/config"
+	"subdomain-checker/logger"
+	"subdomain-checker/stats"
+	"subdomain-checker/utils"
 
 	"github.com/xuri/excelize/v2"
-	"golang.org/x/text/encoding/simplifiedchinese"
-	"golang.org/x/text/transform"
+	_ "golang.org/x/image/bmp"
+	_ "golang.org/x/image/webp"
 )
 
 // 将截图文件转换为base64 data URI，确保HTML内嵌图片可正常显示
@@ -46,60 +52,233 @@ func screenshotToDataURI(screenshotPath string) string {
 	return "data:" + mimeType + ";base64," + encoded
 }
 
-// 显示进度
-func ShowProgress(processed *int32, totalDomains int, startTime time.Time, doneChan, progressDone chan struct{}) {
-	// 启动进度显示goroutine
-	go func() {
-		defer close(progressDone)
-		ticker := time.NewTicker(500 * time.Millisecond) // 更新频率提高到0.5秒一次
-		defer ticker.Stop()
-
-		for {
-			select {
-			case <-ticker.C:
-				current := atomic.LoadInt32(processed)
-				if current >= int32(totalDomains) {
-					return
-				}
-				percent := float64(current) / float64(totalDomains) * 100
-				fmt.Printf("\r进度: %.2f%% (%d/%d) - 耗时: %.1fs",
-					percent, current, totalDomains, time.Since(startTime).Seconds())
-			case <-doneChan:
-				return
-			}
+// 因不在扫描范围内而跳过的目标
+type SkippedTarget struct {
+	Target string
+	Reason string
+}
+
+// 跳过的目标，在报告中单独列出
+var skippedTargets []SkippedTarget
+
+// 设置因不在扫描范围内而跳过的目标
+func SetSkippedTargets(targets []SkippedTarget) {
+	skippedTargets = targets
+}
+
+// 固定的报告生成时间，为零值时使用当前时间
+var fixedReportTime time.Time
+
+// 设置固定的报告生成时间（确定性模式下使用扫描开始时间，保证重复生成的报告字节一致）
+func SetReportTime(t time.Time) {
+	fixedReportTime = t
+}
+
+// Excel截图表的写入方式
+const (
+	ExcelScreenshotsEmbed = "embed" // 内嵌图片（超过 -max-excel-size 时自动改为链接）
+	ExcelScreenshotsLink  = "link"  // 只写入指向截图文件的链接
+	ExcelScreenshotsNone  = "none"  // 不生成截图表
+)
+
+var excelScreenshots = ExcelScreenshotsEmbed
+
+// 设置Excel截图表的写入方式
+func SetExcelScreenshots(mode string) {
+	excelScreenshots = mode
+}
+
+// 返回报告中显示的生成时间
+func reportTime() string {
+	if !fixedReportTime.IsZero() {
+		return fixedReportTime.Format("2006-01-02 15:04:05")
+	}
+	return time.Now().Format("2006-01-02 15:04:05")
+}
+
+// 写入Excel隐藏工作表的配置快照，为空时不写入
+var configSnapshot []config.SnapshotEntry
+
+// 设置写入Excel报告的配置快照（应已脱敏）
+func SetConfigSnapshot(entries []config.SnapshotEntry) {
+	configSnapshot = entries
+}
+
+// 返回国际化域名的punycode形式，普通域名返回空字符串
+func punycode(result checker.Result) string {
+	if result.UnicodeDomain == "" {
+		return ""
+	}
+	return result.Domain
+}
+
+// 返回地址族说明：分别检测了IPv4和IPv6时列出各自结果，否则为应答的地址族
+func formatFamily(result checker.Result) string {
+	if len(result.Families) > 0 {
+		return checker.FormatFamilies(result.Families)
+	}
+	return result.IPFamily
+}
+
+// 返回协商的TLS版本和加密套件，如 "TLS 1.3 TLS_AES_128_GCM_SHA256"，非HTTPS时为空
+func formatTLS(result checker.Result) string {
+	if result.TLSVersion == "" {
+		return ""
+	}
+	return "TLS " + result.TLSVersion + " " + result.TLSCipher
+}
+
+// 报告中只标出非HTML响应的内容类型，普通网页不显示
+func nonHTMLContentType(contentType string) string {
+	if contentType == "text/html" || contentType == "application/xhtml+xml" {
+		return ""
+	}
+	return contentType
+}
+
+// 返回客户端证书要求的说明
+func clientCertLabel(state string) string {
+	switch state {
+	case checker.ClientCertRequested:
+		return T("服务器请求客户端证书")
+	case checker.ClientCertRequired:
+		return T("服务器要求客户端证书（握手失败）")
+	}
+	return ""
+}
+
+// 格式化开放端口列表，如 "80, 443, 8080"
+func FormatPorts(ports []int) string {
+	parts := make([]string, len(ports))
+	for i, port := range ports {
+		parts[i] = strconv.Itoa(port)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// 格式化端口上的服务列表，如 "22/ssh (SSH-2.0-OpenSSH_8.9); 6379/redis"
+func FormatServices(services []checker.Service) string {
+	parts := make([]string, len(services))
+	for i, service := range services {
+		parts[i] = service.String()
+	}
+	return strings.Join(parts, "; ")
+}
+
+// 格式化路径探测结果，如 "/admin (200); /.git/config (403)"，请求失败的路径不列出，软404的路径加注
+func FormatPaths(paths []checker.PathResult) string {
+	parts := make([]string, 0, len(paths))
+	for _, path := range paths {
+		switch {
+		case path.Soft404:
+			parts = append(parts, fmt.Sprintf("%s (%d %s)", path.Path, path.Status, T("软404")))
+		case path.Status != 0:
+			parts = append(parts, fmt.Sprintf("%s (%d)", path.Path, path.Status))
 		}
-	}()
+	}
+	return strings.Join(parts, "; ")
+}
+
+// 格式化CORS检测结果：回显任意来源时加以说明，否则为响应的 Access-Control-Allow-Origin，未检测时为空
+func FormatCORS(cors *checker.CORSInfo) string {
+	switch {
+	case cors == nil:
+		return ""
+	case cors.Permissive:
+		return T("回显任意来源且允许携带凭据")
+	case cors.Reflected:
+		return T("回显任意来源")
+	}
+	return cors.AllowOrigin
+}
+
+// 格式化允许的HTTP方法，有危险方法时附加说明，如 "GET, OPTIONS, PUT（危险: PUT）"
+func FormatMethods(methods, risky []string) string {
+	s := strings.Join(methods, ", ")
+	if len(risky) > 0 {
+		s += Tf("（危险: %s）", strings.Join(risky, ", "))
+	}
+	return s
+}
+
+// 格式化CNAME链，如 "a.example.net → b.cdn.net"
+func FormatCNAMEChain(chain []string) string {
+	return strings.Join(chain, " → ")
+}
+
+// 格式化自治系统号，如 "AS13335"，未知时返回空字符串
+func formatASN(asn uint) string {
+	if asn == 0 {
+		return ""
+	}
+	return fmt.Sprintf("AS%d", asn)
+}
+
+// 格式化结果的检测时间（精确到毫秒并带时区，便于与目标侧日志对照），零值返回空字符串
+func FormatCheckedAt(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format("2006-01-02T15:04:05.000Z07:00")
 }
 
 // 打印总结
-func PrintSummary(total, alive, dead int, cfg *config.Config, pageTypeCount map[string]int, pageTypeCountMutex *sync.Mutex, screenshotCount int32, totalTime time.Duration) {
+// 根据 cfg.SummaryLevel 控制输出详细程度：
+// minimal 只输出总数和耗时，normal 额外输出页面类型和截图统计，full 再加上错误分类、响应时间分位数和重点发现
+func PrintSummary(results []checker.Result, total int, snap stats.Snapshot, cfg *config.Config, totalTime time.Duration) {
 	// 打印表头
-	fmt.Println("\n检测结果 (总结):")
-	fmt.Println("----------------------------------------")
+	fmt.Fprintln(utils.Console, "\n"+T("检测结果 (总结):"))
+	fmt.Fprintln(utils.Console, "----------------------------------------")
 
 	// 输出总结
-	fmt.Printf("总计: %d 个域名, %d 个存活, %d 个无法访问\n", total, alive, dead)
-
-	// 如果启用了页面信息提取，显示页面类型统计
-	if cfg.ExtractInfo && len(pageTypeCount) > 0 {
-		fmt.Println("页面类型统计:")
-		pageTypeCountMutex.Lock()
-		for pageType, count := range pageTypeCount {
-			fmt.Printf("  %s: %d 个\n", pageType, count)
+	fmt.Fprintln(utils.Console, Tf("总计: %s 个域名, %s 个存活, %s 个无法访问", FormatCount(total), FormatCount(snap.Alive), FormatCount(snap.Dead)))
+
+	if cfg.SummaryLevel != "minimal" {
+		// 如果启用了页面信息提取，显示页面类型统计
+		if cfg.ExtractInfo && len(snap.PageTypes) > 0 {
+			fmt.Fprintln(utils.Console, T("页面类型统计:"))
+			for pageType, count := range snap.PageTypes {
+				fmt.Fprintln(utils.Console, "  "+Tf("%s: %s 个", T(pageType), FormatCount(count)))
+			}
+		}
+
+		// 显示云服务商分布
+		if len(snap.Clouds) > 0 {
+			fmt.Fprintln(utils.Console, T("云服务商分布:"))
+			for _, row := range pageTypeBreakdown(snap.Clouds) {
+				fmt.Fprintln(utils.Console, "  "+Tf("%s: %s 个", row.Label, FormatCount(row.Count)))
+			}
+		}
+
+		// 显示截图统计
+		if cfg.Screenshot || cfg.ScreenshotAlive {
+			if cfg.ScreenshotAlive {
+				fmt.Fprintln(utils.Console, Tf("成功截图存活网站: %s 个", FormatCount(snap.Screenshots)))
+			} else {
+				fmt.Fprintln(utils.Console, Tf("成功截图: %s 个", FormatCount(snap.Screenshots)))
+			}
 		}
-		pageTypeCountMutex.Unlock()
 	}
 
-	// 显示截图统计
-	if cfg.Screenshot || cfg.ScreenshotAlive {
-		if cfg.ScreenshotAlive {
-			fmt.Printf("成功截图存活网站: %d 个\n", screenshotCount)
-		} else {
-			fmt.Printf("成功截图: %d 个\n", screenshotCount)
+	if cfg.SummaryLevel != "minimal" {
+		printErrorBreakdown(results)
+		printPlaceholders(results)
+		printSoft404(results)
+		printCORS(results)
+		printRiskyMethods(results)
+		printDomainRecords()
+		printZoneTransfers()
+		if hits, misses := checker.DNSCacheStats(); hits+misses > 0 {
+			fmt.Fprintln(utils.Console, Tf("DNS缓存: 命中 %s 次, 查询 %s 次 (命中率 %.1f%%)",
+				FormatCount(int(hits)), FormatCount(int(misses)), float64(hits)*100/float64(hits+misses)))
 		}
 	}
+	if cfg.SummaryLevel == "full" {
+		printResponseTimePercentiles(results)
+		printTopFindings(results)
+	}
 
-	fmt.Printf("检测耗时: %.2f 秒\n", totalTime.Seconds())
+	fmt.Fprintln(utils.Console, Tf("检测耗时: %s", FormatDuration(totalTime)))
 }
 
 // 保存结果到文件
@@ -111,7 +290,8 @@ func SaveResultsToFile(results []checker.Result, filename string) error {
 	defer file.Close()
 
 	// 写入标题行
-	fmt.Fprintf(file, "域名,状态,状态码,响应时间(毫秒),页面类型,页面标题,消息\n")
+	headers := []string{"域名", "状态", "状态码", millisHeader("响应时间"), "页面类型", "页面标题", "消息", "备注", "最终URL", "重定向链", "检测时间", "错误类型", "Punycode", "地址族", "内容哈希", "CNAME链", "ASN", "组织", "国家", "云服务商", "开放端口", "服务", "路径探测", "CORS", "HTTP方法"}
+	fmt.Fprintln(file, strings.Join(translateAll(headers), ","))
 
 	// 写入数据行
 	for _, result := range results {
@@ -120,21 +300,41 @@ func SaveResultsToFile(results []checker.Result, filename string) error {
 			pageType = result.PageInfo.Type
 		}
 
-		fmt.Fprintf(file, "%s,%s,%d,%.2f,%s,%s,%s\n",
-			result.Domain,
-			result.StatusText,
+		fmt.Fprintf(file, "%s,%s,%d,%.2f,%s,%s,%s,%s,%s,%s,%s,%s,%s,%s,%s,%s,%s,%s,%s,%s,%s,%s,%s,%s,%s\n",
+			result.DisplayDomain(),
+			T(result.StatusText),
 			result.Status,
 			float64(result.ResponseTime.Milliseconds()),
-			pageType,
+			T(pageType),
 			strings.ReplaceAll(result.Title, ",", " "),   // 避免标题中的逗号影响CSV格式
-			strings.ReplaceAll(result.Message, ",", " ")) // 避免消息中的逗号影响CSV格式
+			strings.ReplaceAll(result.Message, ",", " "), // 避免消息中的逗号影响CSV格式
+			strings.ReplaceAll(result.Note, ",", " "),
+			strings.ReplaceAll(result.FinalURL, ",", "%2C"),
+			strings.ReplaceAll(FormatRedirectChain(result.RedirectChain), ",", "%2C"),
+			FormatCheckedAt(result.CheckedAt),
+			T(result.ErrorType.Label()),
+			punycode(result),
+			formatFamily(result),
+			result.BodyHash,
+			FormatCNAMEChain(result.CNAMEs),
+			formatASN(result.ASN),
+			strings.ReplaceAll(result.ASOrg, ",", " "),
+			result.Country,
+			result.Cloud,
+			strings.ReplaceAll(FormatPorts(result.OpenPorts), ", ", " "),
+			strings.ReplaceAll(FormatServices(result.Services), ",", " "),
+			strings.ReplaceAll(FormatPaths(result.Paths), ",", "%2C"),
+			strings.ReplaceAll(FormatCORS(result.CORS), ",", " "),
+			strings.ReplaceAll(FormatMethods(result.Methods, result.RiskyMethods), ", ", " "))
 	}
 
 	return nil
 }
 
 // 保存结果到 Excel 文件
-func SaveResultsToExcel(results []checker.Result, filename string, onlyAlive bool) error {
+// 主表使用StreamWriter逐行写入，内存占用不随行数增长，可导出百万行结果；
+// 截图表只包含有截图的结果，是否内嵌图片由 SetExcelScreenshots 和报告大小上限决定
+func SaveResultsToExcel(results []checker.Result, filename string) error {
 	// 创建输出目录（如果不存在）
 	outputDir := filepath.Dir(filename)
 	if outputDir != "" && outputDir != "." {
@@ -143,28 +343,27 @@ func SaveResultsToExcel(results []checker.Result, filename string, onlyAlive boo
 		}
 	}
 
+	// 估算文件大小，超出上限时截图表改为链接而不内嵌图片
+	embedPictures := excelScreenshots == ExcelScreenshotsEmbed
+	if embedPictures && maxExcelBytes > 0 {
+		if est := EstimateExcelSize(results); est.Bytes > maxExcelBytes {
+			embedPictures = false
+			fmt.Fprintf(utils.Console, "⚠️  预计Excel报告大小 %s（%d行, %d张截图）超过上限 %s，截图将以链接形式写入\n",
+				FormatBytes(est.Bytes), est.Rows, est.Images, FormatBytes(maxExcelBytes))
+		}
+	}
+
 	// 创建一个新的 Excel 文件
 	f := excelize.NewFile()
 	defer func() {
 		if err := f.Close(); err != nil {
-			fmt.Printf("关闭 Excel 文件时出错: %s\n", err)
+			logger.Error("关闭Excel文件时出错", "file", filename, "error", err)
 		}
 	}()
 
-	// 设置表头
-	sheetName := "子域名检测结果"
+	sheetName := T("子域名检测结果")
 	f.SetSheetName("Sheet1", sheetName)
-	headers := []string{"域名", "状态", "状态码", "响应时间(毫秒)", "页面类型", "页面标题", "消息", "截图"}
-	for i, header := range headers {
-		cell, _ := excelize.CoordinatesToCellName(i+1, 1)
-		f.SetCellValue(sheetName, cell, header)
-	}
-
-	// 创建截图工作表
-	screenshotSheet := "页面截图"
-	f.NewSheet(screenshotSheet)
-	f.SetCellValue(screenshotSheet, "A1", "域名")
-	f.SetCellValue(screenshotSheet, "B1", "截图")
+	headers := translateAll([]string{"域名", "状态", "状态码", millisHeader("响应时间"), "页面类型", "页面标题", "消息", "截图", "备注", "最终URL", "重定向链", "检测时间", "错误类型", "Punycode", "地址族", "CNAME链", "ASN", "组织", "国家", "云服务商", "开放端口", "服务", "路径探测", "CORS", "HTTP方法", triageHeader})
 
 	// 设置表头样式
 	headerStyle, _ := f.NewStyle(&excelize.Style{
@@ -185,189 +384,267 @@ func SaveResultsToExcel(results []checker.Result, filename string, onlyAlive boo
 			{Type: "bottom", Color: "#000000", Style: 1},
 		},
 	})
-	f.SetCellStyle(sheetName, "A1", fmt.Sprintf("%c1", 'A'+len(headers)-1), headerStyle)
-	f.SetCellStyle(screenshotSheet, "A1", "B1", headerStyle)
+	// 单元格样式
+	contentStyle, _ := f.NewStyle(&excelize.Style{
+		Border: []excelize.Border{
+			{Type: "left", Color: "#000000", Style: 1},
+			{Type: "right", Color: "#000000", Style: 1},
+			{Type: "top", Color: "#000000", Style: 1},
+			{Type: "bottom", Color: "#000000", Style: 1},
+		},
+	})
+	linkStyle, _ := f.NewStyle(&excelize.Style{
+		Font: &excelize.Font{
+			Color:     "#0563C1",
+			Underline: "single",
+		},
+		Border: []excelize.Border{
+			{Type: "left", Color: "#000000", Style: 1},
+			{Type: "right", Color: "#000000", Style: 1},
+			{Type: "top", Color: "#000000", Style: 1},
+			{Type: "bottom", Color: "#000000", Style: 1},
+		},
+		Alignment: &excelize.Alignment{
+			Horizontal: "center",
+		},
+	})
 
-	// 写入数据行
-	row := 2           // 从第二行开始
-	screenshotRow := 2 // 截图表从第二行开始
+	var withScreenshots []checker.Result
+	var err error
+	if excelPerRoot {
+		withScreenshots, err = writeRootSheets(f, sheetName, headers, results, headerStyle, contentStyle, linkStyle)
+	} else {
+		withScreenshots, err = writeResultsSheet(f, sheetName, headers, results, headerStyle, contentStyle, linkStyle)
+	}
+	if err != nil {
+		return err
+	}
 
-	// 创建模板数据
-	data := TemplateData{
-		TotalDomains: len(results),
-		AliveDomains: 0,
-		DeadDomains:  0,
-		ReportTime:   time.Now().Format("2006-01-02 15:04:05"),
-		Results:      make([]TemplateResult, 0, len(results)),
+	// 截图表只包含有截图的结果
+	if excelScreenshots != ExcelScreenshotsNone {
+		writeScreenshotSheet(f, T("页面截图"), headerStyle, contentStyle, withScreenshots, embedPictures)
 	}
 
-	for _, result := range results {
-		// 如果只导出存活的域名，则跳过非存活的
-		if onlyAlive && !result.Alive {
-			continue
-		}
+	// 路径探测表，每个探测路径一行
+	writePathsSheet(f, T("路径探测"), headerStyle, results)
 
-		// 更新统计数据
-		if result.Alive {
-			data.AliveDomains++
-		} else {
-			data.DeadDomains++
-		}
+	// 范围外目标表，列出因不在扫描范围内而跳过的目标
+	writeSkippedSheet(f, T("范围外目标"), headerStyle)
 
-		pageType := ""
-		if result.PageInfo != nil {
-			pageType = result.PageInfo.Type
-		}
+	// 域名注册信息表，-whois 查询的根域名注册商和注册、到期日期
+	writeDomainRecordsSheet(f, T("域名注册信息"), headerStyle)
 
-		// 设置单元格样式
-		contentStyle, _ := f.NewStyle(&excelize.Style{
-			Border: []excelize.Border{
-				{Type: "left", Color: "#000000", Style: 1},
-				{Type: "right", Color: "#000000", Style: 1},
-				{Type: "top", Color: "#000000", Style: 1},
-				{Type: "bottom", Color: "#000000", Style: 1},
-			},
-		})
+	// 域传送表，-axfr 发现允许域传送的根域名及泄露的主机名
+	writeZoneTransferSheet(f, T("域传送"), headerStyle)
 
-		// 写入一行数据到主表
-		f.SetCellValue(sheetName, fmt.Sprintf("A%d", row), result.Domain)
-		f.SetCellValue(sheetName, fmt.Sprintf("B%d", row), result.StatusText)
-		f.SetCellValue(sheetName, fmt.Sprintf("C%d", row), result.Status)
-		f.SetCellValue(sheetName, fmt.Sprintf("D%d", row), float64(result.ResponseTime.Milliseconds()))
-		f.SetCellValue(sheetName, fmt.Sprintf("E%d", row), pageType)
-		f.SetCellValue(sheetName, fmt.Sprintf("F%d", row), result.Title)
-		f.SetCellValue(sheetName, fmt.Sprintf("G%d", row), result.Message)
+	// 写入汇总看板工作表
+	writeDashboardSheet(f, T("汇总看板"), headerStyle, results)
 
-		// 应用内容样式
-		f.SetCellStyle(sheetName, fmt.Sprintf("A%d", row), fmt.Sprintf("G%d", row), contentStyle)
+	// 写入分组统计工作表
+	writeGroupsSheet(f, T("分组统计"), headerStyle, results)
 
-		// 处理域名链接
-		domainLink := result.Domain
-		if !strings.HasPrefix(domainLink, "http://") && !strings.HasPrefix(domainLink, "https://") {
-			domainLink = "http://" + domainLink
+	// 写入隐藏的扫描配置工作表
+	writeConfigSheet(f, T("扫描配置"), headerStyle)
+
+	// 保存文件
+	if err := f.SaveAs(filename); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// 流式写入结果表，返回有截图的结果供截图表使用
+func writeResultsSheet(f *excelize.File, sheetName string, headers []string, results []checker.Result, headerStyle, contentStyle, linkStyle int) ([]checker.Result, error) {
+	// 列宽和冻结表头需在写入行之前设置
+	sw, err := f.NewStreamWriter(sheetName)
+	if err != nil {
+		return nil, err
+	}
+	sw.SetColWidth(1, len(headers), 20)
+	sw.SetPanes(&excelize.Panes{
+		Freeze:      true,
+		Split:       false,
+		XSplit:      0,
+		YSplit:      1,
+		TopLeftCell: "A2",
+		ActivePane:  "bottomLeft",
+	})
+	headerRow := make([]interface{}, len(headers))
+	for i, header := range headers {
+		headerRow[i] = excelize.Cell{StyleID: headerStyle, Value: header}
+	}
+	if err := sw.SetRow("A1", headerRow); err != nil {
+		return nil, err
+	}
+
+	// 写入数据行
+	row := 2 // 从第二行开始
+	var withScreenshots []checker.Result
+	for _, result := range results {
+		pageType := ""
+		if result.PageInfo != nil {
+			pageType = result.PageInfo.Type
 		}
 
-		// 处理截图路径
-		screenshot := ""
+		// 主表中的"查看截图"链接，使用HYPERLINK公式以支持流式写入
+		screenshotCell := excelize.Cell{StyleID: contentStyle, Value: T("无截图")}
 		if result.Screenshot != "" {
-			// 使用相对路径
-			screenshot = filepath.Join("screenshots", filepath.Base(result.Screenshot))
-			// 将路径分隔符转换为正斜杠，确保在HTML中正确显示
-			screenshot = strings.ReplaceAll(screenshot, "\\", "/")
-			// 确保路径以screenshots/开头
-			if !strings.HasPrefix(screenshot, "screenshots/") {
-				screenshot = "screenshots/" + filepath.Base(screenshot)
+			screenshot := reportScreenshotPath(result.Screenshot)
+			screenshotCell = excelize.Cell{
+				StyleID: linkStyle,
+				Formula: fmt.Sprintf(`HYPERLINK("%s","%s")`, strings.ReplaceAll(screenshot, `"`, `""`), T("查看截图")),
+				Value:   T("查看截图"),
 			}
+			withScreenshots = append(withScreenshots, result)
 		}
 
-		// 处理标题编码
-		title := result.Title
-		if title != "" {
-			// 尝试检测并转换编码
-			if !utf8.ValidString(title) {
-				// 尝试从GBK转换到UTF-8
-				reader := transform.NewReader(strings.NewReader(title), simplifiedchinese.GBK.NewDecoder())
-				if d, err := io.ReadAll(reader); err == nil {
-					title = string(d)
-				}
+		values := []interface{}{
+			result.DisplayDomain(),
+			T(result.StatusText),
+			result.Status,
+			float64(result.ResponseTime.Milliseconds()),
+			T(pageType),
+			excelTitle(result.Title),
+			result.Message,
+			screenshotCell,
+			result.Note,
+			result.FinalURL,
+			FormatRedirectChain(result.RedirectChain),
+			FormatCheckedAt(result.CheckedAt),
+			T(result.ErrorType.Label()),
+			punycode(result),
+			formatFamily(result),
+			FormatCNAMEChain(result.CNAMEs),
+			formatASN(result.ASN),
+			result.ASOrg,
+			result.Country,
+			result.Cloud,
+			FormatPorts(result.OpenPorts),
+			FormatServices(result.Services),
+			FormatPaths(result.Paths),
+			FormatCORS(result.CORS),
+			FormatMethods(result.Methods, result.RiskyMethods),
+			"",
+		}
+		for i, value := range values {
+			if _, ok := value.(excelize.Cell); !ok {
+				values[i] = excelize.Cell{StyleID: contentStyle, Value: value}
 			}
 		}
-
-		// 设置状态样式
-		statusClass := "status-dead"
-		domainStatus := "无法访问"
-		if result.Alive {
-			statusClass = "status-alive"
-			domainStatus = "可访问"
+		cell, _ := excelize.CoordinatesToCellName(1, row)
+		if err := sw.SetRow(cell, values); err != nil {
+			return nil, err
 		}
+		row++
+	}
+	if err := formatResultsSheet(f, sheetName, len(headers), row-2); err != nil {
+		logger.Warn("设置Excel条件格式和筛选失败", "sheet", sheetName, "error", err)
+	}
+	if err := sw.Flush(); err != nil {
+		return nil, err
+	}
+	return withScreenshots, nil
+}
 
-		// 添加到结果列表
-		data.Results = append(data.Results, TemplateResult{
-			Domain:       result.Domain,
-			DomainLink:   domainLink,
-			StatusClass:  statusClass,
-			DomainStatus: domainStatus,
-			StatusText:   result.StatusText,
-			Status:       result.Status,
-			ResponseTime: result.ResponseTime.Seconds() * 1000,
-			PageType:     pageType,
-			Title:        title,
-			Message:      result.Message,
-			Screenshot:   template.URL(screenshot),
-			Alive:        result.Alive,
-		})
+// 截图在报告中的相对路径，使用正斜杠并以screenshots/开头。
+// 按扫描分目录保存的截图保留子目录（如 screenshots/20240501-100000/xxx.png），其他路径只取文件名
+func reportScreenshotPath(path string) string {
+	path = filepath.ToSlash(filepath.Clean(filepath.FromSlash(path)))
+	if strings.HasPrefix(path, "screenshots/") {
+		return path
+	}
+	return "screenshots/" + filepath.Base(filepath.FromSlash(path))
+}
 
-		// 在主表中添加"查看截图"超链接
-		if result.Screenshot != "" {
-			f.SetCellValue(sheetName, fmt.Sprintf("H%d", row), "查看截图")
-			linkStyle, _ := f.NewStyle(&excelize.Style{
-				Font: &excelize.Font{
-					Color:     "#0563C1",
-					Underline: "single",
-				},
-				Border: []excelize.Border{
-					{Type: "left", Color: "#000000", Style: 1},
-					{Type: "right", Color: "#000000", Style: 1},
-					{Type: "top", Color: "#000000", Style: 1},
-					{Type: "bottom", Color: "#000000", Style: 1},
-				},
-				Alignment: &excelize.Alignment{
-					Horizontal: "center",
-				},
-			})
-			f.SetCellStyle(sheetName, fmt.Sprintf("H%d", row), fmt.Sprintf("H%d", row), linkStyle)
-			f.SetCellHyperLink(sheetName, fmt.Sprintf("H%d", row), screenshot, "External")
-		} else {
-			f.SetCellValue(sheetName, fmt.Sprintf("H%d", row), "无截图")
-			f.SetCellStyle(sheetName, fmt.Sprintf("H%d", row), fmt.Sprintf("H%d", row), contentStyle)
-		}
+// 处理标题编码：非UTF-8标题检测字符集后转换为UTF-8
+func excelTitle(title string) string {
+	title, _ = utils.ToUTF8(title, "")
+	return title
+}
+
+// 截图在截图表中的显示选项
+var screenshotGraphicOptions = &excelize.GraphicOptions{
+	ScaleX:          0.3,  // 将图片缩小到30%（原来是10%）
+	ScaleY:          0.3,  // 将图片缩小到30%（原来是10%）
+	LockAspectRatio: true, // 锁定宽高比
+	Positioning:     "oneCell",
+}
+
+// 将截图插入单元格。Excel不支持的格式（如WebP）、扩展名与内容不符或文件部分损坏时，
+// 先解码并重新编码为PNG再插入
+func addScreenshotPicture(f *excelize.File, sheet, cell, path string) error {
+	err := f.AddPicture(sheet, cell, path, screenshotGraphicOptions)
+	if err == nil {
+		return nil
+	}
+	data, reencodeErr := reencodePNG(path)
+	if reencodeErr != nil {
+		return fmt.Errorf("%v；重新编码为PNG失败: %v", err, reencodeErr)
+	}
+	return f.AddPictureFromBytes(sheet, cell, &excelize.Picture{
+		Extension: ".png",
+		File:      data,
+		Format:    screenshotGraphicOptions,
+	})
+}
+
+// 解码图片文件（PNG、JPEG、GIF、WebP、BMP）并重新编码为PNG
+func reencodePNG(path string) ([]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	img, _, err := image.Decode(file)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
 
-		// 在截图表中添加域名和截图
-		f.SetCellValue(screenshotSheet, fmt.Sprintf("A%d", screenshotRow), result.Domain)
+// 写入截图工作表：embed为true时内嵌图片，否则写入指向截图文件的链接
+func writeScreenshotSheet(f *excelize.File, sheet string, headerStyle, contentStyle int, results []checker.Result, embed bool) {
+	f.NewSheet(sheet)
+	f.SetCellValue(sheet, "A1", T("域名"))
+	f.SetCellValue(sheet, "B1", T("截图"))
+	f.SetCellStyle(sheet, "A1", "B1", headerStyle)
+
+	row := 2 // 截图表从第二行开始
+	for _, result := range results {
+		screenshot := reportScreenshotPath(result.Screenshot)
+		f.SetCellValue(sheet, fmt.Sprintf("A%d", row), result.DisplayDomain())
 
 		// 如果文件存在，添加图片
-		if _, err := os.Stat(result.Screenshot); err == nil {
+		if _, err := os.Stat(result.Screenshot); err == nil && !embed {
+			f.SetCellValue(sheet, fmt.Sprintf("B%d", row), screenshot)
+			f.SetCellHyperLink(sheet, fmt.Sprintf("B%d", row), screenshot, "External")
+		} else if err == nil {
 			// 设置行高以适应图片
-			f.SetRowHeight(screenshotSheet, screenshotRow, 300)
-			// 添加图片
-			if err := f.AddPicture(screenshotSheet, fmt.Sprintf("B%d", screenshotRow), result.Screenshot, &excelize.GraphicOptions{
-				ScaleX:          0.3,  // 将图片缩小到30%（原来是10%）
-				ScaleY:          0.3,  // 将图片缩小到30%（原来是10%）
-				LockAspectRatio: true, // 锁定宽高比
-				Positioning:     "oneCell",
-			}); err != nil {
-				fmt.Printf("添加图片到Excel时出错: %s\n", err)
+			f.SetRowHeight(sheet, row, 300)
+			// 添加图片，失败时重新编码为PNG再试，仍然失败则写入提示
+			if err := addScreenshotPicture(f, sheet, fmt.Sprintf("B%d", row), result.Screenshot); err != nil {
+				logger.Warn("添加截图到Excel时出错", "screenshot", result.Screenshot, "error", err)
+				f.SetRowHeight(sheet, row, 15)
+				f.SetCellValue(sheet, fmt.Sprintf("B%d", row), T("无法获取截图"))
 			}
 		} else {
-			f.SetCellValue(screenshotSheet, fmt.Sprintf("B%d", screenshotRow), "无法获取截图")
+			f.SetCellValue(sheet, fmt.Sprintf("B%d", row), T("无法获取截图"))
 		}
 
 		// 设置单元格样式
-		f.SetCellStyle(screenshotSheet, fmt.Sprintf("A%d", screenshotRow), fmt.Sprintf("A%d", screenshotRow), contentStyle)
-		f.SetCellStyle(screenshotSheet, fmt.Sprintf("B%d", screenshotRow), fmt.Sprintf("B%d", screenshotRow), contentStyle)
-
-		screenshotRow++
+		f.SetCellStyle(sheet, fmt.Sprintf("A%d", row), fmt.Sprintf("B%d", row), contentStyle)
 		row++
 	}
 
-	// 自动调整列宽
-	for i := range headers {
-		col, _ := excelize.ColumnNumberToName(i + 1)
-		f.SetColWidth(sheetName, col, col, 20)
-	}
-	f.SetColWidth(screenshotSheet, "A", "A", 40)
-	f.SetColWidth(screenshotSheet, "B", "B", 200) // 加宽截图列以便更好地显示截图（原来是150）
-
-	// 冻结表头
-	f.SetPanes(sheetName, &excelize.Panes{
-		Freeze:      true,
-		Split:       false,
-		XSplit:      0,
-		YSplit:      1,
-		TopLeftCell: "A2",
-		ActivePane:  "bottomLeft",
-	})
-	f.SetPanes(screenshotSheet, &excelize.Panes{
+	f.SetColWidth(sheet, "A", "A", 40)
+	f.SetColWidth(sheet, "B", "B", 200) // 加宽截图列以便更好地显示截图（原来是150）
+	f.SetPanes(sheet, &excelize.Panes{
 		Freeze:      true,
 		Split:       false,
 		XSplit:      0,
@@ -375,42 +652,248 @@ func SaveResultsToExcel(results []checker.Result, filename string, onlyAlive boo
 		TopLeftCell: "A2",
 		ActivePane:  "bottomLeft",
 	})
+}
 
-	// 保存文件
-	if err := f.SaveAs(filename); err != nil {
-		return err
+// 写入扫描配置快照到隐藏工作表，便于报告接收方核对扫描方式（右键工作表标签"取消隐藏"即可查看）
+func writeConfigSheet(f *excelize.File, sheet string, headerStyle int) {
+	if len(configSnapshot) == 0 {
+		return
 	}
+	f.NewSheet(sheet)
+	f.SetCellValue(sheet, "A1", "参数")
+	f.SetCellValue(sheet, "B1", "生效值")
+	f.SetCellValue(sheet, "C1", "来源")
+	f.SetCellStyle(sheet, "A1", "C1", headerStyle)
 
-	return nil
+	for i, entry := range configSnapshot {
+		row := i + 2
+		source := "默认值/配置文件"
+		if entry.Explicit {
+			source = "命令行"
+		}
+		f.SetCellValue(sheet, fmt.Sprintf("A%d", row), "-"+entry.Name)
+		f.SetCellValue(sheet, fmt.Sprintf("B%d", row), entry.Value)
+		f.SetCellValue(sheet, fmt.Sprintf("C%d", row), source)
+	}
+	f.SetColWidth(sheet, "A", "A", 24)
+	f.SetColWidth(sheet, "B", "B", 60)
+	f.SetColWidth(sheet, "C", "C", 16)
+	f.SetSheetVisible(sheet, false)
+}
+
+// 写入路径探测工作表，没有路径探测结果时不生成
+func writePathsSheet(f *excelize.File, sheet string, headerStyle int, results []checker.Result) {
+	row := 2
+	for _, result := range results {
+		for _, path := range result.Paths {
+			if row == 2 {
+				f.NewSheet(sheet)
+				for i, header := range translateAll([]string{"域名", "路径", "状态码", "长度", "标题", "错误", "软404"}) {
+					cell, _ := excelize.CoordinatesToCellName(i+1, 1)
+					f.SetCellValue(sheet, cell, header)
+				}
+				f.SetCellStyle(sheet, "A1", "G1", headerStyle)
+				f.SetColWidth(sheet, "A", "A", 40)
+				f.SetColWidth(sheet, "B", "B", 30)
+				f.SetColWidth(sheet, "C", "D", 10)
+				f.SetColWidth(sheet, "E", "F", 30)
+			}
+			f.SetCellValue(sheet, fmt.Sprintf("A%d", row), result.DisplayDomain())
+			f.SetCellValue(sheet, fmt.Sprintf("B%d", row), path.Path)
+			f.SetCellValue(sheet, fmt.Sprintf("C%d", row), path.Status)
+			f.SetCellValue(sheet, fmt.Sprintf("D%d", row), path.Length)
+			f.SetCellValue(sheet, fmt.Sprintf("E%d", row), excelTitle(path.Title))
+			f.SetCellValue(sheet, fmt.Sprintf("F%d", row), path.Error)
+			if path.Soft404 {
+				f.SetCellValue(sheet, fmt.Sprintf("G%d", row), T("是"))
+			}
+			row++
+		}
+	}
+}
+
+// 写入范围外目标工作表，没有跳过的目标时不生成
+func writeSkippedSheet(f *excelize.File, sheet string, headerStyle int) {
+	if len(skippedTargets) == 0 {
+		return
+	}
+	f.NewSheet(sheet)
+	f.SetCellValue(sheet, "A1", T("目标"))
+	f.SetCellValue(sheet, "B1", T("原因"))
+	f.SetCellStyle(sheet, "A1", "B1", headerStyle)
+	for i, skipped := range skippedTargets {
+		f.SetCellValue(sheet, fmt.Sprintf("A%d", i+2), skipped.Target)
+		f.SetCellValue(sheet, fmt.Sprintf("B%d", i+2), skipped.Reason)
+	}
+	f.SetColWidth(sheet, "A", "A", 40)
+	f.SetColWidth(sheet, "B", "B", 40)
+}
+
+// 写入分组统计工作表（按根域名、IP、失败原因和页面内容分组）
+func writeGroupsSheet(f *excelize.File, sheet string, headerStyle int, results []checker.Result) {
+	f.NewSheet(sheet)
+	headers := []string{"分组方式", "分组", "域名数", "存活数", "域名列表"}
+	for i, header := range headers {
+		cell, _ := excelize.CoordinatesToCellName(i+1, 1)
+		f.SetCellValue(sheet, cell, header)
+	}
+	f.SetCellStyle(sheet, "A1", "E1", headerStyle)
+
+	row := 2
+	writeGroups := func(kind string, groups []ResultGroup) {
+		for _, group := range groups {
+			domains := make([]string, 0, len(group.Members))
+			for _, member := range group.Members {
+				domains = append(domains, member.Domain)
+			}
+			f.SetCellValue(sheet, fmt.Sprintf("A%d", row), kind)
+			f.SetCellValue(sheet, fmt.Sprintf("B%d", row), group.Key)
+			f.SetCellValue(sheet, fmt.Sprintf("C%d", row), group.Total)
+			f.SetCellValue(sheet, fmt.Sprintf("D%d", row), group.Alive)
+			f.SetCellValue(sheet, fmt.Sprintf("E%d", row), strings.Join(domains, "\n"))
+			row++
+		}
+	}
+	writeGroups("根域名", GroupByRootDomain(results))
+	writeGroups("IP", GroupByIP(results))
+	writeGroups("失败原因", GroupByErrorCategory(results))
+	writeGroups("页面内容", GroupByContent(results))
+
+	f.SetColWidth(sheet, "A", "A", 12)
+	f.SetColWidth(sheet, "B", "B", 30)
+	f.SetColWidth(sheet, "C", "D", 10)
+	f.SetColWidth(sheet, "E", "E", 80)
+}
+
+// 将重定向链格式化为 "url (301) -> url (200)" 形式
+func FormatRedirectChain(hops []checker.RedirectHop) string {
+	parts := make([]string, 0, len(hops))
+	for _, hop := range hops {
+		parts = append(parts, fmt.Sprintf("%s (%d)", hop.URL, hop.Status))
+	}
+	return strings.Join(parts, " -> ")
 }
 
 // 定义模板数据结构
+// 截图相同的页面分组，Screenshot为代表页面的截图
+type ScreenshotGroup struct {
+	ResultGroup
+	Screenshot template.URL
+}
+
 type TemplateData struct {
-	TotalDomains int
-	AliveDomains int
-	DeadDomains  int
-	ReportTime   string
-	Results      []TemplateResult
+	TotalDomains     int
+	AliveDomains     int
+	DeadDomains      int
+	ReportTime       string
+	Results          []TemplateResult
+	RootGroups       []ResultGroup     // 按根域名分组
+	IPGroups         []ResultGroup     // 按IP分组
+	ErrorGroups      []ResultGroup     // 按失败原因分组
+	ContentGroups    []ResultGroup     // 按页面内容聚类的相同/近似页面
+	ScreenshotGroups []ScreenshotGroup // 按截图感知哈希聚类的视觉上相同的页面
+	Skipped          []SkippedTarget   // 不在扫描范围内而跳过的目标
+	Latency          *LatencyStats     // 响应时间统计，没有存活域名时为nil
+	DomainRecords    []DomainRecordRow // 根域名注册信息（-whois）
+	ZoneTransfers    []ZoneTransferRow // 允许域传送的根域名（-axfr）
+	RealAlive        int               // 不是占位页面的存活域名数
 }
 
 // 定义单个域名结果的数据结构
 type TemplateResult struct {
-	Domain       string
-	DomainLink   string
-	StatusClass  string
-	DomainStatus string
-	StatusText   string
-	Status       int
-	ResponseTime float64
-	PageType     string
-	Title        string
-	Message      string
-	Screenshot   template.URL
-	Alive        bool
+	Domain          string
+	DomainLink      string
+	StatusClass     string
+	DomainStatus    string
+	StatusText      string
+	Status          int
+	ResponseTime    float64
+	PageType        string
+	Title           string
+	Message         string
+	Screenshot      template.URL
+	Alive           bool
+	Matches         []checker.Match       // 关键词命中证据
+	Note            string                // 输入文件中的备注
+	FinalURL        string                // 最终落地的URL
+	Redirects       []checker.RedirectHop // 重定向链
+	CheckedAt       string                // 检测时间
+	ErrorType       string                // 失败类型
+	Punycode        string                // 国际化域名的punycode形式
+	IPFamily        string                // 应答的地址族（或各地址族的检测结果）
+	CNAMEs          []string              // CNAME链
+	Dangling        bool                  // 悬挂CNAME
+	ASN             string                // IP所属的自治系统号
+	ASOrg           string                // 自治系统所属组织
+	Country         string                // IP所在国家或地区
+	Cloud           string                // IP所属的云服务商
+	OpenPorts       string                // 开放的TCP端口
+	Services        []checker.Service     // 开放端口上识别出的服务
+	Robots          *checker.RobotsInfo   // robots.txt 和 sitemap.xml 的收集结果
+	Paths           []checker.PathResult  // 路径探测结果
+	Soft404         bool                  // 主机对不存在的路径也返回成功
+	RawResponse     string                // 保存的原始响应文件
+	ClientCert      string                // 服务器对客户端证书的要求
+	TLS             string                // 协商的TLS版本和加密套件
+	JARM            string                // JARM指纹
+	JA3S            string                // JA3S指纹
+	JARMMatch       string                // 命中的已知JARM指纹说明
+	OCRText         string                // 从截图中识别出的文字
+	Placeholder     string                // 占位页面的类别
+	ContentType     string                // 非HTML响应的媒体类型，HTML页面为空
+	LoginForm       bool                  // 含有登录表单
+	UploadForm      bool                  // 含有文件上传表单
+	RedirectParams  []string              // 疑似开放重定向的参数名
+	CORS            *checker.CORSInfo     // CORS检测结果
+	Methods         []string              // 允许的HTTP方法
+	RiskyMethods    []string              // 其中有风险的方法
+	Headers         []checker.HeaderField // 响应头
+	Cookies         []checker.CookieField // 响应设置的Cookie
+	SameScreenshot  string                // 截图与该域名的截图相同时为代表域名，此时不再重复显示截图
+	ScreenshotCount int                   // 作为代表截图时，截图相同的页面数
 }
 
 // 保存结果到HTML文件（简化版）
-func SaveResultsToSimpleHTML(results []checker.Result, filename string, onlyAlive bool) error {
+// 预计大小超过上限时先改为链接截图，仍然超出则按上限拆分为多个分片文件
+func SaveResultsToSimpleHTML(results []checker.Result, filename string) error {
+	if maxHTMLBytes <= 0 {
+		return writeHTMLReport(results, filename, true)
+	}
+
+	est := EstimateHTMLSize(results, true)
+	if est.Bytes <= maxHTMLBytes {
+		return writeHTMLReport(results, filename, true)
+	}
+	fmt.Fprintf(utils.Console, "⚠️  预计HTML报告大小 %s（%d行, %d张截图）超过上限 %s，截图将以链接形式引用\n",
+		FormatBytes(est.Bytes), est.Rows, est.Images, FormatBytes(maxHTMLBytes))
+
+	est = EstimateHTMLSize(results, false)
+	if est.Bytes <= maxHTMLBytes {
+		return writeHTMLReport(results, filename, false)
+	}
+
+	// 按上限拆分为多个分片
+	exported := results
+	shards := int((est.Bytes + maxHTMLBytes - 1) / maxHTMLBytes)
+	perShard := (len(exported) + shards - 1) / shards
+	fmt.Fprintf(utils.Console, "⚠️  链接模式下仍超出上限，报告将拆分为 %d 个分片\n", shards)
+	for part := 1; part <= shards; part++ {
+		start := (part - 1) * perShard
+		if start >= len(exported) {
+			break
+		}
+		end := min(start+perShard, len(exported))
+		shardFile := shardFilename(filename, part)
+		if err := writeHTMLReport(exported[start:end], shardFile, false); err != nil {
+			return err
+		}
+		fmt.Fprintf(utils.Console, "📄 已写入分片 %s（%d条结果）\n", shardFile, end-start)
+	}
+	return nil
+}
+
+// 写入单个HTML报告文件，embed为true时截图以base64内嵌，否则引用相对路径
+func writeHTMLReport(results []checker.Result, filename string, embed bool) error {
 	// 创建HTML文件
 	file, err := os.Create(filename)
 	if err != nil {
@@ -421,21 +904,27 @@ func SaveResultsToSimpleHTML(results []checker.Result, filename string, onlyAliv
 	// 写入UTF-8 BOM
 	file.Write([]byte{0xEF, 0xBB, 0xBF})
 
+	return renderHTMLReport(file, results, embed)
+}
+
+// 使用HTML模板渲染报告，写入文件或作为查看服务器的响应
+func renderHTMLReport(w io.Writer, results []checker.Result, embed bool) error {
 	// 计算统计信息并准备模板数据
 	data := TemplateData{
-		ReportTime: time.Now().Format("2006-01-02 15:04:05"),
+		ReportTime:    reportTime(),
+		Skipped:       skippedTargets,
+		DomainRecords: domainRecordRows(),
+		ZoneTransfers: zoneTransferRows(),
 	}
 
 	// 处理结果数据
 	for _, result := range results {
-		// 如果只显示存活域名，跳过非存活的
-		if onlyAlive && !result.Alive {
-			continue
-		}
-
 		data.TotalDomains++
 		if result.Alive {
 			data.AliveDomains++
+			if result.Placeholder == "" {
+				data.RealAlive++
+			}
 		}
 
 		// 准备单个结果数据
@@ -457,51 +946,99 @@ func SaveResultsToSimpleHTML(results []checker.Result, filename string, onlyAliv
 			domainLink = "http://" + domainLink
 		}
 
-		// 处理截图路径 - 转换为base64 data URI内嵌到HTML中
+		// 处理截图路径 - 转换为base64 data URI内嵌到HTML中，链接模式下使用相对路径
 		screenshot := ""
 		if result.Screenshot != "" {
-			screenshotFile := filepath.Join("screenshots", filepath.Base(result.Screenshot))
-			screenshot = screenshotToDataURI(screenshotFile)
-		}
-
-		// 处理标题编码
-		title := result.Title
-		if title != "" {
-			// 尝试检测并转换编码
-			if !utf8.ValidString(title) {
-				// 尝试从GBK转换到UTF-8
-				reader := transform.NewReader(strings.NewReader(title), simplifiedchinese.GBK.NewDecoder())
-				if d, err := io.ReadAll(reader); err == nil {
-					title = string(d)
-				}
+			screenshotFile := reportScreenshotPath(result.Screenshot)
+			if embed {
+				screenshot = screenshotToDataURI(filepath.FromSlash(screenshotFile))
+			} else {
+				screenshot = screenshotFile
 			}
 		}
 
 		data.Results = append(data.Results, TemplateResult{
-			Domain:       result.Domain,
-			DomainLink:   domainLink,
-			StatusClass:  statusClass,
-			DomainStatus: domainStatus,
-			StatusText:   result.StatusText,
-			Status:       result.Status,
-			ResponseTime: result.ResponseTime.Seconds() * 1000,
-			PageType:     pageType,
-			Title:        title,
-			Message:      result.Message,
-			Screenshot:   template.URL(screenshot),
-			Alive:        result.Alive,
+			Domain:         result.DisplayDomain(),
+			Punycode:       punycode(result),
+			IPFamily:       formatFamily(result),
+			CNAMEs:         result.CNAMEs,
+			Dangling:       result.DanglingCNAME,
+			ASN:            formatASN(result.ASN),
+			ASOrg:          result.ASOrg,
+			Country:        result.Country,
+			Cloud:          result.Cloud,
+			OpenPorts:      FormatPorts(result.OpenPorts),
+			Services:       result.Services,
+			Robots:         result.Robots,
+			Paths:          result.Paths,
+			Soft404:        result.Soft404,
+			RawResponse:    result.RawResponse,
+			ClientCert:     clientCertLabel(result.ClientCert),
+			TLS:            formatTLS(result),
+			JARM:           result.JARM,
+			JA3S:           result.JA3S,
+			JARMMatch:      result.JARMMatch,
+			OCRText:        result.OCRText,
+			Placeholder:    result.Placeholder,
+			ContentType:    nonHTMLContentType(result.ContentType),
+			LoginForm:      result.LoginForm,
+			UploadForm:     result.UploadForm,
+			RedirectParams: result.RedirectParams,
+			CORS:           result.CORS,
+			Methods:        result.Methods,
+			RiskyMethods:   result.RiskyMethods,
+			Headers:        result.Headers,
+			Cookies:        result.Cookies,
+			DomainLink:     domainLink,
+			StatusClass:    statusClass,
+			DomainStatus:   domainStatus,
+			StatusText:     result.StatusText,
+			Status:         result.Status,
+			ResponseTime:   result.ResponseTime.Seconds() * 1000,
+			PageType:       pageType,
+			Title:          result.Title,
+			Message:        result.Message,
+			Screenshot:     template.URL(screenshot),
+			Alive:          result.Alive,
+			Note:           result.Note,
+			FinalURL:       result.FinalURL,
+			Redirects:      result.RedirectChain,
+			CheckedAt:      FormatCheckedAt(result.CheckedAt),
+			ErrorType:      result.ErrorType.Label(),
+			Matches:        result.Matches,
 		})
 	}
 	data.DeadDomains = data.TotalDomains - data.AliveDomains
+	data.RootGroups = GroupByRootDomain(results)
+	data.IPGroups = GroupByIP(results)
+	data.ErrorGroups = GroupByErrorCategory(results)
+	data.ContentGroups = GroupByContent(results)
+	data.Latency = latencyStats(results)
+
+	// 视觉上相同的截图只在代表页面中显示，其他页面标注与代表页面相同
+	for _, cluster := range clusterScreenshots(results) {
+		rep := &data.Results[cluster.Rep]
+		rep.ScreenshotCount = len(cluster.Members)
+		members := make([]checker.Result, 0, len(cluster.Members))
+		for _, i := range cluster.Members {
+			members = append(members, results[i])
+			if i != cluster.Rep {
+				data.Results[i].SameScreenshot = rep.Domain
+				data.Results[i].Screenshot = ""
+			}
+		}
+		group := groupResults(members, func(checker.Result) string { return rep.Domain })[0]
+		data.ScreenshotGroups = append(data.ScreenshotGroups, ScreenshotGroup{ResultGroup: group, Screenshot: rep.Screenshot})
+	}
 
 	// 解析模板文件
-	tmpl, err := template.ParseFiles("view/template.html")
+	tmpl, err := parseReportTemplate(templateDir)
 	if err != nil {
 		return fmt.Errorf("解析模板文件失败: %v", err)
 	}
 
 	// 执行模板并写入结果
-	if err := tmpl.Execute(file, data); err != nil {
+	if err := tmpl.Execute(w, data); err != nil {
 		return fmt.Errorf("执行模板失败: %v", err)
 	}
 
@@ -509,6 +1046,6 @@ func SaveResultsToSimpleHTML(results []checker.Result, filename string, onlyAliv
 }
 
 // 保存结果到HTML文件（带详细信息）
-func SaveResultsToHTML(results []checker.Result, filename string, onlyAlive bool) error {
-	return SaveResultsToSimpleHTML(results, filename, onlyAlive)
+func SaveResultsToHTML(results []checker.Result, filename string) error {
+	return SaveResultsToSimpleHTML(results, filename)
 }
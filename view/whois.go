@@ -0,0 +1,118 @@
+package view
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+
+	"subdomain-checker/utils"
+	"subdomain-checker/whois"
+)
+
+// 根域名的注册信息（-whois），为空时报告中不显示
+var domainRecords []whois.Record
+
+// 设置报告中显示的根域名注册信息
+func SetDomainRecords(records []whois.Record) {
+	domainRecords = records
+}
+
+// 报告中显示的一行注册信息
+type DomainRecordRow struct {
+	Domain    string
+	Registrar string
+	Created   string
+	Expires   string
+	Expiring  bool   // 30天内到期或已过期
+	Recent    bool   // 注册不足90天
+	Error     string // 查询失败的原因
+}
+
+// 判断到期和新注册使用的当前时间，确定性模式下为固定的报告时间
+func recordsNow() time.Time {
+	if !fixedReportTime.IsZero() {
+		return fixedReportTime
+	}
+	return time.Now()
+}
+
+// 转换为报告中显示的行，日期只保留年月日
+func domainRecordRows() []DomainRecordRow {
+	now := recordsNow()
+	rows := make([]DomainRecordRow, 0, len(domainRecords))
+	for _, record := range domainRecords {
+		rows = append(rows, DomainRecordRow{
+			Domain:    record.Domain,
+			Registrar: record.Registrar,
+			Created:   formatDate(record.Created),
+			Expires:   formatDate(record.Expires),
+			Expiring:  record.Expiring(now),
+			Recent:    record.Recent(now),
+			Error:     record.Error,
+		})
+	}
+	return rows
+}
+
+func formatDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format("2006-01-02")
+}
+
+// 写入域名注册信息工作表，没有查询注册信息时不生成
+func writeDomainRecordsSheet(f *excelize.File, sheet string, headerStyle int) {
+	if len(domainRecords) == 0 {
+		return
+	}
+	f.NewSheet(sheet)
+	headers := translateAll([]string{"根域名", "注册商", "注册日期", "到期日期", "提示", "查询错误"})
+	for i, header := range headers {
+		cell, _ := excelize.CoordinatesToCellName(i+1, 1)
+		f.SetCellValue(sheet, cell, header)
+	}
+	f.SetCellStyle(sheet, "A1", "F1", headerStyle)
+	for i, row := range domainRecordRows() {
+		var notes []string
+		if row.Expiring {
+			notes = append(notes, T("即将到期"))
+		}
+		if row.Recent {
+			notes = append(notes, T("新注册"))
+		}
+		values := []any{row.Domain, row.Registrar, row.Created, row.Expires, strings.Join(notes, T("、")), row.Error}
+		for j, value := range values {
+			cell, _ := excelize.CoordinatesToCellName(j+1, i+2)
+			f.SetCellValue(sheet, cell, value)
+		}
+	}
+	f.SetColWidth(sheet, "A", "B", 30)
+	f.SetColWidth(sheet, "C", "E", 14)
+	f.SetColWidth(sheet, "F", "F", 40)
+}
+
+// 控制台总结中的注册信息提示：即将到期和新注册的根域名
+func printDomainRecords() {
+	if len(domainRecords) == 0 {
+		return
+	}
+	now := recordsNow()
+	fmt.Fprintln(utils.Console, Tf("域名注册信息: %s 个根域名", FormatCount(len(domainRecords))))
+	failed := 0
+	for _, record := range domainRecords {
+		switch {
+		case record.Error != "":
+			failed++
+		case record.Expiring(now):
+			fmt.Fprintln(utils.Console, "  ⏳ "+Tf("%s 将于 %s 到期（注册商: %s）", record.Domain, formatDate(record.Expires), record.Registrar))
+		case record.Recent(now):
+			fmt.Fprintln(utils.Console, "  🆕 "+Tf("%s 注册于 %s（注册商: %s）", record.Domain, formatDate(record.Created), record.Registrar))
+		}
+	}
+	if failed > 0 {
+		fmt.Fprintln(utils.Console, "  "+Tf("%d 个根域名的注册信息查询失败", failed))
+	}
+}
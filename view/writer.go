@@ -0,0 +1,106 @@
+package view
+
+import (
+	"subdomain-checker/checker"
+)
+
+// 逐条写入结果的输出器：每个经过流水线处理的结果调用一次Write，
+// 全部结果写入后（包括中途出错时）调用一次Flush完成文件并释放资源
+type ResultWriter interface {
+	Write(result checker.Result) error
+	Flush() error
+}
+
+// 为输出文件创建写入器
+type WriterFactory func(filename string, opts WriteOptions) (ResultWriter, error)
+
+// 为只提供整体写入函数的格式缓存结果，Flush时一次性写入
+type bufferedWriter struct {
+	write    WriteFunc
+	filename string
+	opts     WriteOptions
+	rows     []checker.Result
+}
+
+func (w *bufferedWriter) Write(result checker.Result) error {
+	w.rows = append(w.rows, result)
+	return nil
+}
+
+func (w *bufferedWriter) Flush() error {
+	return w.write(w.rows, w.filename, w.opts)
+}
+
+// 创建格式的写入器：优先使用格式提供的逐条写入器，否则缓存结果后交给写入函数
+func (f Format) Writer(filename string, opts WriteOptions) (ResultWriter, error) {
+	if f.NewWriter != nil {
+		return f.NewWriter(filename, opts)
+	}
+	return &bufferedWriter{write: f.Write, filename: filename, opts: opts}, nil
+}
+
+// 将处理好的结果写入文件：有整体写入函数时直接调用，否则逐条交给写入器
+func (f Format) writeRows(rows []checker.Result, filename string, opts WriteOptions) error {
+	if f.Write != nil {
+		return f.Write(rows, filename, opts)
+	}
+	w, err := f.Writer(filename, opts)
+	if err != nil {
+		return err
+	}
+	return writeEach(w, rows)
+}
+
+// 逐条写入后Flush，出错时仍然Flush以关闭文件，返回第一个错误
+func writeEach(w ResultWriter, rows []checker.Result) error {
+	for _, row := range rows {
+		if err := w.Write(row); err != nil {
+			w.Flush()
+			return err
+		}
+	}
+	return w.Flush()
+}
+
+// 一次遍历结果写入多个输出，返回与outputs一一对应的错误；
+// 需要拆分的输出要用到完整的结果列表，仍单独写入
+func WriteAll(outputs []Output, results []checker.Result, opts WriteOptions) []error {
+	errs := make([]error, len(outputs))
+	type active struct {
+		index    int
+		pipeline Pipeline
+		writer   ResultWriter
+	}
+	var writers []active
+	for i, o := range outputs {
+		if opts.Split > 0 && o.Format.Splittable {
+			errs[i] = o.Write(results, opts)
+			continue
+		}
+		var w ResultWriter
+		errs[i] = SafeWrite(func() (err error) {
+			w, err = o.Format.Writer(o.Filename, opts)
+			return err
+		})
+		if errs[i] == nil {
+			writers = append(writers, active{index: i, pipeline: o.pipeline(opts), writer: w})
+		}
+	}
+
+	for _, result := range results {
+		for _, a := range writers {
+			if errs[a.index] != nil {
+				continue
+			}
+			if row, ok := a.pipeline.process(result); ok {
+				errs[a.index] = SafeWrite(func() error { return a.writer.Write(row) })
+			}
+		}
+	}
+	for _, a := range writers {
+		if err := SafeWrite(a.writer.Flush); errs[a.index] == nil {
+			errs[a.index] = err
+		}
+	}
+	return errs
+}
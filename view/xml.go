@@ -0,0 +1,543 @@
+package view
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"subdomain-checker/checker"
+)
+
+// XML输出中的单条结果，字段与JSON导出对应
+type xmlResult struct {
+	Domain         string        `xml:"domain"`
+	UnicodeDomain  string        `xml:"unicode_domain,omitempty"`
+	Alive          bool          `xml:"alive,attr"`
+	Status         int           `xml:"status"`
+	StatusText     string        `xml:"status_text"`
+	Message        string        `xml:"message,omitempty"`
+	ErrorType      string        `xml:"error_type,omitempty"`
+	ResponseTime   string        `xml:"response_time_ms"`
+	Title          string        `xml:"title,omitempty"`
+	PageType       string        `xml:"page_type,omitempty"`
+	IP             string        `xml:"ip,omitempty"`
+	IPFamily       string        `xml:"ip_family,omitempty"`
+	FinalURL       string        `xml:"final_url,omitempty"`
+	Redirects      *xmlRedirects `xml:"redirect_chain"`
+	CNAMEs         *xmlCNAMEs    `xml:"cnames"`
+	DanglingCNAME  bool          `xml:"dangling_cname,omitempty"`
+	Soft404        bool          `xml:"soft_404,omitempty"`
+	CORS           *xmlCORS      `xml:"cors"`
+	Methods        *xmlMethods   `xml:"methods"`
+	Headers        *xmlHeaders   `xml:"headers"`
+	Cookies        *xmlCookies   `xml:"cookies"`
+	ASN            uint          `xml:"asn,omitempty"`
+	ASOrg          string        `xml:"as_org,omitempty"`
+	Country        string        `xml:"country,omitempty"`
+	Cloud          string        `xml:"cloud,omitempty"`
+	Services       *xmlServices  `xml:"services"`
+	Paths          *xmlPaths     `xml:"paths"`
+	Screenshot     string        `xml:"screenshot,omitempty"`
+	ScreenshotPath string        `xml:"screenshot_path,omitempty"`
+	RawResponse    string        `xml:"raw_response,omitempty"`
+	ClientCert     string        `xml:"client_cert,omitempty"`
+	TLSVersion     string        `xml:"tls_version,omitempty"`
+	TLSCipher      string        `xml:"tls_cipher,omitempty"`
+	JARM           string        `xml:"jarm,omitempty"`
+	JA3S           string        `xml:"ja3s,omitempty"`
+	JARMMatch      string        `xml:"jarm_match,omitempty"`
+	OCRText        string        `xml:"ocr_text,omitempty"`
+	Placeholder    string        `xml:"placeholder,omitempty"`
+	ContentType    string        `xml:"content_type,omitempty"`
+	Charset        string        `xml:"charset,omitempty"`
+	LoginForm      bool          `xml:"login_form,omitempty"`
+	UploadForm     bool          `xml:"upload_form,omitempty"`
+	OpenRedirect   string        `xml:"open_redirect,omitempty"`
+	Note           string        `xml:"note,omitempty"`
+	CheckedAt      string        `xml:"checked_at,omitempty"`
+}
+
+// 列表元素包装为指针，列表为空时不输出外层元素
+type xmlRedirects struct {
+	Hops []xmlRedirect `xml:"hop"`
+}
+
+type xmlCNAMEs struct {
+	Names []string `xml:"cname"`
+}
+
+type xmlServices struct {
+	Services []xmlService `xml:"service"`
+}
+
+type xmlPaths struct {
+	Paths []xmlPath `xml:"path"`
+}
+
+type xmlRedirect struct {
+	Status int    `xml:"status,attr"`
+	URL    string `xml:",chardata"`
+}
+
+type xmlService struct {
+	Port   int    `xml:"port,attr"`
+	Name   string `xml:"name,attr"`
+	Banner string `xml:",chardata"`
+}
+
+type xmlCORS struct {
+	Origin           string `xml:"origin,attr"`
+	AllowOrigin      string `xml:"allow_origin,attr,omitempty"`
+	AllowCredentials bool   `xml:"allow_credentials,attr,omitempty"`
+	Reflected        bool   `xml:"reflected,attr,omitempty"`
+	Permissive       bool   `xml:"permissive,attr,omitempty"`
+}
+
+type xmlMethods struct {
+	Methods []xmlMethod `xml:"method"`
+}
+
+type xmlMethod struct {
+	Name  string `xml:",chardata"`
+	Risky bool   `xml:"risky,attr,omitempty"`
+}
+
+type xmlHeaders struct {
+	Headers []xmlHeader `xml:"header"`
+}
+
+type xmlHeader struct {
+	Name  string `xml:"name,attr"`
+	Note  string `xml:"note,attr,omitempty"`
+	Value string `xml:",chardata"`
+}
+
+type xmlCookies struct {
+	Cookies []xmlCookie `xml:"cookie"`
+}
+
+type xmlCookie struct {
+	Name     string `xml:"name,attr"`
+	Domain   string `xml:"domain,attr,omitempty"`
+	Path     string `xml:"path,attr,omitempty"`
+	Secure   bool   `xml:"secure,attr,omitempty"`
+	HttpOnly bool   `xml:"http_only,attr,omitempty"`
+	SameSite string `xml:"same_site,attr,omitempty"`
+	Note     string `xml:"note,attr,omitempty"`
+	Value    string `xml:",chardata"`
+}
+
+type xmlPath struct {
+	Path    string `xml:"path,attr"`
+	Status  int    `xml:"status,attr"`
+	Length  int    `xml:"length,attr"`
+	Title   string `xml:"title,attr,omitempty"`
+	Error   string `xml:"error,attr,omitempty"`
+	Soft404 bool   `xml:"soft404,attr,omitempty"`
+}
+
+// 将结果转换为XML记录
+func newXMLResult(result checker.Result) xmlResult {
+	record := xmlResult{
+		Domain:         result.Domain,
+		UnicodeDomain:  result.UnicodeDomain,
+		Alive:          result.Alive,
+		Status:         result.Status,
+		StatusText:     result.StatusText,
+		Message:        result.Message,
+		ErrorType:      string(result.ErrorType),
+		ResponseTime:   strconv.FormatFloat(result.ResponseTime.Seconds()*1000, 'f', 2, 64),
+		Title:          result.Title,
+		IP:             result.IP,
+		IPFamily:       result.IPFamily,
+		FinalURL:       result.FinalURL,
+		DanglingCNAME:  result.DanglingCNAME,
+		Soft404:        result.Soft404,
+		ASN:            result.ASN,
+		ASOrg:          result.ASOrg,
+		Country:        result.Country,
+		Cloud:          result.Cloud,
+		Screenshot:     result.Screenshot,
+		ScreenshotPath: result.ScreenshotPath,
+		RawResponse:    result.RawResponse,
+		ClientCert:     result.ClientCert,
+		TLSVersion:     result.TLSVersion,
+		TLSCipher:      result.TLSCipher,
+		JARM:           result.JARM,
+		JA3S:           result.JA3S,
+		JARMMatch:      result.JARMMatch,
+		OCRText:        result.OCRText,
+		Placeholder:    result.Placeholder,
+		ContentType:    result.ContentType,
+		Charset:        result.Charset,
+		LoginForm:      result.LoginForm,
+		UploadForm:     result.UploadForm,
+		OpenRedirect:   strings.Join(result.RedirectParams, ","),
+		Note:           result.Note,
+	}
+	if result.PageInfo != nil {
+		record.PageType = result.PageInfo.Type
+	}
+	if len(result.RedirectChain) > 0 {
+		record.Redirects = &xmlRedirects{}
+		for _, hop := range result.RedirectChain {
+			record.Redirects.Hops = append(record.Redirects.Hops, xmlRedirect{Status: hop.Status, URL: hop.URL})
+		}
+	}
+	if len(result.CNAMEs) > 0 {
+		record.CNAMEs = &xmlCNAMEs{Names: result.CNAMEs}
+	}
+	if len(result.Services) > 0 {
+		record.Services = &xmlServices{}
+		for _, service := range result.Services {
+			record.Services.Services = append(record.Services.Services, xmlService{Port: service.Port, Name: service.Name, Banner: service.Banner})
+		}
+	}
+	if len(result.Paths) > 0 {
+		record.Paths = &xmlPaths{}
+		for _, path := range result.Paths {
+			record.Paths.Paths = append(record.Paths.Paths, xmlPath{Path: path.Path, Status: path.Status, Length: path.Length, Title: path.Title, Error: path.Error, Soft404: path.Soft404})
+		}
+	}
+	if cors := result.CORS; cors != nil {
+		record.CORS = &xmlCORS{Origin: cors.Origin, AllowOrigin: cors.AllowOrigin, AllowCredentials: cors.AllowCredentials, Reflected: cors.Reflected, Permissive: cors.Permissive}
+	}
+	if len(result.Methods) > 0 {
+		record.Methods = &xmlMethods{}
+		for _, method := range result.Methods {
+			record.Methods.Methods = append(record.Methods.Methods, xmlMethod{Name: method, Risky: checker.IsRiskyMethod(method)})
+		}
+	}
+	if len(result.Headers) > 0 {
+		record.Headers = &xmlHeaders{}
+		for _, header := range result.Headers {
+			record.Headers.Headers = append(record.Headers.Headers, xmlHeader{Name: header.Name, Note: header.Note, Value: header.Value})
+		}
+	}
+	if len(result.Cookies) > 0 {
+		record.Cookies = &xmlCookies{}
+		for _, cookie := range result.Cookies {
+			record.Cookies.Cookies = append(record.Cookies.Cookies, xmlCookie{
+				Name: cookie.Name, Domain: cookie.Domain, Path: cookie.Path, Secure: cookie.Secure,
+				HttpOnly: cookie.HttpOnly, SameSite: cookie.SameSite, Note: cookie.Note, Value: cookie.Value,
+			})
+		}
+	}
+	if !result.CheckedAt.IsZero() {
+		record.CheckedAt = result.CheckedAt.Format(time.RFC3339)
+	}
+	return record
+}
+
+// 保存结果到XML文件
+func SaveResultsToXML(results []checker.Result, filename string) error {
+	report := struct {
+		XMLName xml.Name    `xml:"squirrel"`
+		Time    string      `xml:"time,attr"`
+		Total   int         `xml:"total,attr"`
+		Results []xmlResult `xml:"result"`
+	}{Time: reportTimestamp(), Total: len(results)}
+	for _, result := range results {
+		report.Results = append(report.Results, newXMLResult(result))
+	}
+	return writeXML(filename, "", report)
+}
+
+// 写入带XML声明的文件，doctype不为空时写在声明之后
+func writeXML(filename, doctype string, v any) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if _, err := file.WriteString(xml.Header + doctype); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(file)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		return err
+	}
+	if _, err := file.WriteString("\n"); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// nmap XML（xmloutputversion 1.05）中用到的元素，字段和属性名与nmap -oX保持一致，
+// Faraday、Dradis、Metasploit（db_import）等工具可以直接导入
+type nmapRun struct {
+	XMLName          xml.Name     `xml:"nmaprun"`
+	Scanner          string       `xml:"scanner,attr"`
+	Args             string       `xml:"args,attr"`
+	Start            int64        `xml:"start,attr"`
+	StartStr         string       `xml:"startstr,attr"`
+	Version          string       `xml:"version,attr"`
+	XMLOutputVersion string       `xml:"xmloutputversion,attr"`
+	ScanInfo         nmapScanInfo `xml:"scaninfo"`
+	Hosts            []*nmapHost  `xml:"host"`
+	RunStats         nmapRunStats `xml:"runstats"`
+}
+
+type nmapScanInfo struct {
+	Type        string `xml:"type,attr"`
+	Protocol    string `xml:"protocol,attr"`
+	NumServices int    `xml:"numservices,attr"`
+	Services    string `xml:"services,attr"`
+}
+
+type nmapHost struct {
+	StartTime int64          `xml:"starttime,attr"`
+	EndTime   int64          `xml:"endtime,attr"`
+	Status    nmapStatus     `xml:"status"`
+	Address   nmapAddress    `xml:"address"`
+	Hostnames []nmapHostname `xml:"hostnames>hostname"`
+	Ports     []*nmapPort    `xml:"ports>port"`
+}
+
+type nmapStatus struct {
+	State  string `xml:"state,attr"`
+	Reason string `xml:"reason,attr"`
+}
+
+type nmapAddress struct {
+	Addr     string `xml:"addr,attr"`
+	AddrType string `xml:"addrtype,attr"`
+}
+
+type nmapHostname struct {
+	Name string `xml:"name,attr"`
+	Type string `xml:"type,attr"`
+}
+
+type nmapPort struct {
+	Protocol string       `xml:"protocol,attr"`
+	PortID   int          `xml:"portid,attr"`
+	State    nmapStatus   `xml:"state"`
+	Service  nmapService  `xml:"service"`
+	Scripts  []nmapScript `xml:"script,omitempty"`
+}
+
+type nmapService struct {
+	Name      string `xml:"name,attr"`
+	Tunnel    string `xml:"tunnel,attr,omitempty"`
+	ExtraInfo string `xml:"extrainfo,attr,omitempty"`
+	Method    string `xml:"method,attr"`
+	Conf      int    `xml:"conf,attr"`
+}
+
+type nmapScript struct {
+	ID     string `xml:"id,attr"`
+	Output string `xml:"output,attr"`
+}
+
+type nmapRunStats struct {
+	Finished struct {
+		Time    int64  `xml:"time,attr"`
+		TimeStr string `xml:"timestr,attr"`
+		Elapsed string `xml:"elapsed,attr"`
+		Summary string `xml:"summary,attr"`
+		Exit    string `xml:"exit,attr"`
+	} `xml:"finished"`
+	Hosts struct {
+		Up    int `xml:"up,attr"`
+		Down  int `xml:"down,attr"`
+		Total int `xml:"total,attr"`
+	} `xml:"hosts"`
+}
+
+// 保存为nmap XML格式：按IP合并结果，每个IP一个host元素，主机名作为hostname，
+// 检测的Web端口和端口扫描发现的开放端口作为port元素，页面标题和重定向写为http-title、http-redirect脚本输出。
+// 没有IP的结果（解析失败）无法在nmap格式中表示，不写入
+func SaveNmapXML(results []checker.Result, filename string) error {
+	hosts := make(map[string]*nmapHost)
+	var order []string
+	var start, end time.Time
+	portSet := make(map[int]bool)
+
+	for _, result := range results {
+		if result.IP == "" {
+			continue
+		}
+		host, ok := hosts[result.IP]
+		if !ok {
+			addrType := "ipv4"
+			if ip := net.ParseIP(result.IP); ip != nil && ip.To4() == nil {
+				addrType = "ipv6"
+			}
+			host = &nmapHost{
+				Status:  nmapStatus{State: "down", Reason: "no-response"},
+				Address: nmapAddress{Addr: result.IP, AddrType: addrType},
+			}
+			hosts[result.IP] = host
+			order = append(order, result.IP)
+		}
+
+		checked, checkedEnd := result.CheckedAt, result.CheckedAt.Add(result.ResponseTime)
+		if !checked.IsZero() {
+			if host.StartTime == 0 || checked.Unix() < host.StartTime {
+				host.StartTime = checked.Unix()
+			}
+			host.EndTime = max(host.EndTime, checkedEnd.Unix())
+			if start.IsZero() || checked.Before(start) {
+				start = checked
+			}
+			if checkedEnd.After(end) {
+				end = checkedEnd
+			}
+		}
+
+		u, err := url.Parse(targetURL(result))
+		if err != nil {
+			continue
+		}
+		if name := u.Hostname(); name != result.IP && !hasHostname(host, name) {
+			host.Hostnames = append(host.Hostnames, nmapHostname{Name: name, Type: "user"})
+		}
+
+		// 收到HTTP响应说明端口开放
+		if result.Status > 0 {
+			host.Status = nmapStatus{State: "up", Reason: "syn-ack"}
+			checkedURL, _ := url.Parse(result.Domain)
+			if checkedURL == nil || checkedURL.Host == "" {
+				checkedURL = u
+			}
+			portID := urlPort(checkedURL)
+			port := hostPort(host, portID)
+			port.Service = nmapService{Name: "http", Method: "probed", Conf: 10}
+			if checkedURL.Scheme == "https" {
+				port.Service.Tunnel = "ssl"
+			}
+			portSet[portID] = true
+			if result.Title != "" {
+				port.Scripts = appendScript(port.Scripts, "http-title", result.Title)
+			}
+			if result.FinalURL != "" && result.FinalURL != result.Domain {
+				port.Scripts = appendScript(port.Scripts, "http-redirect", fmt.Sprintf("%d %s", result.Status, result.FinalURL))
+			}
+		}
+		for _, p := range result.OpenPorts {
+			host.Status = nmapStatus{State: "up", Reason: "syn-ack"}
+			hostPort(host, p)
+			portSet[p] = true
+		}
+		for _, service := range result.Services {
+			port := hostPort(host, service.Port)
+			if port.Service.Method == "probed" && service.Web() {
+				continue
+			}
+			port.Service = nmapService{Name: service.Name, ExtraInfo: service.Banner, Method: "probed", Conf: 10}
+			if service.Name == "https" {
+				port.Service = nmapService{Name: "http", Tunnel: "ssl", ExtraInfo: service.Banner, Method: "probed", Conf: 10}
+			}
+		}
+	}
+
+	run := nmapRun{
+		Scanner:          "nmap",
+		Args:             "squirrel",
+		Version:          "7.94",
+		XMLOutputVersion: "1.05",
+		ScanInfo:         nmapScanInfo{Type: "connect", Protocol: "tcp"},
+	}
+	if !fixedReportTime.IsZero() {
+		start, end = fixedReportTime, fixedReportTime
+	} else if start.IsZero() {
+		start, end = time.Now(), time.Now()
+	}
+	run.Start = start.Unix()
+	run.StartStr = start.Format(time.ANSIC)
+
+	var ports []int
+	for p := range portSet {
+		ports = append(ports, p)
+	}
+	sort.Ints(ports)
+	portNames := make([]string, len(ports))
+	for i, p := range ports {
+		portNames[i] = strconv.Itoa(p)
+	}
+	run.ScanInfo.NumServices = len(ports)
+	run.ScanInfo.Services = strings.Join(portNames, ",")
+
+	for _, ip := range order {
+		host := hosts[ip]
+		sort.Slice(host.Ports, func(i, j int) bool { return host.Ports[i].PortID < host.Ports[j].PortID })
+		if host.StartTime == 0 {
+			host.StartTime, host.EndTime = run.Start, run.Start
+		}
+		run.Hosts = append(run.Hosts, host)
+		if host.Status.State == "up" {
+			run.RunStats.Hosts.Up++
+		} else {
+			run.RunStats.Hosts.Down++
+		}
+	}
+	run.RunStats.Hosts.Total = len(run.Hosts)
+	finished := &run.RunStats.Finished
+	finished.Time = end.Unix()
+	finished.TimeStr = end.Format(time.ANSIC)
+	finished.Elapsed = strconv.FormatFloat(end.Sub(start).Seconds(), 'f', 2, 64)
+	finished.Summary = fmt.Sprintf("Nmap done at %s; %d IP addresses (%d hosts up) scanned in %s seconds",
+		finished.TimeStr, run.RunStats.Hosts.Total, run.RunStats.Hosts.Up, finished.Elapsed)
+	finished.Exit = "success"
+
+	return writeXML(filename, "<!DOCTYPE nmaprun>\n", run)
+}
+
+// URL的端口，未指定时按协议取默认端口
+func urlPort(u *url.URL) int {
+	if port, err := strconv.Atoi(u.Port()); err == nil {
+		return port
+	}
+	if u.Scheme == "https" {
+		return 443
+	}
+	return 80
+}
+
+// 返回主机上的端口元素，不存在时创建为开放状态
+func hostPort(host *nmapHost, portID int) *nmapPort {
+	for _, port := range host.Ports {
+		if port.PortID == portID {
+			return port
+		}
+	}
+	port := &nmapPort{
+		Protocol: "tcp",
+		PortID:   portID,
+		State:    nmapStatus{State: "open", Reason: "syn-ack"},
+		Service:  nmapService{Name: "unknown", Method: "table", Conf: 3},
+	}
+	host.Ports = append(host.Ports, port)
+	return port
+}
+
+func hasHostname(host *nmapHost, name string) bool {
+	for _, hostname := range host.Hostnames {
+		if hostname.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// 添加脚本输出，同一端口上的同名脚本（如多个主机名共用一个IP）合并输出
+func appendScript(scripts []nmapScript, id, output string) []nmapScript {
+	for i := range scripts {
+		if scripts[i].ID == id {
+			if !strings.Contains(scripts[i].Output, output) {
+				scripts[i].Output += "; " + output
+			}
+			return scripts
+		}
+	}
+	return append(scripts, nmapScript{ID: id, Output: output})
+}
@@ -0,0 +1,306 @@
+package whois
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 根域名的注册信息
+type Record struct {
+	Domain    string    `json:"domain"`
+	Registrar string    `json:"registrar,omitempty"`
+	Created   time.Time `json:"created,omitempty"`
+	Expires   time.Time `json:"expires,omitempty"`
+	Source    string    `json:"source,omitempty"` // rdap 或 whois
+	Error     string    `json:"error,omitempty"`
+}
+
+// 即将到期和新注册的判断阈值
+const (
+	ExpiringWithin     = 30 * 24 * time.Hour
+	RecentlyRegistered = 90 * 24 * time.Hour
+)
+
+// 在now之后ExpiringWithin内到期（或已过期）
+func (r Record) Expiring(now time.Time) bool {
+	return !r.Expires.IsZero() && r.Expires.Sub(now) < ExpiringWithin
+}
+
+// 注册时间不足RecentlyRegistered
+func (r Record) Recent(now time.Time) bool {
+	return !r.Created.IsZero() && now.Sub(r.Created) < RecentlyRegistered
+}
+
+// IANA的RDAP引导文件，列出各顶级域的RDAP服务地址
+const bootstrapURL = "https://data.iana.org/rdap/dns.json"
+
+// 查询客户端：优先使用RDAP，顶级域没有RDAP服务或查询失败时回退到WHOIS（43端口）
+type Client struct {
+	http    *http.Client
+	timeout time.Duration
+
+	once      sync.Once
+	bootstrap map[string]string // 顶级域 -> RDAP服务地址
+}
+
+// 创建查询客户端，timeout为单次请求的超时
+func NewClient(timeout time.Duration) *Client {
+	return &Client{http: &http.Client{Timeout: timeout}, timeout: timeout}
+}
+
+// 查询根域名的注册信息
+func (c *Client) Lookup(ctx context.Context, domain string) (Record, error) {
+	domain = strings.ToLower(strings.TrimSuffix(domain, "."))
+	record, rdapErr := c.lookupRDAP(ctx, domain)
+	if rdapErr == nil {
+		return record, nil
+	}
+	record, err := c.lookupWHOIS(ctx, domain)
+	if err != nil {
+		return Record{Domain: domain}, fmt.Errorf("RDAP: %v; WHOIS: %v", rdapErr, err)
+	}
+	return record, nil
+}
+
+// 并发查询多个根域名，查询失败的记录带有Error，结果按域名排序
+func LookupAll(domains []string, timeout time.Duration, concurrency int) []Record {
+	client := NewClient(timeout)
+	records := make([]Record, len(domains))
+	sem := make(chan struct{}, max(concurrency, 1))
+	var wg sync.WaitGroup
+	for i, domain := range domains {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			ctx, cancel := context.WithTimeout(context.Background(), 3*timeout)
+			defer cancel()
+			record, err := client.Lookup(ctx, domain)
+			if err != nil {
+				record.Error = err.Error()
+			}
+			records[i] = record
+		}()
+	}
+	wg.Wait()
+	sort.Slice(records, func(i, j int) bool { return records[i].Domain < records[j].Domain })
+	return records
+}
+
+// 读取RDAP引导文件，只在第一次查询时下载
+func (c *Client) rdapServer(ctx context.Context, tld string) (string, error) {
+	c.once.Do(func() {
+		c.bootstrap = make(map[string]string)
+		var doc struct {
+			Services [][][]string `json:"services"`
+		}
+		if err := c.getJSON(ctx, bootstrapURL, &doc); err != nil {
+			return
+		}
+		for _, service := range doc.Services {
+			if len(service) < 2 || len(service[1]) == 0 {
+				continue
+			}
+			for _, t := range service[0] {
+				c.bootstrap[strings.ToLower(t)] = strings.TrimSuffix(service[1][0], "/")
+			}
+		}
+	})
+	server, ok := c.bootstrap[tld]
+	if !ok {
+		return "", fmt.Errorf("顶级域 %s 没有RDAP服务", tld)
+	}
+	return server, nil
+}
+
+// RDAP域名查询的响应中用到的部分
+type rdapDomain struct {
+	Events []struct {
+		Action string `json:"eventAction"`
+		Date   string `json:"eventDate"`
+	} `json:"events"`
+	Entities []struct {
+		Roles []string          `json:"roles"`
+		VCard []json.RawMessage `json:"vcardArray"`
+	} `json:"entities"`
+}
+
+func (c *Client) lookupRDAP(ctx context.Context, domain string) (Record, error) {
+	tld := domain[strings.LastIndex(domain, ".")+1:]
+	server, err := c.rdapServer(ctx, tld)
+	if err != nil {
+		return Record{}, err
+	}
+	var resp rdapDomain
+	if err := c.getJSON(ctx, server+"/domain/"+domain, &resp); err != nil {
+		return Record{}, err
+	}
+
+	record := Record{Domain: domain, Source: "rdap"}
+	for _, e := range resp.Events {
+		switch e.Action {
+		case "registration":
+			record.Created = parseDate(e.Date)
+		case "expiration":
+			record.Expires = parseDate(e.Date)
+		}
+	}
+	for _, entity := range resp.Entities {
+		for _, role := range entity.Roles {
+			if role == "registrar" {
+				record.Registrar = vcardName(entity.VCard)
+			}
+		}
+	}
+	return record, nil
+}
+
+// 从jCard中取出fn（名称）字段：["vcard", [["fn", {}, "text", "名称"], ...]]
+func vcardName(vcard []json.RawMessage) string {
+	if len(vcard) < 2 {
+		return ""
+	}
+	var props [][]any
+	if json.Unmarshal(vcard[1], &props) != nil {
+		return ""
+	}
+	for _, prop := range props {
+		if len(prop) >= 4 && prop[0] == "fn" {
+			name, _ := prop[3].(string)
+			return name
+		}
+	}
+	return ""
+}
+
+func (c *Client) getJSON(ctx context.Context, url string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/rdap+json, application/json")
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s 返回状态码 %d", url, resp.StatusCode)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, 4<<20)).Decode(v)
+}
+
+// WHOIS查询：先向IANA查询顶级域的WHOIS服务器，再向该服务器查询域名
+func (c *Client) lookupWHOIS(ctx context.Context, domain string) (Record, error) {
+	tld := domain[strings.LastIndex(domain, ".")+1:]
+	iana, err := c.query(ctx, "whois.iana.org", tld)
+	if err != nil {
+		return Record{}, err
+	}
+	server := field(iana, "refer", "whois")
+	if server == "" {
+		return Record{}, fmt.Errorf("顶级域 %s 没有WHOIS服务器", tld)
+	}
+	text, err := c.query(ctx, server, domain)
+	if err != nil {
+		return Record{}, err
+	}
+	// 部分注册局只返回注册商的WHOIS服务器，注册信息需要再查询一次
+	if referral := field(text, "Registrar WHOIS Server", "whois server"); referral != "" && !strings.EqualFold(referral, server) {
+		if detail, err := c.query(ctx, strings.TrimPrefix(referral, "whois://"), domain); err == nil {
+			text += "\n" + detail
+		}
+	}
+
+	record := Record{
+		Domain:    domain,
+		Source:    "whois",
+		Registrar: field(text, "Registrar", "registrar", "Sponsoring Registrar", "Registrar Name"),
+		Created:   parseDate(field(text, "Creation Date", "created", "Registration Time", "Registered on", "Created On")),
+		Expires: parseDate(field(text, "Registry Expiry Date", "Registrar Registration Expiration Date",
+			"Expiration Date", "Expiration Time", "expires", "Expiry date", "paid-till")),
+	}
+	if record.Registrar == "" && record.Created.IsZero() && record.Expires.IsZero() {
+		return Record{}, fmt.Errorf("%s 的WHOIS响应中没有注册信息", server)
+	}
+	return record, nil
+}
+
+// 向WHOIS服务器发送查询并读取完整响应
+func (c *Client) query(ctx context.Context, server, q string) (string, error) {
+	dialer := net.Dialer{Timeout: c.timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(server, "43"))
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(c.timeout))
+	if _, err := fmt.Fprintf(conn, "%s\r\n", q); err != nil {
+		return "", err
+	}
+	data, err := io.ReadAll(io.LimitReader(conn, 1<<20))
+	if err != nil && len(data) == 0 {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// 取出WHOIS响应中第一个出现的字段值，按names的顺序尝试，字段名不区分大小写
+func field(text string, names ...string) string {
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if !ok || strings.HasPrefix(key, "%") || strings.HasPrefix(key, "#") {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		if value = strings.TrimSpace(value); value != "" && values[key] == "" {
+			values[key] = value
+		}
+	}
+	for _, name := range names {
+		if value := values[strings.ToLower(name)]; value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// 注册信息中常见的日期格式
+var dateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05Z",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04:05 MST",
+	"2006-01-02",
+	"2006.01.02",
+	"2006/01/02",
+	"02-Jan-2006",
+	"02.01.2006",
+	"January 2 2006",
+}
+
+// 解析日期，无法识别时返回零值
+func parseDate(s string) time.Time {
+	s = strings.TrimSpace(s)
+	if i := strings.Index(s, " ("); i > 0 {
+		s = s[:i]
+	}
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.UTC()
+		}
+	}
+	return time.Time{}
+}